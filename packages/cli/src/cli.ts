@@ -1,5 +1,14 @@
 import { Command } from 'commander';
-import { NoteStore } from '@agentnotes/engine';
+import {
+  DEFAULT_CONFIG,
+  NoteStore,
+  createGitCommitter,
+  loadConfig,
+  logDebug,
+  resolveNotesRoot,
+  setLogLevel,
+} from '@agentnotes/engine';
+import type { AgentNotesConfig } from '@agentnotes/engine';
 import { addCommand } from './commands/add.js';
 import { listCommand } from './commands/list.js';
 import { showCommand } from './commands/show.js';
@@ -9,9 +18,100 @@ import { deleteCommand } from './commands/delete.js';
 import { tagsCommand } from './commands/tags.js';
 import { catCommand } from './commands/cat.js';
 import { commentCommand } from './commands/comment.js';
+import { notebooksCommand } from './commands/notebooks.js';
+import { linksCommand } from './commands/links.js';
+import { backlinksCommand } from './commands/backlinks.js';
+import { renameCommand } from './commands/rename.js';
+import { restoreCommand } from './commands/restore.js';
+import { trashCommand } from './commands/trash.js';
+import { savedCommand } from './commands/saved.js';
+import { statsCommand } from './commands/stats.js';
+import { recentCommand } from './commands/recent.js';
+import { randomCommand } from './commands/random.js';
+import { openCommand } from './commands/open.js';
+import { templatesCommand } from './commands/templates.js';
+import { agendaCommand } from './commands/agenda.js';
+import { tasksCommand } from './commands/tasks.js';
+import { checkCommand } from './commands/check.js';
+import { aliasCommand } from './commands/alias.js';
+import { exportCommand } from './commands/export.js';
+import { importCommand } from './commands/import.js';
+import { mergeCommand } from './commands/merge.js';
+import { serveCommand } from './commands/serve.js';
+import { mcpCommand } from './commands/mcp.js';
+import { configCommand } from './commands/config.js';
+import { retagCommand } from './commands/retag.js';
+import { attachCommand } from './commands/attach.js';
+import { archiveCommand } from './commands/archive.js';
+import { diffCommand } from './commands/diff.js';
+import { historyCommand } from './commands/history.js';
+import { doctorCommand } from './commands/doctor.js';
+import { commentsCommand } from './commands/comments.js';
+import { todayCommand } from './commands/today.js';
+import { mvCommand } from './commands/mv.js';
+import { cloneCommand } from './commands/clone.js';
+import { pickCommand } from './commands/pick.js';
+import { backupCommand } from './commands/backup.js';
+import { restoreBackupCommand } from './commands/restore-backup.js';
+import { reindexCommand } from './commands/reindex.js';
+import { pinCommand } from './commands/pin.js';
+import { error, setColorEnabled, shouldUseColor } from './display/format.js';
+import { setPagerEnabled } from './utils/pager.js';
 
-export function createStore(dir?: string): NoteStore {
-  return new NoteStore({ notesDirectory: dir || process.cwd() });
+export const NOTES_DIR_ENV = 'AGENTNOTES_DIR';
+export const GIT_ENV = 'AGENTNOTES_GIT';
+
+/**
+ * Resolve the notes directory: --dir flag, then $AGENTNOTES_DIR, then the
+ * nearest directory at or above cwd that has an `.agentnotes` folder (unless
+ * discover is off), then cwd; see `resolveNotesRoot`.
+ */
+export function resolveNotesDirectory(dir?: string, discover = true): string {
+  return resolveNotesRoot({ dir, envDir: process.env[NOTES_DIR_ENV], discover });
+}
+
+export interface StoreFlags {
+  dir?: string;
+  notebook?: string;
+  git?: boolean;
+  color?: boolean;
+  pager?: boolean;
+  discover?: boolean;
+  verbose?: boolean;
+  quiet?: boolean;
+}
+
+/**
+ * Git auto-commit is opt-in: --git on the command line or AGENTNOTES_GIT=1.
+ */
+export function createStore(flags: StoreFlags = {}): NoteStore {
+  const git = flags.git || process.env[GIT_ENV] === '1';
+  const notesDirectory = resolveNotesDirectory(flags.dir, flags.discover !== false);
+  logDebug(`notes directory: ${notesDirectory}${flags.notebook ? `, notebook ${flags.notebook}` : ''}`);
+  return new NoteStore({
+    notesDirectory,
+    notebook: flags.notebook,
+    commit: git ? createGitCommitter() : undefined,
+  });
+}
+
+type ProgramState = Command & { store?: NoteStore; config?: AgentNotesConfig };
+
+/** Config settings that fill in an option the user did not pass. */
+const CONFIG_OPTIONS = ['limit', 'sort', 'author'] as const;
+
+/**
+ * Only options that have a default are filled in, so e.g. `comment list
+ * --limit`, which shows everything when omitted, is left alone.
+ */
+function applyConfigDefaults(command: Command, config: AgentNotesConfig): void {
+  for (const key of CONFIG_OPTIONS) {
+    const value = config[key];
+    if (value === null || value === '' || command.getOptionValueSource(key) !== 'default') {
+      continue;
+    }
+    command.setOptionValueWithSource(key, String(value), 'config');
+  }
 }
 
 export function createProgram(): Command {
@@ -21,12 +121,38 @@ export function createProgram(): Command {
     .name('agentnotes')
     .description('A local-first knowledge base with CLI interface')
     .version('1.0.0')
-    .option('--dir <path>', 'Notes directory (defaults to current directory)');
+    .option(
+      '--dir <path>',
+      `Notes directory (defaults to $${NOTES_DIR_ENV}, then the nearest parent with .agentnotes, then the current directory; created if missing)`,
+    )
+    .option('-n, --notebook <name>', 'Scope commands to a notebook (top-level folder)')
+    .option('--git', `Commit each change to git (or set ${GIT_ENV}=1)`)
+    .option('--no-color', 'Disable colored output (also off with NO_COLOR or when piped)')
+    .option('--no-discover', 'Use the current directory instead of the nearest parent with .agentnotes')
+    .option('--no-pager', 'Print long output directly instead of through $PAGER (also off when piped)')
+    .option('-v, --verbose', 'Also log store operations, such as files written, to stderr (show adds timestamps)')
+    .option('-q, --quiet', 'Hide warnings on stderr; add and clone print only the new note ID');
 
   // Hook to create store before each command runs
-  program.hook('preAction', (thisCommand) => {
-    const opts = thisCommand.opts() as { dir?: string };
-    (thisCommand as Command & { store: NoteStore }).store = createStore(opts.dir);
+  program.hook('preAction', (thisCommand, actionCommand) => {
+    const opts = thisCommand.opts() as StoreFlags;
+    setColorEnabled(shouldUseColor(opts.color !== false));
+    setPagerEnabled(opts.pager !== false);
+    if (opts.verbose && opts.quiet) {
+      console.error(error('Use either --verbose or --quiet, not both'));
+      process.exit(1);
+    }
+    setLogLevel(opts.quiet ? 'quiet' : opts.verbose ? 'verbose' : 'normal');
+    try {
+      const { config } = loadConfig(resolveNotesDirectory(opts.dir, opts.discover !== false));
+      setColorEnabled(shouldUseColor(opts.color !== false && config.color));
+      applyConfigDefaults(actionCommand, config);
+      (thisCommand as ProgramState).config = config;
+      (thisCommand as ProgramState).store = createStore(opts);
+    } catch (err) {
+      console.error(error(err instanceof Error ? err.message : String(err)));
+      process.exit(1);
+    }
   });
 
   addCommand(program);
@@ -38,6 +164,43 @@ export function createProgram(): Command {
   tagsCommand(program);
   catCommand(program);
   commentCommand(program);
+  notebooksCommand(program);
+  linksCommand(program);
+  backlinksCommand(program);
+  renameCommand(program);
+  restoreCommand(program);
+  trashCommand(program);
+  savedCommand(program);
+  statsCommand(program);
+  recentCommand(program);
+  randomCommand(program);
+  openCommand(program);
+  templatesCommand(program);
+  agendaCommand(program);
+  tasksCommand(program);
+  checkCommand(program);
+  aliasCommand(program);
+  exportCommand(program);
+  importCommand(program);
+  mergeCommand(program);
+  serveCommand(program);
+  mcpCommand(program);
+  configCommand(program);
+  retagCommand(program);
+  attachCommand(program);
+  archiveCommand(program);
+  diffCommand(program);
+  historyCommand(program);
+  doctorCommand(program);
+  commentsCommand(program);
+  todayCommand(program);
+  mvCommand(program);
+  cloneCommand(program);
+  pickCommand(program);
+  backupCommand(program);
+  restoreBackupCommand(program);
+  reindexCommand(program);
+  pinCommand(program);
 
   return program;
 }
@@ -46,10 +209,20 @@ export function getStore(cmd: Command): NoteStore {
   // Walk up to root command to find the store
   let current: Command | null = cmd;
   while (current) {
-    const store = (current as Command & { store?: NoteStore }).store;
+    const store = (current as ProgramState).store;
     if (store) return store;
     current = current.parent;
   }
   // Fallback — shouldn't happen since preAction sets it
   return createStore();
 }
+
+export function getConfig(cmd: Command): AgentNotesConfig {
+  let current: Command | null = cmd;
+  while (current) {
+    const config = (current as ProgramState).config;
+    if (config) return config;
+    current = current.parent;
+  }
+  return DEFAULT_CONFIG;
+}
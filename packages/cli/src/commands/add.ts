@@ -1,30 +1,66 @@
 import type { Command } from 'commander';
+import { parseNoteInput, parseTagList, type NoteInput } from '@agentnotes/engine';
 import { success, error } from '../display/format.js';
 import { readStdin } from '../utils/stdin.js';
 import { openEditor } from '../utils/editor.js';
-import { getStore } from '../cli.js';
+import { decryptOrExit, getPassphrase } from '../utils/passphrase.js';
+import { parsePriority } from '../utils/filters.js';
+import { checkTagVocabulary, isStrictTags, type TagVocabularyFlags } from '../utils/tags.js';
+import { getConfig, getStore, type StoreFlags } from '../cli.js';
 
 export function addCommand(program: Command): void {
   program
-    .command('add <title>')
+    .command('add [title]')
     .description('Create a new note')
     .option('--tags <tags>', 'Comma-separated tags')
+    .option('--strict-tags', 'Reject tags that no note uses yet (also `strictTags: true` in config)')
+    .option('--new-tag', 'Allow new tags despite strict tags')
+    .option('--priority <n>', 'Priority from 0 to 10, stored as the priority field')
     .option('-d, --directory <dir>', 'Directory to create note in', '')
-    .action(async function (this: Command, title: string, opts: { tags?: string; directory: string }) {
-      const store = getStore(this);
-
-      let content: string | undefined;
+    .option('--template <name>', 'Start from .agentnotes/templates/<name>.md')
+    .option('--encrypt', 'Encrypt the body with a passphrase (prompted, or $AGENTNOTES_KEY)')
+    .option('--json', 'Read the whole note as a JSON object from stdin (title, tags, priority, source, content, comments)')
+    .action(async function (
+      this: Command,
+      titleArg: string | undefined,
+      opts: TagVocabularyFlags & {
+        tags?: string;
+        priority?: string;
+        directory: string;
+        template?: string;
+        encrypt?: boolean;
+        json?: boolean;
+      },
+    ) {
+      if (opts.json) {
+        await addFromJson(this, titleArg, opts);
+        return;
+      }
 
-      const stdinContent = await readStdin();
-      if (stdinContent) {
-        content = stdinContent;
-      } else if (process.stdin.isTTY) {
-        content = await openEditor(`# ${title}\n\n`);
+      // Shell quoting makes "" and "  " easy to pass by accident.
+      const title = titleArg?.trim();
+      if (!title) {
+        console.error(error(titleArg === undefined ? 'Title is required (or use --json)' : 'Title cannot be empty'));
+        process.exit(1);
       }
 
+      const priority = opts.priority === undefined ? undefined : parsePriority(opts.priority);
+      const tags = opts.tags ? parseTagList(opts.tags) : [];
+      const store = getStore(this);
+      await checkTagVocabulary(store, tags, isStrictTags(opts, getConfig(this)));
+      // Ask before creating anything, so a missing key leaves no plaintext note behind.
+      const passphrase = opts.encrypt ? await getPassphrase({ confirm: true }) : undefined;
+
+      // Piped content goes into the first write; only the editor needs the note created first.
+      const stdinContent = await readStdin();
       const result = await store.createNote({
         title,
         directory: opts.directory,
+        template: stdinContent ? undefined : opts.template,
+        content: stdinContent || undefined,
+        tags,
+        meta: priority === undefined ? undefined : { priority },
+        passphrase,
       });
 
       if (!result.success) {
@@ -32,27 +68,86 @@ export function addCommand(program: Command): void {
         process.exit(1);
       }
 
-      if (content && result.note) {
-        const updateResult = await store.updateNote({
-          noteId: result.note.id,
-          content,
-        });
-        if (!updateResult.success) {
-          console.error(error(updateResult.error ?? 'Failed to update note content'));
+      // The editor opens on the rendered template, decrypted when the note already is.
+      if (!stdinContent && process.stdin.isTTY && result.note) {
+        const initial = passphrase ? decryptOrExit(result.note, passphrase) : result.note.content;
+        const content = await openEditor(initial, getConfig(this).editor);
+        if (content && content !== initial) {
+          const updateResult = await store.updateNote({
+            noteId: result.note.id,
+            content,
+            passphrase,
+          });
+          if (!updateResult.success) {
+            console.error(error(updateResult.error ?? 'Failed to update note content'));
+          }
         }
       }
 
-      if (opts.tags && result.note) {
-        const tags = opts.tags.split(',').map((t: string) => t.trim()).filter(Boolean);
-        await store.updateNoteMetadata({
-          noteId: result.note.id,
-          tags,
-        });
-      }
-
-      console.log(success(`Created note: ${title}`));
-      if (result.note) {
-        console.log(`  ${result.note.id}`);
-      }
+      printCreated(title, result.note?.id, (this.optsWithGlobals() as StoreFlags).quiet);
     });
 }
+
+/** Create a note from a JSON description on stdin in one store call. */
+async function addFromJson(
+  command: Command,
+  titleArg: string | undefined,
+  opts: TagVocabularyFlags & {
+    tags?: string;
+    priority?: string;
+    directory: string;
+    template?: string;
+    encrypt?: boolean;
+  },
+): Promise<void> {
+  if (
+    titleArg !== undefined ||
+    opts.tags !== undefined ||
+    opts.priority !== undefined ||
+    opts.template !== undefined
+  ) {
+    console.error(
+      error('--json takes the title, tags and priority from its input and cannot be combined with a template'),
+    );
+    process.exit(1);
+  }
+
+  const raw = await readStdin();
+  if (!raw) {
+    console.error(error('--json expects a JSON object on stdin'));
+    process.exit(1);
+  }
+
+  let input: NoteInput;
+  try {
+    input = parseNoteInput(raw, getConfig(command).author || '');
+  } catch (err) {
+    console.error(error(err instanceof Error ? err.message : String(err)));
+    process.exit(1);
+  }
+
+  const store = getStore(command);
+  await checkTagVocabulary(store, input.tags ?? [], isStrictTags(opts, getConfig(command)));
+  const passphrase = opts.encrypt ? await getPassphrase({ confirm: true }) : undefined;
+  const result = await store.createNote({ ...input, directory: opts.directory, passphrase });
+  if (!result.success || !result.note) {
+    console.error(error(result.error ?? 'Failed to create note'));
+    process.exit(1);
+  }
+
+  printCreated(input.title, result.note.id, (command.optsWithGlobals() as StoreFlags).quiet);
+}
+
+function printCreated(title: string, noteId: string | undefined, quiet?: boolean): void {
+  if (quiet) {
+    if (noteId) {
+      console.log(noteId);
+    }
+    return;
+  }
+
+  console.log(success(`Created note: ${title}`));
+  if (noteId) {
+    console.log(`  ${noteId}`);
+  }
+}
@@ -0,0 +1,23 @@
+import type { Command } from 'commander';
+import { buildAgenda, search } from '@agentnotes/engine';
+import { formatAgenda } from '../display/format.js';
+import { getDateFilters, type DateFilterFlags } from '../utils/filters.js';
+import { getStore } from '../cli.js';
+
+export function agendaCommand(program: Command): void {
+  program
+    .command('agenda')
+    .description('List notes with a due date, grouped by when they are due')
+    .option('--tags <tags>', 'Filter by tags (comma-separated)')
+    .option('--since <date>', 'Created on or after a date (YYYY-MM-DD) or offset (24h, 7d, 2w)')
+    .option('--until <date>', 'Created on or before a date or offset')
+    .option('--updated-since <date>', 'Updated on or after a date or offset')
+    .action(async function (this: Command, opts: DateFilterFlags & { tags?: string }) {
+      const store = getStore(this);
+      const result = await store.listNotes();
+      const tags = opts.tags ? opts.tags.split(',').map((t: string) => t.trim()) : undefined;
+
+      const filtered = search(result.notes, { tags, ...getDateFilters(opts) });
+      console.log(formatAgenda(buildAgenda(filtered)));
+    });
+}
@@ -0,0 +1,42 @@
+import type { Command } from 'commander';
+import { success, error } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function aliasCommand(program: Command): void {
+  const alias = program
+    .command('alias')
+    .description('Manage short aliases that resolve to a note');
+
+  alias
+    .command('add <id-or-title> <alias>')
+    .description('Give a note an alias (unique across all notes)')
+    .action(async function (this: Command, idOrTitle: string, name: string) {
+      const store = getStore(this);
+      const note = await requireNote(store, idOrTitle);
+      const result = await store.addAlias({ noteId: note.id, alias: name });
+
+      if (!result.success) {
+        console.error(error(result.error ?? 'Failed to add alias'));
+        process.exit(1);
+      }
+
+      console.log(success(`Alias added: ${name.trim().toLowerCase()} -> ${note.title}`));
+    });
+
+  alias
+    .command('remove <id-or-title> <alias>')
+    .description('Remove an alias from a note')
+    .action(async function (this: Command, idOrTitle: string, name: string) {
+      const store = getStore(this);
+      const note = await requireNote(store, idOrTitle);
+      const result = await store.removeAlias({ noteId: note.id, alias: name });
+
+      if (!result.success) {
+        console.error(error(result.error ?? 'Failed to remove alias'));
+        process.exit(1);
+      }
+
+      console.log(success('Alias removed'));
+    });
+}
@@ -0,0 +1,33 @@
+import type { Command } from 'commander';
+import { success, error } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function archiveCommand(program: Command): void {
+  program
+    .command('archive <id-or-title>')
+    .description('Hide a note from list and search without trashing it')
+    .action(async function (this: Command, idOrTitle: string) {
+      await setArchived(this, idOrTitle, true);
+    });
+
+  program
+    .command('unarchive <id-or-title>')
+    .description('Show an archived note in list and search again')
+    .action(async function (this: Command, idOrTitle: string) {
+      await setArchived(this, idOrTitle, false);
+    });
+}
+
+async function setArchived(command: Command, idOrTitle: string, archived: boolean): Promise<void> {
+  const store = getStore(command);
+  const note = await requireNote(store, idOrTitle);
+
+  const result = await store.setNoteArchived({ noteId: note.id, archived });
+  if (!result.success) {
+    console.error(error(result.error ?? 'Failed to update note'));
+    process.exit(1);
+  }
+
+  console.log(success(`${archived ? 'Archived' : 'Unarchived'} note: ${note.title}`));
+}
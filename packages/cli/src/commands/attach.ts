@@ -0,0 +1,43 @@
+import path from 'node:path';
+import type { Command } from 'commander';
+import { success, error } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function attachCommand(program: Command): void {
+  program
+    .command('attach <id-or-title> <file>')
+    .description('Copy a file into the note\'s attachments')
+    .action(async function (this: Command, idOrTitle: string, file: string) {
+      const store = getStore(this);
+      const note = await requireNote(store, idOrTitle);
+
+      const result = await store.attachFile({ noteId: note.id, filePath: file });
+      if (!result.success || !result.attachment) {
+        console.error(error(result.error ?? 'Failed to attach file'));
+        process.exit(1);
+      }
+
+      console.log(success(`Attached ${result.attachment} to ${note.title}`));
+      console.log(`  ${path.join(store.getAttachmentsDirectory(note.id), result.attachment)}`);
+    });
+
+  program
+    .command('attachments <id-or-title>')
+    .description('List files attached to a note')
+    .action(async function (this: Command, idOrTitle: string) {
+      const store = getStore(this);
+      const note = await requireNote(store, idOrTitle);
+
+      const attachments = note.attachments ?? [];
+      if (attachments.length === 0) {
+        console.log('No attachments.');
+        return;
+      }
+
+      const dir = store.getAttachmentsDirectory(note.id);
+      for (const name of attachments) {
+        console.log(path.join(dir, name));
+      }
+    });
+}
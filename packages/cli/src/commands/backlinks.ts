@@ -0,0 +1,17 @@
+import type { Command } from 'commander';
+import { formatNoteList } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function backlinksCommand(program: Command): void {
+  program
+    .command('backlinks <id-or-title>')
+    .description('List notes that link to a note')
+    .action(async function (this: Command, idOrTitle: string) {
+      const store = getStore(this);
+      const note = await requireNote(store, idOrTitle);
+
+      const backlinks = await store.getBacklinks(note.id);
+      console.log(formatNoteList(backlinks));
+    });
+}
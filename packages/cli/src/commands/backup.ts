@@ -0,0 +1,24 @@
+import type { Command } from 'commander';
+import { createBackup } from '@agentnotes/engine';
+import { error, success } from '../display/format.js';
+import { resolveNotesDirectory, type StoreFlags } from '../cli.js';
+
+export function backupCommand(program: Command): void {
+  program
+    .command('backup')
+    .description('Write the whole notes directory, .agentnotes included, to a .tar.gz file')
+    .requiredOption('--out <file>', 'Archive to write, e.g. notes-backup.tar.gz')
+    .action(function (this: Command, opts: { out: string }) {
+      // The whole notes root, whichever notebook is selected.
+      const flags = this.optsWithGlobals() as StoreFlags;
+      const notesDir = resolveNotesDirectory(flags.dir, flags.discover !== false);
+      try {
+        const manifest = createBackup(notesDir, opts.out);
+        console.log(success(`Backed up ${manifest.noteCount} note${manifest.noteCount === 1 ? '' : 's'}`));
+        console.log(`  ${opts.out}`);
+      } catch (err) {
+        console.error(error(`Backup failed: ${err instanceof Error ? err.message : String(err)}`));
+        process.exit(1);
+      }
+    });
+}
@@ -1,20 +1,124 @@
 import type { Command } from 'commander';
-import { error } from '../display/format.js';
-import { resolveNote } from '../utils/resolve.js';
+import { collectLinkedNotes, search, serializeNoteFrontmatter, stripFrontmatter, type Note } from '@agentnotes/engine';
+import { error, info } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { decryptOrExit, getPassphrase, unlockNote } from '../utils/passphrase.js';
+import { getSortFields } from '../utils/filters.js';
+import { printPaged } from '../utils/pager.js';
 import { getStore } from '../cli.js';
 
+/** An HTML comment, so the stream stays valid markdown, naming the note that follows. */
+function formatNoteSeparator(note: Note): string {
+  return `<!-- ${note.id}: ${note.title} -->`;
+}
+
+/** Parse a positive integer option, exiting with `message` otherwise. */
+function parsePositiveInt(value: string, message: string): number {
+  const parsed = Number(value);
+  if (!/^\d+$/.test(value.trim()) || parsed <= 0) {
+    console.error(error(message));
+    process.exit(1);
+  }
+  return parsed;
+}
+
+/** Which bytes of a note to print: the markdown, its body without a YAML block, or its metadata. */
+type CatPart = 'full' | 'body' | 'frontmatter';
+
+function formatNotePart(note: Note, content: string, part: CatPart): string {
+  if (part === 'frontmatter') {
+    return serializeNoteFrontmatter(note);
+  }
+  return `${part === 'body' ? stripFrontmatter(content) : content}\n`;
+}
+
+/** Each note's part under its separator, decrypting with one passphrase prompt at most. */
+async function formatNoteBundle(notes: Note[], part: CatPart): Promise<string> {
+  // Asked for once, on the first encrypted note, and reused for the rest.
+  let passphrase: string | undefined;
+  const sections: string[] = [];
+  for (const note of notes) {
+    let content = note.content;
+    if (note.encrypted && part !== 'frontmatter') {
+      passphrase ??= await getPassphrase();
+      content = decryptOrExit(note, passphrase);
+    }
+    sections.push(`${formatNoteSeparator(note)}\n${formatNotePart(note, content, part)}`);
+  }
+  return sections.join('\n');
+}
+
 export function catCommand(program: Command): void {
   program
-    .command('cat <id-or-title>')
-    .description('Output raw markdown content')
-    .action(async function (this: Command, idOrTitle: string) {
-      const store = getStore(this);
-      const note = await resolveNote(store, idOrTitle);
-      if (!note) {
-        console.error(error(`Note not found: ${idOrTitle}`));
+    .command('cat [id-or-title]')
+    .description('Output raw markdown content of one note, or of every note matching --tags/--query')
+    .option('--tags <tags>', 'Output every note with these tags (comma-separated)')
+    .option('--query <text>', 'Output every note matching this search text')
+    .option('--sort <fields>', 'Order for --tags/--query: created, updated, title, due (comma-separated)', 'created')
+    .option('--follow-links', 'Also output the notes its [[wiki links]] lead to, transitively')
+    .option('--depth <n>', 'How many links away --follow-links goes', '1')
+    .option('--max-notes <n>', 'Most notes --follow-links outputs, the starting note included', '50')
+    .option('--body-only', 'Output the markdown body without any leading YAML frontmatter block')
+    .option('--frontmatter-only', 'Output only the note metadata, as a YAML frontmatter block')
+    .action(async function (
+      this: Command,
+      idOrTitle: string | undefined,
+      opts: {
+        tags?: string;
+        query?: string;
+        sort: string;
+        followLinks?: boolean;
+        depth: string;
+        maxNotes: string;
+        bodyOnly?: boolean;
+        frontmatterOnly?: boolean;
+      },
+    ) {
+      if (opts.bodyOnly && opts.frontmatterOnly) {
+        console.error(error('Give either --body-only or --frontmatter-only, not both'));
+        process.exit(1);
+      }
+      const part: CatPart = opts.bodyOnly ? 'body' : opts.frontmatterOnly ? 'frontmatter' : 'full';
+
+      const selecting = opts.tags !== undefined || opts.query !== undefined;
+      if (idOrTitle !== undefined && selecting) {
+        console.error(error('Give either a note or --tags/--query, not both'));
+        process.exit(1);
+      }
+      if (idOrTitle === undefined && !selecting) {
+        console.error(error('Give a note, or --tags/--query to select several'));
+        process.exit(1);
+      }
+
+      if (opts.followLinks && idOrTitle === undefined) {
+        console.error(error('--follow-links starts from a single note'));
         process.exit(1);
       }
 
-      process.stdout.write(note.content + '\n');
+      const store = getStore(this);
+      if (idOrTitle !== undefined && opts.followLinks) {
+        const depth = parsePositiveInt(opts.depth, '--depth must be a positive integer');
+        const maxNotes = parsePositiveInt(opts.maxNotes, '--max-notes must be a positive integer');
+        const start = await requireNote(store, idOrTitle);
+        const { notes } = await store.listNotes();
+        printPaged(await formatNoteBundle(collectLinkedNotes(notes, start, { depth, maxNotes }), part));
+        return;
+      }
+      if (idOrTitle !== undefined) {
+        const found = await requireNote(store, idOrTitle);
+        const note = part === 'frontmatter' ? found : await unlockNote(found);
+        printPaged(formatNotePart(note, note.content, part));
+        return;
+      }
+
+      const result = await store.listNotes();
+      const tags = opts.tags ? opts.tags.split(',').map((t: string) => t.trim()) : undefined;
+      const notes = search(result.notes, { query: opts.query, tags, sortBy: getSortFields(opts.sort) });
+      if (notes.length === 0) {
+        console.error(info('No notes match'));
+        return;
+      }
+
+      printPaged(await formatNoteBundle(notes, part));
     });
 }
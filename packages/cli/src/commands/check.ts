@@ -0,0 +1,39 @@
+import type { Command } from 'commander';
+import { success, error } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function checkCommand(program: Command): void {
+  program
+    .command('check <id-or-title> <line>')
+    .description('Mark the task on a line as done')
+    .action(async function (this: Command, idOrTitle: string, line: string) {
+      await setTaskDone(this, idOrTitle, line, true);
+    });
+
+  program
+    .command('uncheck <id-or-title> <line>')
+    .description('Mark the task on a line as not done')
+    .action(async function (this: Command, idOrTitle: string, line: string) {
+      await setTaskDone(this, idOrTitle, line, false);
+    });
+}
+
+async function setTaskDone(cmd: Command, idOrTitle: string, lineArg: string, done: boolean): Promise<void> {
+  const line = Number(lineArg);
+  if (!Number.isInteger(line) || line < 1) {
+    console.error(error(`Invalid line number: ${lineArg}`));
+    process.exit(1);
+  }
+
+  const store = getStore(cmd);
+  const note = await requireNote(store, idOrTitle);
+  const result = await store.setTaskDone({ noteId: note.id, line, done });
+
+  if (!result.success) {
+    console.error(error(result.error ?? 'Failed to update task'));
+    process.exit(1);
+  }
+
+  console.log(success(done ? `Checked line ${line}` : `Unchecked line ${line}`));
+}
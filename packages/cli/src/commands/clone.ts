@@ -0,0 +1,39 @@
+import type { Command } from 'commander';
+import { success, error } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore, type StoreFlags } from '../cli.js';
+
+export function cloneCommand(program: Command): void {
+  program
+    .command('clone <id-or-title> [new-title]')
+    .description('Start a new note from a copy of an existing one (content and tags)')
+    .option('--with-comments', 'Copy comments too, under new IDs')
+    .option('--with-fields', 'Copy custom fields such as priority and source too')
+    .action(async function (
+      this: Command,
+      idOrTitle: string,
+      newTitle: string | undefined,
+      opts: { withComments?: boolean; withFields?: boolean },
+    ) {
+      const store = getStore(this);
+      const note = await requireNote(store, idOrTitle);
+
+      const result = await store.cloneNote({
+        noteId: note.id,
+        title: newTitle,
+        withComments: opts.withComments,
+        withFields: opts.withFields,
+      });
+      if (!result.success || !result.note) {
+        console.error(error(result.error ?? 'Failed to clone note'));
+        process.exit(1);
+      }
+
+      if ((this.optsWithGlobals() as StoreFlags).quiet) {
+        console.log(result.note.id);
+        return;
+      }
+      console.log(success(`Cloned ${note.title} as ${result.note.title}`));
+      console.log(`  ${result.note.id}`);
+    });
+}
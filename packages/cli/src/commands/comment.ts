@@ -1,8 +1,15 @@
 import type { Command } from 'commander';
-import { buildAnchorFromRange, getUniqueMatchRange } from '@agentnotes/engine';
+import {
+  buildAnchorFromLines,
+  buildAnchorFromRange,
+  getUniqueMatchRange,
+  type CommentAnchor,
+  type Note,
+  type NoteComment,
+} from '@agentnotes/engine';
 import { success, error, formatCommentList } from '../display/format.js';
 import { readStdin, confirm } from '../utils/stdin.js';
-import { resolveNote } from '../utils/resolve.js';
+import { requireNote } from '../utils/resolve.js';
 import { getStore } from '../cli.js';
 
 export function commentCommand(program: Command): void {
@@ -17,20 +24,26 @@ export function commentCommand(program: Command): void {
     .option('--exact <text>', 'Anchor to unique text match')
     .option('--from <n>', 'Start character offset')
     .option('--to <n>', 'End character offset')
+    .option('--start-line <n>', 'Anchor to whole lines, starting at line n (1-based)')
+    .option('--end-line <n>', 'Last line anchored with --start-line, inclusive (default: the start line)')
+    .option('--quote <text>', 'Text --from/--to or the lines must cover; fails on a mismatch')
     .action(
       async function (
         this: Command,
         noteArg: string,
         commentArg: string | undefined,
-        opts: { author: string; exact?: string; from?: string; to?: string },
+        opts: {
+          author: string;
+          exact?: string;
+          from?: string;
+          to?: string;
+          startLine?: string;
+          endLine?: string;
+          quote?: string;
+        },
       ) {
         const store = getStore(this);
-        const note = await resolveNote(store, noteArg);
-        if (!note) {
-          console.error(error(`Note not found: ${noteArg}`));
-          process.exit(1);
-          return;
-        }
+        const note = await requireNote(store, noteArg);
 
         let commentContent = commentArg;
         if (!commentContent) {
@@ -42,10 +55,32 @@ export function commentCommand(program: Command): void {
           return;
         }
 
+        if (opts.endLine !== undefined && opts.startLine === undefined) {
+          console.error(error('--end-line needs --start-line'));
+          process.exit(1);
+          return;
+        }
+
         let from = 0;
         let to = 0;
+        let lineAnchor: CommentAnchor | undefined;
 
-        if (opts.exact) {
+        if (opts.startLine !== undefined) {
+          if (opts.exact || opts.from !== undefined || opts.to !== undefined) {
+            console.error(error('Cannot use --start-line with --exact or --from/--to'));
+            process.exit(1);
+            return;
+          }
+          const startLine = Number(opts.startLine);
+          const endLine = Number(opts.endLine ?? opts.startLine);
+          try {
+            lineAnchor = buildAnchorFromLines(note.content, startLine, endLine, note.commentRev);
+          } catch (err) {
+            console.error(error(err instanceof Error ? err.message : String(err)));
+            process.exit(1);
+            return;
+          }
+        } else if (opts.exact) {
           if (opts.from !== undefined || opts.to !== undefined) {
             console.error(error('Cannot use --exact with --from/--to'));
             process.exit(1);
@@ -53,7 +88,11 @@ export function commentCommand(program: Command): void {
           }
           const match = getUniqueMatchRange(note.content, opts.exact);
           if (!match) {
-            console.error(error('Exact text not found or is ambiguous'));
+            console.error(error(
+              note.content.includes(opts.exact)
+                ? 'Exact text appears more than once; provide --from and --to instead'
+                : 'Exact text not found in note',
+            ));
             process.exit(1);
             return;
           }
@@ -63,18 +102,32 @@ export function commentCommand(program: Command): void {
           from = parseInt(opts.from, 10);
           to = parseInt(opts.to, 10);
         } else {
-          console.error(error('Must specify either --exact or --from and --to'));
+          console.error(error('Must specify --exact, --from and --to, or --start-line'));
           process.exit(1);
           return;
         }
 
-        const anchor = buildAnchorFromRange(note.content, from, to, note.commentRev);
+        if (opts.quote !== undefined && opts.exact) {
+          console.error(error('--quote checks --from/--to; --exact already anchors to its text'));
+          process.exit(1);
+          return;
+        }
+
+        let anchor: CommentAnchor;
+        try {
+          anchor = lineAnchor ?? buildAnchorFromRange(note.content, from, to, note.commentRev, opts.quote);
+        } catch (err) {
+          console.error(error(err instanceof Error ? err.message : String(err)));
+          process.exit(1);
+          return;
+        }
 
         const result = await store.addComment({
           noteId: note.id,
           content: commentContent,
           author: opts.author,
           anchor,
+          expectedQuote: opts.quote,
         });
 
         if (!result.success) {
@@ -90,15 +143,26 @@ export function commentCommand(program: Command): void {
     .command('list <note>')
     .description('List comments on a note')
     .option('--limit <n>', 'Max comments to show')
-    .action(async function (this: Command, noteArg: string, opts: { limit?: string }) {
+    .option('--unresolved', 'Only show comments that are not resolved')
+    .option('--stale', 'Only show comments whose anchored text changed')
+    .option('--detached', 'Only show comments whose anchored text is gone (with --stale: either)')
+    .action(async function (
+      this: Command,
+      noteArg: string,
+      opts: { limit?: string; unresolved?: boolean; stale?: boolean; detached?: boolean },
+    ) {
       const store = getStore(this);
-      const note = await resolveNote(store, noteArg);
-      if (!note) {
-        console.error(error(`Note not found: ${noteArg}`));
-        process.exit(1);
-      }
+      const note = await requireNote(store, noteArg);
 
       let comments = note.comments;
+      if (opts.unresolved) {
+        comments = comments.filter((c) => !c.resolved);
+      }
+      if (opts.stale || opts.detached) {
+        comments = comments.filter(
+          (c) => (opts.stale && c.status === 'stale') || (opts.detached && c.status === 'detached'),
+        );
+      }
       if (opts.limit) {
         comments = comments.slice(0, parseInt(opts.limit, 10));
       }
@@ -112,19 +176,9 @@ export function commentCommand(program: Command): void {
     .option('--force', 'Skip confirmation')
     .action(async function (this: Command, noteArg: string, commentId: string, opts: { force?: boolean }) {
       const store = getStore(this);
-      const note = await resolveNote(store, noteArg);
-      if (!note) {
-        console.error(error(`Note not found: ${noteArg}`));
-        process.exit(1);
-      }
+      const note = await requireNote(store, noteArg);
 
-      const target = note.comments.find(
-        (c) => c.id === commentId || c.id.startsWith(commentId),
-      );
-      if (!target) {
-        console.error(error(`Comment not found: ${commentId}`));
-        process.exit(1);
-      }
+      const target = requireComment(note, commentId);
 
       if (!opts.force) {
         const confirmed = await confirm(`Delete comment ${target.id.slice(0, 8)}?`);
@@ -146,4 +200,130 @@ export function commentCommand(program: Command): void {
 
       console.log(success('Comment deleted'));
     });
+
+  comment
+    .command('edit <note> <comment-id> [comment]')
+    .description("Change a comment's text (as argument or stdin)")
+    .action(async function (
+      this: Command,
+      noteArg: string,
+      commentId: string,
+      commentArg: string | undefined,
+    ) {
+      const store = getStore(this);
+      const note = await requireNote(store, noteArg);
+      const target = requireComment(note, commentId);
+
+      const commentContent = commentArg || (await readStdin());
+      if (!commentContent) {
+        console.error(error('Comment content required (as argument or stdin)'));
+        process.exit(1);
+      }
+
+      const result = await store.editComment({
+        noteId: note.id,
+        commentId: target.id,
+        content: commentContent,
+      });
+
+      if (!result.success) {
+        console.error(error(result.error ?? 'Failed to edit comment'));
+        process.exit(1);
+      }
+
+      console.log(success('Comment updated'));
+    });
+
+  comment
+    .command('reattach <note> [comment-id]')
+    .description('Re-anchor a stale or detached comment to its quoted text')
+    .option('--all', 'Reattach every stale or detached comment on the note')
+    .action(async function (
+      this: Command,
+      noteArg: string,
+      commentId: string | undefined,
+      opts: { all?: boolean },
+    ) {
+      const store = getStore(this);
+      const note = await requireNote(store, noteArg);
+
+      if (!opts.all && !commentId) {
+        console.error(error('Provide a comment ID or --all'));
+        process.exit(1);
+      }
+
+      const targets = opts.all
+        ? note.comments.filter((c) => c.status !== 'attached')
+        : [requireComment(note, commentId ?? '')];
+
+      if (targets.length === 0) {
+        console.log('No stale or detached comments.');
+        return;
+      }
+
+      let failed = 0;
+      for (const target of targets) {
+        const result = await store.reattachComment({ noteId: note.id, commentId: target.id });
+        if (result.success) {
+          console.log(success(`Reattached ${target.id.slice(0, 8)}`));
+        } else {
+          failed += 1;
+          console.error(error(`${target.id.slice(0, 8)}: ${result.error ?? 'Failed to reattach comment'}`));
+        }
+      }
+
+      if (failed > 0) {
+        process.exit(1);
+      }
+    });
+
+  comment
+    .command('resolve <note> <comment-id>')
+    .description('Mark a comment as resolved')
+    .action(async function (this: Command, noteArg: string, commentId: string) {
+      await setCommentResolved(this, noteArg, commentId, true);
+    });
+
+  comment
+    .command('reopen <note> <comment-id>')
+    .description('Reopen a resolved comment')
+    .action(async function (this: Command, noteArg: string, commentId: string) {
+      await setCommentResolved(this, noteArg, commentId, false);
+    });
+}
+
+async function setCommentResolved(
+  cmd: Command,
+  noteArg: string,
+  commentId: string,
+  resolved: boolean,
+): Promise<void> {
+  const store = getStore(cmd);
+  const note = await requireNote(store, noteArg);
+  const target = requireComment(note, commentId);
+
+  const result = await store.setCommentResolved({
+    noteId: note.id,
+    commentId: target.id,
+    resolved,
+  });
+
+  if (!result.success) {
+    console.error(error(result.error ?? 'Failed to update comment'));
+    process.exit(1);
+  }
+
+  console.log(success(resolved ? 'Comment resolved' : 'Comment reopened'));
+}
+
+/** Find a comment by full ID or unique prefix, exiting if there is none. */
+function requireComment(note: Note, commentId: string): NoteComment {
+  const target = note.comments.find(
+    (c) => c.id === commentId || c.id.startsWith(commentId),
+  );
+  if (!target) {
+    console.error(error(`Comment not found: ${commentId}`));
+    process.exit(1);
+  }
+  return target;
 }
@@ -0,0 +1,55 @@
+import type { Command } from 'commander';
+import { parseDateExpression, type CommentFilter } from '@agentnotes/engine';
+import { formatCommentGroups, formatCommentGroupsJSON, error } from '../display/format.js';
+import { getStore } from '../cli.js';
+
+export function commentsCommand(program: Command): void {
+  program
+    .command('comments')
+    .description('List comments across every note, grouped by note')
+    .option('--author <name>', 'Only comments by this author')
+    .option('--since <date>', 'Created on or after a date (YYYY-MM-DD) or offset (24h, 7d, 2w)')
+    .option('--until <date>', 'Created on or before a date or offset')
+    .option('--resolved', 'Only resolved comments')
+    .option('--unresolved', 'Only comments that are not resolved')
+    .option('--limit <n>', 'Max comments to show')
+    .option('--json', 'Output comments as JSON')
+    .action(async function (
+      this: Command,
+      opts: {
+        author?: string;
+        since?: string;
+        until?: string;
+        resolved?: boolean;
+        unresolved?: boolean;
+        limit?: string;
+        json?: boolean;
+      },
+    ) {
+      if (opts.resolved && opts.unresolved) {
+        console.error(error('Use either --resolved or --unresolved, not both'));
+        process.exit(1);
+      }
+
+      let filter: CommentFilter;
+      try {
+        filter = {
+          author: opts.author,
+          resolved: opts.resolved ? true : opts.unresolved ? false : undefined,
+          createdAfter: opts.since ? parseDateExpression(opts.since) : undefined,
+          createdBefore: opts.until ? parseDateExpression(opts.until, 'end') : undefined,
+        };
+      } catch (err) {
+        console.error(error(err instanceof Error ? err.message : String(err)));
+        process.exit(1);
+      }
+
+      const store = getStore(this);
+      let entries = await store.getAllComments(filter);
+      if (opts.limit) {
+        entries = entries.slice(0, parseInt(opts.limit, 10));
+      }
+
+      console.log(opts.json ? formatCommentGroupsJSON(entries) : formatCommentGroups(entries));
+    });
+}
@@ -0,0 +1,24 @@
+import type { Command } from 'commander';
+import { getNotesConfigPath, getUserConfigPath, loadConfig, toYaml } from '@agentnotes/engine';
+import { resolveNotesDirectory, type StoreFlags } from '../cli.js';
+
+export function configCommand(program: Command): void {
+  const config = program
+    .command('config')
+    .description('Show configuration from ~/.config/agentnotes/config.yml and .agentnotes/config.yml');
+
+  config
+    .command('print')
+    .description('Print the effective configuration as YAML')
+    .action(function (this: Command) {
+      const flags = this.optsWithGlobals() as StoreFlags;
+      const notesDir = resolveNotesDirectory(flags.dir, flags.discover !== false);
+      const { config: effective, sources } = loadConfig(notesDir);
+
+      // Comments keep the output valid YAML, so it can seed a config file.
+      console.log(`# user config: ${getUserConfigPath()}`);
+      console.log(`# notes config: ${getNotesConfigPath(notesDir)}`);
+      console.log(sources.length > 0 ? `# applied: ${sources.join(', ')}` : '# applied: none (defaults)');
+      process.stdout.write(toYaml(effective));
+    });
+}
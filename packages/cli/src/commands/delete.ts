@@ -1,36 +1,46 @@
 import type { Command } from 'commander';
-import { success, error } from '../display/format.js';
-import { resolveNote } from '../utils/resolve.js';
+import { success, error, info } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
 import { confirm } from '../utils/stdin.js';
 import { getStore } from '../cli.js';
 
 export function deleteCommand(program: Command): void {
   program
     .command('delete <id-or-title>')
-    .description('Delete a note')
+    .description('Move a note to the trash')
     .option('--force', 'Skip confirmation')
-    .action(async function (this: Command, idOrTitle: string, opts: { force?: boolean }) {
+    .option('--purge', 'Delete permanently instead of moving to the trash')
+    .option('--dry-run', 'Show what would be deleted without deleting it')
+    .action(async function (
+      this: Command,
+      idOrTitle: string,
+      opts: { force?: boolean; purge?: boolean; dryRun?: boolean },
+    ) {
       const store = getStore(this);
-      const note = await resolveNote(store, idOrTitle);
-      if (!note) {
-        console.error(error(`Note not found: ${idOrTitle}`));
-        process.exit(1);
+      const note = await requireNote(store, idOrTitle);
+
+      if (opts.dryRun) {
+        const action = opts.purge ? 'permanently delete' : 'move to trash';
+        console.log(info(`Would ${action}: ${note.title}`));
+        console.log(`  ${note.id}`);
+        return;
       }
 
       if (!opts.force) {
-        const confirmed = await confirm(`Delete "${note.title}"?`);
+        const prompt = opts.purge ? `Permanently delete "${note.title}"?` : `Delete "${note.title}"?`;
+        const confirmed = await confirm(prompt);
         if (!confirmed) {
           console.log('Cancelled.');
           return;
         }
       }
 
-      const result = await store.deleteNote({ noteId: note.id });
+      const result = await store.deleteNote({ noteId: note.id, purge: opts.purge });
       if (!result.success) {
         console.error(error(result.error ?? 'Failed to delete note'));
         process.exit(1);
       }
 
-      console.log(success(`Deleted: ${note.title}`));
+      console.log(success(opts.purge ? `Deleted: ${note.title}` : `Moved to trash: ${note.title}`));
     });
 }
@@ -0,0 +1,47 @@
+import fs from 'node:fs';
+import type { Command } from 'commander';
+import { getDiffHunks, normalizeContent } from '@agentnotes/engine';
+import { error, formatDiff } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function diffCommand(program: Command): void {
+  program
+    .command('diff <id-or-title>')
+    .description('Show changes to a note since its last git commit, or against a file')
+    .option('--against <file>', 'Compare with this file instead of the committed version')
+    .action(async function (this: Command, idOrTitle: string, opts: { against?: string }) {
+      const store = getStore(this);
+      const note = await requireNote(store, idOrTitle);
+      if (note.encrypted) {
+        console.error(error('Cannot diff an encrypted note'));
+        process.exit(1);
+      }
+
+      let base: string | null;
+      let label: string;
+      if (opts.against !== undefined) {
+        if (!fs.existsSync(opts.against)) {
+          console.error(error(`File not found: ${opts.against}`));
+          process.exit(1);
+        }
+        base = normalizeContent(fs.readFileSync(opts.against, 'utf-8'));
+        label = opts.against;
+      } else {
+        base = store.getCommittedContent(note.id);
+        label = `${note.id} (HEAD)`;
+      }
+
+      if (base === null) {
+        console.error(error(`No committed version of ${note.id}; pass --against <file>`));
+        process.exit(1);
+      }
+
+      const hunks = getDiffHunks(base, note.content);
+      if (hunks.length === 0) {
+        console.log('No differences.');
+        return;
+      }
+      console.log(formatDiff(hunks, label, note.id));
+    });
+}
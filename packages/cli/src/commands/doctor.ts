@@ -0,0 +1,58 @@
+import type { Command } from 'commander';
+import { NOTE_SCHEMA_VERSION } from '@agentnotes/engine';
+import { formatNoteProblems, error, info, success } from '../display/format.js';
+import { getStore } from '../cli.js';
+
+export function doctorCommand(program: Command): void {
+  program
+    .command('doctor')
+    .description('Check notes for broken metadata, drifted filenames and bad comment anchors')
+    .option('--fix', 'Repair what can be repaired: sidecars, timestamps, filenames, comment anchors, duplicate IDs')
+    .option('--normalize-comments', 'Rewrite comment anchors in canonical form, e.g. after importing from other tools')
+    .option('--migrate', `Rewrite metadata sidecars from older versions at schema ${NOTE_SCHEMA_VERSION}`)
+    .action(async function (this: Command, opts: { fix?: boolean; normalizeComments?: boolean; migrate?: boolean }) {
+      const store = getStore(this);
+      if (opts.migrate) {
+        const migration = await store.migrateAllNotes();
+        if (!migration.success) {
+          console.error(error(migration.error ?? 'Failed to migrate notes'));
+          process.exit(1);
+        }
+        const count = migration.migrated.length;
+        console.log(success(`Migrated ${count} note${count === 1 ? '' : 's'} to schema ${NOTE_SCHEMA_VERSION}`));
+        for (const noteId of migration.migrated) {
+          console.log(`  ${noteId}`);
+        }
+      }
+
+      if (opts.normalizeComments) {
+        const normalized = await store.normalizeAllComments();
+        if (!normalized.success) {
+          console.error(error(normalized.error ?? 'Failed to normalize comments'));
+          process.exit(1);
+        }
+        const count = normalized.changed.length;
+        console.log(success(`Normalized comments in ${count} note${count === 1 ? '' : 's'}`));
+        for (const noteId of normalized.changed) {
+          console.log(`  ${noteId}`);
+        }
+      }
+
+      const result = await store.diagnoseNotes({ fix: opts.fix });
+      if (!result.success) {
+        console.error(error(result.error ?? 'Failed to check notes'));
+        process.exit(1);
+      }
+
+      console.log(formatNoteProblems(result.problems));
+
+      const remaining = result.problems.filter((problem) => !problem.fixed);
+      if (remaining.length > 0) {
+        if (!opts.fix && remaining.some((problem) => problem.fixable)) {
+          console.log(info('Run with --fix to repair the fixable problems.'));
+        }
+        // Non-zero so CI can fail on a broken notes directory.
+        process.exit(1);
+      }
+    });
+}
@@ -1,9 +1,24 @@
 import type { Command } from 'commander';
-import { normalizeTags } from '@agentnotes/engine';
-import { success, error } from '../display/format.js';
+import {
+  addTagsToList,
+  deleteLineInContent,
+  extractHeadingTitle,
+  insertLineInContent,
+  isValidMetaKey,
+  normalizeTags,
+  parseMetaValue,
+  parseTagList,
+  removeTagsFromList,
+  replaceLineInContent,
+  replaceNoteTitle,
+} from '@agentnotes/engine';
+import { success, error, info } from '../display/format.js';
 import { readStdin } from '../utils/stdin.js';
-import { resolveNote } from '../utils/resolve.js';
-import { getStore } from '../cli.js';
+import { requireNote } from '../utils/resolve.js';
+import { decryptOrExit, getPassphrase } from '../utils/passphrase.js';
+import { collectValues, parsePriority } from '../utils/filters.js';
+import { checkTagVocabulary, isStrictTags } from '../utils/tags.js';
+import { getConfig, getStore } from '../cli.js';
 
 export function editCommand(program: Command): void {
   program
@@ -13,37 +28,92 @@ export function editCommand(program: Command): void {
     .option('--tags <tags>', 'Replace all tags')
     .option('--add-tags <tags>', 'Add tags')
     .option('--remove-tags <tags>', 'Remove tags')
+    .option('--strict-tags', 'Reject tags that no note uses yet (also `strictTags: true` in config)')
+    .option('--new-tag', 'Allow new tags despite strict tags')
     .option('--content <content>', 'Replace content')
     .option('--append <text>', 'Append text')
     .option('--prepend <text>', 'Prepend text')
     .option('--insert <line:text>', 'Insert text at line')
     .option('--replace-line <line:text>', 'Replace line')
     .option('--delete-line <n>', 'Delete line number')
-    .action(async function (this: Command, idOrTitle: string, opts: Record<string, string | undefined>) {
-      const store = getStore(this);
-      const note = await resolveNote(store, idOrTitle);
-      if (!note) {
-        console.error(error(`Note not found: ${idOrTitle}`));
+    .option('--set <key=value>', 'Set a custom field (repeatable)', collectValues)
+    .option('--unset <key>', 'Remove a custom field (repeatable)', collectValues)
+    .option('--due <date>', 'Set the due date (YYYY-MM-DD), or "clear" to remove it')
+    .option('--priority <n>', 'Set the priority (0-10), or "clear" to remove it')
+    .option('--encrypt', 'Encrypt the body with a passphrase (prompted, or $AGENTNOTES_KEY)')
+    .option('--decrypt', 'Store an encrypted body as plaintext again')
+    .option('--dry-run', 'Show what would change without writing the note')
+    .action(async function (
+      this: Command,
+      idOrTitle: string,
+      opts: Record<string, string | undefined> & {
+        set?: string[];
+        unset?: string[];
+        dryRun?: boolean;
+        encrypt?: boolean;
+        decrypt?: boolean;
+        strictTags?: boolean;
+        newTag?: boolean;
+      },
+    ) {
+      if (opts.encrypt && opts.decrypt) {
+        console.error(error('Use either --encrypt or --decrypt, not both'));
         process.exit(1);
       }
 
+      const store = getStore(this);
+      const note = await requireNote(store, idOrTitle);
+      const dryRun = opts.dryRun === true;
+
+      // Checked before anything is written, so a rejected tag leaves the note as it was.
+      const addedTags = [opts.tags, opts.addTags].flatMap((list) => (list === undefined ? [] : parseTagList(list)));
+      await checkTagVocabulary(store, addedTags, isStrictTags(opts, getConfig(this)));
+
+      const metaChanges = parseMetaChanges(opts.set ?? [], opts.unset ?? [], opts.priority);
+      if (metaChanges && dryRun) {
+        for (const [key, value] of Object.entries(metaChanges)) {
+          console.log(info(value === null ? `Would unset field ${key}` : `Would set ${key} to ${JSON.stringify(value)}`));
+        }
+      } else if (metaChanges) {
+        const result = await store.updateNoteMetadata({ noteId: note.id, meta: metaChanges });
+        if (!result.success) {
+          console.error(error(result.error ?? 'Failed to update fields'));
+          process.exit(1);
+        }
+        console.log(success('Fields updated'));
+      }
+
+      if (opts.due !== undefined && dryRun) {
+        console.log(info(opts.due === 'clear' ? 'Would clear the due date' : `Would set due date to ${opts.due}`));
+      } else if (opts.due !== undefined) {
+        const due = opts.due === 'clear' ? null : opts.due;
+        const result = await store.updateNoteMetadata({ noteId: note.id, due });
+        if (!result.success) {
+          console.error(error(result.error ?? 'Failed to update due date'));
+          process.exit(1);
+        }
+        console.log(success(due ? `Due ${result.note?.due ?? due}` : 'Due date cleared'));
+      }
+
       let tagsChanged = false;
       let newTags = [...note.tags];
 
       if (opts.tags !== undefined) {
-        newTags = parseTags(opts.tags);
+        newTags = parseTagList(opts.tags);
         tagsChanged = true;
       }
       if (opts.addTags !== undefined) {
-        newTags = addTagsToList(newTags, parseTags(opts.addTags));
+        newTags = addTagsToList(newTags, parseTagList(opts.addTags));
         tagsChanged = true;
       }
       if (opts.removeTags !== undefined) {
-        newTags = removeTagsFromList(newTags, parseTags(opts.removeTags));
+        newTags = removeTagsFromList(newTags, parseTagList(opts.removeTags));
         tagsChanged = true;
       }
 
-      if (tagsChanged) {
+      if (tagsChanged && dryRun) {
+        console.log(info(`Would change tags from [${note.tags.join(', ')}] to [${normalizeTags(newTags).join(', ')}]`));
+      } else if (tagsChanged) {
         const result = await store.updateNoteMetadata({
           noteId: note.id,
           tags: normalizeTags(newTags),
@@ -56,75 +126,137 @@ export function editCommand(program: Command): void {
       }
 
       let newContent: string | undefined;
+      let noteId = note.id;
+      let encryptedWithEdit = false;
 
       const stdinContent = await readStdin();
+      const editsContent =
+        stdinContent !== undefined || CONTENT_OPTIONS.some((option) => opts[option] !== undefined);
+
+      // Content edits apply to the plaintext; updateNote re-encrypts it.
+      let passphrase: string | undefined;
+      let content = note.content;
+      if (note.encrypted && (editsContent || opts.decrypt || opts.encrypt)) {
+        passphrase = await getPassphrase();
+        content = decryptOrExit(note, passphrase);
+      } else if (opts.encrypt && editsContent && !dryRun) {
+        // Encrypted in the same write as the edit, so the new text is never saved in plaintext.
+        passphrase = await getPassphrase({ confirm: true });
+      }
+
       if (stdinContent) {
         newContent = stdinContent;
       } else if (opts.content !== undefined) {
         newContent = opts.content;
       } else if (opts.append !== undefined) {
-        newContent = note.content + '\n' + opts.append;
+        newContent = content + '\n' + opts.append;
       } else if (opts.prepend !== undefined) {
-        newContent = opts.prepend + '\n' + note.content;
+        newContent = opts.prepend + '\n' + content;
       } else if (opts.insert !== undefined) {
         const { line, text } = parseLineEdit(opts.insert);
-        newContent = insertLineInContent(note.content, line, text);
+        newContent = insertLineInContent(content, line, text);
       } else if (opts.replaceLine !== undefined) {
         const { line, text } = parseLineEdit(opts.replaceLine);
-        newContent = replaceLineInContent(note.content, line, text);
+        newContent = replaceLineInContent(content, line, text);
       } else if (opts.deleteLine !== undefined) {
         const lineNum = parseInt(opts.deleteLine, 10);
-        newContent = deleteLineInContent(note.content, lineNum);
+        newContent = deleteLineInContent(content, lineNum);
       }
 
       if (opts.title !== undefined) {
-        const currentContent = newContent ?? note.content;
-        const lines = currentContent.split('\n');
-        if (lines[0].match(/^#\s+/)) {
-          lines[0] = `# ${opts.title}`;
-        } else {
-          lines.unshift(`# ${opts.title}`);
+        if (!opts.title.trim()) {
+          console.error(error('Title cannot be empty'));
+          process.exit(1);
         }
-        newContent = lines.join('\n');
+        newContent = replaceNoteTitle(newContent ?? content, opts.title);
       }
 
-      if (newContent !== undefined) {
+      if (newContent !== undefined && dryRun) {
+        const newTitle = extractHeadingTitle(newContent);
+        if (newTitle && newTitle !== note.title) {
+          console.log(info(`Would change title from ${note.title} to ${newTitle}`));
+        }
+        if (newContent !== content) {
+          console.log(info(`Would update content (${countLines(content)} → ${countLines(newContent)} lines)`));
+        }
+      } else if (newContent !== undefined) {
+        const encryptsWithEdit = opts.encrypt === true && !note.encrypted;
         const result = await store.updateNote({
-          noteId: note.id,
+          noteId,
           content: newContent,
+          passphrase,
+          encrypt: encryptsWithEdit || undefined,
         });
         if (!result.success) {
           console.error(error(result.error ?? 'Failed to update content'));
           process.exit(1);
         }
-        console.log(success('Note updated'));
+        // A new title renames the file, and with it the ID.
+        noteId = result.note?.id ?? noteId;
+        encryptedWithEdit = encryptsWithEdit;
+        console.log(success(encryptsWithEdit ? 'Note updated and encrypted' : 'Note updated'));
       }
 
-      if (!tagsChanged && newContent === undefined) {
+      const encrypted = opts.encrypt ? true : opts.decrypt ? false : undefined;
+      if (encrypted !== undefined && dryRun) {
+        console.log(info(encrypted ? 'Would encrypt the note body' : 'Would decrypt the note body'));
+      } else if (encrypted !== undefined && !encryptedWithEdit) {
+        const result = await store.setNoteEncryption({
+          noteId,
+          passphrase: passphrase ?? (await getPassphrase({ confirm: true })),
+          encrypted,
+        });
+        if (!result.success) {
+          console.error(error(result.error ?? 'Failed to change encryption'));
+          process.exit(1);
+        }
+        console.log(success(encrypted ? 'Note encrypted' : 'Note decrypted'));
+      }
+
+      if (
+        !tagsChanged &&
+        !metaChanges &&
+        opts.due === undefined &&
+        newContent === undefined &&
+        encrypted === undefined
+      ) {
         console.log('No changes specified.');
       }
     });
 }
 
-function parseTags(value: string): string[] {
-  return value.split(',').map((t: string) => t.trim()).filter(Boolean);
+const CONTENT_OPTIONS = ['content', 'append', 'prepend', 'insert', 'replaceLine', 'deleteLine', 'title'];
+
+function countLines(content: string): number {
+  return content === '' ? 0 : content.split('\n').length;
 }
 
-function addTagsToList(existing: string[], toAdd: string[]): string[] {
-  const lower = new Set(existing.map((t) => t.toLocaleLowerCase()));
-  const result = [...existing];
-  for (const tag of toAdd) {
-    if (!lower.has(tag.toLocaleLowerCase())) {
-      result.push(tag);
-      lower.add(tag.toLocaleLowerCase());
-    }
+function parseMetaChanges(
+  set: string[],
+  unset: string[],
+  priority: string | undefined,
+): Record<string, unknown> | null {
+  if (set.length === 0 && unset.length === 0 && priority === undefined) {
+    return null;
   }
-  return result;
-}
 
-function removeTagsFromList(existing: string[], toRemove: string[]): string[] {
-  const lower = new Set(toRemove.map((t) => t.toLocaleLowerCase()));
-  return existing.filter((t) => !lower.has(t.toLocaleLowerCase()));
+  const changes: Record<string, unknown> = {};
+  for (const assignment of set) {
+    const separator = assignment.indexOf('=');
+    const key = separator >= 0 ? assignment.slice(0, separator).trim() : '';
+    if (!isValidMetaKey(key)) {
+      console.error(error(`Invalid --set value: ${assignment} (use key=value)`));
+      process.exit(1);
+    }
+    changes[key] = parseMetaValue(assignment.slice(separator + 1));
+  }
+  for (const key of unset) {
+    changes[key.trim()] = null;
+  }
+  if (priority !== undefined) {
+    changes.priority = priority === 'clear' ? null : parsePriority(priority);
+  }
+  return changes;
 }
 
 function parseLineEdit(value: string): { line: number; text: string } {
@@ -137,30 +269,3 @@ function parseLineEdit(value: string): { line: number; text: string } {
     text: value.slice(colonIndex + 1),
   };
 }
-
-function insertLineInContent(content: string, lineNum: number, text: string): string {
-  const lines = content.split('\n');
-  const index = Math.max(0, Math.min(lineNum - 1, lines.length));
-  lines.splice(index, 0, text);
-  return lines.join('\n');
-}
-
-function replaceLineInContent(content: string, lineNum: number, text: string): string {
-  const lines = content.split('\n');
-  const index = lineNum - 1;
-  if (index < 0 || index >= lines.length) {
-    throw new Error(`Line ${lineNum} out of range (1-${lines.length})`);
-  }
-  lines[index] = text;
-  return lines.join('\n');
-}
-
-function deleteLineInContent(content: string, lineNum: number): string {
-  const lines = content.split('\n');
-  const index = lineNum - 1;
-  if (index < 0 || index >= lines.length) {
-    throw new Error(`Line ${lineNum} out of range (1-${lines.length})`);
-  }
-  lines.splice(index, 1);
-  return lines.join('\n');
-}
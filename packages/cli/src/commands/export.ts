@@ -0,0 +1,68 @@
+import fs from 'node:fs';
+import path from 'node:path';
+import type { Command } from 'commander';
+import { exportHTML, exportMarkdown, search } from '@agentnotes/engine';
+import { success, error } from '../display/format.js';
+import { getSortFields } from '../utils/filters.js';
+import { getStore } from '../cli.js';
+
+const EXPORT_FORMATS = ['md', 'html'] as const;
+type ExportFormat = (typeof EXPORT_FORMATS)[number];
+
+function isExportFormat(value: string): value is ExportFormat {
+  return (EXPORT_FORMATS as readonly string[]).includes(value);
+}
+
+export function exportCommand(program: Command): void {
+  program
+    .command('export')
+    .description('Export notes as one markdown document or a static HTML site')
+    .option('--format <format>', 'Export format: md, html', 'md')
+    .option('--out <path>', 'Output file for md (defaults to stdout), output directory for html')
+    .option('--tags <tags>', 'Only export notes with these tags (comma-separated)')
+    .option('--sort <fields>', 'Sort by: created, updated, title, due (comma-separated for tiebreakers)', 'created')
+    .option('--toc', 'Prepend a table of contents (md)')
+    .action(async function (
+      this: Command,
+      opts: { format: string; out?: string; tags?: string; sort: string; toc?: boolean },
+    ) {
+      if (!isExportFormat(opts.format)) {
+        console.error(error(`Unknown format: ${opts.format} (expected ${EXPORT_FORMATS.join(', ')})`));
+        process.exit(1);
+      }
+
+      if (opts.format === 'html' && !opts.out) {
+        console.error(error('HTML export needs --out <directory>'));
+        process.exit(1);
+      }
+
+      const store = getStore(this);
+      const result = await store.listNotes();
+      const tags = opts.tags ? opts.tags.split(',').map((t: string) => t.trim()) : undefined;
+      const notes = search(result.notes, { tags, sortBy: getSortFields(opts.sort), includeArchived: true });
+      const exported = `${notes.length} note${notes.length === 1 ? '' : 's'}`;
+
+      if (opts.format === 'html') {
+        const outDir = path.resolve(opts.out!);
+        for (const file of exportHTML(notes, { title: store.getNotebook() ?? 'Notes' })) {
+          const filePath = path.join(outDir, file.path);
+          fs.mkdirSync(path.dirname(filePath), { recursive: true });
+          fs.writeFileSync(filePath, file.content, 'utf-8');
+        }
+        console.log(success(`Exported ${exported} to ${outDir}`));
+        return;
+      }
+
+      const output = exportMarkdown(notes, { toc: opts.toc });
+
+      if (!opts.out) {
+        process.stdout.write(output);
+        return;
+      }
+
+      const outPath = path.resolve(opts.out);
+      fs.mkdirSync(path.dirname(outPath), { recursive: true });
+      fs.writeFileSync(outPath, output, 'utf-8');
+      console.log(success(`Exported ${exported} to ${outPath}`));
+    });
+}
@@ -0,0 +1,23 @@
+import type { Command } from 'commander';
+import { formatNoteHistory, info } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function historyCommand(program: Command): void {
+  program
+    .command('history <id-or-title>')
+    .description('List the git commits that changed a note')
+    .action(async function (this: Command, idOrTitle: string) {
+      const store = getStore(this);
+      const note = await requireNote(store, idOrTitle);
+
+      const result = store.getNoteHistory(note.id);
+      if (!result.revisions) {
+        // Not being in git is a setup choice, not a failure.
+        console.log(info(result.error ?? 'History unavailable'));
+        return;
+      }
+
+      console.log(formatNoteHistory(result.revisions));
+    });
+}
@@ -0,0 +1,33 @@
+import type { Command } from 'commander';
+import { normalizeTags } from '@agentnotes/engine';
+import { success, error, info } from '../display/format.js';
+import { getStore } from '../cli.js';
+
+export function importCommand(program: Command): void {
+  program
+    .command('import <dir>')
+    .description('Import a directory of markdown files as notes')
+    .option('--tags <tags>', 'Tags to add to every imported note (comma-separated)')
+    .option('--dry-run', 'Show what would be imported without writing anything')
+    .action(async function (this: Command, dir: string, opts: { tags?: string; dryRun?: boolean }) {
+      const store = getStore(this);
+      const tags = opts.tags ? normalizeTags(opts.tags.split(',')) : undefined;
+      const result = await store.importNotes({ sourceDirectory: dir, tags, dryRun: opts.dryRun });
+
+      if (!result.success) {
+        console.error(error(result.error ?? 'Import failed'));
+        process.exit(1);
+      }
+
+      for (const entry of result.imported) {
+        console.log(`  ${entry.source} -> ${entry.noteId}`);
+      }
+      for (const entry of result.skipped) {
+        console.log(`  ${entry.source} skipped: ${entry.reason}`);
+      }
+
+      const count = `${result.imported.length} note${result.imported.length === 1 ? '' : 's'}`;
+      const skipped = result.skipped.length > 0 ? `, ${result.skipped.length} skipped` : '';
+      console.log(opts.dryRun ? info(`Would import ${count}${skipped}`) : success(`Imported ${count}${skipped}`));
+    });
+}
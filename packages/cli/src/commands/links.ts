@@ -0,0 +1,22 @@
+import type { Command } from 'commander';
+import { extractLinks } from '@agentnotes/engine';
+import { formatLinks, type ResolvedLink } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function linksCommand(program: Command): void {
+  program
+    .command('links <id-or-title>')
+    .description('List outgoing [[wiki-links]] and whether they resolve')
+    .action(async function (this: Command, idOrTitle: string) {
+      const store = getStore(this);
+      const note = await requireNote(store, idOrTitle);
+
+      const links: ResolvedLink[] = [];
+      for (const ref of extractLinks(note.content)) {
+        links.push({ ref, note: await store.resolveLink(ref) });
+      }
+
+      console.log(formatLinks(links));
+    });
+}
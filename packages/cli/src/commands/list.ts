@@ -1,26 +1,158 @@
 import type { Command } from 'commander';
-import { search, type SortField } from '@agentnotes/engine';
-import { formatNoteList } from '../display/format.js';
+import { logWarning, search, serializeNotesCSV, serializeNotesTSV, type SearchOptions } from '@agentnotes/engine';
+import {
+  error,
+  info,
+  formatNoteList,
+  formatNoteListJSON,
+  formatPageFooter,
+  toNoteListJSONEntry,
+} from '../display/format.js';
+import {
+  collectValues,
+  getArchiveFilter,
+  getDateFilters,
+  getLimit,
+  getPage,
+  getPriorityFilter,
+  getSortFields,
+  type ArchiveFlags,
+  type DateFilterFlags,
+  type LimitFlags,
+  type PageFlags,
+  type PriorityFlags,
+} from '../utils/filters.js';
+import { exitOnClosedPipe, printPaged } from '../utils/pager.js';
 import { getStore } from '../cli.js';
 
+const LIST_FORMATS = ['text', 'json', 'ndjson', 'csv', 'tsv'] as const;
+type ListFormat = (typeof LIST_FORMATS)[number];
+
+function isListFormat(value: string): value is ListFormat {
+  return (LIST_FORMATS as readonly string[]).includes(value);
+}
+
 export function listCommand(program: Command): void {
   program
     .command('list')
     .description('List notes')
     .option('--tags <tags>', 'Filter by tags (comma-separated)')
-    .option('--limit <n>', 'Max notes to show', '20')
-    .option('--sort <field>', 'Sort by: created, updated, title', 'created')
-    .action(async function (this: Command, opts: { tags?: string; limit: string; sort: string }) {
+    .option('--meta <key[=value]>', 'Filter by custom field, optionally its value (repeatable)', collectValues)
+    .option('--limit <n>', 'Max notes to show (0 for no limit)', '20')
+    .option('--all', 'Show every match (same as --limit 0)')
+    .option('--page <n>', 'Show page n of the results (1-based)')
+    .option('--page-size <n>', 'Notes per page (default: --limit)')
+    .option('--sort <fields>', 'Sort by: created, updated, title, due (comma-separated for tiebreakers)', 'created')
+    .option('--reverse', 'Reverse the sort order')
+    .option('--format <format>', 'Output format: text, json, ndjson, csv, tsv', 'text')
+    .option('--json', 'Output note metadata as JSON (same as --format json)')
+    .option('--no-header', 'Leave out the header row of tsv output')
+    .option('--ndjson', 'Output one JSON object per note per line (same as --format ndjson)')
+    .option('--json-content', 'Include note content in JSON output')
+    .option('--since <date>', 'Created on or after a date (YYYY-MM-DD) or offset (24h, 7d, 2w)')
+    .option('--until <date>', 'Created on or before a date or offset')
+    .option('--updated-since <date>', 'Updated on or after a date or offset')
+    .option('--min-priority <n>', 'Only notes with a priority of at least n (0-10)')
+    .option('--max-priority <n>', 'Only notes with a priority of at most n (0-10)')
+    .option('--archived', 'Include archived notes')
+    .option('--only-archived', 'Show only archived notes')
+    .option('--pinned', 'Show only pinned notes')
+    .option('--count', 'Print only the number of matches, ignoring --limit and paging')
+    .option('--exit-code', 'Exit with status 1 when nothing matches')
+    .action(async function (
+      this: Command,
+      opts: DateFilterFlags & LimitFlags & PageFlags & PriorityFlags & ArchiveFlags & {
+        tags?: string;
+        meta?: string[];
+        sort: string;
+        reverse?: boolean;
+        format: string;
+        json?: boolean;
+        ndjson?: boolean;
+        header: boolean;
+        jsonContent?: boolean;
+        count?: boolean;
+        exitCode?: boolean;
+        pinned?: boolean;
+      },
+    ) {
+      if (opts.json && opts.ndjson) {
+        console.error(error('--json and --ndjson cannot be combined'));
+        process.exit(1);
+      }
+
+      if (!isListFormat(opts.format)) {
+        console.error(error(`Unknown format: ${opts.format} (expected ${LIST_FORMATS.join(', ')})`));
+        process.exit(1);
+      }
+
       const store = getStore(this);
       const result = await store.listNotes();
+      const ids = store.checkNoteIds();
+      if (!ids.success) {
+        // stderr, so the warning never ends up in JSON or CSV output.
+        logWarning(info(`${ids.error}; run \`agentnotes doctor --fix\` to reassign one`));
+      }
       const tags = opts.tags ? opts.tags.split(',').map((t: string) => t.trim()) : undefined;
 
-      const filtered = search(result.notes, {
+      const options: SearchOptions = {
         tags,
-        limit: parseInt(opts.limit, 10),
-        sortBy: opts.sort as SortField,
-      });
+        meta: opts.meta,
+        ...getDateFilters(opts),
+        ...getPriorityFilter(opts),
+        ...getArchiveFilter(opts),
+        onlyPinned: opts.pinned,
+        sortBy: getSortFields(opts.sort),
+        reverse: opts.reverse,
+        pinnedFirst: true,
+      };
+      const limit = getLimit(opts);
+      const page = getPage(opts, limit);
+      const matches = search(result.notes, options);
+      if (opts.exitCode && matches.length === 0) {
+        process.exitCode = 1;
+      }
+      if (opts.count) {
+        console.log(matches.length);
+        return;
+      }
+
+      // The second pass only pages; matches are already filtered, archive state included.
+      const sortOptions = {
+        sortBy: options.sortBy,
+        reverse: options.reverse,
+        pinnedFirst: true,
+        includeArchived: true,
+      };
+      const filtered = page
+        ? search(matches, { ...sortOptions, offset: (page.number - 1) * page.size, limit: page.size })
+        : search(matches, { ...sortOptions, limit });
+
+      exitOnClosedPipe();
+      if (opts.format === 'csv') {
+        process.stdout.write(serializeNotesCSV(filtered));
+        return;
+      }
+
+      if (opts.format === 'tsv') {
+        process.stdout.write(serializeNotesTSV(filtered, { header: opts.header }));
+        return;
+      }
+
+      if (opts.ndjson || opts.format === 'ndjson') {
+        // One write per note, so a consumer can start on the first line right away.
+        for (const note of filtered) {
+          process.stdout.write(JSON.stringify(toNoteListJSONEntry(note, opts.jsonContent ?? false)) + '\n');
+        }
+        return;
+      }
+
+      if (opts.json || opts.jsonContent || opts.format === 'json') {
+        console.log(formatNoteListJSON(filtered, opts.jsonContent ?? false));
+        return;
+      }
 
-      console.log(formatNoteList(filtered));
+      const footer = page ? [formatPageFooter(page.number, page.size, matches.length)] : [];
+      printPaged([formatNoteList(filtered), ...footer].join('\n'));
     });
 }
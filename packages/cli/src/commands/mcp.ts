@@ -0,0 +1,41 @@
+import readline from 'node:readline';
+import type { Command } from 'commander';
+import { handleMcpMessage, type JsonRpcResponse } from '@agentnotes/engine';
+import { getStore } from '../cli.js';
+
+function send(response: JsonRpcResponse): void {
+  process.stdout.write(`${JSON.stringify(response)}\n`);
+}
+
+export function mcpCommand(program: Command): void {
+  program
+    .command('mcp')
+    .description('Run a Model Context Protocol server on stdio for AI agents')
+    .action(async function (this: Command) {
+      const store = getStore(this);
+
+      // stdout carries protocol messages only; send stray logging to stderr.
+      console.log = console.error;
+
+      // Messages are newline-delimited JSON-RPC, handled in order.
+      const lines = readline.createInterface({ input: process.stdin, crlfDelay: Infinity });
+      for await (const line of lines) {
+        if (!line.trim()) {
+          continue;
+        }
+
+        let message: unknown;
+        try {
+          message = JSON.parse(line);
+        } catch {
+          send({ jsonrpc: '2.0', id: null, error: { code: -32700, message: 'Parse error' } });
+          continue;
+        }
+
+        const response = await handleMcpMessage(store, message);
+        if (response) {
+          send(response);
+        }
+      }
+    });
+}
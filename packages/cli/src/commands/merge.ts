@@ -0,0 +1,55 @@
+import type { Command } from 'commander';
+import { success, error, info } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function mergeCommand(program: Command): void {
+  program
+    .command('merge <source> <target>')
+    .description('Append one note to another, moving its comments, tags and aliases')
+    .option('--keep-source', 'Keep the source note instead of moving it to the trash')
+    .option('--dry-run', 'Show what would be merged without changing either note')
+    .action(async function (
+      this: Command,
+      sourceQuery: string,
+      targetQuery: string,
+      options: { keepSource?: boolean; dryRun?: boolean },
+    ) {
+      const store = getStore(this);
+      const source = await requireNote(store, sourceQuery);
+      const target = await requireNote(store, targetQuery);
+
+      if (options.dryRun) {
+        if (source.id === target.id) {
+          console.error(error('Cannot merge a note into itself'));
+          process.exit(1);
+        }
+        console.log(info(`Would merge: ${source.title} → ${target.title}`));
+        const moved = [
+          `${source.comments.length} comment(s)`,
+          `${source.tags.length} tag(s)`,
+          `${source.aliases?.length ?? 0} alias(es)`,
+        ];
+        console.log(`  would move ${moved.join(', ')}`);
+        if (!options.keepSource) {
+          console.log(`  ${source.id} would be moved to trash`);
+        }
+        return;
+      }
+
+      const result = await store.mergeNotes({
+        sourceId: source.id,
+        targetId: target.id,
+        keepSource: options.keepSource,
+      });
+      if (!result.success) {
+        console.error(error(result.error ?? 'Failed to merge notes'));
+        process.exit(1);
+      }
+
+      console.log(success(`Merged: ${source.title} → ${target.title}`));
+      if (!options.keepSource) {
+        console.log(`  ${source.id} moved to trash`);
+      }
+    });
+}
@@ -0,0 +1,38 @@
+import type { Command } from 'commander';
+import type { NoteStore } from '@agentnotes/engine';
+import { success, error } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { createStore, type StoreFlags } from '../cli.js';
+
+export function mvCommand(program: Command): void {
+  program
+    .command('mv <id-or-title>')
+    .description('Move a note into another notebook (-n, --notebook), keeping its filename and comments')
+    .option('--from <notebook>', 'Look the note up in this notebook only (default: the whole notes directory)')
+    .action(async function (this: Command, idOrTitle: string, opts: { from?: string }) {
+      // The global --notebook names the target here, so the note is looked up outside it.
+      const flags = this.optsWithGlobals() as StoreFlags;
+      if (!flags.notebook) {
+        console.error(error('Target notebook is required (--notebook <name>)'));
+        process.exit(1);
+      }
+
+      let store: NoteStore;
+      try {
+        store = createStore({ ...flags, notebook: opts.from });
+      } catch (err) {
+        console.error(error(err instanceof Error ? err.message : String(err)));
+        process.exit(1);
+      }
+
+      const note = await requireNote(store, idOrTitle);
+      const result = await store.moveNoteToNotebook({ noteId: note.id, notebook: flags.notebook });
+      if (!result.success) {
+        console.error(error(result.error ?? 'Failed to move note'));
+        process.exit(1);
+      }
+
+      console.log(success(`Moved ${note.title} to notebook ${flags.notebook}`));
+      console.log(`  ${result.note?.id ?? note.id}`);
+    });
+}
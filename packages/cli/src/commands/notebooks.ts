@@ -0,0 +1,14 @@
+import type { Command } from 'commander';
+import { formatNotebooks } from '../display/format.js';
+import { getStore } from '../cli.js';
+
+export function notebooksCommand(program: Command): void {
+  program
+    .command('notebooks')
+    .description('List notebooks with note counts')
+    .action(async function (this: Command) {
+      const store = getStore(this);
+      const notebooks = await store.listNotebooks();
+      console.log(formatNotebooks(notebooks));
+    });
+}
@@ -0,0 +1,15 @@
+import type { Command } from 'commander';
+import { editNoteInEditor } from '../utils/editor.js';
+import { requireNote } from '../utils/resolve.js';
+import { getConfig, getStore } from '../cli.js';
+
+export function openCommand(program: Command): void {
+  program
+    .command('open <id-or-title>')
+    .description('Edit a note in $EDITOR')
+    .action(async function (this: Command, idOrTitle: string) {
+      const store = getStore(this);
+      const note = await requireNote(store, idOrTitle);
+      await editNoteInEditor(store, note, getConfig(this).editor);
+    });
+}
@@ -0,0 +1,13 @@
+import type { Command } from 'commander';
+import { requirePickedNote } from '../utils/picker.js';
+import { getStore } from '../cli.js';
+
+export function pickCommand(program: Command): void {
+  program
+    .command('pick')
+    .description('Choose a note with an interactive fuzzy finder and print its ID (see also show --pick)')
+    .action(async function (this: Command) {
+      const note = await requirePickedNote(getStore(this));
+      console.log(note.id);
+    });
+}
@@ -0,0 +1,33 @@
+import type { Command } from 'commander';
+import { success, error } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function pinCommand(program: Command): void {
+  program
+    .command('pin <id-or-title>')
+    .description('List a note ahead of the others, whatever the sort')
+    .action(async function (this: Command, idOrTitle: string) {
+      await setPinned(this, idOrTitle, true);
+    });
+
+  program
+    .command('unpin <id-or-title>')
+    .description('List a pinned note in its usual place again')
+    .action(async function (this: Command, idOrTitle: string) {
+      await setPinned(this, idOrTitle, false);
+    });
+}
+
+async function setPinned(command: Command, idOrTitle: string, pinned: boolean): Promise<void> {
+  const store = getStore(command);
+  const note = await requireNote(store, idOrTitle);
+
+  const result = await store.setNotePinned({ noteId: note.id, pinned });
+  if (!result.success) {
+    console.error(error(result.error ?? 'Failed to update note'));
+    process.exit(1);
+  }
+
+  console.log(success(`${pinned ? 'Pinned' : 'Unpinned'} note: ${note.title}`));
+}
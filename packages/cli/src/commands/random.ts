@@ -0,0 +1,34 @@
+import { Option, type Command } from 'commander';
+import { createSeededRandom, pickRandomNotes, search } from '@agentnotes/engine';
+import { formatNoteDetail, info, error } from '../display/format.js';
+import { getStore } from '../cli.js';
+
+export function randomCommand(program: Command): void {
+  program
+    .command('random')
+    .description('Show a random note')
+    .option('--tags <tags>', 'Pick from notes with these tags (comma-separated)')
+    .option('--count <n>', 'Number of distinct notes to show', '1')
+    .addOption(new Option('--seed <n>', 'Seed the random pick').hideHelp())
+    .action(async function (this: Command, opts: { tags?: string; count: string; seed?: string }) {
+      const count = parseInt(opts.count, 10);
+      if (Number.isNaN(count) || count < 1) {
+        console.error(error(`Invalid count: ${opts.count}`));
+        process.exit(1);
+      }
+
+      const store = getStore(this);
+      const result = await store.listNotes();
+      const tags = opts.tags ? opts.tags.split(',').map((t: string) => t.trim()) : undefined;
+      const candidates = search(result.notes, { tags });
+
+      if (candidates.length === 0) {
+        console.log(info(tags ? `No notes tagged ${tags.map((t) => `#${t}`).join(' ')}.` : 'No notes found.'));
+        return;
+      }
+
+      const random = opts.seed !== undefined ? createSeededRandom(parseInt(opts.seed, 10)) : Math.random;
+      const picked = pickRandomNotes(candidates, count, random);
+      console.log(picked.map((note) => formatNoteDetail(note)).join('\n\n'));
+    });
+}
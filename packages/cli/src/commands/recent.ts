@@ -0,0 +1,22 @@
+import type { Command } from 'commander';
+import { search } from '@agentnotes/engine';
+import { formatRecentList } from '../display/format.js';
+import { getStore } from '../cli.js';
+
+export function recentCommand(program: Command): void {
+  program
+    .command('recent')
+    .description('List recently updated notes')
+    .option('--limit <n>', 'Max notes to show', '10')
+    .action(async function (this: Command, opts: { limit: string }) {
+      const store = getStore(this);
+      const result = await store.listNotes();
+      const recent = search(result.notes, {
+        limit: parseInt(opts.limit, 10),
+        sortBy: 'updated',
+        reverse: true,
+      });
+
+      console.log(formatRecentList(recent));
+    });
+}
@@ -0,0 +1,18 @@
+import type { Command } from 'commander';
+import { error, success } from '../display/format.js';
+import { getStore } from '../cli.js';
+
+export function reindexCommand(program: Command): void {
+  program
+    .command('reindex')
+    .description('Rebuild the search index in .agentnotes/index.json from every note')
+    .action(async function (this: Command) {
+      const result = await getStore(this).rebuildSearchIndex();
+      if (!result.success) {
+        console.error(error(result.error ?? 'Failed to rebuild the search index'));
+        process.exit(1);
+      }
+
+      console.log(success(`Indexed ${result.noteCount} note${result.noteCount === 1 ? '' : 's'}`));
+    });
+}
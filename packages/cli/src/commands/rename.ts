@@ -0,0 +1,36 @@
+import type { Command } from 'commander';
+import { success, error, info } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function renameCommand(program: Command): void {
+  program
+    .command('rename <id-or-title> <new-title>')
+    .description('Retitle a note and rename its file to match')
+    .option('--dry-run', 'Show the new title without renaming anything')
+    .action(async function (this: Command, idOrTitle: string, newTitle: string, opts: { dryRun?: boolean }) {
+      const store = getStore(this);
+      const note = await requireNote(store, idOrTitle);
+
+      if (opts.dryRun) {
+        if (!newTitle.trim()) {
+          console.error(error('Title cannot be empty'));
+          process.exit(1);
+        }
+        console.log(info(`Would rename: ${note.title} \u2192 ${newTitle.trim()}`));
+        console.log(`  ${note.id}`);
+        return;
+      }
+
+      const result = await store.renameNote({ noteId: note.id, title: newTitle });
+      if (!result.success) {
+        console.error(error(result.error ?? 'Failed to rename note'));
+        process.exit(1);
+      }
+
+      console.log(success(`Renamed: ${note.title} \u2192 ${newTitle.trim()}`));
+      if (result.note) {
+        console.log(`  ${result.note.id}`);
+      }
+    });
+}
@@ -0,0 +1,33 @@
+import fs from 'node:fs';
+import type { Command } from 'commander';
+import { restoreBackup } from '@agentnotes/engine';
+import { error, info, success } from '../display/format.js';
+import { resolveNotesDirectory, type StoreFlags } from '../cli.js';
+
+export function restoreBackupCommand(program: Command): void {
+  program
+    .command('restore-backup <file>')
+    .description('Extract a backup made with `backup` into the notes directory')
+    .option('--force', 'Restore into a notes directory that already has files, replacing those in the backup')
+    .action(function (this: Command, file: string, opts: { force?: boolean }) {
+      if (!fs.existsSync(file)) {
+        console.error(error(`Backup not found: ${file}`));
+        process.exit(1);
+      }
+
+      const flags = this.optsWithGlobals() as StoreFlags;
+      const notesDir = resolveNotesDirectory(flags.dir, flags.discover !== false);
+      try {
+        const manifest = restoreBackup(file, notesDir, { force: opts.force });
+        const count = manifest.noteCount;
+        console.log(success(`Restored ${count} note${count === 1 ? '' : 's'} from the backup of ${manifest.created}`));
+        console.log(`  ${notesDir}`);
+      } catch (err) {
+        console.error(error(`Restore failed: ${err instanceof Error ? err.message : String(err)}`));
+        if (!opts.force && err instanceof Error && err.message.startsWith('Notes directory is not empty')) {
+          console.error(info('Run with --force to restore over it.'));
+        }
+        process.exit(1);
+      }
+    });
+}
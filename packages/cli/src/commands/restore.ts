@@ -0,0 +1,27 @@
+import type { Command } from 'commander';
+import { lookupNote } from '@agentnotes/engine';
+import { success, error } from '../display/format.js';
+import { requireLookup } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function restoreCommand(program: Command): void {
+  program
+    .command('restore <id-or-title>')
+    .description('Restore a note from the trash')
+    .action(async function (this: Command, idOrTitle: string) {
+      const store = getStore(this);
+      const note = requireLookup(
+        lookupNote(await store.listTrash(), idOrTitle),
+        `Note not found in trash: ${idOrTitle}`,
+      );
+
+      const result = await store.restoreNote({ noteId: note.id });
+      if (!result.success || !result.note) {
+        console.error(error(result.error ?? 'Failed to restore note'));
+        process.exit(1);
+      }
+
+      console.log(success(`Restored: ${result.note.title}`));
+      console.log(`  ${result.note.id}`);
+    });
+}
@@ -0,0 +1,59 @@
+import type { Command } from 'commander';
+import { parseTagList, search } from '@agentnotes/engine';
+import { success, error, info } from '../display/format.js';
+import { getStore } from '../cli.js';
+
+export function retagCommand(program: Command): void {
+  program
+    .command('retag')
+    .description('Add or remove tags on every note matching --tags and/or --query')
+    .option('--add <tags>', 'Tags to add (comma-separated)')
+    .option('--remove <tags>', 'Tags to remove (comma-separated)')
+    .option('--tags <tags>', 'Select notes with all of these tags (comma-separated)')
+    .option('--query <text>', 'Select notes matching a search query')
+    .option('--dry-run', 'Show which notes would change without writing them')
+    .action(async function (
+      this: Command,
+      opts: { add?: string; remove?: string; tags?: string; query?: string; dryRun?: boolean },
+    ) {
+      const add = parseTagList(opts.add ?? '');
+      const remove = parseTagList(opts.remove ?? '');
+      if (add.length === 0 && remove.length === 0) {
+        console.error(error('Nothing to do: pass --add and/or --remove'));
+        process.exit(1);
+      }
+      if (!opts.tags && !opts.query) {
+        console.error(error('Select notes with --tags and/or --query'));
+        process.exit(1);
+      }
+
+      const store = getStore(this);
+      const { notes } = await store.listNotes();
+      // Like tags rename/delete, bulk retagging covers archived notes too.
+      const selected = search(notes, {
+        includeArchived: true,
+        query: opts.query,
+        tags: opts.tags ? parseTagList(opts.tags) : undefined,
+      });
+
+      const result = await store.retagNotes({
+        noteIds: selected.map((note) => note.id),
+        add,
+        remove,
+        dryRun: opts.dryRun,
+      });
+      if (!result.success) {
+        console.error(error(result.error ?? 'Failed to retag notes'));
+        process.exit(1);
+      }
+
+      const titles = new Map(selected.map((note) => [note.id, note.title]));
+      for (const noteId of result.changed) {
+        console.log(`  ${titles.get(noteId)} (${noteId})`);
+      }
+
+      const unchanged = selected.length - result.changed.length;
+      const summary = `${result.changed.length} of ${selected.length} matching note(s)${unchanged > 0 ? `, ${unchanged} already up to date` : ''}`;
+      console.log(opts.dryRun ? info(`Would retag ${summary}`) : success(`Retagged ${summary}`));
+    });
+}
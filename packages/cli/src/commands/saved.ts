@@ -0,0 +1,133 @@
+import type { Command } from 'commander';
+import { getSearchSnippet, type SearchOptions } from '@agentnotes/engine';
+import {
+  success,
+  error,
+  formatNoteList,
+  formatNoteListJSON,
+  formatSavedSearches,
+  formatSearchResults,
+} from '../display/format.js';
+import { collectValues, getLimit, getSortFields } from '../utils/filters.js';
+import { getStore } from '../cli.js';
+
+interface SavedAddFlags {
+  query?: string;
+  regex?: boolean;
+  boolean?: boolean;
+  caseSensitive?: boolean;
+  word?: boolean;
+  tags?: string;
+  meta?: string[];
+  sort?: string;
+  reverse?: boolean;
+  limit?: string;
+  since?: string;
+  until?: string;
+  updatedSince?: string;
+}
+
+export function savedCommand(program: Command): void {
+  const saved = program
+    .command('saved')
+    .description('Manage saved searches');
+
+  saved
+    .command('add <name>')
+    .description('Save a search; flags match list and search')
+    .option('--query <text>', 'Search query')
+    .option('--regex', 'Treat the query as a regular expression')
+    .option('--boolean', 'Parse AND/OR/NOT, parentheses, and "quoted phrases" in the query')
+    .option('--case-sensitive', "Match the query's case exactly")
+    .option('--word', 'Match the query only as a whole word')
+    .option('--tags <tags>', 'Filter by tags (comma-separated)')
+    .option('--meta <key[=value]>', 'Filter by custom field, optionally its value (repeatable)', collectValues)
+    .option(
+      '--sort <fields>',
+      'Sort by: relevance, created, updated, title, due (comma-separated; default relevance with --query, else created)',
+    )
+    .option('--reverse', 'Reverse the sort order')
+    .option('--limit <n>', 'Max notes to show (default: the limit `saved run` is given)')
+    .option('--since <date>', 'Created on or after a date (YYYY-MM-DD) or offset (24h, 7d, 2w)')
+    .option('--until <date>', 'Created on or before a date or offset')
+    .option('--updated-since <date>', 'Updated on or after a date or offset')
+    .action(async function (this: Command, name: string, opts: SavedAddFlags) {
+      if (opts.regex && opts.boolean) {
+        console.error(error('--regex and --boolean cannot be combined'));
+        process.exit(1);
+      }
+
+      // Only flags given are stored, so the rest follow the defaults when the
+      // search runs; date expressions are stored as typed so "7d" stays relative.
+      const options: SearchOptions = {
+        query: opts.query,
+        regex: opts.regex,
+        boolean: opts.boolean,
+        caseSensitive: opts.caseSensitive,
+        wholeWord: opts.word,
+        tags: opts.tags ? opts.tags.split(',').map((t: string) => t.trim()) : undefined,
+        meta: opts.meta,
+        createdAfter: opts.since,
+        createdBefore: opts.until,
+        updatedAfter: opts.updatedSince,
+        limit: opts.limit === undefined ? undefined : getLimit({ limit: opts.limit }),
+        sortBy: opts.sort === undefined ? undefined : getSortFields(opts.sort),
+        reverse: opts.reverse,
+      };
+
+      const store = getStore(this);
+      const result = await store.saveSearch({ name, options });
+      if (!result.success) {
+        console.error(error(result.error ?? 'Failed to save search'));
+        process.exit(1);
+      }
+
+      console.log(success(`Saved search: ${name}`));
+    });
+
+  saved
+    .command('list')
+    .description('List saved searches')
+    .action(async function (this: Command) {
+      const store = getStore(this);
+      console.log(formatSavedSearches(await store.listSavedSearches()));
+    });
+
+  saved
+    .command('run <name>')
+    .description('Run a saved search')
+    .option('--json', 'Output note metadata as JSON')
+    .option('--limit <n>', 'Max notes to show when the search saved no limit (0 for no limit)', '20')
+    .action(async function (this: Command, name: string, opts: { json?: boolean; limit: string }) {
+      const store = getStore(this);
+      const result = await store.runSavedSearch(name, { limit: getLimit(opts) });
+      if (!result.success || !result.notes) {
+        console.error(error(result.error ?? 'Failed to run saved search'));
+        process.exit(1);
+      }
+
+      const options = result.options ?? {};
+      if (opts.json) {
+        console.log(formatNoteListJSON(result.notes));
+      } else if (options.query) {
+        // Printed as `search` prints, with the query highlighted in a snippet.
+        console.log(formatSearchResults(result.notes, (note) => getSearchSnippet(note, options)));
+      } else {
+        console.log(formatNoteList(result.notes));
+      }
+    });
+
+  saved
+    .command('delete <name>')
+    .description('Delete a saved search')
+    .action(async function (this: Command, name: string) {
+      const store = getStore(this);
+      const result = await store.deleteSavedSearch(name);
+      if (!result.success) {
+        console.error(error(result.error ?? 'Failed to delete saved search'));
+        process.exit(1);
+      }
+
+      console.log(success(`Deleted saved search: ${name}`));
+    });
+}
@@ -1,25 +1,182 @@
 import type { Command } from 'commander';
-import { search } from '@agentnotes/engine';
-import { formatNoteList } from '../display/format.js';
+import { NotesWatcher, search, getSearchSnippet } from '@agentnotes/engine';
+import type { Note, SearchOptions } from '@agentnotes/engine';
+import { formatSearchResults, formatPageFooter, formatWatchHeader, error } from '../display/format.js';
+import {
+  getArchiveFilter,
+  getDateFilters,
+  getLimit,
+  getPage,
+  getPriorityFilter,
+  getSortFields,
+  type ArchiveFlags,
+  type DateFilterFlags,
+  type LimitFlags,
+  type PageFlags,
+  type PriorityFlags,
+} from '../utils/filters.js';
 import { getStore } from '../cli.js';
 
+/** Erase the screen and scrollback, then home the cursor. */
+const CLEAR_SCREEN = '\x1b[2J\x1b[3J\x1b[H';
+
+/** Parse --interval, in seconds; fractions such as 0.5 are allowed. */
+function parseWatchInterval(value: string): number {
+  const seconds = Number(value);
+  if (!Number.isFinite(seconds) || seconds <= 0) {
+    console.error(error('--interval must be a positive number of seconds'));
+    process.exit(1);
+  }
+  return seconds;
+}
+
 export function searchCommand(program: Command): void {
   program
     .command('search <query>')
     .description('Search notes')
     .option('--tags <tags>', 'Filter by tags (comma-separated)')
-    .option('--limit <n>', 'Max results', '10')
-    .action(async function (this: Command, query: string, opts: { tags?: string; limit: string }) {
+    .option('--limit <n>', 'Max results (0 for no limit)', '10')
+    .option('--all', 'Show every match (same as --limit 0)')
+    .option(
+      '--sort <fields>',
+      'Sort by: relevance, created, updated, title, due (comma-separated for tiebreakers)',
+      'relevance',
+    )
+    .option('--reverse', 'Reverse the sort order')
+    .option('--page <n>', 'Show page n of the results (1-based)')
+    .option('--page-size <n>', 'Results per page (default: --limit)')
+    .option('--regex', 'Treat the query as a regular expression')
+    .option('--boolean', 'Parse AND/OR/NOT, parentheses, and "quoted phrases" in the query')
+    .option('--case-sensitive', "Match the query's case exactly")
+    .option('--word', 'Match the query only as a whole word')
+    .option('--since <date>', 'Created on or after a date (YYYY-MM-DD) or offset (24h, 7d, 2w)')
+    .option('--until <date>', 'Created on or before a date or offset')
+    .option('--updated-since <date>', 'Updated on or after a date or offset')
+    .option('--min-priority <n>', 'Only notes with a priority of at least n (0-10)')
+    .option('--max-priority <n>', 'Only notes with a priority of at most n (0-10)')
+    .option('--archived', 'Include archived notes')
+    .option('--only-archived', 'Show only archived notes')
+    .option('--count', 'Print only the number of matches, ignoring --limit and paging')
+    .option('--exit-code', 'Exit with status 1 when nothing matches')
+    .option('--watch', 'Rerun the search and redraw whenever notes change, until Ctrl-C')
+    .option('--interval <seconds>', 'With --watch, poll for changes this often instead of using file events')
+    .action(async function (
+      this: Command,
+      query: string,
+      opts: DateFilterFlags &
+        LimitFlags &
+        PageFlags &
+        PriorityFlags &
+        ArchiveFlags & {
+          tags?: string;
+          sort: string;
+          reverse?: boolean;
+          regex?: boolean;
+          boolean?: boolean;
+          caseSensitive?: boolean;
+          word?: boolean;
+          count?: boolean;
+          exitCode?: boolean;
+          watch?: boolean;
+          interval?: string;
+        },
+    ) {
+      if (opts.regex && opts.boolean) {
+        console.error(error('--regex and --boolean cannot be combined'));
+        process.exit(1);
+      }
+
+      const watchInterval = opts.interval === undefined ? undefined : parseWatchInterval(opts.interval);
+      if (watchInterval !== undefined && !opts.watch) {
+        console.error(error('--interval only applies with --watch'));
+        process.exit(1);
+      }
+
       const store = getStore(this);
-      const result = await store.listNotes();
       const tags = opts.tags ? opts.tags.split(',').map((t: string) => t.trim()) : undefined;
 
-      const filtered = search(result.notes, {
+      const limit = getLimit(opts);
+      const page = getPage(opts, limit);
+      const searchOptions: SearchOptions = {
         query,
+        regex: opts.regex,
+        boolean: opts.boolean,
+        caseSensitive: opts.caseSensitive,
+        wholeWord: opts.word,
         tags,
-        limit: parseInt(opts.limit, 10),
-      });
+        ...getDateFilters(opts),
+        ...getPriorityFilter(opts),
+        ...getArchiveFilter(opts),
+        sortBy: getSortFields(opts.sort),
+        reverse: opts.reverse,
+      };
+
+      const runSearch = async (): Promise<{ output: string; matched: number }> => {
+        const candidates = await store.listSearchCandidates(searchOptions);
+        let matches: Note[];
+        try {
+          matches = search(candidates, searchOptions);
+        } catch (err) {
+          console.error(error(err instanceof Error ? err.message : String(err)));
+          process.exit(1);
+        }
+        if (opts.count) {
+          return { output: String(matches.length), matched: matches.length };
+        }
 
-      console.log(formatNoteList(filtered));
+        // The second pass only pages; matches are already filtered, archive state included.
+        // It gets the query again so relevance can be scored.
+        const sortOptions: SearchOptions = {
+          query,
+          regex: searchOptions.regex,
+          boolean: searchOptions.boolean,
+          caseSensitive: searchOptions.caseSensitive,
+          wholeWord: searchOptions.wholeWord,
+          sortBy: searchOptions.sortBy,
+          reverse: searchOptions.reverse,
+          includeArchived: true,
+        };
+        const filtered = page
+          ? search(matches, { ...sortOptions, offset: (page.number - 1) * page.size, limit: page.size })
+          : search(matches, { ...sortOptions, limit });
+
+        const lines = [formatSearchResults(filtered, (note) => getSearchSnippet(note, searchOptions))];
+        if (page) {
+          lines.push(formatPageFooter(page.number, page.size, matches.length));
+        }
+        return { output: lines.join('\n'), matched: matches.length };
+      };
+
+      if (!opts.watch) {
+        const { output, matched } = await runSearch();
+        if (opts.exitCode && matched === 0) {
+          process.exitCode = 1;
+        }
+        console.log(output);
+        return;
+      }
+
+      const notesDir = store.getNotesDirectory();
+      let running = Promise.resolve();
+      const redraw = () => {
+        // Runs one at a time, so a slow search never interleaves with the next.
+        running = running.then(async () => {
+          const { output } = await runSearch();
+          process.stdout.write(CLEAR_SCREEN);
+          console.log(formatWatchHeader(query, notesDir, new Date()));
+          console.log(output);
+        });
+      };
+
+      const watcher = new NotesWatcher(notesDir, redraw, {
+        pollIntervalMs: watchInterval === undefined ? undefined : watchInterval * 1000,
+      });
+      process.once('SIGINT', () => {
+        watcher.close();
+        process.stdout.write('\n');
+        process.exit(0);
+      });
+      watcher.start();
+      redraw();
     });
 }
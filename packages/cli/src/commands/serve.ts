@@ -0,0 +1,58 @@
+import http from 'node:http';
+import type { Command } from 'commander';
+import { createNotesApiHandler } from '@agentnotes/engine';
+import { error, info } from '../display/format.js';
+import { getStore } from '../cli.js';
+
+const DEFAULT_HOST = '127.0.0.1';
+const DEFAULT_ADDR = `${DEFAULT_HOST}:8080`;
+
+/**
+ * Parse `[host]:port`. An empty host (`:8080`) binds to localhost rather than
+ * every interface, since the API has no authentication.
+ */
+function parseAddress(addr: string): { host: string; port: number } | null {
+  const match = addr.match(/^(?:\[([^\]]+)\]|([^:]*)):(\d+)$/) ?? addr.match(/^()()(\d+)$/);
+  if (!match) {
+    return null;
+  }
+
+  const port = Number(match[3]);
+  if (port > 65535) {
+    return null;
+  }
+  return { host: match[1] || match[2] || DEFAULT_HOST, port };
+}
+
+export function serveCommand(program: Command): void {
+  program
+    .command('serve')
+    .description('Serve notes over a JSON HTTP API')
+    .option('--addr <[host]:port>', 'Address to listen on', DEFAULT_ADDR)
+    .action(async function (this: Command, options: { addr: string }) {
+      const store = getStore(this);
+      const address = parseAddress(options.addr);
+      if (!address) {
+        console.error(error(`Invalid address: ${options.addr} (expected [host]:port)`));
+        process.exit(1);
+      }
+
+      const server = http.createServer(createNotesApiHandler(store));
+      server.on('error', (err) => {
+        console.error(error(`Failed to serve on ${options.addr}: ${err.message}`));
+        process.exit(1);
+      });
+
+      server.listen(address.port, address.host, () => {
+        const host = address.host.includes(':') ? `[${address.host}]` : address.host;
+        console.log(info(`Serving ${store.getNotesDirectory()} on http://${host}:${address.port} (Ctrl-C to stop)`));
+      });
+
+      // Finish in-flight requests, then drop idle keep-alive connections.
+      process.once('SIGINT', () => {
+        console.log(info('Shutting down...'));
+        server.close(() => process.exit(0));
+        server.closeIdleConnections();
+      });
+    });
+}
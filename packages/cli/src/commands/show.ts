@@ -1,25 +1,89 @@
 import type { Command } from 'commander';
-import { formatNoteDetail, formatNoteDetailWithComments, error } from '../display/format.js';
-import { resolveNote } from '../utils/resolve.js';
-import { getStore } from '../cli.js';
+import { DEFAULT_WORDS_PER_MINUTE, getReadingMinutes, getWordCount, serializeNote } from '@agentnotes/engine';
+import {
+  formatNoteDetail,
+  formatNoteDetailWithComments,
+  formatReadingStats,
+  error,
+  info,
+} from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { requirePickedNote } from '../utils/picker.js';
+import { unlockNote } from '../utils/passphrase.js';
+import { printPaged } from '../utils/pager.js';
+import { getStore, type StoreFlags } from '../cli.js';
+
+const SHOW_FORMATS = ['pretty', 'json', 'yaml'] as const;
+type ShowFormat = (typeof SHOW_FORMATS)[number];
+
+function isShowFormat(value: string): value is ShowFormat {
+  return (SHOW_FORMATS as readonly string[]).includes(value);
+}
 
 export function showCommand(program: Command): void {
   program
-    .command('show <id-or-title>')
+    .command('show [id-or-title]')
     .description('Display a note')
+    .option('--pick', 'Choose the note with an interactive fuzzy finder')
     .option('--comments', 'Show inline comments')
-    .action(async function (this: Command, idOrTitle: string, opts: { comments?: boolean }) {
-      const store = getStore(this);
-      const note = await resolveNote(store, idOrTitle);
-      if (!note) {
-        console.error(error(`Note not found: ${idOrTitle}`));
+    .option('--format <format>', 'Output format: pretty, json, yaml', 'pretty')
+    .option('--stats', 'Append a word count and estimated reading time (pretty format)')
+    .option('--wpm <n>', 'Reading speed for --stats, in words per minute', String(DEFAULT_WORDS_PER_MINUTE))
+    .option('--revision <n>', 'Show the content at the nth most recent commit (see history)')
+    .action(async function (
+      this: Command,
+      idOrTitle: string | undefined,
+      opts: { pick?: boolean; comments?: boolean; format: string; stats?: boolean; wpm: string; revision?: string },
+    ) {
+      if ((idOrTitle === undefined) === !opts.pick) {
+        console.error(error('Give either a note or --pick'));
+        process.exit(1);
+      }
+
+      if (!isShowFormat(opts.format)) {
+        console.error(error(`Unknown format: ${opts.format} (expected ${SHOW_FORMATS.join(', ')})`));
+        process.exit(1);
+      }
+
+      const wordsPerMinute = parseInt(opts.wpm, 10);
+      if (!Number.isInteger(wordsPerMinute) || wordsPerMinute <= 0) {
+        console.error(error('--wpm must be a positive integer'));
         process.exit(1);
       }
 
-      if (opts.comments) {
-        console.log(formatNoteDetailWithComments(note));
-      } else {
-        console.log(formatNoteDetail(note));
+      const store = getStore(this);
+      let note = await unlockNote(
+        idOrTitle === undefined ? await requirePickedNote(store) : await requireNote(store, idOrTitle),
+      );
+
+      if (opts.revision !== undefined) {
+        const result = store.getNoteRevision(note.id, Number(opts.revision));
+        if (!result.success || result.content === undefined || !result.revision) {
+          console.error(error(result.error ?? 'Failed to read revision'));
+          process.exit(1);
+        }
+        // Comment anchors refer to the current content, so they are not shown here.
+        note = { ...note, content: result.content, comments: [] };
+        if (opts.format === 'pretty') {
+          const { commit, date, message } = result.revision;
+          console.log(info(`Revision ${opts.revision} (${commit.slice(0, 7)}, ${date.slice(0, 10)}): ${message}`));
+        }
+      }
+
+      if (opts.format !== 'pretty') {
+        printPaged(serializeNote(note, opts.format).trimEnd());
+        return;
+      }
+
+      // -v/--verbose is a global flag; here it also adds the timestamps to the header.
+      const { verbose } = this.optsWithGlobals() as StoreFlags;
+      const output = [
+        opts.comments ? formatNoteDetailWithComments(note, verbose) : formatNoteDetail(note, verbose),
+      ];
+      if (opts.stats) {
+        const words = getWordCount(note.content);
+        output.push(formatReadingStats(words, getReadingMinutes(words, wordsPerMinute)));
       }
+      printPaged(output.join('\n'));
     });
 }
@@ -0,0 +1,23 @@
+import type { Command } from 'commander';
+import { computeStats } from '@agentnotes/engine';
+import { formatStats } from '../display/format.js';
+import { getStore } from '../cli.js';
+
+export function statsCommand(program: Command): void {
+  program
+    .command('stats')
+    .description('Show an overview of the knowledge base, archived notes included')
+    .option('--json', 'Output stats as JSON')
+    .action(async function (this: Command, opts: { json?: boolean }) {
+      const store = getStore(this);
+      const result = await store.listNotes();
+      const stats = computeStats(result.notes);
+
+      if (opts.json) {
+        console.log(JSON.stringify(stats, null, 2));
+        return;
+      }
+
+      console.log(formatStats(stats));
+    });
+}
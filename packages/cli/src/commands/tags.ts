@@ -1,16 +1,54 @@
 import type { Command } from 'commander';
-import { getSortedTags } from '@agentnotes/engine';
-import { formatTags } from '../display/format.js';
+import { getSortedTags, getTagTree, type RetagNotesResult } from '@agentnotes/engine';
+import { formatTags, formatTagTree, success, error, info } from '../display/format.js';
 import { getStore } from '../cli.js';
 
 export function tagsCommand(program: Command): void {
-  program
+  const tags = program
     .command('tags')
-    .description('List all tags with counts')
-    .action(async function (this: Command) {
+    .description('List all tags with counts, archived notes included')
+    .option('--tree', 'Show nested tags (a/b) as a hierarchy with rolled-up counts')
+    .action(async function (this: Command, opts: { tree?: boolean }) {
       const store = getStore(this);
       const result = await store.listNotes();
+      if (opts.tree) {
+        console.log(formatTagTree(getTagTree(result.notes)));
+        return;
+      }
+
       const sorted = getSortedTags(result.notes);
       console.log(formatTags(sorted));
     });
+
+  tags
+    .command('rename <old> <new>')
+    .description('Rename a tag on every note, merging it into <new> where both exist')
+    .option('--dry-run', 'Show how many notes would change without writing them')
+    .action(async function (this: Command, from: string, to: string, opts: { dryRun?: boolean }) {
+      const store = getStore(this);
+      const result = await store.renameTag({ from, to, dryRun: opts.dryRun });
+      const change = `#${from.trim()} to #${to.trim()} on`;
+      reportTagChange(result, opts.dryRun ? `Would rename ${change}` : `Renamed ${change}`, opts.dryRun);
+    });
+
+  tags
+    .command('delete <tag>')
+    .description('Remove a tag from every note')
+    .option('--dry-run', 'Show how many notes would change without writing them')
+    .action(async function (this: Command, tag: string, opts: { dryRun?: boolean }) {
+      const store = getStore(this);
+      const result = await store.deleteTag({ tag, dryRun: opts.dryRun });
+      const change = `#${tag.trim()} from`;
+      reportTagChange(result, opts.dryRun ? `Would remove ${change}` : `Removed ${change}`, opts.dryRun);
+    });
+}
+
+function reportTagChange(result: RetagNotesResult, message: string, dryRun = false): void {
+  if (!result.success) {
+    console.error(error(result.error ?? 'Failed to update tags'));
+    process.exit(1);
+  }
+
+  const count = `${result.changed.length} note${result.changed.length === 1 ? '' : 's'}`;
+  console.log(dryRun ? info(`${message} ${count}`) : success(`${message} ${count}`));
 }
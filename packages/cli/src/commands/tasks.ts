@@ -0,0 +1,41 @@
+import type { Command } from 'commander';
+import { extractTasks } from '@agentnotes/engine';
+import { formatTaskGroups, formatTasks, error } from '../display/format.js';
+import { requireNote } from '../utils/resolve.js';
+import { getStore } from '../cli.js';
+
+export function tasksCommand(program: Command): void {
+  program
+    .command('tasks [id-or-title]')
+    .description('List markdown tasks (- [ ] / - [x]) with their line numbers')
+    .option('--all', 'List tasks across every note')
+    .option('--open', 'Only show incomplete tasks')
+    .action(async function (
+      this: Command,
+      idOrTitle: string | undefined,
+      opts: { all?: boolean; open?: boolean },
+    ) {
+      const store = getStore(this);
+
+      if (opts.all) {
+        const result = await store.listNotes();
+        const groups = result.notes
+          .map((note) => ({
+            note,
+            tasks: extractTasks(note.content).filter((task) => !opts.open || !task.done),
+          }))
+          .filter((group) => group.tasks.length > 0);
+        console.log(formatTaskGroups(groups));
+        return;
+      }
+
+      if (!idOrTitle) {
+        console.error(error('Provide a note, or --all to list tasks across notes'));
+        process.exit(1);
+      }
+
+      const note = await requireNote(store, idOrTitle);
+      const tasks = extractTasks(note.content).filter((task) => !opts.open || !task.done);
+      console.log(formatTasks(tasks));
+    });
+}
@@ -0,0 +1,13 @@
+import type { Command } from 'commander';
+import { formatTemplates } from '../display/format.js';
+import { getStore } from '../cli.js';
+
+export function templatesCommand(program: Command): void {
+  program
+    .command('templates')
+    .description('List note templates in .agentnotes/templates/')
+    .action(async function (this: Command) {
+      const store = getStore(this);
+      console.log(formatTemplates(await store.listTemplates()));
+    });
+}
@@ -0,0 +1,69 @@
+import type { Command } from 'commander';
+import { success, error, info } from '../display/format.js';
+import { readStdin } from '../utils/stdin.js';
+import { editNoteInEditor } from '../utils/editor.js';
+import { decryptOrExit, getPassphrase } from '../utils/passphrase.js';
+import { getConfig, getStore } from '../cli.js';
+
+/** Used when --template is not given and `.agentnotes/templates/daily.md` exists. */
+const DAILY_TEMPLATE = 'daily';
+
+export function todayCommand(program: Command): void {
+  program
+    .command('today')
+    .description("Open today's daily note, creating it if needed (piped text is appended instead)")
+    .option('--date <YYYY-MM-DD>', 'Target another day (default: today, local time)')
+    .option('-d, --directory <dir>', 'Directory for daily notes', '')
+    .option('--template <name>', `Template for a new daily note (default: ${DAILY_TEMPLATE}, if it exists)`)
+    .action(async function (this: Command, opts: { date?: string; directory: string; template?: string }) {
+      const date = opts.date === undefined ? getLocalDate(new Date()) : opts.date.trim();
+      if (!isCalendarDate(date)) {
+        console.error(error(`Invalid date: ${opts.date} (use YYYY-MM-DD)`));
+        process.exit(1);
+      }
+
+      const store = getStore(this);
+      const templates = await store.listTemplates();
+      const result = await store.createNote({
+        title: date,
+        directory: opts.directory,
+        template: opts.template ?? (templates.includes(DAILY_TEMPLATE) ? DAILY_TEMPLATE : undefined),
+        reuseExisting: true,
+      });
+      if (!result.success || !result.note) {
+        console.error(error(result.error ?? 'Failed to create daily note'));
+        process.exit(1);
+      }
+
+      const note = result.note;
+      console.log(result.existing ? info(`Daily note ${note.id}`) : success(`Created daily note: ${note.id}`));
+
+      const stdinContent = await readStdin();
+      if (stdinContent) {
+        const passphrase = note.encrypted ? await getPassphrase() : undefined;
+        const current = passphrase ? decryptOrExit(note, passphrase) : note.content;
+        const updated = await store.updateNote({
+          noteId: note.id,
+          content: `${current.trimEnd()}\n\n${stdinContent}`,
+          passphrase,
+        });
+        if (!updated.success) {
+          console.error(error(updated.error ?? 'Failed to append to daily note'));
+          process.exit(1);
+        }
+        console.log(success('Appended to daily note'));
+      } else if (process.stdin.isTTY) {
+        await editNoteInEditor(store, note, getConfig(this).editor);
+      }
+    });
+}
+
+function getLocalDate(now: Date): string {
+  const pad = (value: number) => String(value).padStart(2, '0');
+  return `${now.getFullYear()}-${pad(now.getMonth() + 1)}-${pad(now.getDate())}`;
+}
+
+function isCalendarDate(value: string): boolean {
+  const time = /^\d{4}-\d{2}-\d{2}$/.test(value) ? Date.parse(`${value}T00:00:00Z`) : Number.NaN;
+  return !Number.isNaN(time) && new Date(time).toISOString().startsWith(value);
+}
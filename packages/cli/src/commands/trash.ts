@@ -0,0 +1,18 @@
+import type { Command } from 'commander';
+import { formatNoteList } from '../display/format.js';
+import { getStore } from '../cli.js';
+
+export function trashCommand(program: Command): void {
+  const trash = program
+    .command('trash')
+    .description('Inspect deleted notes');
+
+  trash
+    .command('list')
+    .description('List notes in the trash')
+    .action(async function (this: Command) {
+      const store = getStore(this);
+      const notes = await store.listTrash();
+      console.log(notes.length === 0 ? 'Trash is empty.' : formatNoteList(notes));
+    });
+}
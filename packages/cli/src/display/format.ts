@@ -1,28 +1,73 @@
-import type { Note, NoteComment, TagCount } from '@agentnotes/engine';
-
-const Reset = '\x1b[0m';
-const Bold = '\x1b[1m';
-const Dim = '\x1b[2m';
-const Cyan = '\x1b[36m';
-const Green = '\x1b[32m';
-const Yellow = '\x1b[33m';
-const Red = '\x1b[31m';
-const Magenta = '\x1b[35m';
-const BoldCyan = `${Bold}${Cyan}`;
-const BoldGreen = `${Bold}${Green}`;
-const BoldYellow = `${Bold}${Yellow}`;
-const BoldRed = `${Bold}${Red}`;
+import {
+  formatRelativeTime,
+  getCommentLine,
+  getCreatedDrift,
+  getIdTimestamp,
+  ID_TIMESTAMP_TOLERANCE_MS,
+} from '@agentnotes/engine';
+import type {
+  AgendaBucket,
+  AgendaGroup,
+  CommentStatus,
+  CommentWithNote,
+  DiffHunk,
+  Note,
+  NoteComment,
+  NoteRevision,
+  NoteProblem,
+  NoteMeta,
+  NotebookSummary,
+  NoteStats,
+  NoteSnippet,
+  NoteTask,
+  SavedSearch,
+  TagCount,
+  TagTreeNode,
+} from '@agentnotes/engine';
+
+// SGR codes for colorize().
+const Bold = '1';
+const Dim = '2';
+const Cyan = '36';
+const Green = '32';
+const Yellow = '33';
+const Red = '31';
+const Magenta = '35';
+const BoldCyan = '1;36';
+const BoldGreen = '1;32';
+const BoldYellow = '1;33';
+const BoldRed = '1;31';
+
+let colorEnabled = true;
+
+/** Turn ANSI styling off (or back on) for everything formatted afterwards. */
+export function setColorEnabled(enabled: boolean): void {
+  colorEnabled = enabled;
+}
+
+/**
+ * Color is off when disabled via --no-color or config, when NO_COLOR is set
+ * to anything non-empty, or when stdout is not a terminal.
+ */
+export function shouldUseColor(requested: boolean): boolean {
+  return requested && !process.env.NO_COLOR && Boolean(process.stdout.isTTY);
+}
+
+/** Wrap text in an SGR style, or return it unchanged when color is off. */
+export function colorize(code: string, text: string): string {
+  return colorEnabled && text ? `\x1b[${code}m${text}\x1b[0m` : text;
+}
 
 export function success(msg: string): string {
-  return `${BoldGreen}\u2713${Reset} ${msg}`;
+  return `${colorize(BoldGreen, '\u2713')} ${msg}`;
 }
 
 export function error(msg: string): string {
-  return `${BoldRed}\u2717${Reset} ${msg}`;
+  return `${colorize(BoldRed, '\u2717')} ${msg}`;
 }
 
 export function info(msg: string): string {
-  return `${Cyan}\u2139${Reset} ${msg}`;
+  return `${colorize(Cyan, '\u2139')} ${msg}`;
 }
 
 export function formatNoteList(notes: Note[]): string {
@@ -30,30 +75,194 @@ export function formatNoteList(notes: Note[]): string {
     return 'No notes found.';
   }
 
-  const lines: string[] = [];
-  for (const note of notes) {
-    const idShort = note.id.slice(0, 30);
-    const tags = note.tags.length > 0
-      ? ` ${Green}${note.tags.map((t) => `#${t}`).join(' ')}${Reset}`
-      : '';
-    lines.push(`${BoldCyan}${note.title}${Reset} ${Dim}[${idShort}]${Reset}${tags}`);
+  return notes.map(formatNoteLine).join('\n');
+}
+
+function formatNoteLine(note: Note): string {
+  const idShort = note.id.slice(0, 30);
+  const tags = note.tags.length > 0
+    ? ` ${colorize(Green, note.tags.map((t) => `#${t}`).join(' '))}`
+    : '';
+  const pin = note.pinned ? `${colorize(BoldYellow, '★')} ` : '';
+  return `${pin}${colorize(BoldCyan, note.title)} ${colorize(Dim, `[${idShort}]`)}${tags}`;
+}
+
+/** The picker's input line: what has been typed and how many notes still match. */
+export function formatPickerPrompt(query: string, matched: number, total: number): string {
+  return `${colorize(BoldCyan, '>')} ${query}  ${colorize(Dim, `${matched}/${total}`)}`;
+}
+
+/** One picker row, cut to `width` columns so it never wraps. */
+export function formatPickerRow(note: Note, selected: boolean, width: number): string {
+  const title = note.title.slice(0, Math.max(0, width - 2));
+  const room = width - 2 - title.length - 3;
+  const id = room > 0 ? ` ${colorize(Dim, `[${note.id.slice(0, room)}]`)}` : '';
+  return selected ? `${colorize(BoldYellow, '▸')} ${colorize(BoldCyan, title)}${id}` : `  ${title}${id}`;
+}
+
+/** First line of each `search --watch` redraw. */
+export function formatWatchHeader(query: string, directory: string, at: Date): string {
+  return colorize(Dim, `Watching ${directory} for "${query}" \u00b7 updated ${at.toLocaleTimeString()} \u00b7 Ctrl-C to stop`);
+}
+
+export function formatPageFooter(page: number, pageSize: number, total: number): string {
+  const pageCount = Math.max(1, Math.ceil(total / pageSize));
+  return colorize(Dim, `Page ${page}/${pageCount} (${total} note${total === 1 ? '' : 's'})`);
+}
+
+export function formatRecentList(notes: Note[], now: Date = new Date()): string {
+  if (notes.length === 0) {
+    return 'No notes found.';
   }
 
-  return lines.join('\n');
+  return notes
+    .map((note) => `${formatNoteLine(note)} ${colorize(Dim, formatRelativeTime(note.updated, now))}`)
+    .join('\n');
+}
+
+/**
+ * Search results with a dimmed snippet under each note showing why it matched.
+ */
+export function formatSearchResults(
+  notes: Note[],
+  getSnippet: (note: Note) => NoteSnippet | null,
+): string {
+  if (notes.length === 0) {
+    return 'No notes found.';
+  }
+
+  return notes
+    .map((note) => {
+      const snippet = getSnippet(note);
+      return snippet ? `${formatNoteLine(note)}\n  ${formatSnippet(snippet)}` : formatNoteLine(note);
+    })
+    .join('\n');
+}
+
+function formatSnippet(snippet: NoteSnippet): string {
+  if (snippet.kind === 'tag') {
+    return `${colorize(Dim, 'matched tag')} ${colorize(Green, `#${snippet.tag}`)}`;
+  }
+
+  let output = '';
+  let cursor = 0;
+  for (const range of snippet.highlights) {
+    output += colorize(Dim, snippet.text.slice(cursor, range.from));
+    output += colorize(BoldYellow, snippet.text.slice(range.from, range.to));
+    cursor = range.to;
+  }
+  output += colorize(Dim, snippet.text.slice(cursor));
+  return output;
+}
+
+export interface NoteListJSONEntry {
+  id: string;
+  title: string;
+  tags: string[];
+  directory: string;
+  created: string;
+  updated: string;
+  commentCount: number;
+  meta?: NoteMeta;
+  due?: string;
+  aliases?: string[];
+  archivedAt?: string;
+  pinned?: boolean;
+  content?: string;
 }
 
-export function formatNoteDetail(note: Note): string {
+export function toNoteListJSONEntry(note: Note, includeContent = false): NoteListJSONEntry {
+  return {
+    id: note.id,
+    title: note.title,
+    tags: note.tags,
+    directory: note.directory,
+    created: note.created,
+    updated: note.updated,
+    commentCount: note.comments.length,
+    ...(note.meta ? { meta: note.meta } : {}),
+    ...(note.due ? { due: note.due } : {}),
+    ...(note.aliases ? { aliases: note.aliases } : {}),
+    ...(note.archivedAt ? { archivedAt: note.archivedAt } : {}),
+    ...(note.pinned ? { pinned: true } : {}),
+    ...(includeContent ? { content: note.content } : {}),
+  };
+}
+
+export function formatNoteListJSON(notes: Note[], includeContent = false): string {
+  return JSON.stringify(
+    notes.map((note) => toNoteListJSONEntry(note, includeContent)),
+    null,
+    2,
+  );
+}
+
+export function formatReadingStats(words: number, minutes: number): string {
+  return colorize(Dim, `${words} ${words === 1 ? 'word' : 'words'} \u00b7 ~${minutes} min read`);
+}
+
+/** Rough size of a time span for humans, e.g. `3d` or `45m`. */
+function formatDuration(ms: number): string {
+  const minutes = Math.round(ms / 60000);
+  if (minutes < 60) {
+    return `${Math.max(minutes, 1)}m`;
+  }
+  const hours = Math.round(minutes / 60);
+  return hours < 48 ? `${hours}h` : `${Math.round(hours / 24)}d`;
+}
+
+/** The time the note's ID encodes, flagged when `created` disagrees with it. */
+function formatIdTime(note: Note): string {
+  let stamp;
+  try {
+    stamp = getIdTimestamp(note.id);
+  } catch {
+    return colorize(Dim, 'none in ID');
+  }
+
+  const shown = stamp.precision === 'date' ? stamp.time.slice(0, 10) : stamp.time;
+  const drift = getCreatedDrift(note.created, stamp);
+  if (drift <= ID_TIMESTAMP_TOLERANCE_MS) {
+    return shown;
+  }
+  const reason = Number.isFinite(drift) ? `created is ${formatDuration(drift)} off` : 'created is unreadable';
+  return `${shown} ${colorize(BoldYellow, `\u26a0 ${reason}`)}`;
+}
+
+/** `verbose` adds the created and updated times, checked against the ID. */
+export function formatNoteDetail(note: Note, verbose = false): string {
   const lines: string[] = [];
-  const sep = `${Bold}${'─'.repeat(50)}${Reset}`;
+  const sep = colorize(Bold, '─'.repeat(50));
 
   lines.push(sep);
-  lines.push(`${BoldCyan}${note.title}${Reset}`);
-  lines.push(`${Dim}ID:${Reset}       ${note.id}`);
+  lines.push(colorize(BoldCyan, note.title));
+  lines.push(`${colorize(Dim, 'ID:')}       ${note.id}`);
+  if (verbose) {
+    lines.push(`${colorize(Dim, 'Created:')}  ${note.created || colorize(Dim, 'missing')}`);
+    lines.push(`${colorize(Dim, 'ID time:')}  ${formatIdTime(note)}`);
+    lines.push(`${colorize(Dim, 'Updated:')}  ${note.updated || colorize(Dim, 'missing')}`);
+  }
+  if (note.aliases && note.aliases.length > 0) {
+    lines.push(`${colorize(Dim, 'Aliases:')}  ${note.aliases.join(', ')}`);
+  }
   if (note.tags.length > 0) {
-    lines.push(`${Dim}Tags:${Reset}     ${Green}${note.tags.map((t) => `#${t}`).join(' ')}${Reset}`);
+    lines.push(`${colorize(Dim, 'Tags:')}     ${colorize(Green, note.tags.map((t) => `#${t}`).join(' '))}`);
+  }
+  if (note.due) {
+    lines.push(`${colorize(Dim, 'Due:')}      ${note.due}`);
+  }
+  if (note.archivedAt) {
+    lines.push(`${colorize(Dim, 'Archived:')} ${note.archivedAt.slice(0, 10)}`);
+  }
+  if (note.pinned) {
+    lines.push(`${colorize(Dim, 'Pinned:')}   yes`);
   }
   if (note.comments.length > 0) {
-    lines.push(`${Dim}Comments:${Reset} ${note.comments.length}`);
+    lines.push(`${colorize(Dim, 'Comments:')} ${note.comments.length}`);
+  }
+  for (const [key, value] of Object.entries(note.meta ?? {})) {
+    const shown = typeof value === 'string' ? value : JSON.stringify(value);
+    lines.push(`${colorize(Dim, `${key}:`.padEnd(9))} ${shown}`);
   }
   lines.push(sep);
   lines.push(note.content);
@@ -61,28 +270,47 @@ export function formatNoteDetail(note: Note): string {
   return lines.join('\n');
 }
 
-export function formatNoteDetailWithComments(note: Note): string {
-  const detail = formatNoteDetail(note);
+const COMMENT_STATUS_COLORS: Record<CommentStatus, string> = {
+  attached: Green,
+  stale: Yellow,
+  detached: Red,
+};
+
+/** A comment's anchor status, colored by how far it has drifted from its text. */
+export function formatCommentStatus(status: CommentStatus): string {
+  return colorize(COMMENT_STATUS_COLORS[status] ?? Dim, status);
+}
+
+/** First 60 characters of an anchor quote, on one line even if it spans several. */
+function formatQuotePreview(quote: string | undefined): string {
+  return quote ? quote.replace(/\s+/g, ' ').trim().slice(0, 60) : '';
+}
+
+export function formatNoteDetailWithComments(note: Note, verbose = false): string {
+  const detail = formatNoteDetail(note, verbose);
   if (note.comments.length === 0) {
     return detail;
   }
 
   const commentLines: string[] = [];
   commentLines.push('');
-  commentLines.push(`${Bold}Comments:${Reset}`);
+  commentLines.push(colorize(Bold, 'Comments:'));
   for (const comment of note.comments) {
     const author = comment.author || 'anonymous';
-    const quotePreview = comment.anchor.quote
-      ? comment.anchor.quote.slice(0, 60)
-      : '';
+    const quotePreview = formatQuotePreview(comment.anchor.quote);
     commentLines.push(
-      `  ${Yellow}\u2022${Reset} ${Magenta}${author}${Reset}: ${comment.content}`,
+      comment.resolved
+        ? `  ${colorize(Dim, `\u2713 ${author}: ${comment.content}`)}`
+        : `  ${colorize(Yellow, '\u2022')} ${colorize(Magenta, author)}: ${comment.content}`,
     );
     if (quotePreview) {
-      commentLines.push(`    ${Dim}"${quotePreview}"${Reset}`);
+      commentLines.push(`    ${colorize(Dim, `"${quotePreview}"`)}`);
     }
+    // The line comes from the anchor, so it stays right after edits above the comment.
+    const line = getCommentLine(note, comment);
+    const location = line === null ? '' : ` ${colorize(BoldYellow, `L${line}`)}`;
     commentLines.push(
-      `    ${Dim}[${comment.id.slice(0, 8)}] ${comment.status} [${comment.anchor.from}:${comment.anchor.to}]${Reset}`,
+      `    ${colorize(Dim, `[${comment.id.slice(0, 8)}]`)} ${formatCommentStatus(comment.status)}${location} ${colorize(Dim, `[${comment.anchor.from}:${comment.anchor.to}]`)}`,
     );
   }
 
@@ -97,18 +325,21 @@ export function formatCommentList(comments: NoteComment[]): string {
   const lines: string[] = [];
   for (const comment of comments) {
     const author = comment.author || 'anonymous';
-    const quotePreview = comment.anchor.quote
-      ? comment.anchor.quote.slice(0, 60)
-      : '';
-    lines.push(
-      `${BoldYellow}${comment.id.slice(0, 8)}${Reset} ${Magenta}${author}${Reset}`,
-    );
-    lines.push(`  ${comment.content}`);
+    const quotePreview = formatQuotePreview(comment.anchor.quote);
+    if (comment.resolved) {
+      lines.push(colorize(Dim, `\u2713 ${comment.id.slice(0, 8)} ${author} (resolved)`));
+      lines.push(`  ${colorize(Dim, comment.content)}`);
+    } else {
+      lines.push(
+        `${colorize(BoldYellow, comment.id.slice(0, 8))} ${colorize(Magenta, author)}`,
+      );
+      lines.push(`  ${comment.content}`);
+    }
     lines.push(
-      `  ${Dim}${comment.status} [${comment.anchor.from}:${comment.anchor.to}] rev=${comment.anchor.rev}${Reset}`,
+      `  ${formatCommentStatus(comment.status)} ${colorize(Dim, `[${comment.anchor.from}:${comment.anchor.to}] rev=${comment.anchor.rev}${comment.edited ? ' edited' : ''}`)}`,
     );
     if (quotePreview) {
-      lines.push(`  ${Dim}"${quotePreview}"${Reset}`);
+      lines.push(`  ${colorize(Dim, `"${quotePreview}"`)}`);
     }
     lines.push('');
   }
@@ -116,12 +347,247 @@ export function formatCommentList(comments: NoteComment[]): string {
   return lines.join('\n');
 }
 
+/** Comments from many notes, under a heading line per note, with the line each one anchors to. */
+export function formatCommentGroups(entries: CommentWithNote[]): string {
+  if (entries.length === 0) {
+    return 'No comments found.';
+  }
+
+  const groups: string[][] = [];
+  let currentNoteId: string | null = null;
+  for (const { note, comment, line } of entries) {
+    if (note.id !== currentNoteId) {
+      groups.push([formatNoteLine(note)]);
+      currentNoteId = note.id;
+    }
+
+    const location = line === null ? comment.status : `L${line}`;
+    const author = comment.author || 'anonymous';
+    const header = `${comment.id.slice(0, 8)} ${author} ${formatRelativeTime(comment.created)}`;
+    const group = groups[groups.length - 1];
+    group.push(
+      comment.resolved
+        ? `  ${colorize(Dim, `\u2713 ${location} ${header} (resolved)`)}`
+        : `  ${colorize(BoldYellow, location)} ${colorize(Magenta, header)}`,
+    );
+    const quotePreview = formatQuotePreview(comment.anchor.quote);
+    if (quotePreview) {
+      group.push(`    ${colorize(Dim, `"${quotePreview}"`)}`);
+    }
+    group.push(`    ${comment.content}`);
+  }
+
+  return groups.map((group) => group.join('\n')).join('\n\n');
+}
+
+/** Comment entries for --json: the comment plus its note's id and title. */
+export function formatCommentGroupsJSON(entries: CommentWithNote[]): string {
+  return JSON.stringify(
+    entries.map(({ note, comment, line }) => ({ noteId: note.id, noteTitle: note.title, line, ...comment })),
+    null,
+    2,
+  );
+}
+
 export function formatTags(tags: TagCount[]): string {
   if (tags.length === 0) {
     return 'No tags found.';
   }
 
   return tags
-    .map((tc) => `${Green}#${tc.tag}${Reset} ${Dim}(${tc.count})${Reset}`)
+    .map((tc) => `${colorize(Green, `#${tc.tag}`)} ${colorize(Dim, `(${tc.count})`)}`)
+    .join('\n');
+}
+
+export function formatTagTree(nodes: TagTreeNode[], depth = 0): string {
+  if (nodes.length === 0 && depth === 0) {
+    return 'No tags found.';
+  }
+
+  return nodes
+    .flatMap((node) => {
+      const line = `${'  '.repeat(depth)}${colorize(Green, `#${node.name}`)} ${colorize(Dim, `(${node.count})`)}`;
+      return node.children.length > 0
+        ? [line, formatTagTree(node.children, depth + 1)]
+        : [line];
+    })
+    .join('\n');
+}
+
+export function formatSavedSearches(searches: SavedSearch[]): string {
+  if (searches.length === 0) {
+    return 'No saved searches.';
+  }
+
+  return searches
+    .map(({ name, options }) => {
+      const details = Object.entries(options)
+        .map(([key, value]) => `${key}=${Array.isArray(value) ? value.join(',') : String(value)}`)
+        .join(' ');
+      return `${colorize(BoldCyan, name)}${details ? ` ${colorize(Dim, details)}` : ''}`;
+    })
+    .join('\n');
+}
+
+const HISTOGRAM_WIDTH = 30;
+
+export function formatStats(stats: NoteStats): string {
+  const lines: string[] = [];
+  const label = (name: string) => colorize(Dim, `${name}:`.padEnd(10));
+
+  lines.push(`${label('Notes')}${stats.totalNotes} ${colorize(Dim, `(${stats.untaggedNotes} untagged)`)}`);
+  lines.push(`${label('Words')}${stats.totalWords}`);
+  lines.push(`${label('Comments')}${stats.totalComments}`);
+  if (stats.oldest && stats.newest) {
+    lines.push(`${label('Oldest')}${colorize(BoldCyan, stats.oldest.title)} ${colorize(Dim, stats.oldest.created.slice(0, 10))}`);
+    lines.push(`${label('Newest')}${colorize(BoldCyan, stats.newest.title)} ${colorize(Dim, stats.newest.created.slice(0, 10))}`);
+  }
+
+  if (stats.topTags.length > 0) {
+    lines.push('');
+    lines.push(colorize(Bold, 'Top tags:'));
+    for (const tc of stats.topTags) {
+      lines.push(`  ${colorize(Green, `#${tc.tag}`)} ${colorize(Dim, `(${tc.count})`)}`);
+    }
+  }
+
+  if (stats.notesPerMonth.length > 0) {
+    const busiest = Math.max(...stats.notesPerMonth.map((entry) => entry.count));
+    lines.push('');
+    lines.push(colorize(Bold, 'Notes per month:'));
+    for (const { month, count } of stats.notesPerMonth) {
+      const bar = '\u2588'.repeat(Math.max(1, Math.round((count / busiest) * HISTOGRAM_WIDTH)));
+      lines.push(`  ${month} ${colorize(Cyan, bar)} ${count}`);
+    }
+  }
+
+  return lines.join('\n');
+}
+
+const AGENDA_HEADINGS: Record<AgendaBucket, [style: string, label: string]> = {
+  overdue: [BoldRed, 'Overdue'],
+  today: [BoldYellow, 'Today'],
+  week: [Bold, 'This Week'],
+  later: [Bold, 'Later'],
+};
+
+export function formatAgenda(groups: AgendaGroup[]): string {
+  if (groups.length === 0) {
+    return 'No notes due.';
+  }
+
+  return groups
+    .map((group) => [
+      colorize(...AGENDA_HEADINGS[group.bucket]),
+      ...group.notes.map((note) => `  ${colorize(Dim, note.due ?? '')} ${formatNoteLine(note)}`),
+    ].join('\n'))
+    .join('\n\n');
+}
+
+function formatTask(task: NoteTask): string {
+  const line = colorize(Dim, String(task.line).padStart(4));
+  return task.done
+    ? `${line} ${colorize(Dim, `[x] ${task.text}`)}`
+    : `${line} ${colorize(Yellow, '[ ]')} ${task.text}`;
+}
+
+export function formatTasks(tasks: NoteTask[]): string {
+  if (tasks.length === 0) {
+    return 'No tasks found.';
+  }
+
+  return tasks.map(formatTask).join('\n');
+}
+
+export function formatTaskGroups(groups: { note: Note; tasks: NoteTask[] }[]): string {
+  if (groups.length === 0) {
+    return 'No tasks found.';
+  }
+
+  return groups
+    .map((group) => [formatNoteLine(group.note), ...group.tasks.map(formatTask)].join('\n'))
+    .join('\n\n');
+}
+
+export function formatTemplates(names: string[]): string {
+  if (names.length === 0) {
+    return 'No templates found.';
+  }
+
+  return names.map((name) => colorize(BoldCyan, name)).join('\n');
+}
+
+export function formatNotebooks(notebooks: NotebookSummary[]): string {
+  if (notebooks.length === 0) {
+    return 'No notebooks found.';
+  }
+
+  return notebooks
+    .map((nb) => `${colorize(BoldCyan, nb.name)} ${colorize(Dim, `(${nb.noteCount})`)}`)
+    .join('\n');
+}
+
+export interface ResolvedLink {
+  ref: string;
+  note: Note | null;
+}
+
+export function formatLinks(links: ResolvedLink[]): string {
+  if (links.length === 0) {
+    return 'No links.';
+  }
+
+  return links
+    .map(({ ref, note }) =>
+      note
+        ? `${colorize(BoldGreen, '\u2713')} [[${ref}]] ${colorize(Dim, '\u2192')} ${colorize(BoldCyan, note.title)} ${colorize(Dim, `[${note.id}]`)}`
+        : `${colorize(BoldRed, '\u2717')} [[${ref}]] ${colorize(Red, '(broken)')}`,
+    )
+    .join('\n');
+}
+
+/** Unified diff with `---`/`+++` headers, cyan hunk ranges, red removals and green additions. */
+export function formatDiff(hunks: DiffHunk[], fromLabel: string, toLabel: string): string {
+  const lines = [colorize(Bold, `--- ${fromLabel}`), colorize(Bold, `+++ ${toLabel}`)];
+  for (const hunk of hunks) {
+    lines.push(colorize(Cyan, `@@ -${hunk.oldStart},${hunk.oldLines} +${hunk.newStart},${hunk.newLines} @@`));
+    for (const line of hunk.lines) {
+      if (line.type === 'add') {
+        lines.push(colorize(Green, `+${line.text}`));
+      } else if (line.type === 'remove') {
+        lines.push(colorize(Red, `-${line.text}`));
+      } else {
+        lines.push(` ${line.text}`);
+      }
+    }
+  }
+  return lines.join('\n');
+}
+
+/** Numbered newest-first, matching `show --revision <n>`. */
+export function formatNoteHistory(revisions: NoteRevision[]): string {
+  if (revisions.length === 0) {
+    return 'No committed revisions.';
+  }
+
+  const width = String(revisions.length).length;
+  return revisions
+    .map((revision, index) => {
+      const date = revision.date.slice(0, 16).replace('T', ' ');
+      return `${String(index + 1).padStart(width)}  ${colorize(Dim, date)}  ${revision.message} ${colorize(Dim, revision.commit.slice(0, 7))}`;
+    })
+    .join('\n');
+}
+
+export function formatNoteProblems(problems: NoteProblem[]): string {
+  if (problems.length === 0) {
+    return success('No problems found.');
+  }
+
+  return problems
+    .map((problem) => {
+      const mark = problem.fixed ? colorize(BoldGreen, 'fixed') : colorize(BoldYellow, problem.kind);
+      return `${mark} ${problem.noteId}: ${problem.message}`;
+    })
     .join('\n');
 }
@@ -2,9 +2,13 @@ import { execSync } from 'node:child_process';
 import fs from 'node:fs';
 import path from 'node:path';
 import os from 'node:os';
+import type { Note, NoteStore } from '@agentnotes/engine';
+import { success, error, info } from '../display/format.js';
+import { decryptOrExit, getPassphrase } from './passphrase.js';
 
-export async function openEditor(initialContent = ''): Promise<string | undefined> {
-  const editor = process.env.EDITOR || 'vi';
+/** The editor is the config `editor` setting when given, then $EDITOR, then vi. */
+export async function openEditor(initialContent = '', editorOverride = ''): Promise<string | undefined> {
+  const editor = editorOverride || process.env.EDITOR || 'vi';
   const tmpFile = path.join(os.tmpdir(), `agentnotes-${Date.now()}.md`);
 
   try {
@@ -20,3 +24,32 @@ export async function openEditor(initialContent = ''): Promise<string | undefine
     }
   }
 }
+
+/**
+ * Edit a note's content in the editor and save it, decrypting an encrypted
+ * note first. Exits on a failed save.
+ */
+export async function editNoteInEditor(store: NoteStore, note: Note, editorOverride = ''): Promise<void> {
+  const passphrase = note.encrypted ? await getPassphrase() : undefined;
+  const current = passphrase ? decryptOrExit(note, passphrase) : note.content;
+
+  const content = await openEditor(current, editorOverride);
+  if (content === undefined) {
+    console.log(info('Empty content; note left unchanged.'));
+    return;
+  }
+
+  // The editor helper trims its result, so compare trimmed content.
+  if (content === current.trim()) {
+    console.log('No changes made.');
+    return;
+  }
+
+  // updateNote remaps comment anchors across the edit.
+  const result = await store.updateNote({ noteId: note.id, content, passphrase });
+  if (!result.success) {
+    console.error(error(result.error ?? 'Failed to update content'));
+    process.exit(1);
+  }
+  console.log(success('Note updated'));
+}
@@ -0,0 +1,139 @@
+import {
+  isValidPriority,
+  parseDateExpression,
+  parseSortFields,
+  MAX_PRIORITY,
+  MIN_PRIORITY,
+  type SearchOptions,
+  type SortField,
+} from '@agentnotes/engine';
+import { error } from '../display/format.js';
+
+export interface DateFilterFlags {
+  since?: string;
+  until?: string;
+  updatedSince?: string;
+}
+
+/**
+ * Translate date flags into search options, exiting with an error when an
+ * expression cannot be parsed.
+ */
+export function getDateFilters(flags: DateFilterFlags): Pick<
+  SearchOptions,
+  'createdAfter' | 'createdBefore' | 'updatedAfter'
+> {
+  try {
+    return {
+      createdAfter: flags.since ? parseDateExpression(flags.since) : undefined,
+      createdBefore: flags.until ? parseDateExpression(flags.until, 'end') : undefined,
+      updatedAfter: flags.updatedSince ? parseDateExpression(flags.updatedSince) : undefined,
+    };
+  } catch (err) {
+    console.error(error(err instanceof Error ? err.message : String(err)));
+    process.exit(1);
+  }
+}
+
+/** Parse a --sort value such as `due,title`, exiting on unknown fields. */
+export function getSortFields(value: string): SortField[] {
+  try {
+    return parseSortFields(value);
+  } catch (err) {
+    console.error(error(err instanceof Error ? err.message : String(err)));
+    process.exit(1);
+  }
+}
+
+export interface ArchiveFlags {
+  archived?: boolean;
+  onlyArchived?: boolean;
+}
+
+/** Map --archived/--only-archived onto search options. */
+export function getArchiveFilter(flags: ArchiveFlags): Pick<SearchOptions, 'includeArchived' | 'onlyArchived'> {
+  return { includeArchived: flags.archived, onlyArchived: flags.onlyArchived };
+}
+
+/** Parse a --priority value, exiting unless it is an integer within bounds. */
+export function parsePriority(value: string, flag = '--priority'): number {
+  const priority = Number(value);
+  if (!/^\d+$/.test(value.trim()) || !isValidPriority(priority)) {
+    console.error(error(`${flag} must be an integer from ${MIN_PRIORITY} to ${MAX_PRIORITY}`));
+    process.exit(1);
+  }
+  return priority;
+}
+
+export interface PriorityFlags {
+  minPriority?: string;
+  maxPriority?: string;
+}
+
+/** Map --min-priority/--max-priority onto search options. */
+export function getPriorityFilter(flags: PriorityFlags): Pick<SearchOptions, 'minPriority' | 'maxPriority'> {
+  return {
+    minPriority: flags.minPriority === undefined ? undefined : parsePriority(flags.minPriority, '--min-priority'),
+    maxPriority: flags.maxPriority === undefined ? undefined : parsePriority(flags.maxPriority, '--max-priority'),
+  };
+}
+
+/** Option parser for flags that may be given more than once. */
+export function collectValues(value: string, previous: string[] = []): string[] {
+  return [...previous, value];
+}
+
+export interface LimitFlags {
+  limit: string;
+  all?: boolean;
+}
+
+/** Read --limit, or --all, as a result cap; 0 means no cap. Exits on bad values. */
+export function getLimit(flags: LimitFlags): number {
+  if (flags.all) {
+    return 0;
+  }
+
+  if (!/^\d+$/.test(flags.limit.trim())) {
+    console.error(error('--limit must be a non-negative integer (0 for no limit)'));
+    process.exit(1);
+  }
+  return Number(flags.limit);
+}
+
+export interface PageFlags {
+  page?: string;
+  pageSize?: string;
+}
+
+export interface Page {
+  number: number;
+  size: number;
+}
+
+/**
+ * Read --page/--page-size, exiting on bad values. Returns null when neither
+ * flag is given; the page size falls back to the command's limit, which must
+ * then be set.
+ */
+export function getPage(flags: PageFlags, limit: number): Page | null {
+  if (flags.page === undefined && flags.pageSize === undefined) {
+    return null;
+  }
+  if (flags.pageSize === undefined && limit <= 0) {
+    console.error(error('--page needs --page-size when there is no limit'));
+    process.exit(1);
+  }
+
+  const number = flags.page === undefined ? 1 : Number(flags.page);
+  const size = flags.pageSize === undefined ? limit : Number(flags.pageSize);
+  if (!Number.isInteger(number) || number < 1) {
+    console.error(error('--page must be a positive integer'));
+    process.exit(1);
+  }
+  if (!Number.isInteger(size) || size < 1) {
+    console.error(error('--page-size must be a positive integer'));
+    process.exit(1);
+  }
+  return { number, size };
+}
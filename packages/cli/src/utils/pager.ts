@@ -0,0 +1,49 @@
+import { spawnSync } from 'node:child_process';
+
+/** Tried in order after $PAGER; -R keeps color codes intact. */
+const FALLBACK_PAGERS = ['less -R', 'more'];
+/** The status a shell returns when the command does not exist. */
+const COMMAND_NOT_FOUND = 127;
+
+let pagerEnabled = true;
+
+export function setPagerEnabled(enabled: boolean): void {
+  pagerEnabled = enabled;
+}
+
+/**
+ * Print text, through a pager when stdout is a terminal and the text is
+ * taller than it. A pager that is missing or cannot start falls through to
+ * the next one, and finally to printing directly.
+ */
+export function printPaged(text: string): void {
+  const output = text.endsWith('\n') ? text : `${text}\n`;
+  const rows = process.stdout.rows ?? Number.POSITIVE_INFINITY;
+  if (!pagerEnabled || !process.stdout.isTTY || output.split('\n').length - 1 <= rows) {
+    process.stdout.write(output);
+    return;
+  }
+
+  const pagers = process.env.PAGER ? [process.env.PAGER, ...FALLBACK_PAGERS] : FALLBACK_PAGERS;
+  for (const pager of pagers) {
+    const result = spawnSync(pager, { shell: true, input: output, stdio: ['pipe', 'inherit', 'inherit'] });
+    if (!result.error && result.status !== COMMAND_NOT_FOUND) {
+      return;
+    }
+  }
+
+  process.stdout.write(output);
+}
+
+/**
+ * Exit quietly once the reader of stdout goes away, as with `| head`, instead
+ * of crashing on EPIPE. Other write errors still surface.
+ */
+export function exitOnClosedPipe(): void {
+  process.stdout.on('error', (err: NodeJS.ErrnoException) => {
+    if (err.code === 'EPIPE') {
+      process.exit(0);
+    }
+    throw err;
+  });
+}
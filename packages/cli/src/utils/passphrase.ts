@@ -0,0 +1,54 @@
+import { decryptNoteContent, type Note } from '@agentnotes/engine';
+import { error } from '../display/format.js';
+import { promptHidden } from './stdin.js';
+
+export const PASSPHRASE_ENV = 'AGENTNOTES_KEY';
+
+/**
+ * Passphrase for encrypted notes: `AGENTNOTES_KEY` when set, otherwise a
+ * hidden prompt. With `confirm`, the prompt asks twice so a typo does not
+ * lock the note.
+ */
+export async function getPassphrase(options: { confirm?: boolean } = {}): Promise<string> {
+  const fromEnv = process.env[PASSPHRASE_ENV];
+  if (fromEnv) {
+    return fromEnv;
+  }
+
+  if (!process.stdin.isTTY) {
+    console.error(error(`Set ${PASSPHRASE_ENV} or run in a terminal to enter a passphrase`));
+    process.exit(1);
+  }
+
+  const passphrase = await promptHidden('Passphrase: ');
+  if (!passphrase) {
+    console.error(error('Passphrase cannot be empty'));
+    process.exit(1);
+  }
+
+  if (options.confirm && (await promptHidden('Repeat passphrase: ')) !== passphrase) {
+    console.error(error('Passphrases do not match'));
+    process.exit(1);
+  }
+
+  return passphrase;
+}
+
+/** Decrypt an encrypted note's content, exiting on a wrong passphrase. */
+export function decryptOrExit(note: Note, passphrase: string): string {
+  try {
+    return decryptNoteContent(note.content, passphrase);
+  } catch (err) {
+    console.error(error((err as Error).message));
+    process.exit(1);
+  }
+}
+
+/** The note with its body decrypted for display, prompting when needed. */
+export async function unlockNote(note: Note): Promise<Note> {
+  if (!note.encrypted) {
+    return note;
+  }
+
+  return { ...note, content: decryptOrExit(note, await getPassphrase()) };
+}
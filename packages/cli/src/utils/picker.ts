@@ -0,0 +1,113 @@
+import readline from 'node:readline';
+import { filterNotesFuzzy, search, type Note, type NoteStore } from '@agentnotes/engine';
+import { error, formatNoteList, formatPickerPrompt, formatPickerRow, info } from '../display/format.js';
+
+const MAX_VISIBLE_ROWS = 10;
+
+/** The picker reads keys from stdin and draws on stderr, so both must be a terminal. */
+export function canPick(): boolean {
+  return Boolean(process.stdin.isTTY && process.stderr.isTTY);
+}
+
+/**
+ * Fuzzy-find a note by title in the terminal: typing filters, arrow keys (or
+ * Ctrl-P/Ctrl-N) move, Enter picks, Esc or Ctrl-C cancels with null. Drawn on
+ * stderr and erased afterwards, so stdout stays free for the result.
+ */
+export function pickNote(notes: Note[]): Promise<Note | null> {
+  const input = process.stdin;
+  const output = process.stderr;
+  let query = '';
+  let matches = notes;
+  let selected = 0;
+  let drawnRows = 0;
+
+  const clear = () => {
+    output.write(drawnRows > 0 ? `\x1b[${drawnRows}A\r\x1b[J` : '\r\x1b[J');
+  };
+
+  const render = () => {
+    clear();
+    const visible = Math.min(MAX_VISIBLE_ROWS, Math.max(1, (output.rows || 24) - 2));
+    const width = Math.max(10, (output.columns || 80) - 1);
+    const start = Math.max(0, selected - visible + 1);
+    const rows = matches
+      .slice(start, start + visible)
+      .map((note, index) => formatPickerRow(note, start + index === selected, width));
+    output.write(formatPickerPrompt(query, matches.length, notes.length));
+    for (const row of rows) {
+      output.write(`\n${row}`);
+    }
+    drawnRows = rows.length;
+  };
+
+  return new Promise((resolve) => {
+    const finish = (note: Note | null) => {
+      input.off('keypress', onKeypress);
+      input.setRawMode(false);
+      input.pause();
+      clear();
+      output.write('\x1b[?25h');
+      resolve(note);
+    };
+
+    const onKeypress = (text: string | undefined, key: readline.Key = {}) => {
+      if ((key.ctrl && key.name === 'c') || key.name === 'escape') {
+        finish(null);
+        return;
+      }
+      if (key.name === 'return' || key.name === 'enter') {
+        finish(matches[selected] ?? null);
+        return;
+      }
+
+      if (key.name === 'up' || (key.ctrl && key.name === 'p')) {
+        selected = Math.max(0, selected - 1);
+      } else if (key.name === 'down' || (key.ctrl && key.name === 'n')) {
+        selected = Math.min(Math.max(0, matches.length - 1), selected + 1);
+      } else if (key.name === 'backspace' || (key.ctrl && key.name === 'u')) {
+        query = key.name === 'backspace' ? query.slice(0, -1) : '';
+        matches = filterNotesFuzzy(notes, query);
+        selected = 0;
+      } else if (text && !key.ctrl && !key.meta && text >= ' ') {
+        query += text;
+        matches = filterNotesFuzzy(notes, query);
+        selected = 0;
+      }
+      render();
+    };
+
+    readline.emitKeypressEvents(input);
+    input.setRawMode(true);
+    input.resume();
+    input.on('keypress', onKeypress);
+    output.write('\x1b[?25l');
+    render();
+  });
+}
+
+/**
+ * Let the user pick from the store's notes, most recently updated first, or
+ * exit. Outside a terminal the notes are listed instead, so an ID can be
+ * passed explicitly.
+ */
+export async function requirePickedNote(store: NoteStore): Promise<Note> {
+  const { notes } = await store.listNotes();
+  const candidates = search(notes, { sortBy: 'updated', reverse: true });
+  if (candidates.length === 0) {
+    console.error(error('No notes to pick from'));
+    process.exit(1);
+  }
+
+  if (!canPick()) {
+    console.log(formatNoteList(candidates));
+    console.error(info('Not a terminal, so nothing was picked; pass one of these IDs instead'));
+    process.exit(1);
+  }
+
+  const note = await pickNote(candidates);
+  if (!note) {
+    process.exit(1);
+  }
+  return note;
+}
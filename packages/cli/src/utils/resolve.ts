@@ -1,37 +1,35 @@
-import type { Note, NoteStore } from '@agentnotes/engine';
+import { logDebug, type Note, type NoteLookupResult, type NoteStore } from '@agentnotes/engine';
+import { error, info } from '../display/format.js';
 
 /**
- * Resolve a note by ID (relativePath) or by title/slug match.
- * Tries exact ID first, then searches by title.
+ * Resolve a note or exit, listing the matches when the reference is ambiguous
+ * and "did you mean?" suggestions when the lookup turned up near misses.
  */
-export async function resolveNote(store: NoteStore, idOrTitle: string): Promise<Note | null> {
-  // Try direct ID lookup (relativePath ending in .md)
-  if (idOrTitle.endsWith('.md')) {
-    const note = await store.getNote(idOrTitle);
-    if (note) return note;
-  }
-
-  // Search through all notes for a match
-  const result = await store.listNotes();
-  const lower = idOrTitle.toLocaleLowerCase();
-
-  // Try exact title match
-  const exactMatch = result.notes.find(
-    (n) => n.title.toLocaleLowerCase() === lower,
-  );
-  if (exactMatch) return exactMatch;
+export async function requireNote(store: NoteStore, idOrTitle: string): Promise<Note> {
+  const note = requireLookup(await store.findNote(idOrTitle), `Note not found: ${idOrTitle}`);
+  logDebug(`"${idOrTitle}" resolved to ${note.id}`);
+  return note;
+}
 
-  // Try title contains
-  const containsMatch = result.notes.find(
-    (n) => n.title.toLocaleLowerCase().includes(lower),
-  );
-  if (containsMatch) return containsMatch;
+export function requireLookup(result: NoteLookupResult, notFoundMessage: string): Note {
+  if (result.note) {
+    return result.note;
+  }
 
-  // Try slug contains
-  const slugMatch = result.notes.find(
-    (n) => n.filename.toLocaleLowerCase().includes(lower),
-  );
-  if (slugMatch) return slugMatch;
+  if (result.ambiguous) {
+    console.error(error(`${result.candidates.length} notes match; use an ID or a more specific title:`));
+    for (const candidate of result.candidates) {
+      console.error(`  ${candidate.title} [${candidate.id}]`);
+    }
+    process.exit(1);
+  }
 
-  return null;
+  console.error(error(notFoundMessage));
+  if (result.candidates.length > 0) {
+    console.error(info('Did you mean:'));
+    for (const candidate of result.candidates) {
+      console.error(`  ${candidate.title} [${candidate.id}]`);
+    }
+  }
+  process.exit(1);
 }
@@ -37,3 +37,22 @@ export async function confirm(message: string): Promise<boolean> {
     });
   });
 }
+
+/** Prompt on the terminal without echoing what is typed. */
+export async function promptHidden(message: string): Promise<string> {
+  const rl = readline.createInterface({
+    input: process.stdin,
+    output: process.stderr,
+    terminal: true,
+  });
+
+  return new Promise((resolve) => {
+    process.stderr.write(message);
+    (rl as unknown as { _writeToOutput: (text: string) => void })._writeToOutput = () => {};
+    rl.question('', (answer) => {
+      rl.close();
+      process.stderr.write('\n');
+      resolve(answer);
+    });
+  });
+}
@@ -0,0 +1,49 @@
+import {
+  findSimilarTag,
+  getAllTags,
+  logWarning,
+  type AgentNotesConfig,
+  type NoteStore,
+} from '@agentnotes/engine';
+import { error, info } from '../display/format.js';
+
+export interface TagVocabularyFlags {
+  strictTags?: boolean;
+  newTag?: boolean;
+}
+
+/** --strict-tags or `strictTags` in config turns strict mode on; --new-tag lifts it for one command. */
+export function isStrictTags(opts: TagVocabularyFlags, config: AgentNotesConfig): boolean {
+  return (opts.strictTags === true || config.strictTags) && opts.newTag !== true;
+}
+
+/**
+ * Compare tags about to be set against those already in the store. In strict
+ * mode a tag no note uses yet is rejected; otherwise one that is close to an
+ * existing tag only prints a warning naming it.
+ */
+export async function checkTagVocabulary(store: NoteStore, tags: string[], strict: boolean): Promise<void> {
+  if (tags.length === 0) {
+    return;
+  }
+
+  const { notes } = await store.listNotes();
+  const existing = [...getAllTags(notes, true).keys()];
+  const known = new Set(existing);
+
+  for (const tag of tags) {
+    if (known.has(tag.toLocaleLowerCase())) {
+      continue;
+    }
+
+    const similar = findSimilarTag(tag, existing);
+    if (strict) {
+      const hint = similar ? ` (did you mean "${similar}"?)` : '';
+      console.error(error(`Unknown tag "${tag}"${hint}; pass --new-tag to add it`));
+      process.exit(1);
+    }
+    if (similar) {
+      logWarning(info(`New tag "${tag}" is close to existing "${similar}"`));
+    }
+  }
+}
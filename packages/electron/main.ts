@@ -1,10 +1,10 @@
-import { app, BrowserWindow, dialog, ipcMain } from 'electron';
+import { app, BrowserWindow, dialog, ipcMain, nativeTheme, shell } from 'electron';
 import type { OpenDialogOptions } from 'electron';
 import path from 'node:path';
 import fs from 'node:fs';
 import crypto from 'node:crypto';
 import Store from 'electron-store';
-import { NoteStore } from '@agentnotes/engine';
+import { NoteStore, NotesWatcher, getSortedTags, renderMarkdown, resolveLinkTarget, search } from '@agentnotes/engine';
 import type {
   AddCommentPayload,
   CommentMutationResult,
@@ -20,20 +20,26 @@ import type {
   UpdateNotePayload,
   CreateDirectoryPayload,
   DeleteDirectoryPayload,
+  TagCount,
 } from '@agentnotes/engine';
 
+type Theme = 'dark' | 'light';
+
 interface StoreSchema {
   notesDirectory: string | null;
+  theme: Theme;
 }
 
 const store = new Store<StoreSchema>({
   defaults: {
     notesDirectory: null,
+    theme: 'dark',
   },
 });
 
 let mainWindow: BrowserWindow | null = null;
 let noteStore: NoteStore | null = null;
+let notesWatcher: NotesWatcher | null = null;
 
 function getNotesDir(): string | null {
   return store.get('notesDirectory');
@@ -54,6 +60,28 @@ function ensureNoteStore(): NoteStore | null {
   return noteStore;
 }
 
+/**
+ * Tell the renderer when notes change on disk, e.g. from the CLI or another
+ * editor. The app's own saves trigger it too; the renderer skips reloads that
+ * change nothing it shows.
+ */
+function watchNotesDirectory(): void {
+  notesWatcher?.close();
+  notesWatcher = null;
+
+  const notesDir = getNotesDir();
+  if (!notesDir) {
+    return;
+  }
+
+  notesWatcher = new NotesWatcher(notesDir, () => {
+    if (mainWindow && !mainWindow.isDestroyed()) {
+      mainWindow.webContents.send('notes:changed');
+    }
+  });
+  notesWatcher.start();
+}
+
 function isRecord(value: unknown): value is Record<string, unknown> {
   return typeof value === 'object' && value !== null;
 }
@@ -110,6 +138,23 @@ function isDeleteDirectoryPayload(payload: unknown): payload is DeleteDirectoryP
   return isRecord(payload) && typeof payload.path === 'string';
 }
 
+/** Wiki-links in previews point here; the renderer turns clicks into note selection. */
+const NOTE_LINK_PREFIX = '#note:';
+
+interface SearchNotesPayload {
+  query: string;
+  tags: string[];
+}
+
+function isSearchNotesPayload(payload: unknown): payload is SearchNotesPayload {
+  return (
+    isRecord(payload) &&
+    typeof payload.query === 'string' &&
+    Array.isArray(payload.tags) &&
+    payload.tags.every((tag: unknown) => typeof tag === 'string')
+  );
+}
+
 interface SaveImagePayload {
   data: string;
   mimeType: string;
@@ -156,7 +201,9 @@ function createWindow(): void {
 }
 
 app.whenReady().then(() => {
+  nativeTheme.themeSource = store.get('theme');
   createWindow();
+  watchNotesDirectory();
 
   app.on('activate', () => {
     if (BrowserWindow.getAllWindows().length === 0) {
@@ -195,6 +242,47 @@ ipcMain.handle('notes:get', async (_event, noteId: string): Promise<Note | null>
   return ns.getNote(noteId);
 });
 
+ipcMain.handle('notes:search', async (_event, payload: unknown): Promise<Note[]> => {
+  const ns = ensureNoteStore();
+  if (!ns || !isSearchNotesPayload(payload)) {
+    return [];
+  }
+
+  const { notes } = await ns.listNotes();
+  return search(notes, { query: payload.query.trim() || undefined, tags: payload.tags });
+});
+
+ipcMain.handle('notes:tags', async (): Promise<TagCount[]> => {
+  const ns = ensureNoteStore();
+  if (!ns) {
+    return [];
+  }
+
+  const { notes } = await ns.listNotes();
+  return getSortedTags(notes);
+});
+
+ipcMain.handle('notes:render', async (_event, content: unknown): Promise<string> => {
+  if (typeof content !== 'string') {
+    return '';
+  }
+
+  const ns = ensureNoteStore();
+  const notes = ns ? (await ns.listNotes()).notes : [];
+  return renderMarkdown(content, {
+    resolveLink: (ref) => {
+      const target = resolveLinkTarget(notes, ref);
+      return target ? `${NOTE_LINK_PREFIX}${encodeURIComponent(target.id)}` : null;
+    },
+  });
+});
+
+ipcMain.on('shell:openExternal', (_event, url: unknown) => {
+  if (typeof url === 'string' && /^(https?|mailto):/i.test(url)) {
+    void shell.openExternal(url);
+  }
+});
+
 ipcMain.handle(
   'notes:create',
   async (_event, payload: unknown): Promise<CommentMutationResult> => {
@@ -309,6 +397,18 @@ ipcMain.on('window:close', () => {
   mainWindow?.close();
 });
 
+ipcMain.handle('theme:get', async (): Promise<Theme> => {
+  return store.get('theme');
+});
+
+ipcMain.handle('theme:set', async (_event, theme: unknown): Promise<Theme> => {
+  if (theme === 'dark' || theme === 'light') {
+    store.set('theme', theme);
+    nativeTheme.themeSource = theme;
+  }
+  return store.get('theme');
+});
+
 ipcMain.handle('directory:get', async (): Promise<string | null> => {
   return getNotesDir();
 });
@@ -356,6 +456,7 @@ ipcMain.handle('directory:select', async (): Promise<string | null> => {
     if (selectedPath) {
       store.set('notesDirectory', selectedPath);
       noteStore = null;
+      watchNotesDirectory();
     }
     return selectedPath;
   }
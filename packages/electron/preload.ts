@@ -8,11 +8,18 @@ import type {
   NotesListResult,
   PreloadApi,
   SaveImageResult,
+  TagCount,
+  Theme,
 } from './src/types';
 
 const api: PreloadApi = {
   listNotes: () => ipcRenderer.invoke('notes:list') as Promise<NotesListResult>,
   getNote: (noteId: string) => ipcRenderer.invoke('notes:get', noteId) as Promise<Note | null>,
+  searchNotes: (query: string, tags: string[]) =>
+    ipcRenderer.invoke('notes:search', { query, tags }) as Promise<Note[]>,
+  listTags: () => ipcRenderer.invoke('notes:tags') as Promise<TagCount[]>,
+  renderMarkdown: (content: string) => ipcRenderer.invoke('notes:render', content) as Promise<string>,
+  openExternal: (url: string) => ipcRenderer.send('shell:openExternal', url),
   createNote: (title: string, directory: string) =>
     ipcRenderer.invoke('notes:create', { title, directory }) as Promise<CommentMutationResult>,
   deleteNote: (noteId: string) =>
@@ -46,11 +53,16 @@ const api: PreloadApi = {
     ipcRenderer.invoke('notes:deleteComment', { noteId, commentId }) as Promise<CommentMutationResult>,
   saveImage: (data: string, mimeType: string, filename?: string) =>
     ipcRenderer.invoke('images:save', { data, mimeType, filename }) as Promise<SaveImageResult>,
+  getTheme: () => ipcRenderer.invoke('theme:get') as Promise<Theme>,
+  setTheme: (theme: Theme) => ipcRenderer.invoke('theme:set', theme) as Promise<Theme>,
   getDirectory: () => ipcRenderer.invoke('directory:get') as Promise<string | null>,
   selectDirectory: () => ipcRenderer.invoke('directory:select') as Promise<string | null>,
   windowMinimize: () => ipcRenderer.send('window:minimize'),
   windowMaximize: () => ipcRenderer.send('window:maximize'),
   windowClose: () => ipcRenderer.send('window:close'),
+  onNotesChanged: (callback: () => void) => {
+    ipcRenderer.on('notes:changed', () => callback());
+  },
 };
 
 contextBridge.exposeInMainWorld('api', api);
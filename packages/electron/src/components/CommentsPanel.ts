@@ -1,12 +1,23 @@
 import type { CommentAnchor, NoteComment } from '../types';
 
+/** A comment being written: on a selection, or on a line picked in the card. */
 interface PendingComment {
-  anchor: CommentAnchor;
+  anchor: CommentAnchor | null;
   selectedText: string;
+  line: number;
+  draft: string;
 }
 
-type CommentSubmitHandler = (content: string, anchor: CommentAnchor) => void | Promise<void>;
+interface LineAnchor {
+  anchor: CommentAnchor;
+  text: string;
+}
+
+type CommentSubmitHandler = (content: string, anchor: CommentAnchor, author: string) => void | Promise<void>;
 type CommentDeleteHandler = (commentId: string) => void | Promise<void>;
+type LineAnchorResolver = (line: number) => LineAnchor | null;
+
+const AUTHOR_STORAGE_KEY = 'agentnotes.commentAuthor';
 
 function parseDate(value: string): number {
   return new Date(value).getTime();
@@ -18,6 +29,7 @@ export class CommentsPanel {
   private pendingComment: PendingComment | null;
   private onCommentSubmitCallback: CommentSubmitHandler | null;
   private onCommentDeleteCallback: CommentDeleteHandler | null;
+  private lineAnchorResolver: LineAnchorResolver | null;
   private deletingCommentIds: Set<string>;
 
   constructor(container: HTMLElement) {
@@ -26,6 +38,7 @@ export class CommentsPanel {
     this.pendingComment = null;
     this.onCommentSubmitCallback = null;
     this.onCommentDeleteCallback = null;
+    this.lineAnchorResolver = null;
     this.deletingCommentIds = new Set<string>();
   }
 
@@ -37,11 +50,29 @@ export class CommentsPanel {
     this.onCommentDeleteCallback = callback;
   }
 
+  setLineAnchorResolver(resolver: LineAnchorResolver): void {
+    this.lineAnchorResolver = resolver;
+  }
+
   startNewComment(anchor: CommentAnchor, selectedText: string): void {
     this.pendingComment = {
       anchor,
       selectedText:
         selectedText.length > 50 ? `${selectedText.substring(0, 47)}...` : selectedText,
+      line: 0,
+      draft: this.pendingComment?.draft ?? '',
+    };
+
+    this.renderWithPending();
+  }
+
+  /** Open a comment card without a selection; it anchors to a chosen line. */
+  startLineComment(): void {
+    this.pendingComment = {
+      anchor: null,
+      selectedText: '',
+      line: 1,
+      draft: this.pendingComment?.draft ?? '',
     };
 
     this.renderWithPending();
@@ -52,13 +83,25 @@ export class CommentsPanel {
     this.render(this.comments);
   }
 
-  private submitPendingComment(content: string): void {
+  private submitPendingComment(content: string, author: string, error: HTMLElement): void {
     if (!this.pendingComment || !content.trim()) {
       return;
     }
 
+    let anchor = this.pendingComment.anchor;
+    if (!anchor) {
+      const lineAnchor = this.lineAnchorResolver?.(this.pendingComment.line) ?? null;
+      if (!lineAnchor) {
+        error.textContent = `Line ${this.pendingComment.line} has no text to comment on.`;
+        error.classList.remove('hidden');
+        return;
+      }
+      anchor = lineAnchor.anchor;
+    }
+
+    window.localStorage.setItem(AUTHOR_STORAGE_KEY, author.trim());
     if (this.onCommentSubmitCallback) {
-      this.onCommentSubmitCallback(content.trim(), this.pendingComment.anchor);
+      this.onCommentSubmitCallback(content.trim(), anchor, author.trim());
     }
 
     this.pendingComment = null;
@@ -95,7 +138,14 @@ export class CommentsPanel {
 
   private createCommentCard(comment: NoteComment): HTMLDivElement {
     const card = document.createElement('div');
-    card.className = 'comment-card';
+    card.className = comment.resolved ? 'comment-card comment-card-resolved' : 'comment-card';
+
+    if (comment.resolved) {
+      const badge = document.createElement('div');
+      badge.className = 'comment-resolved-badge';
+      badge.textContent = '\u2713 Resolved';
+      card.appendChild(badge);
+    }
 
     const previewText = comment.anchor.quote || '';
     if (previewText) {
@@ -110,6 +160,13 @@ export class CommentsPanel {
     content.textContent = comment.content;
     card.appendChild(content);
 
+    if (comment.author) {
+      const author = document.createElement('div');
+      author.className = 'comment-author';
+      author.textContent = `\u2014 ${comment.author}`;
+      card.appendChild(author);
+    }
+
     if (comment.id) {
       const isDeleting = this.deletingCommentIds.has(comment.id);
       const deleteButton = document.createElement('button');
@@ -137,27 +194,61 @@ export class CommentsPanel {
     const card = document.createElement('div');
     card.className = 'comment-card comment-card-pending';
 
-    const preview = document.createElement('div');
-    preview.className = 'comment-preview';
-    preview.textContent = `"${pendingComment.selectedText}"`;
-    card.appendChild(preview);
+    const error = document.createElement('p');
+    error.className = 'comment-error hidden';
+
+    if (pendingComment.anchor) {
+      const preview = document.createElement('div');
+      preview.className = 'comment-preview';
+      preview.textContent = `"${pendingComment.selectedText}"`;
+      card.appendChild(preview);
+    } else {
+      const lineLabel = document.createElement('label');
+      lineLabel.className = 'comment-field';
+      lineLabel.textContent = 'Line';
+
+      const lineInput = document.createElement('input');
+      lineInput.className = 'comment-input comment-line-input';
+      lineInput.type = 'number';
+      lineInput.min = '1';
+      lineInput.value = String(pendingComment.line);
+      lineInput.addEventListener('input', () => {
+        pendingComment.line = Math.max(1, parseInt(lineInput.value, 10) || 1);
+        error.classList.add('hidden');
+      });
+
+      lineLabel.appendChild(lineInput);
+      card.appendChild(lineLabel);
+    }
+
+    const authorInput = document.createElement('input');
+    authorInput.className = 'comment-input';
+    authorInput.type = 'text';
+    authorInput.placeholder = 'Your name (optional)';
+    authorInput.value = window.localStorage.getItem(AUTHOR_STORAGE_KEY) ?? '';
+    card.appendChild(authorInput);
 
     const textarea = document.createElement('textarea');
     textarea.className = 'comment-textarea';
     textarea.placeholder = 'Write your comment...';
     textarea.rows = 3;
+    textarea.value = pendingComment.draft;
+
+    textarea.addEventListener('input', () => {
+      pendingComment.draft = textarea.value;
+    });
 
     textarea.addEventListener('keydown', (event) => {
       if (event.key === 'Enter' && !event.shiftKey) {
         event.preventDefault();
-        this.submitPendingComment(textarea.value);
+        this.submitPendingComment(textarea.value, authorInput.value, error);
       } else if (event.key === 'Escape') {
         event.preventDefault();
         this.cancelPendingComment();
       }
     });
 
-    card.appendChild(textarea);
+    card.append(textarea, error);
 
     const buttonRow = document.createElement('div');
     buttonRow.className = 'comment-buttons';
@@ -170,12 +261,17 @@ export class CommentsPanel {
     const saveButton = document.createElement('button');
     saveButton.className = 'comment-btn comment-btn-save';
     saveButton.textContent = 'Save';
-    saveButton.addEventListener('click', () => this.submitPendingComment(textarea.value));
+    saveButton.addEventListener('click', () =>
+      this.submitPendingComment(textarea.value, authorInput.value, error),
+    );
 
     buttonRow.append(cancelButton, saveButton);
     card.appendChild(buttonRow);
 
-    setTimeout(() => textarea.focus(), 0);
+    setTimeout(() => {
+      textarea.focus();
+      textarea.setSelectionRange(textarea.value.length, textarea.value.length);
+    }, 0);
 
     return card;
   }
@@ -206,6 +302,12 @@ export class CommentsPanel {
 
   render(comments: NoteComment[] = []): void {
     this.comments = comments;
+    if (this.pendingComment) {
+      // Keep a half-written comment when the list refreshes underneath it.
+      this.renderWithPending();
+      return;
+    }
+
     this.container.innerHTML = '';
 
     if (this.comments.length === 0) {
@@ -227,6 +329,7 @@ export class CommentsPanel {
 
   clear(): void {
     this.comments = [];
+    this.pendingComment = null;
     this.deletingCommentIds.clear();
     this.container.innerHTML = '<p class="empty-state">No comments</p>';
   }
@@ -24,6 +24,10 @@ interface NoteListCallbacks {
 }
 
 function compareNotes(a: Note, b: Note): number {
+  if (Boolean(a.pinned) !== Boolean(b.pinned)) {
+    return a.pinned ? -1 : 1;
+  }
+
   const pathDiff = a.relativePath.localeCompare(b.relativePath);
   if (pathDiff !== 0) {
     return pathDiff;
@@ -142,7 +146,7 @@ function buildNoteTree(notes: Note[], directories: string[]): TreeNode[] {
   return tree;
 }
 
-function createIcon(type: 'folder' | 'document' | 'chevron'): SVGSVGElement {
+function createIcon(type: 'folder' | 'document' | 'chevron' | 'star'): SVGSVGElement {
   const svg = document.createElementNS('http://www.w3.org/2000/svg', 'svg');
   svg.setAttribute('width', '16');
   svg.setAttribute('height', '16');
@@ -161,6 +165,11 @@ function createIcon(type: 'folder' | 'document' | 'chevron'): SVGSVGElement {
       'd',
       'M4 0a2 2 0 0 0-2 2v12a2 2 0 0 0 2 2h8a2 2 0 0 0 2-2V4.5L9.5 0H4zm5 1.5V5h3.5L9 1.5zM5 7h6v1H5V7zm0 2h6v1H5V9zm0 2h4v1H5v-1z',
     );
+  } else if (type === 'star') {
+    path.setAttribute(
+      'd',
+      'M3.612 15.443c-.386.198-.824-.149-.746-.592l.83-4.73L.173 6.765c-.329-.314-.158-.888.283-.95l4.898-.696L7.538.792c.197-.39.73-.39.927 0l2.184 4.327 4.898.696c.441.062.612.636.282.95l-3.522 3.356.83 4.73c.078.443-.36.79-.746.592L8 13.187l-4.389 2.256z',
+    );
   } else {
     path.setAttribute(
       'd',
@@ -210,6 +219,13 @@ function createNoteItem(note: Note, isSelected: boolean, depth = 0): HTMLDivElem
   title.textContent = note.title;
 
   item.append(docIcon, title);
+  if (note.pinned) {
+    const pinIcon = document.createElement('span');
+    pinIcon.className = 'note-pin-icon';
+    pinIcon.title = 'Pinned';
+    pinIcon.appendChild(createIcon('star'));
+    item.append(pinIcon);
+  }
   return item;
 }
 
@@ -573,6 +589,29 @@ export class NoteList {
     }
   }
 
+  /**
+   * Move the selection up or down through the notes as listed, skipping those
+   * inside collapsed folders. With nothing selected the first note is picked.
+   */
+  selectAdjacent(offset: number): void {
+    const items = Array.from(this.container.querySelectorAll<HTMLElement>('.note-item')).filter(
+      (item) => item.offsetParent !== null,
+    );
+    if (items.length === 0) {
+      return;
+    }
+
+    const currentIndex = items.findIndex((item) => item.dataset.noteId === this.selectedNoteId);
+    const nextIndex = currentIndex < 0 ? 0 : Math.min(Math.max(currentIndex + offset, 0), items.length - 1);
+    const next = items[nextIndex];
+    if (!next?.dataset.noteId || nextIndex === currentIndex) {
+      return;
+    }
+
+    this.selectNote(next.dataset.noteId);
+    next.scrollIntoView({ block: 'nearest' });
+  }
+
   getSelectedNote(): Note | null {
     return this.notes.find((note) => note.id === this.selectedNoteId) ?? null;
   }
@@ -16,6 +16,14 @@ type CommentCreateHandler = (anchor: CommentAnchor, selectedText: string) => voi
 type NoteSaveHandler = (noteId: string, content: string) => Promise<Note | null>;
 type NoteMetadataSaveHandler = (noteId: string, tags: string[]) => Promise<Note | null>;
 type NoteActionHandler = (note: Note) => void | Promise<void>;
+type NoteLinkHandler = (noteId: string) => void;
+
+const NOTE_LINK_PREFIX = '#note:';
+
+/** Decoration colors come from the active theme's CSS variables. */
+function getThemeColor(variable: string, fallback: string): string {
+  return getComputedStyle(document.documentElement).getPropertyValue(variable).trim() || fallback;
+}
 
 interface SaveEditsOptions {
   keepEditing?: boolean;
@@ -32,6 +40,9 @@ export class NoteView {
   private onNoteSaveCallback: NoteSaveHandler | null;
   private onNoteMetadataSaveCallback: NoteMetadataSaveHandler | null;
   private onNoteDeleteCallback: NoteActionHandler | null;
+  private onNoteLinkOpenCallback: NoteLinkHandler | null;
+  private isPreviewMode: boolean;
+  private previewElement: HTMLDivElement | null;
   private currentSelection: CurrentSelection | null;
   private isSaving: boolean;
   private isSavingMetadata: boolean;
@@ -71,6 +82,9 @@ export class NoteView {
     this.onNoteSaveCallback = null;
     this.onNoteMetadataSaveCallback = null;
     this.onNoteDeleteCallback = null;
+    this.onNoteLinkOpenCallback = null;
+    this.isPreviewMode = false;
+    this.previewElement = null;
     this.currentSelection = null;
     this.isSaving = false;
     this.isSavingMetadata = false;
@@ -80,7 +94,7 @@ export class NoteView {
     this.lastSavedContent = '';
     this.isApplyingContent = false;
     this.contentBackgroundMouseHandler = (event: MouseEvent) => {
-      if (event.button !== 0 || !this.editor || event.target !== this.contentContainer) {
+      if (event.button !== 0 || !this.editor || this.isPreviewMode || event.target !== this.contentContainer) {
         return;
       }
 
@@ -124,6 +138,99 @@ export class NoteView {
     this.onNoteDeleteCallback = callback;
   }
 
+  setOnNoteLinkOpen(callback: NoteLinkHandler): void {
+    this.onNoteLinkOpenCallback = callback;
+  }
+
+  /**
+   * Switch between the editable source and a rendered preview. The editor
+   * stays mounted underneath so its text and cursor survive the round trip;
+   * comments have no highlights in the preview but stay listed in the panel.
+   */
+  setPreviewMode(enabled: boolean): void {
+    this.isPreviewMode = enabled;
+    if (enabled) {
+      void this.flushUnsavedEdits();
+      this.hideSelectionTooltip();
+      this.hideHeadingActionButton();
+    }
+
+    this.contentContainer.classList.toggle(
+      'note-content-preview',
+      enabled && this.currentNote !== null && !this.currentNote.encrypted
+    );
+    this.renderActions();
+    void this.renderPreview();
+  }
+
+  /** Re-apply decorations after a theme switch so highlight colors follow it. */
+  refreshTheme(): void {
+    if (!this.editor || !this.currentNote) {
+      return;
+    }
+
+    this.updateDecorations();
+    this.editor.render(this.editorState);
+  }
+
+  private ensurePreviewElement(): HTMLDivElement {
+    if (!this.previewElement) {
+      this.previewElement = document.createElement('div');
+      this.previewElement.className = 'note-preview';
+      this.previewElement.addEventListener('click', (event) => {
+        this.handlePreviewClick(event);
+      });
+    }
+
+    if (!this.contentContainer.contains(this.previewElement)) {
+      this.contentContainer.appendChild(this.previewElement);
+    }
+
+    return this.previewElement;
+  }
+
+  private async renderPreview(): Promise<void> {
+    if (!this.isPreviewMode || !this.currentNote || this.currentNote.encrypted) {
+      return;
+    }
+
+    const content = this.getEditorText();
+    const html = await window.api.renderMarkdown(content);
+    if (!this.isPreviewMode || this.getEditorText() !== content) {
+      return;
+    }
+
+    const preview = this.ensurePreviewElement();
+    preview.innerHTML = html;
+    preview.querySelectorAll<HTMLImageElement>('img').forEach((image) => {
+      image.src = this.resolveImagePath(image.getAttribute('src') ?? '');
+    });
+  }
+
+  private handlePreviewClick(event: MouseEvent): void {
+    const target = event.target;
+    const link = target instanceof Element ? target.closest<HTMLAnchorElement>('a[href]') : null;
+    if (!link) {
+      return;
+    }
+
+    const href = link.getAttribute('href') ?? '';
+    event.preventDefault();
+
+    if (href.startsWith(NOTE_LINK_PREFIX)) {
+      this.onNoteLinkOpenCallback?.(decodeURIComponent(href.slice(NOTE_LINK_PREFIX.length)));
+      return;
+    }
+
+    if (href.startsWith('#')) {
+      const heading = this.previewElement?.querySelector(`[id="${CSS.escape(href.slice(1))}"]`);
+      heading?.scrollIntoView({ block: 'start' });
+      return;
+    }
+
+    window.api.openExternal(href);
+  }
+
   private initEditor(): void {
     if (this.editor) {
       this.editor.destroy();
@@ -362,7 +469,7 @@ export class NoteView {
           from: offset + match.index,
           to: offset + match.index + match[0].length,
           type: 'highlight',
-          attributes: { color: 'rgba(128, 128, 128, 0.3)' },
+          attributes: { color: getThemeColor('--code-highlight', 'rgba(128, 128, 128, 0.3)') },
         });
       }
 
@@ -442,7 +549,7 @@ export class NoteView {
       from: range.from,
       to: range.to,
       type: 'highlight' as const,
-      attributes: { color: 'rgba(255, 255, 0, 0.3)' },
+      attributes: { color: getThemeColor('--comment-highlight', 'rgba(255, 255, 0, 0.3)') },
     }));
   }
 
@@ -1121,6 +1228,32 @@ export class NoteView {
     }
   }
 
+  /**
+   * Save editor text that the autosave has not written yet, e.g. when the
+   * user switches notes or closes the window inside the debounce window. The
+   * note and text are captured before the first await, so the caller may
+   * load another note straight away.
+   */
+  async flushUnsavedEdits(): Promise<void> {
+    if (!this.currentNote || !this.onNoteSaveCallback) {
+      return;
+    }
+
+    const noteId = this.currentNote.id;
+    const content = this.getEditorText();
+    if (content === this.lastSavedContent) {
+      return;
+    }
+
+    this.clearAutosaveTimer();
+    this.lastSavedContent = content;
+    try {
+      await this.onNoteSaveCallback(noteId, content);
+    } catch (error) {
+      console.error('Error saving note:', error);
+    }
+  }
+
   private getEditorText(): string {
     return this.editorState.text;
   }
@@ -1162,6 +1295,7 @@ export class NoteView {
 
     const isDifferentNote = this.currentNote?.id !== note.id;
     if (isDifferentNote) {
+      void this.flushUnsavedEdits();
       this.clearAutosaveTimer();
       this.isSaving = false;
       this.isSavingMetadata = false;
@@ -1176,13 +1310,66 @@ export class NoteView {
     this.lastSavedContent = note.content;
     this.renderHeader(note);
     this.renderContent(note);
+    this.contentContainer.classList.toggle('note-content-preview', this.isPreviewMode && !note.encrypted);
+    void this.renderPreview();
+  }
+
+  /**
+   * Show a version of the current note that changed on disk. Unsaved edits
+   * win: while the editor holds text that is not saved yet the reload is
+   * skipped and the next autosave writes over it. When only metadata changed
+   * the editor keeps its cursor. Returns whether anything was re-rendered.
+   */
+  applyExternalChange(note: Note): boolean {
+    if (this.currentNote?.id !== note.id) {
+      this.render(note);
+      return true;
+    }
+
+    if (this.isSaving || this.autosaveTimer !== null || this.getEditorText() !== this.lastSavedContent) {
+      return false;
+    }
+
+    if (JSON.stringify(note) === JSON.stringify(this.currentNote)) {
+      return false;
+    }
+
+    if (note.content !== this.lastSavedContent) {
+      this.render(note);
+      return true;
+    }
+
+    this.currentNote = note;
+    this.renderHeader(note);
+    if (this.editor) {
+      this.updateDecorations();
+      this.editor.render(this.editorState);
+    }
+    return true;
   }
 
   private renderHeader(note: Note): void {
     this.renderTags(note);
+    this.renderAttachments(note);
     this.renderActions();
   }
 
+  private renderAttachments(note: Note): void {
+    const attachmentsElement = this.headerContainer.querySelector<HTMLElement>('.note-attachments');
+    if (!attachmentsElement) {
+      return;
+    }
+
+    attachmentsElement.innerHTML = '';
+    for (const name of note.attachments ?? []) {
+      const item = document.createElement('li');
+      item.className = 'note-attachment';
+      item.textContent = name;
+      item.title = name;
+      attachmentsElement.appendChild(item);
+    }
+  }
+
   private renderTags(note: Note): void {
     const tagsElement = this.headerContainer.querySelector<HTMLElement>('.note-tags');
     if (!tagsElement) {
@@ -1367,8 +1554,17 @@ export class NoteView {
       }
     });
 
+    const modeButton = document.createElement('button');
+    modeButton.type = 'button';
+    modeButton.className = 'note-view-mode-btn';
+    modeButton.textContent = this.isPreviewMode ? 'Source' : 'Preview';
+    modeButton.setAttribute('aria-pressed', String(this.isPreviewMode));
+    modeButton.addEventListener('click', () => {
+      this.setPreviewMode(!this.isPreviewMode);
+    });
+
     dropdown.append(deleteButton);
-    actionsElement.append(overflowButton, dropdown);
+    actionsElement.append(modeButton, overflowButton, dropdown);
   }
 
   private teardownActionsMenuListeners(): void {
@@ -1510,6 +1706,11 @@ export class NoteView {
   }
 
   private renderContent(note: Note): void {
+    if (note.encrypted) {
+      this.renderLocked(note);
+      return;
+    }
+
     if (!this.editor) {
       this.initEditor();
     }
@@ -1532,6 +1733,47 @@ export class NoteView {
     }
   }
 
+  /**
+   * An encrypted note's body is ciphertext, and saving needs a passphrase the
+   * app does not ask for, so it gets a placeholder instead of the editor.
+   */
+  private renderLocked(note: Note): void {
+    this.hideSelectionTooltip();
+    this.teardownHeadingActionControls();
+    if (this.editor) {
+      this.editor.destroy();
+      this.editor = null;
+    }
+
+    // Matching the saved content keeps autosave and flushes from writing.
+    this.editorState.text = note.content;
+    this.editorState.selection = { anchor: 0, head: 0 };
+    this.editorState.decorations = [];
+
+    const placeholder = document.createElement('p');
+    placeholder.className = 'empty-state';
+    placeholder.textContent = 'This note is encrypted. Decrypt it with `agentnotes edit --decrypt` to edit it here.';
+    this.contentContainer.innerHTML = '';
+    this.contentContainer.appendChild(placeholder);
+  }
+
+  /** Anchor covering a 1-based line of the editor text, or null for a blank or missing line. */
+  buildLineAnchor(line: number): { anchor: CommentAnchor; text: string } | null {
+    if (!this.currentNote) {
+      return null;
+    }
+
+    const content = this.getEditorText();
+    const lines = content.split('\n');
+    const text = lines[line - 1];
+    if (text === undefined || !text.trim()) {
+      return null;
+    }
+
+    const from = lines.slice(0, line - 1).reduce((offset, entry) => offset + entry.length + 1, 0);
+    return { anchor: this.buildAnchor(content, from, from + text.length), text };
+  }
+
   private buildAnchor(content: string, startChar: number, endChar: number): CommentAnchor {
     const rev = this.currentNote?.commentRev ?? 0;
     return buildAnchorFromRange(content, startChar, endChar, rev);
@@ -1559,11 +1801,17 @@ export class NoteView {
       tagsElement.innerHTML = '';
     }
 
+    const attachmentsElement = this.headerContainer.querySelector<HTMLElement>('.note-attachments');
+    if (attachmentsElement) {
+      attachmentsElement.innerHTML = '';
+    }
+
     const actionsElement = this.headerContainer.querySelector<HTMLElement>('.note-actions');
     if (actionsElement) {
       actionsElement.innerHTML = '';
     }
 
+    this.contentContainer.classList.remove('note-content-preview');
     this.contentContainer.innerHTML =
       '<p class="empty-state">Select a note from the list to view its content.</p>';
 
@@ -6,6 +6,7 @@ import type {
   NotesListResponse,
   NotesListResult,
   OperationResult,
+  TagCount,
 } from '../types';
 
 let notesCache: NotesListResult | null = null;
@@ -37,6 +38,14 @@ export async function getNote(noteId: string): Promise<Note | null> {
   return window.api.getNote(noteId);
 }
 
+export async function searchNotes(query: string, tags: string[]): Promise<Note[]> {
+  return window.api.searchNotes(query, tags);
+}
+
+export async function listTags(): Promise<TagCount[]> {
+  return window.api.listTags();
+}
+
 export async function createNote(
   title: string,
   directory: string,
@@ -10,13 +10,16 @@ import {
   deleteDirectory,
   deleteNote,
   getDirectory,
+  getNote,
   listNotes,
+  listTags,
   moveNote,
+  searchNotes,
   selectDirectory,
   updateNote,
   updateNoteMetadata,
 } from './lib/noteStore';
-import type { CommentAnchor, Note, NotesListResponse, NotesListResult } from './types';
+import type { CommentAnchor, Note, NotesListResponse, NotesListResult, Theme } from './types';
 
 let noteList: NoteList | null = null;
 let noteView: NoteView | null = null;
@@ -29,6 +32,11 @@ let titleBarDirectory: HTMLElement | null = null;
 let directoryPath: HTMLElement | null = null;
 let toggleNoteListButton: HTMLButtonElement | null = null;
 let toggleCommentsButton: HTMLButtonElement | null = null;
+let searchInput: HTMLInputElement | null = null;
+let tagFilter: HTMLSelectElement | null = null;
+let searchTimer: number | null = null;
+let themeToggleButton: HTMLButtonElement | null = null;
+let currentTheme: Theme = 'dark';
 let isNoteListVisible = true;
 let isCommentsVisible = true;
 
@@ -217,6 +225,32 @@ function setCommentsVisible(visible: boolean): void {
   updatePanelToggleButtons();
 }
 
+function applyTheme(theme: Theme): void {
+  currentTheme = theme;
+  document.documentElement.dataset.theme = theme;
+
+  if (themeToggleButton) {
+    const label = theme === 'dark' ? 'Switch to light theme' : 'Switch to dark theme';
+    themeToggleButton.title = label;
+    themeToggleButton.setAttribute('aria-label', label);
+  }
+
+  noteView?.refreshTheme();
+}
+
+async function initTheme(): Promise<void> {
+  themeToggleButton = requireElementById<HTMLButtonElement>('themeToggleBtn');
+  themeToggleButton.addEventListener('click', async () => {
+    applyTheme(await window.api.setTheme(currentTheme === 'dark' ? 'light' : 'dark'));
+  });
+
+  try {
+    applyTheme(await window.api.getTheme());
+  } catch (error) {
+    console.error('Error loading theme:', error);
+  }
+}
+
 function initPanelToggles(): void {
   toggleNoteListButton = requireElementById<HTMLButtonElement>('toggleNoteListBtn');
   toggleCommentsButton = requireElementById<HTMLButtonElement>('toggleCommentsBtn');
@@ -239,7 +273,8 @@ function initTitleBar(): void {
   const minimizeButton = requireElementById<HTMLElement>('btnMinimize');
   const maximizeButton = requireElementById<HTMLElement>('btnMaximize');
 
-  closeButton.addEventListener('click', () => {
+  closeButton.addEventListener('click', async () => {
+    await noteView?.flushUnsavedEdits();
     window.api.windowClose();
   });
 
@@ -273,7 +308,153 @@ function initTitleBar(): void {
   });
 }
 
+/**
+ * Render the sidebar, narrowed to the search box and tag filter when either is
+ * set. The tag options are rebuilt from the full set each time.
+ */
+async function renderNoteList(notes: Note[], directories: string[]): Promise<void> {
+  await updateTagFilterOptions();
+
+  const query = searchInput?.value.trim() ?? '';
+  const tag = tagFilter?.value ?? '';
+  if (!query && !tag) {
+    noteList?.render(notes, directories);
+    return;
+  }
+
+  const matches = await searchNotes(query, tag ? [tag] : []);
+  noteList?.render(matches);
+}
+
+async function updateTagFilterOptions(): Promise<void> {
+  if (!tagFilter) {
+    return;
+  }
+
+  const tags = await listTags();
+  const selected = tagFilter.value;
+  tagFilter.innerHTML = '';
+
+  const allTags = document.createElement('option');
+  allTags.value = '';
+  allTags.textContent = 'All tags';
+  tagFilter.appendChild(allTags);
+
+  for (const { tag, count } of tags) {
+    const option = document.createElement('option');
+    option.value = tag;
+    option.textContent = `#${tag} (${count})`;
+    tagFilter.appendChild(option);
+  }
+
+  // A tag that no longer exists falls back to showing everything.
+  tagFilter.value = tags.some((entry) => entry.tag === selected) ? selected : '';
+}
+
+async function applyNoteFilters(): Promise<void> {
+  try {
+    const result = await listNotes();
+    await renderNoteList(extractNotes(result), extractDirectories(result));
+  } catch (error) {
+    console.error('Error filtering notes:', error);
+  }
+}
+
+function initNoteFilters(): void {
+  searchInput = requireElementById<HTMLInputElement>('noteSearchInput');
+  tagFilter = requireElementById<HTMLSelectElement>('noteTagFilter');
+
+  searchInput.addEventListener('input', () => {
+    if (searchTimer !== null) {
+      window.clearTimeout(searchTimer);
+    }
+
+    searchTimer = window.setTimeout(() => {
+      searchTimer = null;
+      void applyNoteFilters();
+    }, 150);
+  });
+
+  searchInput.addEventListener('keydown', (event) => {
+    if (event.key === 'Escape' && searchInput?.value) {
+      event.preventDefault();
+      searchInput.value = '';
+      void applyNoteFilters();
+    }
+  });
+
+  tagFilter.addEventListener('change', () => {
+    void applyNoteFilters();
+  });
+}
+
+/** Keys typed into a text field, select or the editor belong to it, not to shortcuts. */
+function isTypingTarget(target: EventTarget | null): boolean {
+  if (!(target instanceof HTMLElement)) {
+    return false;
+  }
+
+  return (
+    target instanceof HTMLInputElement ||
+    target instanceof HTMLTextAreaElement ||
+    target instanceof HTMLSelectElement ||
+    target.isContentEditable
+  );
+}
+
+/**
+ * Single-key shortcuts for browsing: j/k or the arrow keys move through the
+ * list, / focuses search, n creates a note next to the selected one, and
+ * Delete removes the selected note after confirmation.
+ */
+function initKeyboardShortcuts(): void {
+  document.addEventListener('keydown', (event) => {
+    if (event.metaKey || event.ctrlKey || event.altKey || isTypingTarget(event.target)) {
+      return;
+    }
+
+    if (document.querySelector('.input-dialog-overlay') || appElement?.classList.contains('hidden')) {
+      return;
+    }
+
+    switch (event.key) {
+      case 'j':
+      case 'ArrowDown':
+        event.preventDefault();
+        noteList?.selectAdjacent(1);
+        break;
+      case 'k':
+      case 'ArrowUp':
+        event.preventDefault();
+        noteList?.selectAdjacent(-1);
+        break;
+      case '/':
+        event.preventDefault();
+        setNoteListVisible(true);
+        searchInput?.focus();
+        searchInput?.select();
+        break;
+      case 'n':
+        event.preventDefault();
+        void onCreateNote(noteList?.getSelectedNote()?.directory ?? '');
+        break;
+      case 'Delete':
+      case 'Backspace': {
+        const note = noteList?.getSelectedNote();
+        if (note) {
+          event.preventDefault();
+          void onDeleteNote(note);
+        }
+        break;
+      }
+    }
+  });
+}
+
 function onSelectNote(note: Note): void {
+  if (note.id !== currentNoteId) {
+    commentsPanel?.clear();
+  }
   currentNoteId = note.id;
   noteView?.render(note);
   commentsPanel?.render(note.comments);
@@ -283,14 +464,15 @@ function onCommentCreate(anchor: CommentAnchor, selectedText: string): void {
   commentsPanel?.startNewComment(anchor, selectedText);
 }
 
-async function onCommentSubmit(content: string, anchor: CommentAnchor): Promise<void> {
+async function onCommentSubmit(content: string, anchor: CommentAnchor, author: string): Promise<void> {
   if (!currentNoteId) {
     console.error('No note selected');
     return;
   }
 
   try {
-    const result = await addComment(currentNoteId, content, '', anchor);
+    await noteView?.flushUnsavedEdits();
+    const result = await addComment(currentNoteId, content, author, anchor);
 
     if (!result.success || !result.note) {
       console.error('Failed to add comment:', result.error);
@@ -304,7 +486,7 @@ async function onCommentSubmit(content: string, anchor: CommentAnchor): Promise<
     const notesResult = await listNotes();
     const notes = extractNotes(notesResult);
     const directories = extractDirectories(notesResult);
-    noteList?.render(notes, directories);
+    await renderNoteList(notes, directories);
     noteList?.selectNote(currentNoteId);
   } catch (error) {
     console.error('Error adding comment:', error);
@@ -332,7 +514,7 @@ async function onCommentDelete(commentId: string): Promise<void> {
     const notesResult = await listNotes();
     const notes = extractNotes(notesResult);
     const directories = extractDirectories(notesResult);
-    noteList?.render(notes, directories);
+    await renderNoteList(notes, directories);
     noteList?.selectNote(currentNoteId);
   } catch (error) {
     console.error('Error deleting comment:', error);
@@ -358,7 +540,7 @@ async function onNoteSave(noteId: string, content: string): Promise<Note | null>
     const notesResult = await listNotes();
     const notes = extractNotes(notesResult);
     const directories = extractDirectories(notesResult);
-    noteList?.render(notes, directories);
+    await renderNoteList(notes, directories);
 
     return result.note;
   } catch (error) {
@@ -386,7 +568,7 @@ async function onNoteMetadataSave(noteId: string, tags: string[]): Promise<Note
     const notesResult = await listNotes();
     const notes = extractNotes(notesResult);
     const directories = extractDirectories(notesResult);
-    noteList?.render(notes, directories);
+    await renderNoteList(notes, directories);
 
     return result.note;
   } catch (error) {
@@ -539,6 +721,38 @@ async function onDeleteNote(note: Note): Promise<void> {
   }
 }
 
+/**
+ * Reload after notes change on disk, keeping the selected note where it still
+ * exists. The list is re-rendered in place; the open note is only refreshed,
+ * so typing in it is not interrupted.
+ */
+async function onNotesChanged(): Promise<void> {
+  clearCache();
+
+  try {
+    const result = await listNotes();
+    if (isNotesListResult(result) && result.noDirectory) {
+      return;
+    }
+
+    const notes = extractNotes(result);
+    await renderNoteList(notes, extractDirectories(result));
+
+    const selectedNoteId = currentNoteId;
+    if (!selectedNoteId || !notes.some((note) => note.id === selectedNoteId)) {
+      await loadNotes(null);
+      return;
+    }
+
+    const note = await getNote(selectedNoteId);
+    if (note && currentNoteId === selectedNoteId && noteView?.applyExternalChange(note)) {
+      commentsPanel?.render(note.comments);
+    }
+  } catch (error) {
+    console.error('Error reloading notes:', error);
+  }
+}
+
 function updateDirectoryIndicator(path: string | null): void {
   if (!titleBarDirectory || !directoryPath) {
     return;
@@ -589,7 +803,7 @@ async function loadNotes(preferredNoteId: string | null = currentNoteId): Promis
       return;
     }
 
-    noteList?.render(notes, directories);
+    await renderNoteList(notes, directories);
 
     if (notes.length > 0) {
       const fallbackNoteId = notes[0]?.id ?? null;
@@ -617,6 +831,9 @@ async function init(): Promise<void> {
   directoryOverlay = requireElementById<HTMLElement>('directoryOverlay');
   appElement = requireElementBySelector<HTMLElement>('.app');
   initPanelToggles();
+  initNoteFilters();
+  initKeyboardShortcuts();
+  await initTheme();
   titleBarDirectory = requireElementById<HTMLElement>('titleBarDirectory');
   directoryPath = requireElementById<HTMLElement>('directoryPath');
 
@@ -650,8 +867,20 @@ async function init(): Promise<void> {
   noteView.setOnNoteSave(onNoteSave);
   noteView.setOnNoteMetadataSave(onNoteMetadataSave);
   noteView.setOnNoteDelete(onDeleteNote);
+  noteView.setOnNoteLinkOpen((noteId) => {
+    noteList?.selectNote(noteId);
+  });
   commentsPanel.setOnCommentSubmit(onCommentSubmit);
   commentsPanel.setOnCommentDelete(onCommentDelete);
+  commentsPanel.setLineAnchorResolver((line) => noteView?.buildLineAnchor(line) ?? null);
+  requireElementById<HTMLButtonElement>('addCommentBtn').addEventListener('click', () => {
+    if (currentNoteId) {
+      commentsPanel?.startLineComment();
+    }
+  });
+  window.api.onNotesChanged(() => {
+    void onNotesChanged();
+  });
 
   try {
     const currentDirectory = await getDirectory();
@@ -23,6 +23,9 @@ export interface NoteComment {
   created: string;
   anchor: CommentAnchor;
   status: CommentStatus;
+  resolved?: boolean;
+  resolvedAt?: string;
+  edited?: string;
 }
 
 export interface Note {
@@ -32,9 +35,17 @@ export interface Note {
   commentRev: number;
   comments: NoteComment[];
   content: string;
+  created: string;
+  updated: string;
   filename: string;
   relativePath: string;
   directory: string;
+  meta?: Record<string, unknown>;
+  due?: string;
+  aliases?: string[];
+  attachments?: string[];
+  encrypted?: boolean;
+  pinned?: boolean;
 }
 
 export interface NotesListResult {
@@ -43,6 +54,11 @@ export interface NotesListResult {
   noDirectory?: boolean;
 }
 
+export interface TagCount {
+  tag: string;
+  count: number;
+}
+
 export interface OperationResult {
   success: boolean;
   error?: string;
@@ -70,11 +86,19 @@ export interface SaveImageResult extends OperationResult {
   relativePath?: string;
 }
 
+export type Theme = 'dark' | 'light';
+
 export type NotesListResponse = Note[] | NotesListResult;
 
 export interface PreloadApi {
   listNotes: () => Promise<NotesListResult>;
   getNote: (noteId: string) => Promise<Note | null>;
+  /** Notes matching a plain-text query and all of the given tags. */
+  searchNotes: (query: string, tags: string[]) => Promise<Note[]>;
+  listTags: () => Promise<TagCount[]>;
+  /** HTML for a preview; wiki-links to existing notes get `#note:<id>` hrefs. */
+  renderMarkdown: (content: string) => Promise<string>;
+  openExternal: (url: string) => void;
   createNote: (title: string, directory: string) => Promise<CommentMutationResult>;
   deleteNote: (noteId: string) => Promise<OperationResult>;
   moveNote: (noteId: string, directory: string) => Promise<CommentMutationResult>;
@@ -93,9 +117,14 @@ export interface PreloadApi {
   ) => Promise<CommentMutationResult>;
   deleteComment: (noteId: string, commentId: string) => Promise<CommentMutationResult>;
   saveImage: (data: string, mimeType: string, filename?: string) => Promise<SaveImageResult>;
+  /** The saved theme; setTheme persists it and returns the theme now in effect. */
+  getTheme: () => Promise<Theme>;
+  setTheme: (theme: Theme) => Promise<Theme>;
   getDirectory: () => Promise<string | null>;
   selectDirectory: () => Promise<string | null>;
   windowMinimize: () => void;
   windowMaximize: () => void;
   windowClose: () => void;
+  /** Called when notes change on disk, at most once per burst of writes. */
+  onNotesChanged: (callback: () => void) => void;
 }
@@ -15,19 +15,36 @@ export function hashQuote(text: string): string {
   return hash.toString(16).padStart(16, '0');
 }
 
+/**
+ * Anchor a comment to content[from:to]. With expectedQuote, the range must
+ * cover exactly that text, which catches offsets computed against another
+ * revision of the content.
+ */
 export function buildAnchorFromRange(
   content: string,
   from: number,
   to: number,
   rev: number,
+  expectedQuote?: string,
 ): CommentAnchor {
   const normalizedFrom = Math.floor(from);
   const normalizedTo = Math.floor(to);
-  if (normalizedFrom < 0 || normalizedTo <= normalizedFrom || normalizedTo > content.length) {
+  if (
+    !Number.isFinite(normalizedFrom) ||
+    !Number.isFinite(normalizedTo) ||
+    normalizedFrom < 0 ||
+    normalizedTo <= normalizedFrom ||
+    normalizedTo > content.length
+  ) {
     throw new Error('Invalid comment anchor range');
   }
 
   const quote = content.slice(normalizedFrom, normalizedTo);
+  if (expectedQuote !== undefined && quote !== expectedQuote) {
+    throw new Error(
+      `Quote mismatch: ${normalizedFrom}:${normalizedTo} covers ${JSON.stringify(quote)}, expected ${JSON.stringify(expectedQuote)}`,
+    );
+  }
 
   return {
     from: normalizedFrom,
@@ -40,6 +57,43 @@ export function buildAnchorFromRange(
   };
 }
 
+/**
+ * Anchor a comment to whole lines: 1-based and inclusive, from the start of
+ * startLine to the end of endLine, leaving out its newline.
+ */
+export function buildAnchorFromLines(
+  content: string,
+  startLine: number,
+  endLine: number,
+  rev: number,
+): CommentAnchor {
+  const lines = content.split('\n');
+  if (!Number.isInteger(startLine) || !Number.isInteger(endLine) || startLine < 1) {
+    throw new Error('Line numbers must be positive integers');
+  }
+  if (startLine > endLine) {
+    throw new Error(`Start line ${startLine} is after end line ${endLine}`);
+  }
+  if (endLine > lines.length) {
+    throw new Error(`Line ${endLine} is past the end of the note (${lines.length} lines)`);
+  }
+
+  let from = 0;
+  for (let index = 0; index < startLine - 1; index += 1) {
+    from += lines[index].length + 1;
+  }
+  let to = from;
+  for (let index = startLine - 1; index < endLine; index += 1) {
+    to += lines[index].length + 1;
+  }
+  to -= 1;
+
+  if (to <= from) {
+    throw new Error(startLine === endLine ? `Line ${startLine} is empty` : `Lines ${startLine}-${endLine} are empty`);
+  }
+  return buildAnchorFromRange(content, from, to, rev);
+}
+
 export function getUniqueMatchRange(
   content: string,
   exact: string,
@@ -0,0 +1,46 @@
+import type { CommentFilter, CommentWithNote, Note, NoteComment } from '../types.js';
+import { resolveCommentRange } from './resolution.js';
+
+/**
+ * Comments from every note that pass the filter, in note order and then
+ * oldest first within a note.
+ */
+export function collectComments(notes: Note[], filter: CommentFilter = {}): CommentWithNote[] {
+  const results: CommentWithNote[] = [];
+  for (const note of notes) {
+    const comments = note.comments
+      .filter((comment) => matchesCommentFilter(comment, filter))
+      .sort((a, b) => a.created.localeCompare(b.created));
+    for (const comment of comments) {
+      results.push({ note, comment, line: getCommentLine(note, comment) });
+    }
+  }
+  return results;
+}
+
+export function matchesCommentFilter(comment: NoteComment, filter: CommentFilter): boolean {
+  if (filter.author !== undefined && comment.author.toLowerCase() !== filter.author.toLowerCase()) {
+    return false;
+  }
+  if (filter.resolved !== undefined && Boolean(comment.resolved) !== filter.resolved) {
+    return false;
+  }
+  if (filter.createdAfter && comment.created < filter.createdAfter) {
+    return false;
+  }
+  if (filter.createdBefore && comment.created > filter.createdBefore) {
+    return false;
+  }
+  return true;
+}
+
+/**
+ * The 1-based line a comment's anchor starts on in the note as it is now, or
+ * null when the anchor cannot be placed. Anchors follow edits, so unlike a
+ * line number saved with the comment this stays on the commented text.
+ */
+export function getCommentLine(note: Note, comment: NoteComment): number | null {
+  // An encrypted note's anchors point into plaintext that is not loaded.
+  const range = note.encrypted ? null : resolveCommentRange(note.content, comment);
+  return range ? note.content.slice(0, range.from).split('\n').length : null;
+}
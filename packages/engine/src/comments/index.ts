@@ -1,7 +1,8 @@
-export { hashQuote, buildAnchorFromRange, getUniqueMatchRange } from './anchoring.js';
+export { hashQuote, buildAnchorFromRange, buildAnchorFromLines, getUniqueMatchRange } from './anchoring.js';
 export {
   deriveTextEditOps,
   remapCommentsForEdit,
+  relocateComments,
   normalizeComment,
   transformOffset,
   commonPrefixLen,
@@ -11,3 +12,4 @@ export {
 export type { TextEditOp } from './transformation.js';
 export { resolveCommentRange, getAllHighlightRanges } from './resolution.js';
 export type { CharRange } from './resolution.js';
+export { collectComments, matchesCommentFilter, getCommentLine } from './collect.js';
@@ -1,5 +1,5 @@
 import type { CommentAffinity, CommentAnchor, CommentStatus, NoteComment } from '../types.js';
-import { hashQuote } from './anchoring.js';
+import { buildAnchorFromRange, hashQuote } from './anchoring.js';
 
 export interface TextEditOp {
   at: number;
@@ -53,6 +53,31 @@ export function remapCommentsForEdit(
   return { comments: remapped, nextRev };
 }
 
+/**
+ * Carry comments into different content, such as a note they are merged
+ * into, by mapping each anchor offset. Ranges are re-quoted against the new
+ * content at rev; empty ranges keep their offsets and status.
+ */
+export function relocateComments(
+  comments: NoteComment[],
+  mapOffset: (offset: number) => number,
+  content: string,
+  rev: number,
+): NoteComment[] {
+  return comments.map((comment) => {
+    const from = clamp(mapOffset(comment.anchor.from), 0, content.length);
+    const to = clamp(mapOffset(comment.anchor.to), from, content.length);
+    if (to <= from) {
+      return { ...comment, anchor: { ...comment.anchor, from, to, rev } };
+    }
+
+    const anchor = buildAnchorFromRange(content, from, to, rev);
+    anchor.startAffinity = normalizeAffinity(comment.anchor.startAffinity, DEFAULT_START_AFFINITY);
+    anchor.endAffinity = normalizeAffinity(comment.anchor.endAffinity, DEFAULT_END_AFFINITY);
+    return { ...comment, anchor };
+  });
+}
+
 function remapComment(
   input: NoteComment,
   ops: TextEditOp[],
@@ -2,28 +2,89 @@
 export { NoteStore } from './notes/store.js';
 export type { NoteStoreOptions } from './notes/store.js';
 
+// Serialization
+export { serializeNote, serializeNoteJSON, serializeNoteYAML, serializeNoteFrontmatter } from './notes/serialization.js';
+export type { NoteSerializationFormat } from './notes/serialization.js';
+export {
+  serializeNotesCSV,
+  escapeCSVField,
+  CSV_COLUMNS,
+  serializeNotesTSV,
+  escapeTSVField,
+  TSV_COLUMNS,
+} from './notes/csv.js';
+export { exportMarkdown, exportHTML, getNoteExportPath, EXPORT_STYLESHEET } from './notes/export.js';
+export type { ExportOptions, HtmlExportOptions, ExportedFile } from './notes/export.js';
+export { renderMarkdown, renderInline, escapeHtml, getHeadingAnchor } from './notes/render.js';
+export type { RenderOptions } from './notes/render.js';
+
+// Links
+export { extractLinks, resolveLinkTarget, collectLinkedNotes } from './notes/links.js';
+export type { LinkWalkOptions } from './notes/links.js';
+
 // Search & filtering
-export { search, getAllTags, getSortedTags } from './notes/search.js';
+export {
+  search,
+  compileSearchPattern,
+  getAllTags,
+  getSortedTags,
+  getTagTree,
+  matchesTag,
+  matchesMetaFilter,
+} from './notes/search.js';
+export { parseQuery, evaluateQuery, getPositiveTerms } from './notes/query.js';
+export { SearchIndex, SEARCH_INDEX_VERSION, getSearchIndexPath, getIndexQuery } from './notes/searchindex.js';
+export { lookupNote, findSimilarTag, levenshtein, scoreFuzzyMatch, filterNotesFuzzy } from './notes/lookup.js';
+export { getIdTimestamp, getCreatedDrift, ID_TIMESTAMP_TOLERANCE_MS } from './notes/ids.js';
+export type { IdTimestamp } from './notes/ids.js';
+export { computeStats, DEFAULT_TOP_TAGS } from './notes/stats.js';
+export { getWordCount, getReadingMinutes, DEFAULT_WORDS_PER_MINUTE } from './notes/reading.js';
+export { pickRandomNotes, createSeededRandom } from './notes/random.js';
+export { buildAgenda } from './notes/agenda.js';
+export { mergeNoteContent } from './notes/merge.js';
+export { parseNoteInput } from './notes/input.js';
+export type { NoteInput } from './notes/input.js';
+export { encryptText, decryptText, encryptNoteContent, decryptNoteContent } from './notes/crypto.js';
+export type { MergedContent } from './notes/merge.js';
+export { extractTasks, setTaskLineDone } from './notes/tasks.js';
+export type { RandomSource } from './notes/random.js';
+export { getSearchSnippet, extractSnippet, DEFAULT_SNIPPET_LENGTH } from './notes/snippets.js';
+export type { NoteSnippet } from './notes/snippets.js';
+export type { QueryNode } from './notes/query.js';
 
 // Comment system
 export {
   hashQuote,
   buildAnchorFromRange,
+  buildAnchorFromLines,
   getUniqueMatchRange,
   deriveTextEditOps,
   remapCommentsForEdit,
+  relocateComments,
   normalizeComment,
   transformOffset,
   resolveCommentRange,
   getAllHighlightRanges,
+  collectComments,
+  matchesCommentFilter,
+  getCommentLine,
 } from './comments/index.js';
 export type { TextEditOp, CharRange } from './comments/index.js';
 
 // Storage
 export {
+  INTERNAL_DIRECTORY,
+  findNotesRoot,
+  resolveNotesRoot,
   parseNoteFile,
   extractNoteTitle,
+  extractHeadingTitle,
+  replaceNoteTitle,
+  stripFrontmatter,
   getNoteSidecarPath,
+  NOTE_SCHEMA_VERSION,
+  getSidecarSchema,
+  migrateSidecarData,
   parseComments,
   toCommentRecord,
   getAllMarkdownFiles,
@@ -31,21 +92,87 @@ export {
   normalizeDirectoryInput,
   resolveNotesPath,
   compareNotes,
+  writeFileAtomic,
 } from './storage/index.js';
-export type { MarkdownFileRecord } from './storage/index.js';
+export type { MarkdownFileRecord, NotesRootSources } from './storage/index.js';
+
+// Git auto-commit
+export { isInsideGitWorktree, commitNotesDirectory, createGitCommitter } from './storage/index.js';
+export type { CommitFunction } from './storage/index.js';
+
+// CLI configuration
+export {
+  DEFAULT_CONFIG,
+  getNotesConfigPath,
+  getUserConfigPath,
+  loadConfig,
+  SORT_FIELDS,
+  parseSortFields,
+} from './storage/index.js';
+export type { AgentNotesConfig, LoadedConfig } from './storage/index.js';
+
+// Advisory locks
+export { DEFAULT_LOCK_TIMEOUT_MS, acquireLock, acquireLocks } from './storage/index.js';
+export type { ReleaseLock } from './storage/index.js';
+
+// Backups
+export { BACKUP_MANIFEST_NAME, createBackup, readBackupManifest, restoreBackup } from './storage/index.js';
+export type { BackupManifest } from './storage/index.js';
+
+// Directory watching
+export { NotesWatcher, DEFAULT_WATCH_DEBOUNCE_MS } from './storage/index.js';
+export type { NotesWatcherOptions } from './storage/index.js';
+
+// HTTP API
+export { createNotesApiHandler, MAX_BODY_BYTES } from './server/index.js';
+export type { NotesApiHandler } from './server/index.js';
+
+// Model Context Protocol
+export { MCP_PROTOCOL_VERSION, MCP_TOOLS, callMcpTool, handleMcpMessage } from './server/index.js';
+export type { McpTool, McpToolResult, JsonRpcRequest, JsonRpcResponse } from './server/index.js';
 
 // Utilities
 export {
   slugify,
+  slugifyTitle,
   normalizeTags,
   normalizeContent,
+  normalizeLineEndings,
   toTitleCase,
   isRecord,
   toStringValue,
   toNumberValue,
   toIsoDate,
   toStringArray,
+  toYaml,
+  parseYaml,
+  parseDateExpression,
+  parseDueDate,
+  formatRelativeTime,
+  insertLineInContent,
+  replaceLineInContent,
+  deleteLineInContent,
+  isValidMetaKey,
+  isValidPriority,
+  getMetaChangesError,
+  parseMetaValue,
+  normalizeAlias,
+  parseTagList,
+  addTagsToList,
+  removeTagsFromList,
+  renameTagInList,
+  diffLines,
+  getDiffHunks,
+  DEFAULT_DIFF_CONTEXT,
+  MIN_PRIORITY,
+  MAX_PRIORITY,
+  setLogLevel,
+  getLogLevel,
+  logError,
+  logWarning,
+  logDebug,
 } from './utils/index.js';
+export type { LogLevel } from './utils/index.js';
 
 // Types
 export type {
@@ -53,21 +180,68 @@ export type {
   CommentStatus,
   CommentAnchor,
   NoteComment,
+  CommentFilter,
+  CommentWithNote,
   Note,
+  NoteMeta,
   NotesListResult,
+  NotebookSummary,
+  NoteLookupResult,
   CommentMutationResult,
   OperationResult,
   DirectoryMutationResult,
   AddCommentPayload,
+  AttachFilePayload,
+  AttachFileResult,
   DeleteCommentPayload,
+  EditCommentPayload,
+  ReattachCommentPayload,
+  SetCommentResolvedPayload,
+  SetTaskDonePayload,
+  NoteAliasPayload,
   UpdateNotePayload,
+  SetNoteEncryptionPayload,
+  SetNoteArchivedPayload,
+  SetNotePinnedPayload,
   UpdateNoteMetadataPayload,
+  CloneNotePayload,
   CreateNotePayload,
+  CreateNoteResult,
+  NewNoteComment,
+  ImportNotesPayload,
+  ImportNotesResult,
+  ImportedNoteEntry,
+  SkippedImportEntry,
   DeleteNotePayload,
+  MergeNotesPayload,
   MoveNotePayload,
+  MoveNoteToNotebookPayload,
+  RenameNotePayload,
+  RetagNotesPayload,
+  RetagNotesResult,
+  NormalizeCommentsResult,
+  MigrateNotesResult,
+  ReindexResult,
+  RenameTagPayload,
+  DeleteTagPayload,
+  RestoreNotePayload,
   CreateDirectoryPayload,
   DeleteDirectoryPayload,
   SortField,
   SearchOptions,
+  SavedSearch,
+  SaveSearchPayload,
+  SavedSearchRunResult,
   TagCount,
+  TagTreeNode,
+  AgendaBucket,
+  AgendaGroup,
+  NoteStats,
+  NoteStatsEntry,
+  NoteTask,
+  NoteRevision,
+  NoteHistoryResult,
+  NoteRevisionResult,
+  DiffLine,
+  DiffHunk,
 } from './types.js';
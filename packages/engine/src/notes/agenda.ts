@@ -0,0 +1,33 @@
+import type { AgendaBucket, AgendaGroup, Note } from '../types.js';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+const BUCKET_ORDER: AgendaBucket[] = ['overdue', 'today', 'week', 'later'];
+
+function toDateString(time: number): string {
+  return new Date(time).toISOString().slice(0, 10);
+}
+
+/**
+ * Group notes with a due date into overdue, today, the next seven days and
+ * later, each sorted by due date. Dates compare as UTC calendar days; notes
+ * without a due date are left out and empty groups are omitted.
+ */
+export function buildAgenda(notes: Note[], now: Date = new Date()): AgendaGroup[] {
+  const today = toDateString(now.getTime());
+  const weekEnd = toDateString(now.getTime() + 7 * DAY_MS);
+  const groups = new Map<AgendaBucket, Note[]>(BUCKET_ORDER.map((bucket) => [bucket, []]));
+
+  const dated = notes
+    .filter((note): note is Note & { due: string } => Boolean(note.due))
+    .sort((a, b) => a.due.localeCompare(b.due) || a.relativePath.localeCompare(b.relativePath));
+
+  for (const note of dated) {
+    const bucket: AgendaBucket =
+      note.due < today ? 'overdue' : note.due === today ? 'today' : note.due <= weekEnd ? 'week' : 'later';
+    groups.get(bucket)!.push(note);
+  }
+
+  return BUCKET_ORDER
+    .map((bucket) => ({ bucket, notes: groups.get(bucket)! }))
+    .filter((group) => group.notes.length > 0);
+}
@@ -0,0 +1,80 @@
+import fs from 'node:fs';
+import type { Note } from '../types.js';
+import { parseNoteFile } from '../storage/filesystem.js';
+import type { MarkdownFileRecord } from '../storage/filesystem.js';
+import { getNoteSidecarPath } from '../storage/sidecar.js';
+
+interface NoteCacheEntry {
+  signature: string;
+  note: Note;
+}
+
+function statSignature(filePath: string): string | null {
+  try {
+    const stats = fs.statSync(filePath);
+    return `${stats.mtimeMs}:${stats.size}`;
+  } catch {
+    return null;
+  }
+}
+
+/** A note's markdown and sidecar mtimes and sizes; null when the note file is gone. */
+export function getNoteSignature(fullPath: string): string | null {
+  const noteSignature = statSignature(fullPath);
+  if (!noteSignature) {
+    return null;
+  }
+
+  return `${noteSignature}|${statSignature(getNoteSidecarPath(fullPath)) ?? '-'}`;
+}
+
+/**
+ * Parsed notes keyed by file path. An entry is reused while its markdown file
+ * and sidecar are unchanged on disk, so repeat listings cost a stat per file
+ * instead of a read and parse. Callers get copies and may mutate them freely.
+ */
+export class NoteCache {
+  private entries = new Map<string, NoteCacheEntry>();
+
+  load(record: MarkdownFileRecord): Note | null {
+    const currentSignature = getNoteSignature(record.fullPath);
+    if (!currentSignature) {
+      this.entries.delete(record.fullPath);
+      return null;
+    }
+
+    const cached = this.entries.get(record.fullPath);
+    if (cached && cached.signature === currentSignature) {
+      return structuredClone(cached.note);
+    }
+
+    const note = parseNoteFile(record.fullPath, record.relativePath);
+    // Parsing can backfill the sidecar, so sign the files afterwards.
+    const signature = getNoteSignature(record.fullPath);
+    if (!note || !signature) {
+      this.entries.delete(record.fullPath);
+      return note;
+    }
+
+    this.entries.set(record.fullPath, { signature, note });
+    return structuredClone(note);
+  }
+
+  /** Drop entries for files that are no longer listed. */
+  retain(records: MarkdownFileRecord[]): void {
+    const live = new Set(records.map((record) => record.fullPath));
+    for (const fullPath of this.entries.keys()) {
+      if (!live.has(fullPath)) {
+        this.entries.delete(fullPath);
+      }
+    }
+  }
+
+  clear(): void {
+    this.entries.clear();
+  }
+
+  get size(): number {
+    return this.entries.size;
+  }
+}
@@ -0,0 +1,84 @@
+import { createCipheriv, createDecipheriv, randomBytes, scryptSync } from 'node:crypto';
+import { extractHeadingTitle } from '../storage/markdown.js';
+
+const FORMAT_VERSION = 1;
+const SALT_BYTES = 16;
+const IV_BYTES = 12;
+const TAG_BYTES = 16;
+const KEY_BYTES = 32;
+/** scrypt cost parameters; N = 2^15 takes ~100ms, which is fine per note. */
+const SCRYPT_OPTIONS = { N: 2 ** 15, r: 8, p: 1, maxmem: 64 * 1024 * 1024 };
+
+function deriveKey(passphrase: string, salt: Buffer): Buffer {
+  return scryptSync(passphrase, salt, KEY_BYTES, SCRYPT_OPTIONS);
+}
+
+/**
+ * Encrypt text with AES-256-GCM under a key derived from the passphrase with
+ * scrypt and a random salt. The result is one base64 string holding the
+ * format version, salt, IV, auth tag and ciphertext.
+ */
+export function encryptText(plaintext: string, passphrase: string): string {
+  if (!passphrase) {
+    throw new Error('Passphrase cannot be empty');
+  }
+
+  const salt = randomBytes(SALT_BYTES);
+  const iv = randomBytes(IV_BYTES);
+  const cipher = createCipheriv('aes-256-gcm', deriveKey(passphrase, salt), iv);
+  const ciphertext = Buffer.concat([cipher.update(plaintext, 'utf-8'), cipher.final()]);
+
+  return Buffer.concat([Buffer.from([FORMAT_VERSION]), salt, iv, cipher.getAuthTag(), ciphertext]).toString(
+    'base64',
+  );
+}
+
+/** Reverse encryptText. A wrong passphrase or altered payload throws. */
+export function decryptText(payload: string, passphrase: string): string {
+  const data = Buffer.from(payload.trim(), 'base64');
+  const headerBytes = 1 + SALT_BYTES + IV_BYTES + TAG_BYTES;
+  if (data.length < headerBytes || data[0] !== FORMAT_VERSION) {
+    throw new Error('Encrypted content is malformed');
+  }
+
+  let offset = 1;
+  const salt = data.subarray(offset, (offset += SALT_BYTES));
+  const iv = data.subarray(offset, (offset += IV_BYTES));
+  const tag = data.subarray(offset, (offset += TAG_BYTES));
+  const ciphertext = data.subarray(offset);
+
+  try {
+    const decipher = createDecipheriv('aes-256-gcm', deriveKey(passphrase, salt), iv);
+    decipher.setAuthTag(tag);
+    return Buffer.concat([decipher.update(ciphertext), decipher.final()]).toString('utf-8');
+  } catch {
+    throw new Error('Wrong passphrase, or the encrypted content was modified');
+  }
+}
+
+/**
+ * Encrypt a note's body. The `# Title` line stays readable so the note keeps
+ * its title and filename in listings; everything after it is replaced by the
+ * base64 payload.
+ */
+export function encryptNoteContent(content: string, passphrase: string): string {
+  if (extractHeadingTitle(content) === null) {
+    return encryptText(content, passphrase);
+  }
+
+  const lineBreak = content.indexOf('\n');
+  const heading = lineBreak >= 0 ? content.slice(0, lineBreak) : content;
+  // The body keeps its leading line break so a heading-only note round-trips.
+  const body = lineBreak >= 0 ? content.slice(lineBreak) : '';
+  return `${heading}\n\n${encryptText(body, passphrase)}`;
+}
+
+/** Reverse encryptNoteContent, restoring the original content exactly. */
+export function decryptNoteContent(content: string, passphrase: string): string {
+  const lineBreak = content.indexOf('\n');
+  if (extractHeadingTitle(content) === null || lineBreak < 0) {
+    return decryptText(content, passphrase);
+  }
+
+  return content.slice(0, lineBreak) + decryptText(content.slice(lineBreak + 1), passphrase);
+}
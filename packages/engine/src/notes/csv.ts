@@ -0,0 +1,68 @@
+import type { Note } from '../types.js';
+
+/** Column order of the CSV note listing. */
+export const CSV_COLUMNS = ['id', 'title', 'tags', 'created', 'updated', 'priority', 'source', 'comment_count'] as const;
+
+/**
+ * Quote a field per RFC 4180 when it contains a delimiter, quote or line
+ * break, doubling embedded quotes.
+ */
+export function escapeCSVField(value: string): string {
+  return /[",\r\n]/.test(value) ? `"${value.replace(/"/g, '""')}"` : value;
+}
+
+function formatMetaField(note: Note, key: string): string {
+  const value = note.meta?.[key];
+  if (value === undefined || value === null) {
+    return '';
+  }
+  return typeof value === 'object' ? JSON.stringify(value) : String(value);
+}
+
+/**
+ * Spreadsheet-friendly listing: one row per note, without content. Tags are
+ * `;`-joined; priority and source come from the custom fields of that name.
+ * Rows end in CRLF, as RFC 4180 specifies.
+ */
+export function serializeNotesCSV(notes: Note[]): string {
+  const rows = notes.map((note) => [
+    note.id,
+    note.title,
+    note.tags.join(';'),
+    note.created,
+    note.updated,
+    formatMetaField(note, 'priority'),
+    formatMetaField(note, 'source'),
+    String(note.comments.length),
+  ]);
+
+  return [[...CSV_COLUMNS], ...rows].map((row) => `${row.map(escapeCSVField).join(',')}\r\n`).join('');
+}
+
+/** Column order of the TSV note listing. */
+export const TSV_COLUMNS = ['id', 'created', 'priority', 'tags', 'title'] as const;
+
+/**
+ * Backslash-escape tabs, line breaks and backslashes, so every row stays on
+ * one line with exactly one field per column; nothing is ever quoted.
+ */
+export function escapeTSVField(value: string): string {
+  return value.replace(/[\\\t\n\r]/g, (char) => ({ '\\': '\\\\', '\t': '\\t', '\n': '\\n', '\r': '\\r' })[char]!);
+}
+
+/**
+ * Listing for awk and cut: one `\n`-terminated row per note, tags
+ * `,`-joined, under a header row unless `header` is false.
+ */
+export function serializeNotesTSV(notes: Note[], options: { header?: boolean } = {}): string {
+  const rows = notes.map((note) => [
+    note.id,
+    note.created,
+    formatMetaField(note, 'priority'),
+    note.tags.join(','),
+    note.title,
+  ]);
+
+  const header = options.header === false ? [] : [[...TSV_COLUMNS]];
+  return [...header, ...rows].map((row) => `${row.map(escapeTSVField).join('\t')}\n`).join('');
+}
@@ -0,0 +1,159 @@
+import fs from 'node:fs';
+import path from 'node:path';
+import type { Note, NoteComment, NoteProblem } from '../types.js';
+import type { MarkdownFileRecord } from '../storage/filesystem.js';
+import { getTitledFilePath } from '../storage/filesystem.js';
+import { extractHeadingTitle, parseMarkdownContent } from '../storage/markdown.js';
+import { NOTE_SCHEMA_VERSION, getNoteSidecarPath, getSidecarSchema } from '../storage/sidecar.js';
+import { isRecord } from '../utils/validation.js';
+
+const DATE_PREFIX_PATTERN = /^\d{4}-\d{2}-\d{2}-/;
+
+/**
+ * Problems in one note's files that can be found without parsing it as a
+ * Note (which would write a missing sidecar). Returns `readable: false` when
+ * the sidecar is broken and the note should not be parsed further.
+ */
+export function checkNoteFiles(record: MarkdownFileRecord): { problems: NoteProblem[]; readable: boolean } {
+  const noteId = record.relativePath;
+  const sidecarPath = getNoteSidecarPath(record.fullPath);
+
+  if (!fs.existsSync(sidecarPath)) {
+    const problems: NoteProblem[] = [
+      { noteId, kind: 'missing-sidecar', message: 'No metadata sidecar', fixable: true },
+    ];
+    const raw = fs.readFileSync(record.fullPath, 'utf-8');
+    if (/^---\r?\n/.test(raw) && !parseMarkdownContent(record.fullPath, true).hasLegacyFrontmatter) {
+      problems.push({
+        noteId,
+        kind: 'frontmatter',
+        message: 'Leading --- block is not valid note frontmatter and will be kept as content',
+        fixable: false,
+      });
+    }
+    return { problems, readable: false };
+  }
+
+  let sidecar: unknown;
+  try {
+    sidecar = JSON.parse(fs.readFileSync(sidecarPath, 'utf-8'));
+  } catch (error) {
+    const message = `Sidecar is not valid JSON: ${(error as Error).message}`;
+    return { problems: [{ noteId, kind: 'invalid-sidecar', message, fixable: false }], readable: false };
+  }
+  if (!isRecord(sidecar)) {
+    const message = 'Sidecar is not a JSON object';
+    return { problems: [{ noteId, kind: 'invalid-sidecar', message, fixable: false }], readable: false };
+  }
+
+  const problems: NoteProblem[] = [];
+  const missing = ['created', 'updated'].filter((field) => typeof sidecar[field] !== 'string');
+  if (missing.length > 0) {
+    problems.push({
+      noteId,
+      kind: 'missing-timestamp',
+      message: `Sidecar has no ${missing.join(' or ')} timestamp`,
+      fixable: true,
+    });
+  }
+  const schema = getSidecarSchema(sidecar);
+  if (schema > NOTE_SCHEMA_VERSION) {
+    problems.push({
+      noteId,
+      kind: 'newer-schema',
+      message: `Sidecar uses schema ${schema}; this version of agentnotes reads up to ${NOTE_SCHEMA_VERSION} and may drop fields it writes back`,
+      fixable: false,
+    });
+  }
+  return { problems, readable: true };
+}
+
+/** Problems visible on a parsed note: title, filename and comment anchors. */
+export function checkNote(note: Note, fullPath: string): NoteProblem[] {
+  const problems: NoteProblem[] = [];
+  const title = extractHeadingTitle(note.content);
+
+  if (title === null) {
+    problems.push({
+      noteId: note.id,
+      kind: 'missing-title',
+      message: 'No "# Title" heading on the first line; the filename is used as the title',
+      fixable: false,
+    });
+  } else if (DATE_PREFIX_PATTERN.test(note.filename)) {
+    // Files without a date prefix were named by hand, so their names are left alone.
+    const expected = getTitledFilePath(fullPath, title, note.created);
+    if (expected !== fullPath) {
+      problems.push({
+        noteId: note.id,
+        kind: 'filename-mismatch',
+        message: `Filename does not match the title; expected ${path.basename(expected)}`,
+        fixable: true,
+      });
+    }
+  }
+
+  // Anchors of an encrypted note refer to its plaintext, which is not available here.
+  const comments = note.encrypted ? [] : note.comments;
+  for (const comment of comments.filter((entry) => hasOutOfRangeAnchor(entry, note.content))) {
+    const { from, to } = comment.anchor;
+    problems.push({
+      noteId: note.id,
+      kind: 'comment-range',
+      message: `Comment ${comment.id.slice(0, 8)} anchors ${from}:${to}, outside the ${note.content.length}-character note`,
+      fixable: true,
+    });
+  }
+
+  return problems;
+}
+
+export function hasOutOfRangeAnchor(comment: NoteComment, content: string): boolean {
+  const { from, to } = comment.anchor;
+  return comment.status !== 'detached' && (from < 0 || to < from || to > content.length);
+}
+
+/** Aliases claimed by more than one note, which makes lookups ambiguous. */
+export function checkDuplicateAliases(notes: Note[]): NoteProblem[] {
+  const owners = new Map<string, string[]>();
+  for (const note of notes) {
+    for (const alias of note.aliases ?? []) {
+      owners.set(alias, [...(owners.get(alias) ?? []), note.id]);
+    }
+  }
+
+  return [...owners.entries()]
+    .filter(([, ids]) => ids.length > 1)
+    .map(([alias, ids]) => ({
+      noteId: ids[0],
+      kind: 'duplicate-alias' as const,
+      message: `Alias "${alias}" is also used by ${ids.slice(1).join(', ')}`,
+      fixable: false,
+    }));
+}
+
+/**
+ * Note IDs are relative paths, so two files only share one when their paths
+ * differ in case alone. They coexist on Linux but overwrite each other once
+ * the notes are synced to a case-insensitive filesystem.
+ */
+export function findDuplicateIds(records: MarkdownFileRecord[]): string[][] {
+  const groups = new Map<string, string[]>();
+  for (const record of records) {
+    const key = record.relativePath.toLowerCase();
+    groups.set(key, [...(groups.get(key) ?? []), record.relativePath]);
+  }
+  return [...groups.values()].filter((ids) => ids.length > 1);
+}
+
+/** Every file after the first in a duplicate group, reported against the first. */
+export function checkDuplicateIds(records: MarkdownFileRecord[]): NoteProblem[] {
+  return findDuplicateIds(records).flatMap(([first, ...rest]) =>
+    rest.map((noteId) => ({
+      noteId,
+      kind: 'duplicate-id' as const,
+      message: `ID differs from ${first} only in case; the files collide on case-insensitive filesystems`,
+      fixable: true,
+    })),
+  );
+}
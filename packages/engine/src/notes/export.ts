@@ -0,0 +1,275 @@
+import path from 'node:path';
+import type { Note, NoteComment } from '../types.js';
+import { extractHeadingTitle } from '../storage/markdown.js';
+import { resolveLinkTarget } from './links.js';
+import { escapeHtml, getHeadingAnchor, renderMarkdown } from './render.js';
+
+export interface ExportOptions {
+  /** Prepend a table of contents linking to each note's heading. */
+  toc?: boolean;
+}
+
+export interface HtmlExportOptions {
+  /** Heading and page title for the index page. */
+  title?: string;
+}
+
+/** A file to write, by its path relative to the export directory. */
+export interface ExportedFile {
+  path: string;
+  content: string;
+}
+
+const NOTE_SEPARATOR = '\n\n---\n\n';
+const TOC_HEADING = 'Table of Contents';
+
+function getExportBody(note: Note): string {
+  if (extractHeadingTitle(note.content) === null) {
+    return note.content.trim();
+  }
+
+  const firstLineBreak = note.content.indexOf('\n');
+  return firstLineBreak >= 0 ? note.content.slice(firstLineBreak + 1).trim() : '';
+}
+
+function formatMetadataBlock(note: Note): string {
+  const lines = [`- **ID:** ${note.id}`];
+  if (note.tags.length > 0) {
+    lines.push(`- **Tags:** ${note.tags.map((tag) => `#${tag}`).join(' ')}`);
+  }
+  if (note.aliases && note.aliases.length > 0) {
+    lines.push(`- **Aliases:** ${note.aliases.join(', ')}`);
+  }
+  lines.push(`- **Created:** ${note.created}`);
+  lines.push(`- **Updated:** ${note.updated}`);
+  if (note.due) {
+    lines.push(`- **Due:** ${note.due}`);
+  }
+  for (const [key, value] of Object.entries(note.meta ?? {})) {
+    lines.push(`- **${key}:** ${typeof value === 'string' ? value : JSON.stringify(value)}`);
+  }
+  return lines.join('\n');
+}
+
+function formatExportSection(note: Note): string {
+  const body = getExportBody(note);
+  const parts = [`# ${note.title}`, formatMetadataBlock(note)];
+  if (body) {
+    parts.push(body);
+  }
+  return parts.join('\n\n');
+}
+
+/**
+ * Concatenate notes into one markdown document, in the order given. Each note
+ * gets a level-1 title, a metadata list and its content, separated by `---`.
+ * The note's own title heading is replaced rather than repeated.
+ */
+export function exportMarkdown(notes: Note[], options: ExportOptions = {}): string {
+  const sections = notes.map(formatExportSection);
+
+  if (options.toc && notes.length > 0) {
+    const used = new Map<string, number>();
+    getHeadingAnchor(TOC_HEADING, used);
+    const entries = notes.map((note) => `- [${note.title}](#${getHeadingAnchor(note.title, used)})`);
+    sections.unshift([`# ${TOC_HEADING}`, entries.join('\n')].join('\n\n'));
+  }
+
+  return sections.length > 0 ? `${sections.join(NOTE_SEPARATOR)}\n` : '';
+}
+
+export const EXPORT_STYLESHEET = `body {
+  margin: 0;
+  font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif;
+  line-height: 1.6;
+  color: #1f2328;
+  background: #ffffff;
+}
+
+nav, main {
+  max-width: 46rem;
+  margin: 0 auto;
+  padding: 1rem 1.5rem;
+}
+
+nav {
+  border-bottom: 1px solid #d0d7de;
+}
+
+a {
+  color: #0969da;
+}
+
+pre, code {
+  font-family: ui-monospace, SFMono-Regular, Menlo, monospace;
+  background: #f6f8fa;
+}
+
+pre {
+  padding: 0.75rem 1rem;
+  overflow-x: auto;
+}
+
+blockquote {
+  margin: 0;
+  padding-left: 1rem;
+  border-left: 3px solid #d0d7de;
+  color: #59636e;
+}
+
+li.task {
+  list-style: none;
+}
+
+.meta, time {
+  color: #59636e;
+  font-size: 0.9em;
+}
+
+.tag {
+  margin-right: 0.5em;
+}
+
+.broken-link {
+  color: #cf222e;
+  text-decoration: underline dotted;
+}
+
+.comments {
+  margin-top: 2rem;
+  border-top: 1px solid #d0d7de;
+  font-size: 0.9em;
+}
+
+.comments .resolved {
+  color: #59636e;
+}
+`;
+
+const INDEX_FILE = 'index.html';
+const STYLESHEET_FILE = 'style.css';
+
+/** Export path for a note: its ID with `.html` in place of `.md`. */
+export function getNoteExportPath(note: Note): string {
+  return note.relativePath.replace(/\.md$/i, '') + '.html';
+}
+
+function relativeHref(fromFile: string, toFile: string): string {
+  return path.posix.relative(path.posix.dirname(fromFile), toFile) || path.posix.basename(toFile);
+}
+
+function renderPage(title: string, filePath: string, body: string): string {
+  return [
+    '<!DOCTYPE html>',
+    '<html lang="en">',
+    '<head>',
+    '<meta charset="utf-8">',
+    '<meta name="viewport" content="width=device-width, initial-scale=1">',
+    `<title>${escapeHtml(title)}</title>`,
+    `<link rel="stylesheet" href="${relativeHref(filePath, STYLESHEET_FILE)}">`,
+    '</head>',
+    '<body>',
+    `<nav><a href="${relativeHref(filePath, INDEX_FILE)}">All notes</a></nav>`,
+    '<main>',
+    body,
+    '</main>',
+    '</body>',
+    '</html>',
+    '',
+  ].join('\n');
+}
+
+function renderTagLinks(note: Note, filePath: string): string {
+  const indexHref = relativeHref(filePath, INDEX_FILE);
+  return note.tags
+    .map((tag) => `<a class="tag" href="${indexHref}#tag-${escapeHtml(getHeadingAnchor(tag))}">#${escapeHtml(tag)}</a>`)
+    .join('');
+}
+
+function renderComments(comments: NoteComment[]): string {
+  const items = comments.map((comment, index) => {
+    const quote = comment.anchor.quote ? `<blockquote>${escapeHtml(comment.anchor.quote)}</blockquote>` : '';
+    const author = escapeHtml(comment.author || 'anonymous');
+    const className = comment.resolved ? ' class="resolved"' : '';
+    return `<li id="comment-${index + 1}"${className}>${quote}<p><strong>${author}</strong>: ${escapeHtml(comment.content)}</p></li>`;
+  });
+  return `<section class="comments">\n<h2>Comments</h2>\n<ol>\n${items.join('\n')}\n</ol>\n</section>`;
+}
+
+function renderNotePage(note: Note, notes: Note[]): string {
+  const filePath = getNoteExportPath(note);
+  const resolveLink = (ref: string) => {
+    const target = resolveLinkTarget(notes, ref);
+    return target ? relativeHref(filePath, getNoteExportPath(target)) : null;
+  };
+
+  // The title heading comes first, then the date and tag line, then the body.
+  const body = renderMarkdown(note.content, { resolveLink }).split('\n');
+  const heading = extractHeadingTitle(note.content) === null
+    ? `<h1>${escapeHtml(note.title)}</h1>`
+    : body.shift()!;
+
+  const meta = [`<time datetime="${note.created}">${note.created.slice(0, 10)}</time>`];
+  if (note.due) {
+    meta.push(`due ${note.due}`);
+  }
+  const tags = renderTagLinks(note, filePath);
+  const parts = [heading, `<p class="meta">${meta.join(' · ')}${tags ? ` · ${tags}` : ''}</p>`, ...body];
+  if (note.comments.length > 0) {
+    parts.push(renderComments(note.comments));
+  }
+
+  return renderPage(note.title, filePath, `<article>\n${parts.join('\n')}\n</article>`);
+}
+
+function renderNoteLink(note: Note): string {
+  return `<a href="${escapeHtml(getNoteExportPath(note))}">${escapeHtml(note.title)}</a>`;
+}
+
+function renderIndexPage(notes: Note[], title: string): string {
+  const byTag = new Map<string, Note[]>();
+  const untagged: Note[] = [];
+  for (const note of notes) {
+    if (note.tags.length === 0) {
+      untagged.push(note);
+    }
+    for (const tag of note.tags) {
+      byTag.set(tag, [...(byTag.get(tag) ?? []), note]);
+    }
+  }
+
+  const sections = [`<h1>${escapeHtml(title)}</h1>`, '<h2>By tag</h2>'];
+  const tags = [...byTag.keys()].sort((a, b) => a.localeCompare(b));
+  for (const tag of tags) {
+    sections.push(`<h3 id="tag-${escapeHtml(getHeadingAnchor(tag))}">#${escapeHtml(tag)}</h3>`);
+    sections.push(`<ul>\n${byTag.get(tag)!.map((note) => `<li>${renderNoteLink(note)}</li>`).join('\n')}\n</ul>`);
+  }
+  if (untagged.length > 0) {
+    sections.push('<h3 id="untagged">Untagged</h3>');
+    sections.push(`<ul>\n${untagged.map((note) => `<li>${renderNoteLink(note)}</li>`).join('\n')}\n</ul>`);
+  }
+
+  const byDate = [...notes].sort((a, b) => b.created.localeCompare(a.created) || a.id.localeCompare(b.id));
+  sections.push('<h2>By date</h2>');
+  sections.push(
+    `<ul>\n${byDate
+      .map((note) => `<li><time datetime="${note.created}">${note.created.slice(0, 10)}</time> ${renderNoteLink(note)}</li>`)
+      .join('\n')}\n</ul>`,
+  );
+
+  return renderPage(title, INDEX_FILE, sections.join('\n'));
+}
+
+/**
+ * Render notes as a static site: one page per note at its ID with `.html`,
+ * an index listing notes by tag and by date, and a shared stylesheet.
+ * `[[wiki-links]]` become relative links and comments are listed as notes
+ * after the content. Nothing is written; callers get the files to write.
+ */
+export function exportHTML(notes: Note[], options: HtmlExportOptions = {}): ExportedFile[] {
+  return [
+    { path: INDEX_FILE, content: renderIndexPage(notes, options.title ?? 'Notes') },
+    { path: STYLESHEET_FILE, content: EXPORT_STYLESHEET },
+    ...notes.map((note) => ({ path: getNoteExportPath(note), content: renderNotePage(note, notes) })),
+  ];
+}
@@ -0,0 +1,60 @@
+import path from 'path';
+import { decodeTime } from 'ulid';
+
+const ULID_PATTERN = /^[0-9A-HJKMNP-TV-Z]{26}$/i;
+const DATE_PREFIX_PATTERN = /^(\d{4})-(\d{2})-(\d{2})-/;
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+/** How far `created` may drift from the time in its ID before it is worth flagging. */
+export const ID_TIMESTAMP_TOLERANCE_MS = 60 * 1000;
+
+export interface IdTimestamp {
+  /** ISO timestamp; for a date prefix, midnight UTC of that day. */
+  time: string;
+  /** `ulid` IDs are exact to the millisecond, `date` prefixes only to the UTC day. */
+  precision: 'ulid' | 'date';
+}
+
+/**
+ * The creation time encoded in a note ID. Filenames made by createNote start
+ * with the UTC creation date; a filename that is itself a ULID decodes to the
+ * millisecond. Throws when the ID carries neither.
+ */
+export function getIdTimestamp(id: string): IdTimestamp {
+  const name = path.posix.basename(id).replace(/\.md$/i, '');
+
+  if (ULID_PATTERN.test(name)) {
+    return { time: new Date(decodeTime(name.toUpperCase())).toISOString(), precision: 'ulid' };
+  }
+
+  const match = DATE_PREFIX_PATTERN.exec(name);
+  if (match) {
+    const [, year, month, day] = match;
+    const time = new Date(Date.UTC(Number(year), Number(month) - 1, Number(day)));
+    // Date.UTC rolls 2024-02-31 over into March; reject it instead.
+    if (time.toISOString().slice(0, 10) === `${year}-${month}-${day}`) {
+      return { time: time.toISOString(), precision: 'date' };
+    }
+  }
+
+  throw new Error(`Note ID has no timestamp: ${id}`);
+}
+
+/**
+ * Milliseconds by which `created` falls outside the time its ID encodes: 0
+ * when it is on the same UTC day as a date prefix or equal to a ULID's time,
+ * Infinity when `created` is not a valid timestamp.
+ */
+export function getCreatedDrift(created: string, stamp: IdTimestamp): number {
+  const createdMs = Date.parse(created);
+  if (Number.isNaN(createdMs)) {
+    return Number.POSITIVE_INFINITY;
+  }
+
+  const start = Date.parse(stamp.time);
+  const end = stamp.precision === 'date' ? start + DAY_MS - 1 : start;
+  if (createdMs < start) {
+    return start - createdMs;
+  }
+  return createdMs > end ? createdMs - end : 0;
+}
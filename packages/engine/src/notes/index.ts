@@ -1,3 +1,53 @@
 export { NoteStore } from './store.js';
 export type { NoteStoreOptions } from './store.js';
-export { search, getAllTags, getSortedTags } from './search.js';
+export {
+  search,
+  compileSearchPattern,
+  getAllTags,
+  getSortedTags,
+  getTagTree,
+  matchesTag,
+  matchesMetaFilter,
+} from './search.js';
+export { parseQuery, evaluateQuery, getPositiveTerms } from './query.js';
+export {
+  SearchIndex,
+  SEARCH_INDEX_VERSION,
+  getSearchIndexPath,
+  getTextTerms,
+  getNoteTerms,
+  getIndexQuery,
+} from './searchindex.js';
+export { lookupNote, findSimilarTag, levenshtein, scoreFuzzyMatch, filterNotesFuzzy } from './lookup.js';
+export { getIdTimestamp, getCreatedDrift, ID_TIMESTAMP_TOLERANCE_MS } from './ids.js';
+export type { IdTimestamp } from './ids.js';
+export { computeStats, DEFAULT_TOP_TAGS } from './stats.js';
+export { getWordCount, getReadingMinutes, DEFAULT_WORDS_PER_MINUTE } from './reading.js';
+export { pickRandomNotes, createSeededRandom } from './random.js';
+export { buildAgenda } from './agenda.js';
+export {
+  serializeNotesCSV,
+  escapeCSVField,
+  CSV_COLUMNS,
+  serializeNotesTSV,
+  escapeTSVField,
+  TSV_COLUMNS,
+} from './csv.js';
+export { mergeNoteContent } from './merge.js';
+export { parseNoteInput } from './input.js';
+export type { NoteInput } from './input.js';
+export { encryptText, decryptText, encryptNoteContent, decryptNoteContent } from './crypto.js';
+export type { MergedContent } from './merge.js';
+export { extractTasks, setTaskLineDone } from './tasks.js';
+export type { RandomSource } from './random.js';
+export { getSearchSnippet, extractSnippet, DEFAULT_SNIPPET_LENGTH } from './snippets.js';
+export type { NoteSnippet } from './snippets.js';
+export type { QueryNode } from './query.js';
+export { serializeNote, serializeNoteJSON, serializeNoteYAML, serializeNoteFrontmatter } from './serialization.js';
+export type { NoteSerializationFormat } from './serialization.js';
+export { exportMarkdown, exportHTML, getNoteExportPath, EXPORT_STYLESHEET } from './export.js';
+export type { ExportOptions, HtmlExportOptions, ExportedFile } from './export.js';
+export { renderMarkdown, renderInline, escapeHtml, getHeadingAnchor } from './render.js';
+export type { RenderOptions } from './render.js';
+export { extractLinks, resolveLinkTarget, collectLinkedNotes } from './links.js';
+export type { LinkWalkOptions } from './links.js';
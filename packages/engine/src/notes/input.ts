@@ -0,0 +1,115 @@
+import type { CreateNotePayload, NewNoteComment, NoteMeta } from '../types.js';
+import { isValidPriority, MAX_PRIORITY, MIN_PRIORITY } from '../utils/meta.js';
+import { isRecord } from '../utils/validation.js';
+
+/** Everything a JSON note description can set; the directory comes from the caller. */
+export type NoteInput = Omit<CreateNotePayload, 'directory' | 'template'>;
+
+const NOTE_INPUT_KEYS = ['title', 'tags', 'priority', 'source', 'content', 'comments'];
+const COMMENT_INPUT_KEYS = ['content', 'author', 'exact', 'from', 'to'];
+
+/**
+ * Parse a note described as a JSON object, as `add --json` reads it from
+ * stdin. `title` is required, unknown keys are rejected, and `priority` and
+ * `source` become custom fields. Throws with a message naming the bad field.
+ */
+export function parseNoteInput(raw: string, defaultAuthor: string): NoteInput {
+  let value: unknown;
+  try {
+    value = JSON.parse(raw);
+  } catch (error) {
+    throw new Error(`Note JSON is not valid: ${(error as Error).message}`);
+  }
+  if (!isRecord(value) || Array.isArray(value)) {
+    throw new Error('Note JSON must be an object');
+  }
+  rejectUnknownKeys(value, NOTE_INPUT_KEYS, 'note');
+
+  if (typeof value.title !== 'string' || !value.title.trim()) {
+    throw new Error('"title" is required and must be a non-empty string');
+  }
+  const input: NoteInput = { title: value.title.trim() };
+
+  if (value.content !== undefined) {
+    if (typeof value.content !== 'string') {
+      throw new Error('"content" must be a string');
+    }
+    input.content = value.content;
+  }
+
+  if (value.tags !== undefined) {
+    if (!Array.isArray(value.tags) || value.tags.some((tag) => typeof tag !== 'string')) {
+      throw new Error('"tags" must be an array of strings');
+    }
+    input.tags = value.tags;
+  }
+
+  const meta: NoteMeta = {};
+  if (value.priority !== undefined) {
+    if (!isValidPriority(value.priority)) {
+      throw new Error(`"priority" must be an integer from ${MIN_PRIORITY} to ${MAX_PRIORITY}`);
+    }
+    meta.priority = value.priority;
+  }
+  if (value.source !== undefined) {
+    if (typeof value.source !== 'string') {
+      throw new Error('"source" must be a string');
+    }
+    meta.source = value.source;
+  }
+  if (Object.keys(meta).length > 0) {
+    input.meta = meta;
+  }
+
+  if (value.comments !== undefined) {
+    if (!Array.isArray(value.comments)) {
+      throw new Error('"comments" must be an array');
+    }
+    input.comments = value.comments.map((comment, index) => parseCommentInput(comment, index, defaultAuthor));
+  }
+
+  return input;
+}
+
+function parseCommentInput(value: unknown, index: number, defaultAuthor: string): NewNoteComment {
+  const label = `comments[${index}]`;
+  if (!isRecord(value) || Array.isArray(value)) {
+    throw new Error(`${label} must be an object`);
+  }
+  rejectUnknownKeys(value, COMMENT_INPUT_KEYS, label);
+
+  if (typeof value.content !== 'string' || !value.content.trim()) {
+    throw new Error(`${label}.content is required and must be a non-empty string`);
+  }
+  if (value.author !== undefined && typeof value.author !== 'string') {
+    throw new Error(`${label}.author must be a string`);
+  }
+
+  const comment: NewNoteComment = {
+    content: value.content,
+    author: (value.author as string | undefined) || defaultAuthor,
+  };
+  if (value.exact !== undefined) {
+    if (typeof value.exact !== 'string' || !value.exact) {
+      throw new Error(`${label}.exact must be a non-empty string`);
+    }
+    if (value.from !== undefined || value.to !== undefined) {
+      throw new Error(`${label} takes either exact or from/to, not both`);
+    }
+    comment.exact = value.exact;
+  } else if (Number.isInteger(value.from) && Number.isInteger(value.to)) {
+    comment.from = value.from as number;
+    comment.to = value.to as number;
+  } else {
+    throw new Error(`${label} needs exact text or integer from and to offsets`);
+  }
+
+  return comment;
+}
+
+function rejectUnknownKeys(value: Record<string, unknown>, allowed: string[], label: string): void {
+  const unknown = Object.keys(value).filter((key) => !allowed.includes(key));
+  if (unknown.length > 0) {
+    throw new Error(`Unknown ${label} field${unknown.length === 1 ? '' : 's'}: ${unknown.join(', ')}`);
+  }
+}
@@ -0,0 +1,112 @@
+import type { Note } from '../types.js';
+import { slugify } from '../utils/slugify.js';
+
+const WIKI_LINK_PATTERN = /\[\[([^[\]\n]+?)\]\]/g;
+const DATE_PREFIX_PATTERN = /^\d{4}-\d{2}-\d{2}-/;
+
+/**
+ * Extract `[[ref]]` and `[[ref|label]]` link targets, in order of first appearance.
+ */
+export function extractLinks(content: string): string[] {
+  const seen = new Set<string>();
+  const links: string[] = [];
+
+  for (const match of content.matchAll(WIKI_LINK_PATTERN)) {
+    const ref = match[1].split('|')[0].trim();
+    if (!ref || seen.has(ref)) {
+      continue;
+    }
+
+    seen.add(ref);
+    links.push(ref);
+  }
+
+  return links;
+}
+
+/**
+ * Resolve a link reference against a set of notes. Tries, in order: note ID
+ * (with or without `.md`), filename, exact title, then title slug. A tier that
+ * matches more than one note is ambiguous and the link does not resolve.
+ */
+export function resolveLinkTarget(notes: Note[], ref: string): Note | null {
+  const target = ref.trim();
+  if (!target) {
+    return null;
+  }
+
+  const withExtension = target.toLocaleLowerCase().endsWith('.md') ? target : `${target}.md`;
+  const lowerTarget = target.toLocaleLowerCase();
+  const slugTarget = slugify(target);
+
+  const tiers: Array<(note: Note) => boolean> = [
+    (note) => note.id === target || note.id === withExtension,
+    (note) => note.filename === target || note.filename === withExtension,
+    (note) => note.title.toLocaleLowerCase() === lowerTarget,
+    (note) => slugTarget.length > 0 && noteSlug(note) === slugTarget,
+  ];
+
+  for (const matches of tiers) {
+    const found = notes.filter(matches);
+    if (found.length === 1) {
+      return found[0];
+    }
+    if (found.length > 1) {
+      return null;
+    }
+  }
+
+  return null;
+}
+
+function noteSlug(note: Note): string {
+  const titleSlug = slugify(note.title);
+  if (titleSlug) {
+    return titleSlug;
+  }
+
+  return note.filename.replace(/\.md$/i, '').replace(DATE_PREFIX_PATTERN, '');
+}
+
+export interface LinkWalkOptions {
+  /** How many links away from the start to go; 1 takes only its direct links. */
+  depth: number;
+  /** Stop once this many notes, the start included, have been collected. */
+  maxNotes?: number;
+}
+
+/**
+ * The start note followed by every note reachable through its wiki links,
+ * breadth first, each note once however many paths lead to it. Links in
+ * encrypted bodies cannot be read and are not followed.
+ */
+export function collectLinkedNotes(notes: Note[], start: Note, options: LinkWalkOptions): Note[] {
+  const maxNotes = options.maxNotes ?? Number.POSITIVE_INFINITY;
+  const seen = new Set<string>([start.id]);
+  const collected: Note[] = [start];
+  let frontier: Note[] = [start];
+
+  for (let level = 0; level < options.depth && frontier.length > 0; level++) {
+    const next: Note[] = [];
+    for (const note of frontier) {
+      if (note.encrypted) {
+        continue;
+      }
+      for (const ref of extractLinks(note.content)) {
+        const target = resolveLinkTarget(notes, ref);
+        if (!target || seen.has(target.id)) {
+          continue;
+        }
+        if (collected.length >= maxNotes) {
+          return collected;
+        }
+        seen.add(target.id);
+        collected.push(target);
+        next.push(target);
+      }
+    }
+    frontier = next;
+  }
+
+  return collected;
+}
@@ -0,0 +1,152 @@
+import type { Note, NoteLookupResult } from '../types.js';
+
+/**
+ * Find a note by alias, ID or title. An exact alias, ID or title wins outright; then
+ * titles containing the query, then filenames containing it. When a tier has
+ * several matches the lookup is ambiguous and they are all returned as
+ * candidates. Failing those, titles within a small edit distance are
+ * considered: a single closest title is returned, a tie becomes suggestions.
+ */
+export function lookupNote(notes: Note[], query: string): NoteLookupResult {
+  const alias = query.trim().toLocaleLowerCase();
+  const aliasMatch = notes.find((note) => note.aliases?.includes(alias));
+  if (aliasMatch) {
+    return { note: aliasMatch, ambiguous: false, candidates: [] };
+  }
+
+  const idMatch = notes.find((note) => note.id === query);
+  if (idMatch) {
+    return { note: idMatch, ambiguous: false, candidates: [] };
+  }
+
+  const lower = query.toLocaleLowerCase();
+  const tiers = [
+    (note: Note) => note.title.toLocaleLowerCase() === lower,
+    (note: Note) => note.title.toLocaleLowerCase().includes(lower),
+    (note: Note) => note.filename.toLocaleLowerCase().includes(lower),
+  ];
+
+  for (const matches of tiers) {
+    const matched = notes.filter(matches);
+    if (matched.length === 1) {
+      return { note: matched[0], ambiguous: false, candidates: [] };
+    }
+    if (matched.length > 1) {
+      return { note: null, ambiguous: true, candidates: matched };
+    }
+  }
+
+  return fuzzyLookup(notes, lower);
+}
+
+function fuzzyLookup(notes: Note[], query: string): NoteLookupResult {
+  const threshold = getFuzzyThreshold(query);
+  const scored = notes
+    .map((note) => ({ note, distance: levenshtein(query, note.title.toLocaleLowerCase()) }))
+    .filter((entry) => entry.distance <= threshold)
+    .sort((a, b) => a.distance - b.distance || a.note.title.localeCompare(b.note.title));
+
+  if (scored.length === 0) {
+    return { note: null, ambiguous: false, candidates: [] };
+  }
+
+  const closest = scored.filter((entry) => entry.distance === scored[0].distance);
+  if (closest.length === 1) {
+    return { note: closest[0].note, ambiguous: false, candidates: [] };
+  }
+
+  return { note: null, ambiguous: false, candidates: closest.map((entry) => entry.note) };
+}
+
+/**
+ * The existing tag closest to a new one, within the same edit distance as
+ * title lookup, e.g. `kubernetes` for `kubernets`. Comparison ignores case;
+ * null when the tag already exists or nothing is close.
+ */
+export function findSimilarTag(tag: string, existingTags: Iterable<string>): string | null {
+  const lower = tag.toLocaleLowerCase();
+  const threshold = getFuzzyThreshold(lower);
+  let closest: { tag: string; distance: number } | null = null;
+
+  for (const candidate of existingTags) {
+    const distance = levenshtein(lower, candidate.toLocaleLowerCase());
+    if (distance === 0) {
+      return null;
+    }
+    if (
+      distance <= threshold &&
+      (!closest || distance < closest.distance || (distance === closest.distance && candidate < closest.tag))
+    ) {
+      closest = { tag: candidate, distance };
+    }
+  }
+
+  return closest?.tag ?? null;
+}
+
+/**
+ * Score `text` as a fuzzy-finder match for `query`: each query character
+ * must appear in order, ignoring case and spaces in the query. Runs of
+ * consecutive characters and characters at word starts score higher. Null
+ * when it does not match; an empty query matches everything with 0.
+ */
+export function scoreFuzzyMatch(text: string, query: string): number | null {
+  const haystack = text.toLocaleLowerCase();
+  const needle = [...query.toLocaleLowerCase().replace(/\s+/g, '')];
+  if (needle.length === 0) {
+    return 0;
+  }
+
+  // best[j]: top score with the current query character placed at haystack[j].
+  let best: number[] = [];
+  for (const [i, char] of needle.entries()) {
+    const next: number[] = new Array(haystack.length).fill(-Infinity);
+    let earlier = i === 0 ? 0 : -Infinity;
+    for (let j = 0; j < haystack.length; j++) {
+      if (i > 0 && j > 0) {
+        earlier = Math.max(earlier, j > 1 ? best[j - 2] : -Infinity);
+      }
+      if (haystack[j] !== char) {
+        continue;
+      }
+      const wordStart = j === 0 || !/[\p{L}\p{N}]/u.test(haystack[j - 1]);
+      const consecutive = i > 0 && j > 0 ? best[j - 1] + 2 : -Infinity;
+      next[j] = 1 + (wordStart ? 3 : 0) + Math.max(earlier, consecutive);
+    }
+    best = next;
+  }
+
+  const score = Math.max(...best);
+  return Number.isFinite(score) ? score : null;
+}
+
+/** Notes whose title fuzzily matches the query, best first; ties keep their order. */
+export function filterNotesFuzzy(notes: Note[], query: string): Note[] {
+  return notes
+    .map((note, index) => ({ note, index, score: scoreFuzzyMatch(note.title, query) }))
+    .filter((entry): entry is { note: Note; index: number; score: number } => entry.score !== null)
+    .sort((a, b) => b.score - a.score || a.index - b.index)
+    .map((entry) => entry.note);
+}
+
+function getFuzzyThreshold(query: string): number {
+  return Math.max(1, Math.floor(query.length / 3));
+}
+
+export function levenshtein(a: string, b: string): number {
+  if (a === b) {
+    return 0;
+  }
+
+  let previous = Array.from({ length: b.length + 1 }, (_, index) => index);
+  for (let i = 1; i <= a.length; i++) {
+    const current = [i];
+    for (let j = 1; j <= b.length; j++) {
+      const substitution = previous[j - 1] + (a[i - 1] === b[j - 1] ? 0 : 1);
+      current.push(Math.min(previous[j] + 1, current[j - 1] + 1, substitution));
+    }
+    previous = current;
+  }
+
+  return previous[b.length];
+}
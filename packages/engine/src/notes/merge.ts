@@ -0,0 +1,40 @@
+import type { Note } from '../types.js';
+import { extractHeadingTitle } from '../storage/markdown.js';
+import { clamp } from '../comments/transformation.js';
+
+export interface MergedContent {
+  content: string;
+  /** Where an offset in the source content ends up in the merged content. */
+  mapSourceOffset: (offset: number) => number;
+}
+
+/**
+ * Append a source note to a target under `## Merged from <title>`. The
+ * source's own `# Title` line is dropped; offsets inside it map onto the
+ * title in the new heading, so comments on the title stay on the title.
+ */
+export function mergeNoteContent(target: Note, source: Note): MergedContent {
+  const hasHeading = extractHeadingTitle(source.content) !== null;
+  const firstLineBreak = source.content.indexOf('\n');
+  const titleLineEnd = !hasHeading ? 0 : firstLineBreak >= 0 ? firstLineBreak : source.content.length;
+  let bodyStart = titleLineEnd;
+  while (hasHeading && bodyStart < source.content.length && source.content[bodyStart] === '\n') {
+    bodyStart += 1;
+  }
+  const body = source.content.slice(bodyStart);
+
+  const prefix = target.content ? `${target.content}\n\n` : '';
+  const headingLead = '## Merged from ';
+  const heading = `${headingLead}${source.title}`;
+  const headingTitleStart = prefix.length + headingLead.length;
+  const mergedBodyStart = prefix.length + heading.length + 2;
+  const sourceTitleStart = hasHeading ? Math.max(0, source.content.indexOf(source.title)) : 0;
+
+  return {
+    content: body ? `${prefix}${heading}\n\n${body}` : `${prefix}${heading}`,
+    mapSourceOffset: (offset) =>
+      body && offset >= bodyStart
+        ? mergedBodyStart + Math.min(offset, source.content.length) - bodyStart
+        : headingTitleStart + clamp(offset - sourceTitleStart, 0, source.title.length),
+  };
+}
@@ -0,0 +1,194 @@
+/**
+ * Boolean search queries: `kubernetes AND (ingress OR gateway) NOT deprecated`.
+ *
+ * Operators are uppercase AND, OR and NOT, with precedence NOT > AND > OR.
+ * Adjacent terms without an operator are ANDed, parentheses group, and
+ * double quotes make a phrase that is matched as a single term.
+ */
+export type QueryNode =
+  | { type: 'term'; value: string }
+  | { type: 'not'; operand: QueryNode }
+  | { type: 'and'; left: QueryNode; right: QueryNode }
+  | { type: 'or'; left: QueryNode; right: QueryNode };
+
+type QueryToken =
+  | { type: 'term'; value: string }
+  | { type: 'and' | 'or' | 'not' | 'open' | 'close' };
+
+const OPERATORS = new Map<string, 'and' | 'or' | 'not'>([
+  ['AND', 'and'],
+  ['OR', 'or'],
+  ['NOT', 'not'],
+]);
+
+function tokenizeQuery(query: string): QueryToken[] {
+  const tokens: QueryToken[] = [];
+  let index = 0;
+
+  while (index < query.length) {
+    const char = query[index];
+
+    if (/\s/.test(char)) {
+      index += 1;
+      continue;
+    }
+
+    if (char === '(' || char === ')') {
+      tokens.push({ type: char === '(' ? 'open' : 'close' });
+      index += 1;
+      continue;
+    }
+
+    if (char === '"') {
+      const end = query.indexOf('"', index + 1);
+      if (end < 0) {
+        throw new Error('Invalid query: unterminated quote');
+      }
+      const phrase = query.slice(index + 1, end);
+      if (phrase.trim()) {
+        tokens.push({ type: 'term', value: phrase });
+      }
+      index = end + 1;
+      continue;
+    }
+
+    let end = index;
+    while (end < query.length && !/[\s()"]/.test(query[end])) {
+      end += 1;
+    }
+
+    const word = query.slice(index, end);
+    const operator = OPERATORS.get(word);
+    tokens.push(operator ? { type: operator } : { type: 'term', value: word });
+    index = end;
+  }
+
+  return tokens;
+}
+
+class QueryParser {
+  private tokens: QueryToken[];
+  private position = 0;
+
+  constructor(tokens: QueryToken[]) {
+    this.tokens = tokens;
+  }
+
+  parse(): QueryNode {
+    if (this.tokens.length === 0) {
+      throw new Error('Invalid query: empty query');
+    }
+
+    const node = this.parseOr();
+    const leftover = this.peek();
+    if (leftover) {
+      throw new Error(
+        leftover.type === 'close'
+          ? 'Invalid query: unmatched ")"'
+          : `Invalid query: unexpected ${describeToken(leftover)}`,
+      );
+    }
+    return node;
+  }
+
+  private parseOr(): QueryNode {
+    let node = this.parseAnd();
+    while (this.peek()?.type === 'or') {
+      this.position += 1;
+      node = { type: 'or', left: node, right: this.parseAnd() };
+    }
+    return node;
+  }
+
+  private parseAnd(): QueryNode {
+    let node = this.parseNot();
+    for (;;) {
+      const next = this.peek();
+      if (next?.type === 'and') {
+        this.position += 1;
+      } else if (!next || (next.type !== 'term' && next.type !== 'not' && next.type !== 'open')) {
+        return node;
+      }
+      node = { type: 'and', left: node, right: this.parseNot() };
+    }
+  }
+
+  private parseNot(): QueryNode {
+    if (this.peek()?.type === 'not') {
+      this.position += 1;
+      return { type: 'not', operand: this.parseNot() };
+    }
+    return this.parsePrimary();
+  }
+
+  private parsePrimary(): QueryNode {
+    const token = this.peek();
+    if (!token) {
+      throw new Error('Invalid query: expected a term at end of query');
+    }
+
+    this.position += 1;
+    if (token.type === 'term') {
+      return { type: 'term', value: token.value };
+    }
+
+    if (token.type === 'open') {
+      const node = this.parseOr();
+      if (this.peek()?.type !== 'close') {
+        throw new Error('Invalid query: unmatched "("');
+      }
+      this.position += 1;
+      return node;
+    }
+
+    throw new Error(`Invalid query: unexpected ${describeToken(token)}`);
+  }
+
+  private peek(): QueryToken | undefined {
+    return this.tokens[this.position];
+  }
+}
+
+function describeToken(token: QueryToken): string {
+  switch (token.type) {
+    case 'term':
+      return `"${token.value}"`;
+    case 'open':
+      return '"("';
+    case 'close':
+      return '")"';
+    default:
+      return token.type.toUpperCase();
+  }
+}
+
+/** Parse a boolean query, throwing an `Invalid query` error on bad syntax. */
+export function parseQuery(query: string): QueryNode {
+  return new QueryParser(tokenizeQuery(query)).parse();
+}
+
+export function evaluateQuery(node: QueryNode, matchesTerm: (term: string) => boolean): boolean {
+  switch (node.type) {
+    case 'term':
+      return matchesTerm(node.value);
+    case 'not':
+      return !evaluateQuery(node.operand, matchesTerm);
+    case 'and':
+      return evaluateQuery(node.left, matchesTerm) && evaluateQuery(node.right, matchesTerm);
+    case 'or':
+      return evaluateQuery(node.left, matchesTerm) || evaluateQuery(node.right, matchesTerm);
+  }
+}
+
+/** Terms that can contribute to a match, i.e. those not under a NOT. */
+export function getPositiveTerms(node: QueryNode): string[] {
+  switch (node.type) {
+    case 'term':
+      return [node.value];
+    case 'not':
+      return [];
+    case 'and':
+    case 'or':
+      return [...getPositiveTerms(node.left), ...getPositiveTerms(node.right)];
+  }
+}
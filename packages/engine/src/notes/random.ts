@@ -0,0 +1,38 @@
+import type { Note } from '../types.js';
+
+export type RandomSource = () => number;
+
+/**
+ * A deterministic RandomSource (mulberry32) for reproducible picks. Values are
+ * uniform in [0, 1), like Math.random.
+ */
+export function createSeededRandom(seed: number): RandomSource {
+  let state = seed >>> 0;
+  return () => {
+    state = (state + 0x6d2b79f5) >>> 0;
+    let t = state;
+    t = Math.imul(t ^ (t >>> 15), t | 1);
+    t ^= t + Math.imul(t ^ (t >>> 7), t | 61);
+    return ((t ^ (t >>> 14)) >>> 0) / 4294967296;
+  };
+}
+
+/**
+ * Pick up to `count` distinct notes uniformly at random, in pick order.
+ */
+export function pickRandomNotes(
+  notes: Note[],
+  count = 1,
+  random: RandomSource = Math.random,
+): Note[] {
+  const pool = [...notes];
+  const picks = Math.min(Math.max(0, Math.floor(count)), pool.length);
+
+  // Partial Fisher-Yates: the first `picks` slots end up as the sample.
+  for (let i = 0; i < picks; i++) {
+    const j = i + Math.floor(random() * (pool.length - i));
+    [pool[i], pool[j]] = [pool[j], pool[i]];
+  }
+
+  return pool.slice(0, picks);
+}
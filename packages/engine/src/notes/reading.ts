@@ -0,0 +1,46 @@
+/** Typical silent reading speed, in words per minute. */
+export const DEFAULT_WORDS_PER_MINUTE = 200;
+
+const FENCE_PATTERN = /^\s*(```|~~~)/;
+
+/**
+ * Reduce one line of markdown to its prose: block markers (headings, quotes,
+ * bullets, task boxes, rules), link and image syntax, and emphasis or code
+ * delimiters are dropped; link and wiki-link labels are kept.
+ */
+function stripMarkdownLine(line: string): string {
+  return line
+    .replace(/^\s{0,3}([-*_])(?:\s*\1){2,}\s*$/, '')
+    .replace(/^\s*(?:>\s*)+/, '')
+    .replace(/^\s*#{1,6}\s+/, '')
+    .replace(/^\s*(?:[-*+]|\d+[.)])\s+(?:\[[ xX]\]\s+)?/, '')
+    .replace(/!\[([^\]]*)\]\([^)]*\)/g, '')
+    .replace(/\[([^\]]+)\]\([^)]*\)/g, '$1')
+    .replace(/\[\[([^[\]|]+)(?:\|([^[\]]+))?\]\]/g, (_, ref: string, label?: string) => label ?? ref)
+    .replace(/[*_~`]+/g, ' ');
+}
+
+/**
+ * Words of prose in a markdown note. Code inside fences still counts, but
+ * the fence lines, markup, and tokens without a letter or digit do not.
+ */
+export function getWordCount(content: string): number {
+  let words = 0;
+  for (const line of content.split(/\r?\n/)) {
+    if (FENCE_PATTERN.test(line)) {
+      continue;
+    }
+    words += stripMarkdownLine(line)
+      .split(/\s+/)
+      .filter((token) => /[\p{L}\p{N}]/u.test(token)).length;
+  }
+  return words;
+}
+
+/** Whole minutes to read a word count, rounded up; any text takes at least one. */
+export function getReadingMinutes(words: number, wordsPerMinute: number = DEFAULT_WORDS_PER_MINUTE): number {
+  if (words <= 0) {
+    return 0;
+  }
+  return Math.max(1, Math.ceil(words / Math.max(1, wordsPerMinute)));
+}
@@ -0,0 +1,239 @@
+/**
+ * A small markdown-to-HTML renderer for exports: ATX headings, paragraphs,
+ * emphasis, code spans and fences, links, images, `[[wiki-links]]`,
+ * blockquotes, rules, and nested ordered/unordered/task lists. Raw HTML in
+ * the source is escaped rather than passed through.
+ */
+export interface RenderOptions {
+  /** Turn a `[[wiki-link]]` reference into an href, or null to leave it unlinked. */
+  resolveLink?: (ref: string) => string | null;
+}
+
+interface RenderContext {
+  options: RenderOptions;
+  anchors: Map<string, number>;
+}
+
+const FENCE_PATTERN = /^\s*(```|~~~)\s*([\w+-]*)/;
+const HEADING_PATTERN = /^\s{0,3}(#{1,6})\s+(.*?)(?:\s+#+)?\s*$/;
+const RULE_PATTERN = /^\s{0,3}([-*_])(?:\s*\1){2,}\s*$/;
+const QUOTE_PATTERN = /^\s{0,3}>/;
+const LIST_ITEM_PATTERN = /^(\s*)([-*+]|\d+[.)])\s+(.*)$/;
+const TASK_PATTERN = /^\[([ xX])\]\s+/;
+const PLACEHOLDER_PATTERN = /\u0000(\d+)\u0000/g;
+
+export function escapeHtml(text: string): string {
+  return text
+    .replace(/&/g, '&amp;')
+    .replace(/</g, '&lt;')
+    .replace(/>/g, '&gt;')
+    .replace(/"/g, '&quot;')
+    .replace(/'/g, '&#39;');
+}
+
+/**
+ * GitHub-style heading anchor: lowercased, punctuation dropped, spaces as
+ * hyphens. Repeated anchors get `-1`, `-2`, ... suffixes in document order.
+ */
+export function getHeadingAnchor(heading: string, used: Map<string, number> = new Map()): string {
+  const base = heading
+    .trim()
+    .toLocaleLowerCase()
+    .replace(/[^\p{L}\p{N}\s_-]/gu, '')
+    .replace(/\s/g, '-');
+  const count = used.get(base) ?? 0;
+  used.set(base, count + 1);
+  return count === 0 ? base : `${base}-${count}`;
+}
+
+export function renderMarkdown(content: string, options: RenderOptions = {}): string {
+  const lines = content.replace(/\r\n/g, '\n').split('\n');
+  return renderBlocks(lines, { options, anchors: new Map() });
+}
+
+export function renderInline(text: string, options: RenderOptions = {}): string {
+  const rendered: string[] = [];
+  const hold = (html: string) => `\u0000${rendered.push(html) - 1}\u0000`;
+
+  let output = text
+    .replace(/`([^`\n]+)`/g, (_, code: string) => hold(`<code>${escapeHtml(code)}</code>`))
+    .replace(/\[\[([^[\]\n]+?)\]\]/g, (_, body: string) => {
+      const [ref, label] = body.split('|').map((part) => part.trim());
+      const href = options.resolveLink?.(ref) ?? null;
+      const shown = escapeHtml(label || ref);
+      return hold(href ? `<a href="${escapeHtml(href)}">${shown}</a>` : `<span class="broken-link">${shown}</span>`);
+    })
+    .replace(/!\[([^\]]*)\]\(([^)\s]+)(?:\s+"([^"]*)")?\)/g, (_, alt: string, url: string, title?: string) =>
+      hold(`<img src="${escapeHtml(safeUrl(url))}" alt="${escapeHtml(alt)}"${titleAttribute(title)}>`),
+    )
+    .replace(/\[([^\]]+)\]\(([^)\s]+)(?:\s+"([^"]*)")?\)/g, (_, label: string, url: string, title?: string) =>
+      hold(`<a href="${escapeHtml(safeUrl(url))}"${titleAttribute(title)}>${renderInline(label, options)}</a>`),
+    )
+    .replace(/<(https?:\/\/[^>\s]+)>/g, (_, url: string) => hold(`<a href="${escapeHtml(url)}">${escapeHtml(url)}</a>`));
+
+  output = escapeHtml(output)
+    .replace(/\*\*(?=\S)([\s\S]*?\S)\*\*/g, '<strong>$1</strong>')
+    .replace(/(^|[^\w])__(?=\S)([\s\S]*?\S)__(?!\w)/g, '$1<strong>$2</strong>')
+    .replace(/\*(?=\S)([^*]*?\S)\*/g, '<em>$1</em>')
+    .replace(/(^|[^\w])_(?=\S)([^_]*?\S)_(?!\w)/g, '$1<em>$2</em>')
+    .replace(/~~(?=\S)([\s\S]*?\S)~~/g, '<del>$1</del>')
+    .replace(/ {2,}\n/g, '<br>\n');
+
+  return output.replace(PLACEHOLDER_PATTERN, (_, index: string) => rendered[Number(index)]);
+}
+
+function safeUrl(url: string): string {
+  return /^\s*(?:javascript|vbscript|data):/i.test(url) ? '#' : url;
+}
+
+function titleAttribute(title?: string): string {
+  return title ? ` title="${escapeHtml(title)}"` : '';
+}
+
+function leadingSpaces(line: string): number {
+  return line.length - line.trimStart().length;
+}
+
+function startsBlock(line: string): boolean {
+  return (
+    FENCE_PATTERN.test(line) ||
+    HEADING_PATTERN.test(line) ||
+    RULE_PATTERN.test(line) ||
+    QUOTE_PATTERN.test(line) ||
+    LIST_ITEM_PATTERN.test(line)
+  );
+}
+
+function renderBlocks(lines: string[], context: RenderContext): string {
+  const html: string[] = [];
+  let index = 0;
+
+  while (index < lines.length) {
+    const line = lines[index];
+    if (!line.trim()) {
+      index += 1;
+      continue;
+    }
+
+    const fence = line.match(FENCE_PATTERN);
+    if (fence) {
+      const code: string[] = [];
+      index += 1;
+      while (index < lines.length && !lines[index].trim().startsWith(fence[1])) {
+        code.push(lines[index]);
+        index += 1;
+      }
+      index += 1;
+      const language = fence[2] ? ` class="language-${escapeHtml(fence[2])}"` : '';
+      html.push(`<pre><code${language}>${escapeHtml(code.join('\n'))}</code></pre>`);
+      continue;
+    }
+
+    const heading = line.match(HEADING_PATTERN);
+    if (heading) {
+      const level = heading[1].length;
+      const id = getHeadingAnchor(heading[2], context.anchors);
+      html.push(`<h${level} id="${escapeHtml(id)}">${renderInline(heading[2], context.options)}</h${level}>`);
+      index += 1;
+      continue;
+    }
+
+    if (RULE_PATTERN.test(line)) {
+      html.push('<hr>');
+      index += 1;
+      continue;
+    }
+
+    if (QUOTE_PATTERN.test(line)) {
+      const quoted: string[] = [];
+      while (index < lines.length && QUOTE_PATTERN.test(lines[index])) {
+        quoted.push(lines[index].replace(/^\s{0,3}>\s?/, ''));
+        index += 1;
+      }
+      html.push(`<blockquote>\n${renderBlocks(quoted, context)}\n</blockquote>`);
+      continue;
+    }
+
+    if (LIST_ITEM_PATTERN.test(line)) {
+      const list = renderList(lines, index, context);
+      html.push(list.html);
+      index = list.next;
+      continue;
+    }
+
+    const paragraph: string[] = [];
+    while (index < lines.length && lines[index].trim() && (paragraph.length === 0 || !startsBlock(lines[index]))) {
+      paragraph.push(lines[index].trimStart());
+      index += 1;
+    }
+    html.push(`<p>${renderInline(paragraph.join('\n'), context.options)}</p>`);
+  }
+
+  return html.join('\n');
+}
+
+function renderList(lines: string[], start: number, context: RenderContext): { html: string; next: number } {
+  const first = lines[start].match(LIST_ITEM_PATTERN)!;
+  const indent = first[1].length;
+  const ordered = /\d/.test(first[2]);
+  const items: string[] = [];
+  let index = start;
+
+  while (index < lines.length) {
+    const match = lines[index].match(LIST_ITEM_PATTERN);
+    if (!match || match[1].length !== indent || /\d/.test(match[2]) !== ordered) {
+      break;
+    }
+
+    const contentIndent = indent + match[2].length + 1;
+    const body = [match[3]];
+    let loose = false;
+    index += 1;
+
+    while (index < lines.length) {
+      const next = lines[index];
+      if (!next.trim()) {
+        const following = lines[index + 1];
+        if (following !== undefined && following.trim() && leadingSpaces(following) > indent) {
+          body.push('');
+          loose = true;
+          index += 1;
+          continue;
+        }
+        break;
+      }
+
+      if (leadingSpaces(next) <= indent && startsBlock(next)) {
+        break;
+      }
+
+      body.push(next.slice(Math.min(leadingSpaces(next), contentIndent)));
+      index += 1;
+    }
+
+    items.push(renderListItem(body, loose, context));
+  }
+
+  const startNumber = ordered ? parseInt(first[2], 10) : 1;
+  const open = ordered ? (startNumber === 1 ? '<ol>' : `<ol start="${startNumber}">`) : '<ul>';
+  return { html: `${open}\n${items.join('\n')}\n${ordered ? '</ol>' : '</ul>'}`, next: index };
+}
+
+function renderListItem(body: string[], loose: boolean, context: RenderContext): string {
+  const task = body[0].match(TASK_PATTERN);
+  if (task) {
+    body[0] = body[0].slice(task[0].length);
+  }
+
+  let inner = renderBlocks(body, context);
+  if (!loose) {
+    inner = inner.replace(/^<p>([\s\S]*?)<\/p>/, '$1');
+  }
+
+  if (!task) {
+    return `<li>${inner}</li>`;
+  }
+
+  const checked = task[1] === ' ' ? '' : ' checked';
+  return `<li class="task"><input type="checkbox" disabled${checked}> ${inner}</li>`;
+}
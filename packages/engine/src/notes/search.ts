@@ -1,11 +1,37 @@
-import type { Note, SearchOptions, SortField, TagCount } from '../types.js';
+import type { Note, SearchOptions, SortField, TagCount, TagTreeNode } from '../types.js';
+import { evaluateQuery, getPositiveTerms, parseQuery } from './query.js';
 
 export function search(notes: Note[], opts: SearchOptions = {}): Note[] {
   let result = [...notes];
 
-  if (opts.query) {
-    const query = opts.query.toLocaleLowerCase();
-    result = result.filter((note) => matchesQuery(note, query));
+  if (opts.onlyArchived) {
+    result = result.filter((note) => note.archivedAt);
+  } else if (!opts.includeArchived) {
+    result = result.filter((note) => !note.archivedAt);
+  }
+
+  if (opts.onlyPinned) {
+    result = result.filter((note) => note.pinned);
+  }
+
+  if (opts.query && opts.regex) {
+    const pattern = compileSearchPattern(opts.query, opts);
+    result = result.filter((note) => matchesQuery(note, (text) => pattern.test(text)));
+  } else if (opts.query && opts.boolean) {
+    const expression = parseQuery(opts.query);
+    const matchers = new Map<string, (text: string) => boolean>();
+    const getMatcher = (term: string) => {
+      let matcher = matchers.get(term);
+      if (!matcher) {
+        matcher = createTermMatcher(term, opts);
+        matchers.set(term, matcher);
+      }
+      return matcher;
+    };
+    result = result.filter((note) => evaluateQuery(expression, (term) => matchesQuery(note, getMatcher(term))));
+  } else if (opts.query) {
+    const matcher = createTermMatcher(opts.query, opts);
+    result = result.filter((note) => matchesQuery(note, matcher));
   }
 
   if (opts.tags && opts.tags.length > 0) {
@@ -13,7 +39,41 @@ export function search(notes: Note[], opts: SearchOptions = {}): Note[] {
     result = result.filter((note) => filterTags.every((tag) => hasTag(note, tag)));
   }
 
-  sortNotes(result, opts.sortBy ?? 'created', opts.reverse ?? false);
+  if (opts.meta && opts.meta.length > 0) {
+    const filters = opts.meta;
+    result = result.filter((note) => filters.every((filter) => matchesMetaFilter(note, filter)));
+  }
+
+  if (opts.minPriority !== undefined || opts.maxPriority !== undefined) {
+    result = result.filter((note) => {
+      const priority = note.meta?.priority;
+      return (
+        typeof priority === 'number' &&
+        (opts.minPriority === undefined || priority >= opts.minPriority) &&
+        (opts.maxPriority === undefined || priority <= opts.maxPriority)
+      );
+    });
+  }
+
+  if (opts.createdAfter || opts.createdBefore) {
+    result = result.filter((note) =>
+      isWithinRange(note.created, opts.createdAfter, opts.createdBefore),
+    );
+  }
+
+  if (opts.updatedAfter || opts.updatedBefore) {
+    result = result.filter((note) =>
+      isWithinRange(note.updated, opts.updatedAfter, opts.updatedBefore),
+    );
+  }
+
+  const sortBy = [opts.sortBy ?? (opts.query ? 'relevance' : 'created')].flat();
+  const scores = sortBy.includes('relevance') ? scoreNotes(result, opts) : new Map<Note, number>();
+  sortNotes(result, sortBy, opts.reverse ?? false, scores, opts.pinnedFirst ?? false);
+
+  if (opts.offset && opts.offset > 0) {
+    result = result.slice(opts.offset);
+  }
 
   if (opts.limit && opts.limit > 0) {
     result = result.slice(0, opts.limit);
@@ -22,12 +82,122 @@ export function search(notes: Note[], opts: SearchOptions = {}): Note[] {
   return result;
 }
 
-export function getAllTags(notes: Note[]): Map<string, number> {
+type MatchOptions = Pick<SearchOptions, 'caseSensitive' | 'wholeWord'>;
+
+/** A query hit in the title counts this many times one in the content. */
+const TITLE_MATCH_WEIGHT = 5;
+/** Each tag the query matches counts this many times a content hit. */
+const TAG_MATCH_WEIGHT = 3;
+
+/**
+ * How well a note matches the query: occurrences in the content, plus
+ * weighted occurrences in the title and matching tags. Boolean queries sum
+ * their positive terms. Zero without a query.
+ */
+export function scoreNote(
+  note: Note,
+  opts: Pick<SearchOptions, 'query' | 'regex' | 'boolean' | 'caseSensitive' | 'wholeWord'>,
+): number {
+  if (!opts.query) {
+    return 0;
+  }
+
+  const patterns = getScoringPatterns(opts.query, opts);
+  let score = 0;
+  for (const pattern of patterns) {
+    score += countMatches(pattern, note.content);
+    score += countMatches(pattern, note.title) * TITLE_MATCH_WEIGHT;
+    score += note.tags.filter((tag) => countMatches(pattern, tag) > 0).length * TAG_MATCH_WEIGHT;
+  }
+  return score;
+}
+
+/** Global patterns, so each occurrence counts. */
+function getScoringPatterns(
+  query: string,
+  opts: Pick<SearchOptions, 'regex' | 'boolean' | 'caseSensitive' | 'wholeWord'>,
+): RegExp[] {
+  const patterns = opts.regex
+    ? [compileSearchPattern(query, opts)]
+    : opts.boolean
+      ? [...new Set(getPositiveTerms(parseQuery(query)))].map((term) => compileTermPattern(term, opts))
+      : [compileTermPattern(query, opts)];
+  return patterns.map((pattern) => new RegExp(pattern.source, `${pattern.flags}g`));
+}
+
+function countMatches(pattern: RegExp, text: string): number {
+  let count = 0;
+  for (const match of text.matchAll(pattern)) {
+    // An empty match, such as a bare `^`, says where rather than what; skip it.
+    if (match[0].length > 0) {
+      count++;
+    }
+  }
+  return count;
+}
+
+function scoreNotes(notes: Note[], opts: SearchOptions): Map<Note, number> {
+  return new Map(notes.map((note) => [note, scoreNote(note, opts)]));
+}
+
+/**
+ * Compile a regex search query. Matching is case-insensitive like plain search
+ * unless caseSensitive is set, and `^`/`$` match at line boundaries. Throws a
+ * SyntaxError for bad patterns.
+ */
+export function compileSearchPattern(query: string, opts: MatchOptions = {}): RegExp {
+  const source = opts.wholeWord ? wrapInWordBoundaries(`(?:${query})`) : query;
+  return new RegExp(source, opts.caseSensitive ? 'm' : 'im');
+}
+
+/** The pattern a plain or boolean search term is matched with. */
+export function compileTermPattern(term: string, opts: MatchOptions = {}): RegExp {
+  const source = escapeRegExp(term);
+  return new RegExp(opts.wholeWord ? wrapInWordBoundaries(source) : source, opts.caseSensitive ? '' : 'i');
+}
+
+/**
+ * Lookarounds rather than `\b`, so a term that starts or ends with
+ * punctuation, such as `c++`, can still match as a whole word.
+ */
+function wrapInWordBoundaries(source: string): string {
+  return `(?<!\\w)${source}(?!\\w)`;
+}
+
+function escapeRegExp(value: string): string {
+  return value.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
+}
+
+function createTermMatcher(term: string, opts: MatchOptions): (text: string) => boolean {
+  if (!opts.caseSensitive && !opts.wholeWord) {
+    const needle = term.toLocaleLowerCase();
+    return (text) => text.toLocaleLowerCase().includes(needle);
+  }
+
+  const pattern = compileTermPattern(term, opts);
+  return (text) => pattern.test(text);
+}
+
+/**
+ * Count notes per tag. With rollup, a nested tag such as `project/alpha` also
+ * counts toward `project`, once per note however many children it has.
+ */
+export function getAllTags(notes: Note[], rollup = false): Map<string, number> {
   const tagCounts = new Map<string, number>();
 
   for (const note of notes) {
+    const keys = new Set<string>();
     for (const tag of note.tags) {
       const key = tag.toLocaleLowerCase();
+      keys.add(key);
+      if (rollup) {
+        for (const parent of getParentTags(key)) {
+          keys.add(parent);
+        }
+      }
+    }
+
+    for (const key of keys) {
       tagCounts.set(key, (tagCounts.get(key) ?? 0) + 1);
     }
   }
@@ -35,8 +205,8 @@ export function getAllTags(notes: Note[]): Map<string, number> {
   return tagCounts;
 }
 
-export function getSortedTags(notes: Note[]): TagCount[] {
-  const tagCounts = getAllTags(notes);
+export function getSortedTags(notes: Note[], options: { rollup?: boolean } = {}): TagCount[] {
+  const tagCounts = getAllTags(notes, options.rollup ?? false);
   const sorted: TagCount[] = [];
 
   for (const [tag, count] of tagCounts) {
@@ -53,43 +223,127 @@ export function getSortedTags(notes: Note[]): TagCount[] {
   return sorted;
 }
 
-function matchesQuery(note: Note, query: string): boolean {
-  if (note.title.toLocaleLowerCase().includes(query)) {
-    return true;
+function matchesQuery(note: Note, matches: (text: string) => boolean): boolean {
+  return matches(note.title) || matches(note.content) || note.tags.some(matches);
+}
+
+/** `key` matches when the field is set; `key=value` compares its string form. */
+export function matchesMetaFilter(note: Note, filter: string): boolean {
+  const separator = filter.indexOf('=');
+  const key = separator >= 0 ? filter.slice(0, separator) : filter;
+  if (!note.meta || !Object.prototype.hasOwnProperty.call(note.meta, key)) {
+    return false;
   }
 
-  if (note.content.toLocaleLowerCase().includes(query)) {
-    return true;
+  return separator < 0 || String(note.meta[key]) === filter.slice(separator + 1);
+}
+
+function isWithinRange(value: string, after?: string, before?: string): boolean {
+  const time = Date.parse(value);
+  if (Number.isNaN(time)) {
+    return false;
   }
 
-  for (const tag of note.tags) {
-    if (tag.toLocaleLowerCase().includes(query)) {
-      return true;
-    }
+  if (after && time < Date.parse(after)) {
+    return false;
+  }
+
+  return !(before && time > Date.parse(before));
+}
+
+/**
+ * Arrange tags into a `/`-separated hierarchy with counts rolled up to each
+ * parent. Siblings are ordered like getSortedTags.
+ */
+export function getTagTree(notes: Note[]): TagTreeNode[] {
+  const roots: TagTreeNode[] = [];
+  const nodes = new Map<string, TagTreeNode>();
+
+  const sortedByDepth = getSortedTags(notes, { rollup: true }).sort(
+    (a, b) => a.tag.split('/').length - b.tag.split('/').length,
+  );
+
+  for (const { tag, count } of sortedByDepth) {
+    const separator = tag.lastIndexOf('/');
+    const node: TagTreeNode = { name: tag.slice(separator + 1), tag, count, children: [] };
+    nodes.set(tag, node);
+
+    const parent = separator >= 0 ? nodes.get(tag.slice(0, separator)) : undefined;
+    (parent ? parent.children : roots).push(node);
   }
 
-  return false;
+  return roots;
+}
+
+/** A note tag matches a filter tag when equal to it or nested beneath it. */
+export function matchesTag(noteTag: string, filterTag: string): boolean {
+  const tag = noteTag.toLocaleLowerCase();
+  const filter = filterTag.toLocaleLowerCase();
+  return tag === filter || tag.startsWith(`${filter}/`);
+}
+
+function getParentTags(tag: string): string[] {
+  const parents: string[] = [];
+  let separator = tag.indexOf('/');
+  while (separator > 0) {
+    parents.push(tag.slice(0, separator));
+    separator = tag.indexOf('/', separator + 1);
+  }
+  return parents;
 }
 
 function hasTag(note: Note, tag: string): boolean {
-  return note.tags.some((t) => t.toLocaleLowerCase() === tag);
+  return note.tags.some((t) => matchesTag(t, tag));
 }
 
-function sortNotes(notes: Note[], sortBy: SortField, reverse: boolean): void {
+/**
+ * Sort by each field in turn. The note path (which starts with the creation
+ * date) breaks any remaining ties, so the order is always deterministic.
+ */
+function sortNotes(
+  notes: Note[],
+  sortBy: SortField[],
+  reverse: boolean,
+  scores: Map<Note, number>,
+  pinnedFirst: boolean,
+): void {
+  const fields = [...sortBy, 'created' as const];
   notes.sort((a, b) => {
-    let cmp: number;
-
-    switch (sortBy) {
-      case 'title':
-        cmp = a.title.toLocaleLowerCase().localeCompare(b.title.toLocaleLowerCase());
-        break;
-      case 'updated':
-      case 'created':
-      default:
-        cmp = a.relativePath.localeCompare(b.relativePath);
-        break;
+    // Pinned notes stay on top even when the order is reversed.
+    if (pinnedFirst && Boolean(a.pinned) !== Boolean(b.pinned)) {
+      return a.pinned ? -1 : 1;
     }
 
-    return reverse ? -cmp : cmp;
+    for (const field of fields) {
+      // Undated notes sort after dated ones in either direction.
+      if (field === 'due' && (!a.due || !b.due)) {
+        if (a.due || b.due) {
+          return a.due ? -1 : 1;
+        }
+        continue;
+      }
+
+      // Best match first; reverse puts it last.
+      const cmp =
+        field === 'relevance' ? (scores.get(b) ?? 0) - (scores.get(a) ?? 0) : compareByField(a, b, field);
+      if (cmp !== 0) {
+        return reverse ? -cmp : cmp;
+      }
+    }
+    return 0;
   });
 }
+
+function compareByField(a: Note, b: Note, field: SortField): number {
+  switch (field) {
+    case 'title':
+      return a.title.toLocaleLowerCase().localeCompare(b.title.toLocaleLowerCase());
+    case 'updated':
+      return a.updated.localeCompare(b.updated);
+    case 'due':
+      return (a.due ?? '').localeCompare(b.due ?? '');
+    case 'created':
+    default:
+      return a.relativePath.localeCompare(b.relativePath);
+  }
+}
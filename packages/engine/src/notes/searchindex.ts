@@ -0,0 +1,167 @@
+import fs from 'node:fs';
+import path from 'node:path';
+import type { Note, SearchOptions } from '../types.js';
+import { INTERNAL_DIRECTORY } from '../storage/filesystem.js';
+import { writeFileAtomic } from '../storage/atomic.js';
+import { isRecord } from '../utils/validation.js';
+import { logError } from '../utils/log.js';
+import { parseQuery } from './query.js';
+import type { QueryNode } from './query.js';
+
+export const SEARCH_INDEX_VERSION = 1;
+
+/**
+ * Search matches substrings, so the index holds every three-character run of
+ * a note's text. A term can only occur in a note that has all of its runs.
+ */
+const TERM_LENGTH = 3;
+
+export function getSearchIndexPath(notesRoot: string): string {
+  return path.join(notesRoot, INTERNAL_DIRECTORY, 'index.json');
+}
+
+/** The three-character runs of text, lowercased as case-insensitive search compares it. */
+export function getTextTerms(text: string): string[] {
+  const lower = text.toLocaleLowerCase();
+  const terms = new Set<string>();
+  for (let index = 0; index + TERM_LENGTH <= lower.length; index++) {
+    terms.add(lower.slice(index, index + TERM_LENGTH));
+  }
+  return [...terms];
+}
+
+/** Terms from everything search looks at: title, content and tags. */
+export function getNoteTerms(note: Note): Set<string> {
+  return new Set([note.title, note.content, ...note.tags].flatMap(getTextTerms));
+}
+
+/**
+ * The query as the index can check it, or null when it cannot narrow the
+ * notes: no query, a regex, or a boolean query that does not parse (search
+ * reports that error itself).
+ */
+export function getIndexQuery(opts: Pick<SearchOptions, 'query' | 'regex' | 'boolean'>): QueryNode | null {
+  if (!opts.query || opts.regex) {
+    return null;
+  }
+  if (!opts.boolean) {
+    return { type: 'term', value: opts.query };
+  }
+
+  try {
+    return parseQuery(opts.query);
+  } catch {
+    return null;
+  }
+}
+
+interface SearchIndexEntry {
+  signature: string;
+  terms: Set<string>;
+}
+
+/**
+ * Term runs per note, keyed by path relative to the notes root and signed
+ * with the note files' mtimes and sizes so stale entries can be spotted. On
+ * disk it is inverted: each term lists the positions of the notes that have it.
+ */
+export class SearchIndex {
+  private entries = new Map<string, SearchIndexEntry>();
+
+  /** The index saved under notesRoot, or null when there is none or it cannot be read. */
+  static read(notesRoot: string): SearchIndex | null {
+    const indexPath = getSearchIndexPath(notesRoot);
+    if (!fs.existsSync(indexPath)) {
+      return null;
+    }
+
+    try {
+      const data = JSON.parse(fs.readFileSync(indexPath, 'utf-8')) as unknown;
+      if (!isRecord(data) || data.version !== SEARCH_INDEX_VERSION) {
+        return null;
+      }
+      if (!Array.isArray(data.notes) || !isRecord(data.terms)) {
+        return null;
+      }
+
+      const index = new SearchIndex();
+      const entries: SearchIndexEntry[] = [];
+      for (const note of data.notes) {
+        const entry = { signature: isRecord(note) ? String(note.signature) : '', terms: new Set<string>() };
+        entries.push(entry);
+        if (isRecord(note) && typeof note.path === 'string') {
+          index.entries.set(note.path, entry);
+        }
+      }
+      for (const [term, positions] of Object.entries(data.terms)) {
+        for (const position of Array.isArray(positions) ? positions : []) {
+          entries[position as number]?.terms.add(term);
+        }
+      }
+      return index;
+    } catch (error) {
+      logError(`Error reading search index ${indexPath}:`, error);
+      return null;
+    }
+  }
+
+  write(notesRoot: string): void {
+    const notes: { path: string; signature: string }[] = [];
+    const terms: Record<string, number[]> = {};
+    for (const [notePath, entry] of this.entries) {
+      const position = notes.length;
+      notes.push({ path: notePath, signature: entry.signature });
+      for (const term of entry.terms) {
+        (terms[term] ??= []).push(position);
+      }
+    }
+
+    const indexPath = getSearchIndexPath(notesRoot);
+    fs.mkdirSync(path.dirname(indexPath), { recursive: true });
+    writeFileAtomic(indexPath, JSON.stringify({ version: SEARCH_INDEX_VERSION, notes, terms }));
+  }
+
+  get size(): number {
+    return this.entries.size;
+  }
+
+  paths(): string[] {
+    return [...this.entries.keys()];
+  }
+
+  getSignature(notePath: string): string | undefined {
+    return this.entries.get(notePath)?.signature;
+  }
+
+  set(notePath: string, signature: string, note: Note): void {
+    this.entries.set(notePath, { signature, terms: getNoteTerms(note) });
+  }
+
+  delete(notePath: string): void {
+    this.entries.delete(notePath);
+  }
+
+  /**
+   * False only when the note certainly does not match: a term has a run the
+   * note lacks. Terms shorter than a run, NOT, and notes the index does not
+   * know can never be ruled out.
+   */
+  mayMatch(notePath: string, query: QueryNode): boolean {
+    const entry = this.entries.get(notePath);
+    return !entry || mayMatchNode(query, entry.terms);
+  }
+}
+
+function mayMatchNode(node: QueryNode, terms: Set<string>): boolean {
+  switch (node.type) {
+    case 'term':
+      return getTextTerms(node.value).every((term) => terms.has(term));
+    case 'and':
+      return mayMatchNode(node.left, terms) && mayMatchNode(node.right, terms);
+    case 'or':
+      return mayMatchNode(node.left, terms) || mayMatchNode(node.right, terms);
+    case 'not':
+      // A note with every run of the term may still not contain it.
+      return true;
+  }
+}
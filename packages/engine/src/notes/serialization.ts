@@ -0,0 +1,46 @@
+import type { Note } from '../types.js';
+import { toCommentRecord } from '../storage/sidecar.js';
+import { toYaml } from '../utils/yaml.js';
+
+export type NoteSerializationFormat = 'json' | 'yaml';
+
+export function serializeNoteJSON(note: Note): string {
+  return JSON.stringify(note, null, 2);
+}
+
+/** Identity plus the on-disk sidecar fields, snake_case as they are stored. */
+function getNoteYamlFields(note: Note): Record<string, unknown> {
+  return {
+    id: note.id,
+    title: note.title,
+    tags: note.tags,
+    created: note.created,
+    updated: note.updated,
+    ...(note.commentRev > 0 ? { comment_rev: note.commentRev } : {}),
+    comments: note.comments.map((comment) => toCommentRecord(comment)),
+    ...(note.meta ? { meta: note.meta } : {}),
+    ...(note.due ? { due: note.due } : {}),
+    ...(note.aliases ? { aliases: note.aliases } : {}),
+    ...(note.attachments ? { attachments: note.attachments } : {}),
+    ...(note.encrypted ? { encrypted: true } : {}),
+    ...(note.archivedAt ? { archived_at: note.archivedAt } : {}),
+    ...(note.pinned ? { pinned: true } : {}),
+  };
+}
+
+/**
+ * YAML form of a note: the on-disk sidecar fields (snake_case) plus identity
+ * and a `content` field, so a note can be round-tripped from a single document.
+ */
+export function serializeNoteYAML(note: Note): string {
+  return toYaml({ ...getNoteYamlFields(note), content: note.content });
+}
+
+/** The same fields without content, as a `---` delimited frontmatter block. */
+export function serializeNoteFrontmatter(note: Note): string {
+  return `---\n${toYaml(getNoteYamlFields(note))}---\n`;
+}
+
+export function serializeNote(note: Note, format: NoteSerializationFormat): string {
+  return format === 'yaml' ? serializeNoteYAML(note) : serializeNoteJSON(note);
+}
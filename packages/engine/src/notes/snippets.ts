@@ -0,0 +1,127 @@
+import type { CharRange } from '../comments/resolution.js';
+import type { Note, SearchOptions } from '../types.js';
+import { getPositiveTerms, parseQuery } from './query.js';
+import { compileSearchPattern, compileTermPattern } from './search.js';
+
+export const DEFAULT_SNIPPET_LENGTH = 120;
+
+export type NoteSnippet =
+  | { kind: 'content'; text: string; highlights: CharRange[] }
+  | { kind: 'tag'; tag: string };
+
+/**
+ * Explain why a note matched a search: the line around the first content
+ * match, or the matching tag when only a tag matched. Body matches are
+ * preferred over the title heading on the first line.
+ */
+export function getSearchSnippet(
+  note: Note,
+  opts: SearchOptions,
+  maxLength = DEFAULT_SNIPPET_LENGTH,
+): NoteSnippet | null {
+  if (!opts.query) {
+    return null;
+  }
+
+  const patterns = getSnippetPatterns(opts);
+  const snippet = extractSnippet(note.content, patterns, maxLength);
+  if (snippet) {
+    return { kind: 'content', ...snippet };
+  }
+
+  if (opts.regex) {
+    return null;
+  }
+
+  const tag = note.tags.find((candidate) => patterns.some((pattern) => pattern.test(candidate)));
+  return tag ? { kind: 'tag', tag } : null;
+}
+
+/**
+ * Cut the line containing the first match down to maxLength characters around
+ * it, marking truncation with an ellipsis. Highlights cover every match in the
+ * returned text.
+ */
+export function extractSnippet(
+  content: string,
+  patterns: RegExp[],
+  maxLength = DEFAULT_SNIPPET_LENGTH,
+): { text: string; highlights: CharRange[] } | null {
+  const firstLineEnd = content.indexOf('\n');
+  const bodyStart = firstLineEnd >= 0 ? firstLineEnd + 1 : content.length;
+  const match = findFirstMatch(content, patterns, bodyStart) ?? findFirstMatch(content, patterns, 0);
+  if (!match) {
+    return null;
+  }
+
+  let lineStart = content.lastIndexOf('\n', match.from - 1) + 1;
+  let lineEnd = content.indexOf('\n', match.to);
+  if (lineEnd < 0) {
+    lineEnd = content.length;
+  }
+  while (lineStart < match.from && /\s/.test(content[lineStart])) {
+    lineStart += 1;
+  }
+  while (lineEnd > match.to && /\s/.test(content[lineEnd - 1])) {
+    lineEnd -= 1;
+  }
+
+  let start = lineStart;
+  let end = lineEnd;
+  if (end - start > maxLength) {
+    const matchLength = match.to - match.from;
+    start = Math.max(lineStart, match.from - Math.floor(Math.max(0, maxLength - matchLength) / 2));
+    end = Math.min(lineEnd, start + maxLength);
+    start = Math.max(lineStart, end - maxLength);
+  }
+
+  const prefix = start > lineStart ? '…' : '';
+  const suffix = end < lineEnd ? '…' : '';
+  const window = content.slice(start, end);
+  const highlights = findAllMatches(window, patterns).map((range) => ({
+    from: range.from + prefix.length,
+    to: range.to + prefix.length,
+  }));
+
+  return { text: `${prefix}${window}${suffix}`, highlights };
+}
+
+function getSnippetPatterns(opts: SearchOptions): RegExp[] {
+  const query = opts.query ?? '';
+  if (opts.regex) {
+    return [compileSearchPattern(query, opts)];
+  }
+
+  const terms = opts.boolean ? getPositiveTerms(parseQuery(query)) : [query];
+  return terms.filter((term) => term.length > 0).map((term) => compileTermPattern(term, opts));
+}
+
+function findFirstMatch(content: string, patterns: RegExp[], fromIndex: number): CharRange | null {
+  const matches = findAllMatches(content.slice(fromIndex), patterns);
+  const first = matches[0];
+  return first ? { from: first.from + fromIndex, to: first.to + fromIndex } : null;
+}
+
+function findAllMatches(text: string, patterns: RegExp[]): CharRange[] {
+  const ranges: CharRange[] = [];
+  for (const pattern of patterns) {
+    const global = new RegExp(pattern.source, pattern.flags.includes('g') ? pattern.flags : `${pattern.flags}g`);
+    for (const match of text.matchAll(global)) {
+      if (match[0].length > 0 && match.index !== undefined) {
+        ranges.push({ from: match.index, to: match.index + match[0].length });
+      }
+    }
+  }
+
+  ranges.sort((a, b) => a.from - b.from || b.to - a.to);
+  const merged: CharRange[] = [];
+  for (const range of ranges) {
+    const last = merged[merged.length - 1];
+    if (last && range.from <= last.to) {
+      last.to = Math.max(last.to, range.to);
+    } else {
+      merged.push({ ...range });
+    }
+  }
+  return merged;
+}
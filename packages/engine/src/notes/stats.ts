@@ -0,0 +1,37 @@
+import type { Note, NoteStats, NoteStatsEntry } from '../types.js';
+import { getSortedTags } from './search.js';
+
+export const DEFAULT_TOP_TAGS = 10;
+
+function countWords(content: string): number {
+  return content.split(/\s+/).filter(Boolean).length;
+}
+
+function toStatsEntry(note: Note): NoteStatsEntry {
+  return { id: note.id, title: note.title, created: note.created };
+}
+
+/**
+ * Aggregate figures for a set of notes. Months are `YYYY-MM` in UTC, taken from
+ * each note's created timestamp, and listed oldest first.
+ */
+export function computeStats(notes: Note[], topTagLimit = DEFAULT_TOP_TAGS): NoteStats {
+  const byCreated = [...notes].sort((a, b) => a.created.localeCompare(b.created));
+  const monthCounts = new Map<string, number>();
+
+  for (const note of byCreated) {
+    const month = note.created.slice(0, 7);
+    monthCounts.set(month, (monthCounts.get(month) ?? 0) + 1);
+  }
+
+  return {
+    totalNotes: notes.length,
+    totalWords: notes.reduce((sum, note) => sum + countWords(note.content), 0),
+    totalComments: notes.reduce((sum, note) => sum + note.comments.length, 0),
+    untaggedNotes: notes.filter((note) => note.tags.length === 0).length,
+    topTags: getSortedTags(notes).slice(0, topTagLimit),
+    oldest: byCreated.length > 0 ? toStatsEntry(byCreated[0]) : null,
+    newest: byCreated.length > 0 ? toStatsEntry(byCreated[byCreated.length - 1]) : null,
+    notesPerMonth: [...monthCounts].map(([month, count]) => ({ month, count })),
+  };
+}
@@ -3,58 +3,208 @@ import path from 'node:path';
 import { ulid } from 'ulid';
 import type {
   AddCommentPayload,
+  AttachFilePayload,
+  AttachFileResult,
   CommentAnchor,
+  CommentFilter,
   CommentMutationResult,
+  CommentWithNote,
   CreateDirectoryPayload,
+  CloneNotePayload,
   CreateNotePayload,
+  CreateNoteResult,
   DeleteCommentPayload,
   DeleteDirectoryPayload,
   DeleteNotePayload,
   DirectoryMutationResult,
+  EditCommentPayload,
+  ImportNotesPayload,
+  ImportNotesResult,
+  MergeNotesPayload,
   MoveNotePayload,
+  MoveNoteToNotebookPayload,
+  NewNoteComment,
   Note,
+  NoteAliasPayload,
+  NoteHistoryResult,
+  NoteRevisionResult,
+  NoteComment,
+  NotebookSummary,
+  NoteLookupResult,
   NotesListResult,
   OperationResult,
+  ReattachCommentPayload,
+  RenameNotePayload,
+  RestoreNotePayload,
+  RetagNotesPayload,
+  RetagNotesResult,
+  RenameTagPayload,
+  DeleteTagPayload,
+  DiagnoseNotesPayload,
+  DiagnoseNotesResult,
+  NormalizeCommentsResult,
+  MigrateNotesResult,
+  ReindexResult,
+  SearchOptions,
+  NoteProblem,
+  SavedSearch,
+  SavedSearchRunResult,
+  SaveSearchPayload,
+  SetCommentResolvedPayload,
+  SetNoteArchivedPayload,
+  SetNotePinnedPayload,
+  SetNoteEncryptionPayload,
+  SetTaskDonePayload,
   UpdateNoteMetadataPayload,
   UpdateNotePayload,
 } from '../types.js';
-import { slugify } from '../utils/slugify.js';
-import { normalizeTags, normalizeContent } from '../utils/normalization.js';
+import { slugifyTitle } from '../utils/slugify.js';
+import { normalizeTags, normalizeContent, normalizeLineEndings } from '../utils/normalization.js';
 import { normalizeAffinity } from '../utils/normalization.js';
-import { buildAnchorFromRange } from '../comments/anchoring.js';
-import { remapCommentsForEdit } from '../comments/transformation.js';
+import { applyMetaChanges, getMetaChangesError, normalizeMeta } from '../utils/meta.js';
+import { replaceLineInContent } from '../utils/lines.js';
+import { parseDueDate } from '../utils/dates.js';
+import { normalizeAlias, normalizeAliases } from '../utils/aliases.js';
+import { addTagsToList, removeTagsFromList, renameTagInList } from '../utils/tags.js';
+import { logDebug, logError } from '../utils/log.js';
+import { buildAnchorFromRange, getUniqueMatchRange } from '../comments/anchoring.js';
+import { normalizeComment, relocateComments, remapCommentsForEdit } from '../comments/transformation.js';
+import { collectComments } from '../comments/collect.js';
+import { extractLinks, resolveLinkTarget } from './links.js';
+import { NoteCache, getNoteSignature } from './cache.js';
+import { SearchIndex, getIndexQuery, getSearchIndexPath } from './searchindex.js';
+import { lookupNote } from './lookup.js';
+import { extractTasks, setTaskLineDone } from './tasks.js';
+import { mergeNoteContent } from './merge.js';
+import { decryptNoteContent, encryptNoteContent } from './crypto.js';
 import {
+  checkDuplicateAliases,
+  checkDuplicateIds,
+  checkNote,
+  checkNoteFiles,
+  findDuplicateIds,
+  hasOutOfRangeAnchor,
+} from './doctor.js';
+import { search } from './search.js';
+import {
+  INTERNAL_DIRECTORY,
   formatRelativePath,
   normalizeDirectoryInput,
   resolveNotesPath,
   getAllMarkdownFiles,
   getAllDirectories,
   generateUniqueFilePath,
+  getTitledFilePath,
   cleanupEmptyParentDirectories,
   findNoteRecordById,
   compareNotes,
   parseNoteFile,
 } from '../storage/filesystem.js';
 import {
+  NOTE_SCHEMA_VERSION,
   getNoteSidecarPath,
+  getSidecarMetadata,
+  getSidecarSchema,
+  readSidecarData,
+  toCommentRecord,
   writeSidecarData,
 } from '../storage/sidecar.js';
+import { extractHeadingTitle, replaceNoteTitle } from '../storage/markdown.js';
+import { createGitHistoryReader } from '../storage/git.js';
+import type { CommitFunction, HistoryReader } from '../storage/git.js';
+import type { MarkdownFileRecord } from '../storage/filesystem.js';
+import { DEFAULT_LOCK_TIMEOUT_MS, acquireLocks } from '../storage/lock.js';
+import type { ReleaseLock } from '../storage/lock.js';
+import { writeFileAtomic } from '../storage/atomic.js';
+import {
+  copyAttachment,
+  getAttachmentsDirectory,
+  moveAttachments,
+  removeAttachments,
+} from '../storage/attachments.js';
+import {
+  isValidSavedSearchName,
+  normalizeSearchOptions,
+  readSavedSearches,
+  resolveSearchDates,
+  writeSavedSearches,
+} from '../storage/searches.js';
+import { listTemplates, readTemplate, renderTemplate } from '../storage/templates.js';
+import { getImportFileName, readImportSource } from '../storage/import.js';
 
 export interface NoteStoreOptions {
   notesDirectory: string;
+  notebook?: string;
+  /** Called after each successful mutation, e.g. to commit the change to git. */
+  commit?: CommitFunction;
+  /** How long a mutation waits for another process's lock, in milliseconds. */
+  lockTimeout?: number;
+  /** Source of note history; defaults to reading git. */
+  history?: HistoryReader;
 }
 
+/** Lock name for operations that scan or reshape the whole notes root. */
+const STORE_LOCK = 'store';
+
 export class NoteStore {
+  private rootDir: string;
+  private notebook: string | null;
   private notesDir: string;
+  private commit: CommitFunction | null;
+  private lockTimeout: number;
+  private history: HistoryReader;
+  private noteCache = new NoteCache();
 
   constructor(options: NoteStoreOptions) {
-    this.notesDir = options.notesDirectory;
+    this.rootDir = options.notesDirectory;
+    this.commit = options.commit ?? null;
+    this.lockTimeout = options.lockTimeout ?? DEFAULT_LOCK_TIMEOUT_MS;
+    this.history = options.history ?? createGitHistoryReader();
+    this.notebook = options.notebook ? normalizeNotebookName(options.notebook) : null;
+    this.notesDir = this.notebook ? path.join(this.rootDir, this.notebook) : this.rootDir;
   }
 
   getNotesDirectory(): string {
     return this.notesDir;
   }
 
+  getNotebook(): string | null {
+    return this.notebook;
+  }
+
+  /**
+   * Returns a store scoped to a notebook, a top-level folder of the notes root.
+   */
+  withNotebook(name: string): NoteStore {
+    return new NoteStore({
+      notesDirectory: this.rootDir,
+      notebook: name,
+      commit: this.commit ?? undefined,
+      lockTimeout: this.lockTimeout,
+      history: this.history,
+    });
+  }
+
+  async listNotebooks(): Promise<NotebookSummary[]> {
+    if (!fs.existsSync(this.rootDir)) {
+      return [];
+    }
+
+    try {
+      return fs
+        .readdirSync(this.rootDir, { withFileTypes: true })
+        .filter((entry) => entry.isDirectory() && !entry.name.startsWith('.'))
+        .map((entry) => ({
+          name: entry.name,
+          noteCount: getAllMarkdownFiles(path.join(this.rootDir, entry.name)).length,
+        }))
+        .sort((a, b) => a.name.localeCompare(b.name));
+    } catch (error) {
+      logError('Error listing notebooks:', error);
+      return [];
+    }
+  }
+
   async listNotes(): Promise<NotesListResult> {
     if (!fs.existsSync(this.notesDir)) {
       return { notes: [], directories: [], noDirectory: false };
@@ -66,18 +216,69 @@ export class NoteStore {
         a.localeCompare(b),
       );
 
+      this.noteCache.retain(files);
       const notes = files
-        .map(({ fullPath, relativePath }) => parseNoteFile(fullPath, relativePath))
+        .map((record) => this.noteCache.load(record))
         .filter((note): note is Note => note !== null)
         .sort(compareNotes);
 
       return { notes, directories, noDirectory: false };
     } catch (error) {
-      console.error('Error listing notes:', error);
+      logError('Error listing notes:', error);
       return { notes: [], directories: [], noDirectory: false };
     }
   }
 
+  /**
+   * Notes that may match the options' query, for passing to search(). The
+   * search index rules out, without reading them, notes that lack part of a
+   * term; every other filter is left to search(). Without a query the index
+   * can use, this is every note.
+   */
+  async listSearchCandidates(options: SearchOptions): Promise<Note[]> {
+    const query = getIndexQuery(options);
+    if (!query || !fs.existsSync(this.notesDir)) {
+      return (await this.listNotes()).notes;
+    }
+
+    try {
+      const files = getAllMarkdownFiles(this.notesDir);
+      this.noteCache.retain(files);
+      const index = this.refreshSearchIndex();
+      return files
+        .filter((record) => index.mayMatch(this.getIndexPath(record.fullPath), query))
+        .map((record) => this.noteCache.load(record))
+        .filter((note): note is Note => note !== null)
+        .sort(compareNotes);
+    } catch (error) {
+      logError('Error searching the index:', error);
+      return (await this.listNotes()).notes;
+    }
+  }
+
+  /** Rebuild the search index at `.agentnotes/index.json` from every note, ignoring the saved one. */
+  async rebuildSearchIndex(): Promise<ReindexResult> {
+    if (!fs.existsSync(this.rootDir)) {
+      return { success: false, error: 'Notes directory not found', noteCount: 0 };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore();
+      const index = this.refreshSearchIndex(true);
+      return { success: true, noteCount: index.size };
+    } catch (error) {
+      logError('Error rebuilding search index:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+        noteCount: 0,
+      };
+    } finally {
+      release?.();
+    }
+  }
+
   async getNote(noteId: string): Promise<Note | null> {
     if (!fs.existsSync(this.notesDir)) {
       return null;
@@ -89,14 +290,172 @@ export class NoteStore {
         return null;
       }
 
-      return parseNoteFile(record.fullPath, record.relativePath);
+      return this.noteCache.load(record);
     } catch (error) {
-      console.error('Error getting note:', error);
+      logError('Error getting note:', error);
       return null;
     }
   }
 
-  async createNote(payload: CreateNotePayload): Promise<CommentMutationResult> {
+  /**
+   * Look a note up by ID or title, falling back to fuzzy title matching.
+   * Several equally good matches are reported as ambiguous, never guessed.
+   */
+  async findNote(idOrTitle: string): Promise<NoteLookupResult> {
+    if (idOrTitle.endsWith('.md')) {
+      const note = await this.getNote(idOrTitle);
+      if (note) {
+        return { note, ambiguous: false, candidates: [] };
+      }
+    }
+
+    const { notes } = await this.listNotes();
+    return lookupNote(notes, idOrTitle);
+  }
+
+  async resolveLink(ref: string): Promise<Note | null> {
+    const { notes } = await this.listNotes();
+    return resolveLinkTarget(notes, ref);
+  }
+
+  /** Comments across all notes, joined to their notes, for triage in one place. */
+  async getAllComments(filter: CommentFilter = {}): Promise<CommentWithNote[]> {
+    const { notes } = await this.listNotes();
+    return collectComments(notes, filter);
+  }
+
+  /**
+   * Notes whose [[wiki-links]] resolve to the given note, excluding the note itself.
+   */
+  async getBacklinks(noteId: string): Promise<Note[]> {
+    const { notes } = await this.listNotes();
+    if (!notes.some((note) => note.id === noteId)) {
+      return [];
+    }
+
+    return notes.filter(
+      (note) =>
+        note.id !== noteId &&
+        extractLinks(note.content).some((ref) => resolveLinkTarget(notes, ref)?.id === noteId),
+    );
+  }
+
+  async listSavedSearches(): Promise<SavedSearch[]> {
+    try {
+      return readSavedSearches(this.rootDir);
+    } catch (error) {
+      logError('Error reading saved searches:', error);
+      return [];
+    }
+  }
+
+  async saveSearch(payload: SaveSearchPayload): Promise<OperationResult> {
+    if (!fs.existsSync(this.rootDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    const name = payload.name.trim();
+    if (!isValidSavedSearchName(name)) {
+      return { success: false, error: `Invalid saved search name: ${payload.name}` };
+    }
+
+    const options = normalizeSearchOptions(payload.options);
+    try {
+      // Surface bad patterns, queries, and dates now rather than on every run.
+      search([], resolveSearchDates(options));
+    } catch (error) {
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Invalid search options',
+      };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore();
+      const searches = readSavedSearches(this.rootDir);
+      if (searches.some((saved) => saved.name === name)) {
+        return { success: false, error: `A saved search named ${name} already exists` };
+      }
+
+      writeSavedSearches(this.rootDir, [...searches, { name, options }]);
+      this.recordChange(`save search: ${name}`);
+      return { success: true };
+    } catch (error) {
+      logError('Error saving search:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  async deleteSavedSearch(name: string): Promise<OperationResult> {
+    if (!fs.existsSync(this.rootDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore();
+      const searches = readSavedSearches(this.rootDir);
+      const remaining = searches.filter((saved) => saved.name !== name);
+      if (remaining.length === searches.length) {
+        return { success: false, error: `Saved search not found: ${name}` };
+      }
+
+      writeSavedSearches(this.rootDir, remaining);
+      this.recordChange(`delete search: ${name}`);
+      return { success: true };
+    } catch (error) {
+      logError('Error deleting saved search:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Run a saved search against this store's notes, resolving relative date
+   * bounds against the current time. Options the search did not save come
+   * from defaults, so they follow the caller's current ones.
+   */
+  async runSavedSearch(name: string, defaults: SearchOptions = {}): Promise<SavedSearchRunResult> {
+    const saved = (await this.listSavedSearches()).find((entry) => entry.name === name);
+    if (!saved) {
+      return { success: false, error: `Saved search not found: ${name}` };
+    }
+
+    try {
+      const options = resolveSearchDates({ ...defaults, ...saved.options });
+      return { success: true, notes: search(await this.listSearchCandidates(options), options), options };
+    } catch (error) {
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    }
+  }
+
+  async listTemplates(): Promise<string[]> {
+    try {
+      return listTemplates(this.rootDir);
+    } catch (error) {
+      logError('Error reading templates:', error);
+      return [];
+    }
+  }
+
+  async createNote(payload: CreateNotePayload): Promise<CreateNoteResult> {
+    if (this.notebook && fs.existsSync(this.rootDir)) {
+      fs.mkdirSync(this.notesDir, { recursive: true });
+    }
+
     if (!fs.existsSync(this.notesDir)) {
       return { success: false, error: 'Notes directory not found' };
     }
@@ -116,28 +475,219 @@ export class NoteStore {
       return { success: false, error: 'Directory path escapes notes root' };
     }
 
+    if (payload.template && payload.content !== undefined) {
+      return { success: false, error: 'Use either a template or content, not both' };
+    }
+
+    const template = payload.template ? readTemplate(this.rootDir, payload.template) : null;
+    if (payload.template && template === null) {
+      return { success: false, error: `Template not found: ${payload.template}` };
+    }
+
+    const metaError = getMetaChangesError(payload.meta ?? {});
+    if (metaError) {
+      return { success: false, error: metaError };
+    }
+
+    let due: string | undefined;
+    if (payload.due !== undefined) {
+      try {
+        due = parseDueDate(payload.due);
+      } catch (error) {
+        return { success: false, error: (error as Error).message };
+      }
+    }
+
+    if (payload.passphrase && payload.comments && payload.comments.length > 0) {
+      return { success: false, error: 'Comments quote the note text, so an encrypted note cannot start with them' };
+    }
+
+    let release: ReleaseLock | null = null;
     try {
+      release = await this.lockStore();
+      if (payload.reuseExisting) {
+        const existing = this.findNoteByTitle(targetDirectory, title);
+        if (existing) {
+          return { success: true, note: existing, existing: true };
+        }
+      }
       fs.mkdirSync(targetDirectory, { recursive: true });
 
       const nowIso = new Date().toISOString();
       const datePrefix = nowIso.slice(0, 10);
-      const titleSlug = slugify(title) || 'note';
+      const titleSlug = slugifyTitle(title);
       const filePath = generateUniqueFilePath(targetDirectory, `${datePrefix}-${titleSlug}`);
-      const noteContent = `# ${title}\n\n`;
-      fs.writeFileSync(filePath, noteContent, 'utf-8');
-      writeSidecarData(filePath, [], [], 0);
-
       const relativePath = this.getRelativePath(filePath);
+      const noteContent =
+        template !== null
+          ? normalizeLineEndings(renderTemplate(template, { title, date: datePrefix, id: relativePath }))
+          : getInitialContent(title, payload.content);
+
+      let comments: NoteComment[];
+      try {
+        comments = (payload.comments ?? []).map((comment) => buildNewComment(noteContent, comment, nowIso));
+      } catch (error) {
+        return { success: false, error: error instanceof Error ? error.message : 'Invalid comment range' };
+      }
+
+      writeFileAtomic(filePath, payload.passphrase ? encryptNoteContent(noteContent, payload.passphrase) : noteContent);
+      writeSidecarData(filePath, normalizeTags(payload.tags ?? []), comments, comments.length > 0 ? 1 : 0, {
+        created: nowIso,
+        updated: nowIso,
+        meta: normalizeMeta(payload.meta),
+        due,
+        encrypted: Boolean(payload.passphrase),
+      });
+
+      this.recordChange(`add note: ${title}`);
+
       return {
         success: true,
         note: parseNoteFile(filePath, relativePath) ?? undefined,
       };
     } catch (error) {
-      console.error('Error creating note:', error);
+      logError('Error creating note:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Create a note next to an existing one with its content and tags, fresh
+   * timestamps and a filename of its own. Comments and custom fields are
+   * copied only when asked for.
+   */
+  async cloneNote(payload: CloneNotePayload): Promise<CreateNoteResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    if (payload.title !== undefined && !payload.title.trim()) {
+      return { success: false, error: 'Title cannot be empty' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore([payload.noteId]);
+      const record = findNoteRecordById(this.notesDir, payload.noteId);
+      const source = record ? parseNoteFile(record.fullPath, record.relativePath) : null;
+      if (!record || !source) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      const title = payload.title?.trim() || source.title;
+      const sourceContent = fs.readFileSync(record.fullPath, 'utf-8');
+      const content = title === source.title ? sourceContent : replaceNoteTitle(sourceContent, title);
+      const nowIso = new Date().toISOString();
+      const filePath = generateUniqueFilePath(
+        path.dirname(record.fullPath),
+        `${nowIso.slice(0, 10)}-${slugifyTitle(title)}`,
+      );
+
+      // Anchors are carried over the retitled heading before the comments get new IDs.
+      const remapped = payload.withComments
+        ? remapCommentsForEdit(source.comments, sourceContent, content, source.commentRev)
+        : { comments: [], nextRev: 0 };
+      const comments = remapped.comments.map((comment) => ({ ...comment, id: ulid() }));
+
+      writeFileAtomic(filePath, content);
+      writeSidecarData(filePath, source.tags, comments, remapped.nextRev, {
+        created: nowIso,
+        updated: nowIso,
+        meta: payload.withFields ? source.meta : undefined,
+        encrypted: source.encrypted,
+      });
+
+      this.recordChange(`clone note: ${source.title} -> ${title}`);
+
+      return { success: true, note: parseNoteFile(filePath, this.getRelativePath(filePath)) ?? undefined };
+    } catch (error) {
+      logError('Error cloning note:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Copy a directory of markdown files in as notes, keeping their relative
+   * folders. A file whose `<date>-<slug>.md` name is already taken, by an
+   * existing note or an earlier file in the same import, is skipped.
+   */
+  async importNotes(payload: ImportNotesPayload): Promise<ImportNotesResult> {
+    const result: ImportNotesResult = { success: true, imported: [], skipped: [] };
+
+    if (this.notebook && fs.existsSync(this.rootDir)) {
+      fs.mkdirSync(this.notesDir, { recursive: true });
+    }
+
+    if (!fs.existsSync(this.notesDir)) {
+      return { ...result, success: false, error: 'Notes directory not found' };
+    }
+
+    const sourceDir = path.resolve(payload.sourceDirectory);
+    if (!fs.existsSync(sourceDir) || !fs.statSync(sourceDir).isDirectory()) {
+      return { ...result, success: false, error: `Import directory not found: ${payload.sourceDirectory}` };
+    }
+
+    const relativeToNotes = path.relative(path.resolve(this.rootDir), sourceDir);
+    if (!relativeToNotes.startsWith('..') && !path.isAbsolute(relativeToNotes)) {
+      return { ...result, success: false, error: 'Import directory is inside the notes directory' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore();
+      const planned = new Set<string>();
+      for (const record of getAllMarkdownFiles(sourceDir)) {
+        const data = readImportSource(record.fullPath);
+        const directory = path.dirname(record.relativePath);
+        const targetPath = path.join(this.notesDir, directory, getImportFileName(data));
+        const noteId = this.getRelativePath(targetPath);
+
+        if (planned.has(targetPath) || fs.existsSync(targetPath) || fs.existsSync(getNoteSidecarPath(targetPath))) {
+          result.skipped.push({ source: record.relativePath, reason: `${noteId} already exists` });
+          continue;
+        }
+
+        planned.add(targetPath);
+        result.imported.push({ source: record.relativePath, noteId });
+        if (payload.dryRun) {
+          continue;
+        }
+
+        fs.mkdirSync(path.dirname(targetPath), { recursive: true });
+        writeFileAtomic(targetPath, data.content);
+        writeSidecarData(
+          targetPath,
+          [...data.tags, ...(payload.tags ?? [])],
+          data.comments,
+          data.commentRev,
+          { created: data.created, updated: data.updated, meta: data.meta },
+        );
+      }
+
+      if (!payload.dryRun && result.imported.length > 0) {
+        this.recordChange(`import ${result.imported.length} note${result.imported.length === 1 ? '' : 's'}`);
+      }
+
+      return result;
+    } catch (error) {
+      logError('Error importing notes:', error);
       return {
+        ...result,
         success: false,
         error: error instanceof Error ? error.message : 'Unknown error',
       };
+    } finally {
+      release?.();
     }
   }
 
@@ -146,7 +696,10 @@ export class NoteStore {
       return { success: false, error: 'Notes directory not found' };
     }
 
+    let release: ReleaseLock | null = null;
     try {
+      // A new heading renames the file, and picking the free name needs the store lock.
+      release = await this.lockStore([payload.noteId]);
       const record = findNoteRecordById(this.notesDir, payload.noteId);
       if (!record) {
         return { success: false, error: 'Note not found' };
@@ -157,34 +710,97 @@ export class NoteStore {
         return { success: false, error: 'Failed to parse current note' };
       }
 
+      if ((currentNote.encrypted || payload.encrypt) && !payload.passphrase) {
+        return {
+          success: false,
+          error: currentNote.encrypted ? 'Note is encrypted; a passphrase is required' : 'Encrypting needs a passphrase',
+        };
+      }
+
+      if (payload.encrypt && currentNote.encrypted) {
+        return { success: false, error: 'Note is already encrypted' };
+      }
+
+      if (payload.encrypt && currentNote.comments.length > 0) {
+        return { success: false, error: 'Note has comments quoting its text; delete them before encrypting' };
+      }
+
       const updatedContent = normalizeContent(payload.content);
-      let nextComments = currentNote.comments;
-      let nextRev = currentNote.commentRev;
+      const destinationPath = getRetitledFilePath(record.fullPath, currentNote, updatedContent);
+      this.writeNoteContent(
+        record.fullPath,
+        currentNote,
+        updatedContent,
+        destinationPath,
+        currentNote.encrypted || payload.encrypt ? payload.passphrase : undefined,
+      );
+      this.recordChange(`${payload.encrypt ? 'update and encrypt' : 'update'} note: ${currentNote.title}`);
+
+      return {
+        success: true,
+        note: parseNoteFile(destinationPath, this.getRelativePath(destinationPath)) ?? undefined,
+      };
+    } catch (error) {
+      logError('Error updating note:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Check or uncheck the task on one line, rewriting only that line. Comment
+   * anchors are remapped as for any other content edit.
+   */
+  async setTaskDone(payload: SetTaskDonePayload): Promise<CommentMutationResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockNotes([payload.noteId]);
+      const record = findNoteRecordById(this.notesDir, payload.noteId);
+      if (!record) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      const currentNote = parseNoteFile(record.fullPath, record.relativePath);
+      if (!currentNote) {
+        return { success: false, error: 'Failed to parse current note' };
+      }
+
+      const task = extractTasks(currentNote.content).find((entry) => entry.line === payload.line);
+      if (!task) {
+        return { success: false, error: `No task on line ${payload.line}` };
+      }
 
-      if (updatedContent !== currentNote.content) {
-        const remap = remapCommentsForEdit(
-          currentNote.comments,
+      if (task.done !== payload.done) {
+        const line = currentNote.content.split('\n')[payload.line - 1];
+        const updatedContent = replaceLineInContent(
           currentNote.content,
-          updatedContent,
-          currentNote.commentRev,
+          payload.line,
+          setTaskLineDone(line, payload.done),
         );
-        nextComments = remap.comments;
-        nextRev = remap.nextRev;
+        this.writeNoteContent(record.fullPath, currentNote, updatedContent);
+        this.recordChange(`${payload.done ? 'check' : 'uncheck'} task: ${currentNote.title}`);
       }
 
-      fs.writeFileSync(record.fullPath, updatedContent, 'utf-8');
-      writeSidecarData(record.fullPath, currentNote.tags, nextComments, nextRev);
-
       return {
         success: true,
         note: parseNoteFile(record.fullPath, record.relativePath) ?? undefined,
       };
     } catch (error) {
-      console.error('Error updating note:', error);
+      logError('Error updating task:', error);
       return {
         success: false,
         error: error instanceof Error ? error.message : 'Unknown error',
       };
+    } finally {
+      release?.();
     }
   }
 
@@ -193,9 +809,23 @@ export class NoteStore {
       return { success: false, error: 'Notes directory not found' };
     }
 
-    const normalizedTags = normalizeTags(payload.tags);
+    const metaError = getMetaChangesError(payload.meta ?? {});
+    if (metaError) {
+      return { success: false, error: metaError };
+    }
+
+    let due: string | null | undefined = payload.due;
+    if (typeof due === 'string') {
+      try {
+        due = parseDueDate(due);
+      } catch (error) {
+        return { success: false, error: (error as Error).message };
+      }
+    }
 
+    let release: ReleaseLock | null = null;
     try {
+      release = await this.lockNotes([payload.noteId]);
       const record = findNoteRecordById(this.notesDir, payload.noteId);
       if (!record) {
         return { success: false, error: 'Note not found' };
@@ -206,55 +836,869 @@ export class NoteStore {
         return { success: false, error: 'Failed to parse current note' };
       }
 
+      const normalizedTags = normalizeTags(payload.tags ?? currentNote.tags);
+      const meta = payload.meta ? applyMetaChanges(currentNote.meta, payload.meta) : currentNote.meta;
+      const nextDue = due === undefined ? currentNote.due : due ?? undefined;
+      const changed =
+        normalizedTags.join('\n') !== currentNote.tags.join('\n') ||
+        JSON.stringify(meta ?? {}) !== JSON.stringify(currentNote.meta ?? {}) ||
+        nextDue !== currentNote.due;
       writeSidecarData(
         record.fullPath,
         normalizedTags,
         currentNote.comments,
         currentNote.commentRev,
+        {
+          ...getSidecarMetadata(currentNote),
+          updated: changed ? new Date().toISOString() : currentNote.updated,
+          meta,
+          due: nextDue,
+        },
       );
+      const action = payload.meta || payload.due !== undefined ? 'update metadata' : 'update tags';
+      this.recordChange(`${action}: ${currentNote.title}`);
 
       return {
         success: true,
         note: parseNoteFile(record.fullPath, record.relativePath) ?? undefined,
       };
     } catch (error) {
-      console.error('Error updating note metadata:', error);
-      return {
-        success: false,
-        error: error instanceof Error ? error.message : 'Unknown error',
-      };
+      logError('Error updating note metadata:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /** Copy a file into the note's attachments directory and record it in the sidecar. */
+  async attachFile(payload: AttachFilePayload): Promise<AttachFileResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    const sourcePath = path.resolve(payload.filePath);
+    if (!fs.existsSync(sourcePath) || !fs.statSync(sourcePath).isFile()) {
+      return { success: false, error: `File not found: ${payload.filePath}` };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockNotes([payload.noteId]);
+      const record = findNoteRecordById(this.notesDir, payload.noteId);
+      if (!record) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      const currentNote = parseNoteFile(record.fullPath, record.relativePath);
+      if (!currentNote) {
+        return { success: false, error: 'Failed to parse current note' };
+      }
+
+      const attachment = copyAttachment(sourcePath, getAttachmentsDirectory(this.rootDir, record.fullPath));
+      writeSidecarData(record.fullPath, currentNote.tags, currentNote.comments, currentNote.commentRev, {
+        ...getSidecarMetadata(currentNote),
+        updated: new Date().toISOString(),
+        attachments: [...(currentNote.attachments ?? []), attachment],
+      });
+      this.recordChange(`attach file: ${currentNote.title}`);
+
+      return {
+        success: true,
+        attachment,
+        note: parseNoteFile(record.fullPath, record.relativePath) ?? undefined,
+      };
+    } catch (error) {
+      logError('Error attaching file:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Archive or unarchive a note. Archiving only hides the note from search, so
+   * its updated time is left alone.
+   */
+  async setNoteArchived(payload: SetNoteArchivedPayload): Promise<CommentMutationResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockNotes([payload.noteId]);
+      const record = findNoteRecordById(this.notesDir, payload.noteId);
+      if (!record) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      const currentNote = parseNoteFile(record.fullPath, record.relativePath);
+      if (!currentNote) {
+        return { success: false, error: 'Failed to parse current note' };
+      }
+
+      if (Boolean(currentNote.archivedAt) === payload.archived) {
+        return { success: false, error: payload.archived ? 'Note is already archived' : 'Note is not archived' };
+      }
+
+      writeSidecarData(record.fullPath, currentNote.tags, currentNote.comments, currentNote.commentRev, {
+        ...getSidecarMetadata(currentNote),
+        archivedAt: payload.archived ? new Date().toISOString() : undefined,
+      });
+      this.recordChange(`${payload.archived ? 'archive' : 'unarchive'} note: ${currentNote.title}`);
+
+      return {
+        success: true,
+        note: parseNoteFile(record.fullPath, record.relativePath) ?? undefined,
+      };
+    } catch (error) {
+      logError('Error archiving note:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Pin or unpin a note. Pinning only changes where the note is listed, so its
+   * updated time is left alone.
+   */
+  async setNotePinned(payload: SetNotePinnedPayload): Promise<CommentMutationResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockNotes([payload.noteId]);
+      const record = findNoteRecordById(this.notesDir, payload.noteId);
+      if (!record) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      const currentNote = parseNoteFile(record.fullPath, record.relativePath);
+      if (!currentNote) {
+        return { success: false, error: 'Failed to parse current note' };
+      }
+
+      if (Boolean(currentNote.pinned) === payload.pinned) {
+        return { success: false, error: payload.pinned ? 'Note is already pinned' : 'Note is not pinned' };
+      }
+
+      writeSidecarData(record.fullPath, currentNote.tags, currentNote.comments, currentNote.commentRev, {
+        ...getSidecarMetadata(currentNote),
+        pinned: payload.pinned,
+      });
+      this.recordChange(`${payload.pinned ? 'pin' : 'unpin'} note: ${currentNote.title}`);
+
+      return {
+        success: true,
+        note: parseNoteFile(record.fullPath, record.relativePath) ?? undefined,
+      };
+    } catch (error) {
+      logError('Error pinning note:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Encrypt or decrypt a note's body in place. A note with comments is not
+   * encrypted: their anchors quote the body, and the sidecar is plaintext.
+   */
+  async setNoteEncryption(payload: SetNoteEncryptionPayload): Promise<CommentMutationResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockNotes([payload.noteId]);
+      const record = findNoteRecordById(this.notesDir, payload.noteId);
+      if (!record) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      const currentNote = parseNoteFile(record.fullPath, record.relativePath);
+      if (!currentNote) {
+        return { success: false, error: 'Failed to parse current note' };
+      }
+
+      if (Boolean(currentNote.encrypted) === payload.encrypted) {
+        return { success: false, error: payload.encrypted ? 'Note is already encrypted' : 'Note is not encrypted' };
+      }
+
+      if (payload.encrypted && currentNote.comments.length > 0) {
+        return { success: false, error: 'Note has comments quoting its text; delete them before encrypting' };
+      }
+
+      const content = payload.encrypted
+        ? encryptNoteContent(currentNote.content, payload.passphrase)
+        : decryptNoteContent(currentNote.content, payload.passphrase);
+      writeFileAtomic(record.fullPath, content);
+      writeSidecarData(record.fullPath, currentNote.tags, currentNote.comments, currentNote.commentRev, {
+        ...getSidecarMetadata(currentNote),
+        updated: new Date().toISOString(),
+        encrypted: payload.encrypted,
+      });
+      this.recordChange(`${payload.encrypted ? 'encrypt' : 'decrypt'} note: ${currentNote.title}`);
+
+      return {
+        success: true,
+        note: parseNoteFile(record.fullPath, record.relativePath) ?? undefined,
+      };
+    } catch (error) {
+      logError('Error changing note encryption:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Scan every note for problems. With `fix`, the fixable ones are repaired:
+   * missing sidecars are written, timestamps backfilled from the file,
+   * drifted filenames renamed to match the title, and out-of-range comments
+   * detached so `comment reattach` can find their quote again.
+   */
+  async diagnoseNotes(payload: DiagnoseNotesPayload = {}): Promise<DiagnoseNotesResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found', problems: [] };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = payload.fix ? await this.lockStore() : null;
+      const problems: NoteProblem[] = checkDuplicateIds(getAllMarkdownFiles(this.notesDir));
+      const notes: Note[] = [];
+
+      if (payload.fix) {
+        for (const problem of problems) {
+          const fullPath = resolveNotesPath(this.notesDir, problem.noteId);
+          if (fullPath) {
+            this.moveToFreshId(fullPath);
+            problem.fixed = true;
+          }
+        }
+      }
+
+      for (const record of getAllMarkdownFiles(this.notesDir)) {
+        const fileCheck = checkNoteFiles(record);
+        problems.push(...fileCheck.problems);
+        // Parsing writes a missing sidecar, so a plain check stops short of it.
+        if (!fileCheck.readable && !(payload.fix && fileCheck.problems[0]?.kind === 'missing-sidecar')) {
+          continue;
+        }
+
+        const note = parseNoteFile(record.fullPath, record.relativePath);
+        if (!note) {
+          continue;
+        }
+
+        const noteProblems = checkNote(note, record.fullPath);
+        problems.push(...noteProblems);
+        if (!payload.fix) {
+          notes.push(note);
+          continue;
+        }
+
+        const found = [...fileCheck.problems, ...noteProblems];
+        if (found.some((problem) => problem.kind === 'missing-timestamp' || problem.kind === 'comment-range')) {
+          // The parsed note already carries file-time fallbacks for missing timestamps.
+          const comments = note.comments.map((comment) =>
+            !note.encrypted && hasOutOfRangeAnchor(comment, note.content)
+              ? { ...comment, status: 'detached' as const, anchor: { ...comment.anchor, from: 0, to: 0 } }
+              : comment,
+          );
+          writeSidecarData(record.fullPath, note.tags, comments, note.commentRev, getSidecarMetadata(note));
+        }
+
+        let noteId = note.id;
+        if (found.some((problem) => problem.kind === 'filename-mismatch')) {
+          const destinationPath = getTitledFilePath(record.fullPath, note.title, note.created);
+          const uniquePath = fs.existsSync(destinationPath) || fs.existsSync(getNoteSidecarPath(destinationPath))
+            ? generateUniqueFilePath(path.dirname(destinationPath), path.basename(destinationPath, '.md'))
+            : destinationPath;
+          this.moveNoteFiles(record.fullPath, uniquePath);
+          noteId = this.getRelativePath(uniquePath);
+        }
+
+        for (const problem of found) {
+          problem.fixed = problem.fixable;
+        }
+        notes.push({ ...note, id: noteId });
+      }
+
+      problems.push(...checkDuplicateAliases(notes));
+      const fixed = problems.filter((problem) => problem.fixed).length;
+      if (fixed > 0) {
+        this.recordChange(`doctor: fix ${fixed} problem${fixed === 1 ? '' : 's'}`);
+      }
+
+      return { success: true, problems };
+    } catch (error) {
+      logError('Error checking notes:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+        problems: [],
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Rewrite every sidecar whose comments are not stored in canonical form:
+   * legacy `start`/`end`/`exact` anchor keys, missing quotes, hashes or
+   * statuses, or a `comment_rev` behind its anchors. Encrypted notes are
+   * skipped, since their anchors point into plaintext that is not loaded.
+   */
+  async normalizeAllComments(): Promise<NormalizeCommentsResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found', changed: [] };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore();
+      const changed: string[] = [];
+
+      for (const record of getAllMarkdownFiles(this.notesDir)) {
+        if (!fs.existsSync(getNoteSidecarPath(record.fullPath))) {
+          continue;
+        }
+        const raw = readSidecarData(record.fullPath);
+        const note = parseNoteFile(record.fullPath, record.relativePath);
+        if (!note || note.encrypted || note.comments.length === 0) {
+          continue;
+        }
+
+        const commentRev = Math.max(note.commentRev, ...note.comments.map((comment) => comment.anchor.rev));
+        const comments = note.comments.map((comment) => normalizeComment(comment, note.content, commentRev));
+        const stored = JSON.stringify({ comments: raw.comments, rev: raw.comment_rev ?? 0 });
+        const normalized = JSON.stringify({ comments: comments.map(toCommentRecord), rev: commentRev });
+        if (stored === normalized) {
+          continue;
+        }
+
+        writeSidecarData(record.fullPath, note.tags, comments, commentRev, getSidecarMetadata(note));
+        changed.push(note.id);
+      }
+
+      if (changed.length > 0) {
+        this.recordChange(`doctor: normalize comments in ${changed.length} note${changed.length === 1 ? '' : 's'}`);
+      }
+      return { success: true, changed };
+    } catch (error) {
+      logError('Error normalizing comments:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+        changed: [],
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Rewrite every sidecar older than NOTE_SCHEMA_VERSION in the current
+   * layout. Notes load fine without this; it only saves redoing the upgrade
+   * on every read. Sidecars from a newer version are left alone.
+   */
+  async migrateAllNotes(): Promise<MigrateNotesResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found', migrated: [] };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore();
+      const migrated: string[] = [];
+
+      for (const record of getAllMarkdownFiles(this.notesDir)) {
+        if (!fs.existsSync(getNoteSidecarPath(record.fullPath))) {
+          continue;
+        }
+        if (getSidecarSchema(readSidecarData(record.fullPath)) >= NOTE_SCHEMA_VERSION) {
+          continue;
+        }
+        const note = parseNoteFile(record.fullPath, record.relativePath);
+        if (!note) {
+          continue;
+        }
+
+        writeSidecarData(record.fullPath, note.tags, note.comments, note.commentRev, getSidecarMetadata(note));
+        migrated.push(note.id);
+      }
+
+      if (migrated.length > 0) {
+        this.recordChange(
+          `doctor: migrate ${migrated.length} note${migrated.length === 1 ? '' : 's'} to schema ${NOTE_SCHEMA_VERSION}`,
+        );
+      }
+      return { success: true, migrated };
+    } catch (error) {
+      logError('Error migrating notes:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+        migrated: [],
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Fail when two notes have IDs that differ only in case, naming both paths.
+   * Such files overwrite each other on case-insensitive filesystems.
+   */
+  checkNoteIds(): OperationResult {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: true };
+    }
+
+    const duplicates = findDuplicateIds(getAllMarkdownFiles(this.notesDir));
+    if (duplicates.length === 0) {
+      return { success: true };
+    }
+
+    return {
+      success: false,
+      error: `Duplicate note IDs: ${duplicates.map((ids) => ids.join(' and ')).join('; ')}`,
+    };
+  }
+
+  /** Move a note, with its sidecar and attachments, to a fresh ID in the same directory. */
+  async reassignNoteId(noteId: string): Promise<CommentMutationResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore();
+      const record = findNoteRecordById(this.notesDir, noteId);
+      if (!record) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      const newPath = this.moveToFreshId(record.fullPath);
+      const note = parseNoteFile(newPath, this.getRelativePath(newPath));
+      this.recordChange(`reassign note id: ${record.relativePath} -> ${this.getRelativePath(newPath)}`);
+
+      return { success: true, note: note ?? undefined };
+    } catch (error) {
+      logError('Error reassigning note id:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /** Commits that touched the note, newest first. Fails when there is no git history to read. */
+  getNoteHistory(noteId: string): NoteHistoryResult {
+    const record = findNoteRecordById(this.notesDir, noteId);
+    if (!record) {
+      return { success: false, error: 'Note not found' };
+    }
+
+    const revisions = this.history.log(record.fullPath);
+    if (revisions === null) {
+      return { success: false, error: 'History unavailable: the notes directory is not in a git repository' };
+    }
+
+    return { success: true, revisions };
+  }
+
+  /** The note's content at its nth most recent commit, counting from 1. */
+  getNoteRevision(noteId: string, index: number): NoteRevisionResult {
+    const record = findNoteRecordById(this.notesDir, noteId);
+    const history = this.getNoteHistory(noteId);
+    if (!record || !history.revisions) {
+      return { success: false, error: history.error };
+    }
+
+    const revision = history.revisions[index - 1];
+    if (!Number.isInteger(index) || !revision) {
+      return {
+        success: false,
+        error: history.revisions.length === 0
+          ? 'Note has no committed revisions'
+          : `Revision ${index} out of range (1-${history.revisions.length})`,
+      };
+    }
+
+    const content = this.history.show(record.fullPath, revision);
+    if (content === null) {
+      return { success: false, error: `Could not read revision ${index}` };
+    }
+
+    return { success: true, revision, content: normalizeContent(content) };
+  }
+
+  /** The note's content as of the last git commit, or null if it has none. */
+  getCommittedContent(noteId: string): string | null {
+    const result = this.getNoteRevision(noteId, 1);
+    return result.content ?? null;
+  }
+
+  /** Where a note's attachments are stored, whether or not it has any yet. */
+  getAttachmentsDirectory(noteId: string): string {
+    return getAttachmentsDirectory(this.rootDir, path.join(this.notesDir, noteId));
+  }
+
+  /**
+   * Add and remove tags on many notes at once. Notes whose tags come out the
+   * same are left untouched, so their updated time and history stay clean.
+   */
+  async retagNotes(payload: RetagNotesPayload): Promise<RetagNotesResult> {
+    const add = normalizeTags(payload.add ?? []);
+    const remove = normalizeTags(payload.remove ?? []);
+    return this.updateTagsInBulk(
+      payload.noteIds,
+      (tags) => addTagsToList(removeTagsFromList(tags, remove), add),
+      'retag',
+      payload.dryRun,
+    );
+  }
+
+  /**
+   * Rename a tag on every note, matching it case-insensitively. A note that
+   * already has the new tag keeps a single copy.
+   */
+  async renameTag(payload: RenameTagPayload): Promise<RetagNotesResult> {
+    const from = payload.from.trim();
+    const to = payload.to.trim();
+    if (!from || !to) {
+      return { success: false, error: 'Tag cannot be empty', changed: [] };
+    }
+
+    const noteIds = await this.getNoteIdsWithTag(from);
+    return this.updateTagsInBulk(
+      noteIds,
+      (tags) => renameTagInList(tags, from, to),
+      'rename tag',
+      payload.dryRun,
+    );
+  }
+
+  /** Remove a tag from every note that has it. */
+  async deleteTag(payload: DeleteTagPayload): Promise<RetagNotesResult> {
+    const tag = payload.tag.trim();
+    if (!tag) {
+      return { success: false, error: 'Tag cannot be empty', changed: [] };
+    }
+
+    const noteIds = await this.getNoteIdsWithTag(tag);
+    return this.updateTagsInBulk(
+      noteIds,
+      (tags) => removeTagsFromList(tags, [tag]),
+      'delete tag',
+      payload.dryRun,
+    );
+  }
+
+  /**
+   * Give a note a short alias that lookups resolve before IDs and titles.
+   * Aliases are unique across the whole notes root, notebooks included.
+   */
+  async addAlias(payload: NoteAliasPayload): Promise<CommentMutationResult> {
+    const alias = normalizeAlias(payload.alias);
+    if (!alias) {
+      return { success: false, error: `Invalid alias: ${payload.alias}` };
+    }
+
+    return this.updateAliases(payload.noteId, `add alias ${alias}`, (aliases, fullPath) => {
+      if (aliases.includes(alias)) {
+        return aliases;
+      }
+
+      const owner = this.findAliasOwner(alias);
+      if (owner && path.resolve(owner.fullPath) !== path.resolve(fullPath)) {
+        return `Alias already used by ${owner.relativePath}`;
+      }
+
+      return [...aliases, alias];
+    });
+  }
+
+  async removeAlias(payload: NoteAliasPayload): Promise<CommentMutationResult> {
+    const alias = payload.alias.trim().toLocaleLowerCase();
+    return this.updateAliases(payload.noteId, `remove alias ${alias}`, (aliases) =>
+      aliases.includes(alias) ? aliases.filter((entry) => entry !== alias) : `Note has no alias ${alias}`,
+    );
+  }
+
+  /**
+   * Retitle a note and move its files to the matching `<date>-<slug>.md` name.
+   * The note ID is its relative path, so the returned note carries the new ID.
+   */
+  async renameNote(payload: RenameNotePayload): Promise<CommentMutationResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    const title = payload.title.trim();
+    if (!title) {
+      return { success: false, error: 'Title cannot be empty' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore([payload.noteId]);
+      const record = findNoteRecordById(this.notesDir, payload.noteId);
+      if (!record) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      const currentNote = parseNoteFile(record.fullPath, record.relativePath);
+      if (!currentNote) {
+        return { success: false, error: 'Failed to parse current note' };
+      }
+
+      const destinationPath = getTitledFilePath(record.fullPath, title, currentNote.created, currentNote.title);
+      if (
+        path.resolve(destinationPath) !== path.resolve(record.fullPath) &&
+        (fs.existsSync(destinationPath) || fs.existsSync(getNoteSidecarPath(destinationPath)))
+      ) {
+        return {
+          success: false,
+          error: `A note already exists at ${this.getRelativePath(destinationPath)}`,
+        };
+      }
+
+      const updatedContent = replaceNoteTitle(currentNote.content, title);
+      this.writeNoteContent(record.fullPath, currentNote, updatedContent, destinationPath);
+      this.recordChange(`rename note: ${currentNote.title} -> ${title}`);
+
+      return {
+        success: true,
+        note: parseNoteFile(destinationPath, this.getRelativePath(destinationPath)) ?? undefined,
+      };
+    } catch (error) {
+      logError('Error renaming note:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  async deleteNote(payload: DeleteNotePayload): Promise<OperationResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore([payload.noteId]);
+      const record = findNoteRecordById(this.notesDir, payload.noteId);
+      if (!record) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      const title = parseNoteFile(record.fullPath, record.relativePath)?.title ?? record.relativePath;
+      const sidecarPath = getNoteSidecarPath(record.fullPath);
+      if (payload.purge) {
+        fs.unlinkSync(record.fullPath);
+        if (fs.existsSync(sidecarPath)) {
+          fs.unlinkSync(sidecarPath);
+        }
+        removeAttachments(this.rootDir, record.fullPath);
+        this.cleanupAfterRemoval(record.fullPath);
+      } else {
+        this.moveToTrash(record);
+      }
+
+      this.recordChange(`delete note: ${title}`);
+      return { success: true };
+    } catch (error) {
+      logError('Error deleting note:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Fold the source note into the target: its content is appended under a
+   * `## Merged from <title>` heading, its comments move with their anchors
+   * shifted onto the appended text, and tags are unioned. The source then
+   * goes to the trash and its aliases move to the target. With keepSource the
+   * source stays as it is and the target gets copies of its comments under
+   * new IDs.
+   */
+  async mergeNotes(payload: MergeNotesPayload): Promise<CommentMutationResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore([payload.sourceId, payload.targetId]);
+      const sourceRecord = findNoteRecordById(this.notesDir, payload.sourceId);
+      const targetRecord = findNoteRecordById(this.notesDir, payload.targetId);
+      if (!sourceRecord || !targetRecord) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      if (path.resolve(sourceRecord.fullPath) === path.resolve(targetRecord.fullPath)) {
+        return { success: false, error: 'Cannot merge a note into itself' };
+      }
+
+      const source = parseNoteFile(sourceRecord.fullPath, sourceRecord.relativePath);
+      const target = parseNoteFile(targetRecord.fullPath, targetRecord.relativePath);
+      if (!source || !target) {
+        return { success: false, error: 'Failed to parse current note' };
+      }
+
+      if (source.encrypted || target.encrypted) {
+        return { success: false, error: 'Cannot merge encrypted notes; decrypt them first' };
+      }
+
+      const merged = mergeNoteContent(target, source);
+      const remap = remapCommentsForEdit(target.comments, target.content, merged.content, target.commentRev);
+      const commentRev = Math.max(1, remap.nextRev);
+      const movedComments = relocateComments(
+        payload.keepSource ? source.comments.map((comment) => ({ ...comment, id: ulid() })) : source.comments,
+        merged.mapSourceOffset,
+        merged.content,
+        commentRev,
+      );
+      const meta = source.meta || target.meta ? { ...source.meta, ...target.meta } : undefined;
+      const aliases = payload.keepSource
+        ? target.aliases
+        : [...(target.aliases ?? []), ...(source.aliases ?? [])];
+
+      writeFileAtomic(targetRecord.fullPath, merged.content);
+      writeSidecarData(
+        targetRecord.fullPath,
+        [...target.tags, ...source.tags],
+        [...remap.comments, ...movedComments],
+        movedComments.length > 0 || remap.comments.length > 0 ? commentRev : target.commentRev,
+        {
+          ...getSidecarMetadata(target),
+          updated: new Date().toISOString(),
+          meta,
+          due: target.due ?? source.due,
+          aliases,
+        },
+      );
+
+      if (!payload.keepSource) {
+        if (source.aliases) {
+          // Aliases now belong to the target; a restored source must not claim them.
+          writeSidecarData(sourceRecord.fullPath, source.tags, source.comments, source.commentRev, {
+            ...getSidecarMetadata(source),
+            aliases: [],
+          });
+        }
+        this.moveToTrash(sourceRecord);
+      }
+
+      this.recordChange(`merge note: ${source.title} into ${target.title}`);
+      return {
+        success: true,
+        note: parseNoteFile(targetRecord.fullPath, targetRecord.relativePath) ?? undefined,
+      };
+    } catch (error) {
+      logError('Error merging notes:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Notes moved to the trash by deleteNote. IDs are relative to the trash and
+   * match the path each note is restored to.
+   */
+  async listTrash(): Promise<Note[]> {
+    const trashDir = this.getTrashDirectory();
+    if (!fs.existsSync(trashDir)) {
+      return [];
+    }
+
+    try {
+      return getAllMarkdownFiles(trashDir)
+        .map(({ fullPath, relativePath }) => parseNoteFile(fullPath, relativePath))
+        .filter((note): note is Note => note !== null)
+        .sort(compareNotes);
+    } catch (error) {
+      logError('Error listing trash:', error);
+      return [];
     }
   }
 
-  async deleteNote(payload: DeleteNotePayload): Promise<OperationResult> {
+  async restoreNote(payload: RestoreNotePayload): Promise<CommentMutationResult> {
     if (!fs.existsSync(this.notesDir)) {
       return { success: false, error: 'Notes directory not found' };
     }
 
+    let release: ReleaseLock | null = null;
     try {
-      const record = findNoteRecordById(this.notesDir, payload.noteId);
+      release = await this.lockStore();
+      const trashDir = this.getTrashDirectory();
+      const record = findNoteRecordById(trashDir, payload.noteId);
       if (!record) {
-        return { success: false, error: 'Note not found' };
+        return { success: false, error: 'Note not found in trash' };
       }
 
-      fs.unlinkSync(record.fullPath);
-      const sidecarPath = getNoteSidecarPath(record.fullPath);
-      if (fs.existsSync(sidecarPath)) {
-        fs.unlinkSync(sidecarPath);
+      const destinationPath = path.join(this.notesDir, record.relativePath);
+      if (fs.existsSync(destinationPath) || fs.existsSync(getNoteSidecarPath(destinationPath))) {
+        return { success: false, error: `A note already exists at ${record.relativePath}` };
       }
 
-      const parentDir = path.dirname(record.fullPath);
-      if (path.resolve(parentDir) !== path.resolve(this.notesDir)) {
-        cleanupEmptyParentDirectories(parentDir, this.notesDir);
+      fs.mkdirSync(path.dirname(destinationPath), { recursive: true });
+      this.moveNoteFiles(record.fullPath, destinationPath);
+
+      const trashParent = path.dirname(record.fullPath);
+      if (path.resolve(trashParent) !== path.resolve(trashDir)) {
+        cleanupEmptyParentDirectories(trashParent, trashDir);
       }
 
-      return { success: true };
+      const note = parseNoteFile(destinationPath, record.relativePath) ?? undefined;
+      this.recordChange(`restore note: ${note?.title ?? record.relativePath}`);
+
+      return { success: true, note };
     } catch (error) {
-      console.error('Error deleting note:', error);
+      logError('Error restoring note:', error);
       return {
         success: false,
         error: error instanceof Error ? error.message : 'Unknown error',
       };
+    } finally {
+      release?.();
     }
   }
 
@@ -273,7 +1717,9 @@ export class NoteStore {
       return { success: false, error: 'Target directory escapes notes root' };
     }
 
+    let release: ReleaseLock | null = null;
     try {
+      release = await this.lockStore([payload.noteId]);
       const record = findNoteRecordById(this.notesDir, payload.noteId);
       if (!record) {
         return { success: false, error: 'Note not found' };
@@ -297,28 +1743,92 @@ export class NoteStore {
       }
 
       if (path.resolve(destinationPath) !== currentPath) {
-        const sourceSidecarPath = getNoteSidecarPath(record.fullPath);
-        const destinationSidecarPath = getNoteSidecarPath(destinationPath);
-        fs.renameSync(record.fullPath, destinationPath);
-        if (fs.existsSync(sourceSidecarPath)) {
-          fs.renameSync(sourceSidecarPath, destinationSidecarPath);
-        }
+        this.moveNoteFiles(record.fullPath, destinationPath);
         if (path.resolve(currentDirectory) !== path.resolve(this.notesDir)) {
           cleanupEmptyParentDirectories(currentDirectory, this.notesDir);
         }
       }
 
       const relativePath = this.getRelativePath(destinationPath);
+      const note = parseNoteFile(destinationPath, relativePath) ?? undefined;
+      this.recordChange(`move note: ${note?.title ?? relativePath} -> ${normalizedDirectory || '/'}`);
+
+      return { success: true, note };
+    } catch (error) {
+      logError('Error moving note:', error);
       return {
-        success: true,
-        note: parseNoteFile(destinationPath, relativePath) ?? undefined,
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
       };
+    } finally {
+      release?.();
+    }
+  }
+
+  /**
+   * Move a note into another notebook at the same path within it, so its ID
+   * and filename there stay the same. Comments, attachments and timestamps go
+   * with it. The returned note's ID is relative to the target notebook.
+   */
+  async moveNoteToNotebook(payload: MoveNoteToNotebookPayload): Promise<CommentMutationResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    let notebook: string;
+    try {
+      notebook = normalizeNotebookName(payload.notebook);
     } catch (error) {
-      console.error('Error moving note:', error);
+      return { success: false, error: error instanceof Error ? error.message : 'Invalid notebook name' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore([payload.noteId]);
+      const record = findNoteRecordById(this.notesDir, payload.noteId);
+      if (!record) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      // Notebooks are top-level folders, so the first segment from the root names the current one.
+      const rootPath = formatRelativePath(path.relative(this.rootDir, record.fullPath));
+      const separator = rootPath.indexOf('/');
+      const sourceNotebook = separator >= 0 ? rootPath.slice(0, separator) : null;
+      const notebookPath = rootPath.slice(separator + 1);
+      if (sourceNotebook === notebook) {
+        return { success: false, error: `Note is already in notebook ${notebook}` };
+      }
+
+      const targetRoot = path.join(this.rootDir, notebook);
+      const destinationPath = path.join(targetRoot, notebookPath);
+      const targetDirectory = path.dirname(destinationPath);
+      // generateUniqueFilePath only picks another name when this one is taken, in any case.
+      if (generateUniqueFilePath(targetDirectory, path.basename(destinationPath, '.md')) !== destinationPath) {
+        return {
+          success: false,
+          error: `A note named ${path.basename(destinationPath)} already exists in notebook ${notebook}`,
+        };
+      }
+
+      fs.mkdirSync(targetDirectory, { recursive: true });
+      this.moveNoteFiles(record.fullPath, destinationPath);
+      cleanupEmptyParentDirectories(
+        path.dirname(record.fullPath),
+        sourceNotebook ? path.join(this.rootDir, sourceNotebook) : this.rootDir,
+      );
+
+      const note = parseNoteFile(destinationPath, notebookPath) ?? undefined;
+      this.recordChange(`move note: ${note?.title ?? notebookPath} -> notebook ${notebook}`);
+
+      return { success: true, note };
+    } catch (error) {
+      logError('Error moving note to notebook:', error);
       return {
         success: false,
         error: error instanceof Error ? error.message : 'Unknown error',
       };
+    } finally {
+      release?.();
     }
   }
 
@@ -327,7 +1837,9 @@ export class NoteStore {
       return { success: false, error: 'Notes directory not found' };
     }
 
+    let release: ReleaseLock | null = null;
     try {
+      release = await this.lockNotes([payload.noteId]);
       const record = findNoteRecordById(this.notesDir, payload.noteId);
       if (!record) {
         return { success: false, error: 'Note not found' };
@@ -338,6 +1850,11 @@ export class NoteStore {
         return { success: false, error: 'Failed to parse current note' };
       }
 
+      // A comment quotes the body into the plaintext sidecar.
+      if (currentNote.encrypted) {
+        return { success: false, error: 'Cannot comment on an encrypted note; decrypt it first' };
+      }
+
       if (payload.anchor.rev !== currentNote.commentRev) {
         return {
           success: false,
@@ -354,6 +1871,7 @@ export class NoteStore {
           payload.anchor.from,
           payload.anchor.to,
           targetRev,
+          payload.expectedQuote,
         );
       } catch (error) {
         return {
@@ -375,18 +1893,27 @@ export class NoteStore {
       };
 
       const comments = [...currentNote.comments, newComment];
-      writeSidecarData(record.fullPath, currentNote.tags, comments, targetRev);
+      writeSidecarData(
+        record.fullPath,
+        currentNote.tags,
+        comments,
+        targetRev,
+        getSidecarMetadata(currentNote),
+      );
+      this.recordChange(`add comment: ${currentNote.title}`);
 
       return {
         success: true,
         note: parseNoteFile(record.fullPath, record.relativePath) ?? undefined,
       };
     } catch (error) {
-      console.error('Error adding comment:', error);
+      logError('Error adding comment:', error);
       return {
         success: false,
         error: error instanceof Error ? error.message : 'Unknown error',
       };
+    } finally {
+      release?.();
     }
   }
 
@@ -399,7 +1926,9 @@ export class NoteStore {
       return { success: false, error: 'Comment ID is required' };
     }
 
+    let release: ReleaseLock | null = null;
     try {
+      release = await this.lockNotes([payload.noteId]);
       const record = findNoteRecordById(this.notesDir, payload.noteId);
       if (!record) {
         return { success: false, error: 'Note not found' };
@@ -418,19 +1947,80 @@ export class NoteStore {
         return { success: false, error: 'Comment not found' };
       }
 
-      writeSidecarData(record.fullPath, currentNote.tags, nextComments, currentNote.commentRev);
+      writeSidecarData(
+        record.fullPath,
+        currentNote.tags,
+        nextComments,
+        currentNote.commentRev,
+        getSidecarMetadata(currentNote),
+      );
+      this.recordChange(`delete comment: ${currentNote.title}`);
 
       return {
         success: true,
         note: parseNoteFile(record.fullPath, record.relativePath) ?? undefined,
       };
     } catch (error) {
-      console.error('Error deleting comment:', error);
+      logError('Error deleting comment:', error);
       return {
         success: false,
         error: error instanceof Error ? error.message : 'Unknown error',
       };
+    } finally {
+      release?.();
+    }
+  }
+
+  /** Change a comment's text, keeping its ID, author, created time, and anchor. */
+  async editComment(payload: EditCommentPayload): Promise<CommentMutationResult> {
+    if (!payload.content.trim()) {
+      return { success: false, error: 'Comment content is required' };
     }
+
+    return this.updateComment(payload.noteId, payload.commentId, 'edit comment', (comment) => ({
+      ...comment,
+      content: payload.content,
+      edited: new Date().toISOString(),
+    }));
+  }
+
+  /**
+   * Re-anchor a comment to its stored quote. This only succeeds when the quote
+   * occurs exactly once in the current content.
+   */
+  async reattachComment(payload: ReattachCommentPayload): Promise<CommentMutationResult> {
+    return this.updateComment(payload.noteId, payload.commentId, 'reattach comment', (comment, note) => {
+      const quote = comment.anchor.quote;
+      if (!quote) {
+        return 'Comment has no quoted text to reattach to';
+      }
+
+      const match = getUniqueMatchRange(note.content, quote);
+      if (!match) {
+        return note.content.includes(quote)
+          ? 'Quoted text appears more than once in the note'
+          : 'Quoted text not found in the note';
+      }
+
+      const anchor = buildAnchorFromRange(note.content, match.from, match.to, note.commentRev);
+      anchor.startAffinity = normalizeAffinity(comment.anchor.startAffinity, 'after');
+      anchor.endAffinity = normalizeAffinity(comment.anchor.endAffinity, 'before');
+      return { ...comment, anchor, status: 'attached' };
+    });
+  }
+
+  async setCommentResolved(payload: SetCommentResolvedPayload): Promise<CommentMutationResult> {
+    const action = payload.resolved ? 'resolve comment' : 'reopen comment';
+    return this.updateComment(payload.noteId, payload.commentId, action, (comment) => {
+      if (payload.resolved) {
+        return { ...comment, resolved: true, resolvedAt: comment.resolvedAt ?? new Date().toISOString() };
+      }
+
+      const reopened = { ...comment };
+      delete reopened.resolved;
+      delete reopened.resolvedAt;
+      return reopened;
+    });
   }
 
   async createDirectory(payload: CreateDirectoryPayload): Promise<DirectoryMutationResult> {
@@ -448,15 +2038,19 @@ export class NoteStore {
       return { success: false, error: 'Directory path escapes notes root' };
     }
 
+    let release: ReleaseLock | null = null;
     try {
+      release = await this.lockStore();
       fs.mkdirSync(targetPath, { recursive: true });
       return { success: true, path: normalizedPath };
     } catch (error) {
-      console.error('Error creating directory:', error);
+      logError('Error creating directory:', error);
       return {
         success: false,
         error: error instanceof Error ? error.message : 'Unknown error',
       };
+    } finally {
+      release?.();
     }
   }
 
@@ -479,12 +2073,17 @@ export class NoteStore {
       return { success: false, error: 'Directory not found' };
     }
 
+    let release: ReleaseLock | null = null;
     try {
+      release = await this.lockStore();
       const stats = fs.statSync(targetPath);
       if (!stats.isDirectory()) {
         return { success: false, error: 'Target path is not a directory' };
       }
 
+      for (const record of getAllMarkdownFiles(targetPath)) {
+        removeAttachments(this.rootDir, record.fullPath);
+      }
       fs.rmSync(targetPath, { recursive: true, force: false });
 
       const parentDir = path.dirname(targetPath);
@@ -494,15 +2093,470 @@ export class NoteStore {
 
       return { success: true, path: normalizedPath };
     } catch (error) {
-      console.error('Error deleting directory:', error);
+      logError('Error deleting directory:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  private moveToTrash(record: MarkdownFileRecord): void {
+    let trashPath = path.join(this.getTrashDirectory(), record.relativePath);
+    fs.mkdirSync(path.dirname(trashPath), { recursive: true });
+    if (fs.existsSync(trashPath) || fs.existsSync(getNoteSidecarPath(trashPath))) {
+      trashPath = generateUniqueFilePath(path.dirname(trashPath), path.basename(trashPath, '.md'));
+    }
+
+    this.moveNoteFiles(record.fullPath, trashPath);
+    this.cleanupAfterRemoval(record.fullPath);
+  }
+
+  /** Move a note's markdown file, sidecar and attachments together. */
+  private moveNoteFiles(fromPath: string, toPath: string): void {
+    const sidecarPath = getNoteSidecarPath(fromPath);
+    fs.renameSync(fromPath, toPath);
+    if (fs.existsSync(sidecarPath)) {
+      fs.renameSync(sidecarPath, getNoteSidecarPath(toPath));
+    }
+    moveAttachments(this.rootDir, fromPath, toPath);
+  }
+
+  /** The first note, by path, directly in the directory whose title is exactly the given one. */
+  private findNoteByTitle(directory: string, title: string): Note | null {
+    if (!fs.existsSync(directory)) {
+      return null;
+    }
+
+    const matches = getAllMarkdownFiles(this.notesDir)
+      .filter((record) => path.resolve(path.dirname(record.fullPath)) === path.resolve(directory))
+      .map((record) => this.noteCache.load(record))
+      .filter((note): note is Note => note !== null && note.title === title)
+      .sort(compareNotes);
+    return matches[0] ?? null;
+  }
+
+  /** The file's own name counts as taken, so this always yields a new `-N` path. */
+  private moveToFreshId(fullPath: string): string {
+    const newPath = generateUniqueFilePath(path.dirname(fullPath), path.basename(fullPath, '.md'));
+    this.moveNoteFiles(fullPath, newPath);
+    return newPath;
+  }
+
+  private cleanupAfterRemoval(fullPath: string): void {
+    const parentDir = path.dirname(fullPath);
+    if (path.resolve(parentDir) !== path.resolve(this.notesDir)) {
+      cleanupEmptyParentDirectories(parentDir, this.notesDir);
+    }
+  }
+
+  /**
+   * Write new content for a note, remapping its comments, optionally moving the
+   * markdown file and sidecar to destinationPath first. With a passphrase,
+   * `updatedContent` is plaintext: comments are remapped against the decrypted
+   * body and the file gets the encrypted form, which also encrypts a note
+   * that was stored in plaintext.
+   */
+  private writeNoteContent(
+    fullPath: string,
+    currentNote: Note,
+    updatedContent: string,
+    destinationPath = fullPath,
+    passphrase?: string,
+  ): void {
+    if (passphrase && currentNote.encrypted) {
+      currentNote = { ...currentNote, content: decryptNoteContent(currentNote.content, passphrase) };
+    }
+
+    let nextComments = currentNote.comments;
+    let nextRev = currentNote.commentRev;
+    let updated = currentNote.updated;
+
+    if (updatedContent !== currentNote.content) {
+      updated = new Date().toISOString();
+      const remap = remapCommentsForEdit(
+        currentNote.comments,
+        currentNote.content,
+        updatedContent,
+        currentNote.commentRev,
+      );
+      nextComments = remap.comments;
+      nextRev = remap.nextRev;
+    }
+
+    if (path.resolve(destinationPath) !== path.resolve(fullPath)) {
+      this.moveNoteFiles(fullPath, destinationPath);
+    }
+
+    writeFileAtomic(destinationPath, passphrase ? encryptNoteContent(updatedContent, passphrase) : updatedContent);
+    writeSidecarData(destinationPath, currentNote.tags, nextComments, nextRev, {
+      ...getSidecarMetadata(currentNote),
+      updated,
+      encrypted: Boolean(currentNote.encrypted || passphrase),
+    });
+  }
+
+  private async getNoteIdsWithTag(tag: string): Promise<string[]> {
+    const key = tag.toLocaleLowerCase();
+    const { notes } = await this.listNotes();
+    return notes.filter((note) => note.tags.some((t) => t.toLocaleLowerCase() === key)).map((note) => note.id);
+  }
+
+  /**
+   * Apply a tag change to each note and save the ones that differ afterwards,
+   * as a single history entry.
+   */
+  private async updateTagsInBulk(
+    noteIds: string[],
+    change: (tags: string[]) => string[],
+    action: string,
+    dryRun = false,
+  ): Promise<RetagNotesResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found', changed: [] };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockNotes(noteIds);
+      const updates: Array<{ record: MarkdownFileRecord; note: Note; tags: string[] }> = [];
+      for (const noteId of noteIds) {
+        const record = findNoteRecordById(this.notesDir, noteId);
+        const note = record ? parseNoteFile(record.fullPath, record.relativePath) : null;
+        if (!record || !note) {
+          return { success: false, error: `Note not found: ${noteId}`, changed: [] };
+        }
+
+        const tags = normalizeTags(change(note.tags));
+        if (tags.join('\n') !== note.tags.join('\n')) {
+          updates.push({ record, note, tags });
+        }
+      }
+
+      const changed = updates.map(({ note }) => note.id);
+      if (dryRun || updates.length === 0) {
+        return { success: true, changed };
+      }
+
+      const updated = new Date().toISOString();
+      for (const { record, note, tags } of updates) {
+        writeSidecarData(record.fullPath, tags, note.comments, note.commentRev, {
+          ...getSidecarMetadata(note),
+          updated,
+        });
+      }
+      this.recordChange(`${action} ${updates.length} note${updates.length === 1 ? '' : 's'}`);
+
+      return { success: true, changed };
+    } catch (error) {
+      logError('Error updating tags:', error);
       return {
         success: false,
         error: error instanceof Error ? error.message : 'Unknown error',
+        changed: [],
       };
+    } finally {
+      release?.();
     }
   }
 
+  /**
+   * Replace a single comment and save the sidecar. `change` returns the new
+   * comment, or an error message to abort without writing.
+   */
+  private async updateComment(
+    noteId: string,
+    commentId: string,
+    action: string,
+    change: (comment: NoteComment, note: Note) => NoteComment | string,
+  ): Promise<CommentMutationResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    if (!commentId) {
+      return { success: false, error: 'Comment ID is required' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockNotes([noteId]);
+      const record = findNoteRecordById(this.notesDir, noteId);
+      if (!record) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      const currentNote = parseNoteFile(record.fullPath, record.relativePath);
+      if (!currentNote) {
+        return { success: false, error: 'Failed to parse current note' };
+      }
+
+      const index = currentNote.comments.findIndex((comment) => comment.id === commentId);
+      if (index < 0) {
+        return { success: false, error: 'Comment not found' };
+      }
+
+      const updatedComment = change(currentNote.comments[index], currentNote);
+      if (typeof updatedComment === 'string') {
+        return { success: false, error: updatedComment };
+      }
+
+      const nextComments = [...currentNote.comments];
+      nextComments[index] = updatedComment;
+      writeSidecarData(
+        record.fullPath,
+        currentNote.tags,
+        nextComments,
+        currentNote.commentRev,
+        getSidecarMetadata(currentNote),
+      );
+      this.recordChange(`${action}: ${currentNote.title}`);
+
+      return {
+        success: true,
+        note: parseNoteFile(record.fullPath, record.relativePath) ?? undefined,
+      };
+    } catch (error) {
+      logError('Error updating comment:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  private async updateAliases(
+    noteId: string,
+    action: string,
+    change: (aliases: string[], fullPath: string) => string[] | string,
+  ): Promise<CommentMutationResult> {
+    if (!fs.existsSync(this.notesDir)) {
+      return { success: false, error: 'Notes directory not found' };
+    }
+
+    let release: ReleaseLock | null = null;
+    try {
+      release = await this.lockStore([noteId]);
+      const record = findNoteRecordById(this.notesDir, noteId);
+      if (!record) {
+        return { success: false, error: 'Note not found' };
+      }
+
+      const currentNote = parseNoteFile(record.fullPath, record.relativePath);
+      if (!currentNote) {
+        return { success: false, error: 'Failed to parse current note' };
+      }
+
+      const currentAliases = currentNote.aliases ?? [];
+      const aliases = change(currentAliases, record.fullPath);
+      if (typeof aliases === 'string') {
+        return { success: false, error: aliases };
+      }
+
+      if (aliases !== currentAliases) {
+        writeSidecarData(record.fullPath, currentNote.tags, currentNote.comments, currentNote.commentRev, {
+          ...getSidecarMetadata(currentNote),
+          aliases,
+        });
+        this.recordChange(`${action}: ${currentNote.title}`);
+      }
+
+      return {
+        success: true,
+        note: parseNoteFile(record.fullPath, record.relativePath) ?? undefined,
+      };
+    } catch (error) {
+      logError('Error updating aliases:', error);
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      };
+    } finally {
+      release?.();
+    }
+  }
+
+  /** The note holding an alias anywhere under the notes root, by its root-relative path. */
+  private findAliasOwner(alias: string): { fullPath: string; relativePath: string } | null {
+    return (
+      getAllMarkdownFiles(this.rootDir).find((record) =>
+        normalizeAliases(readSidecarData(record.fullPath).aliases).includes(alias),
+      ) ?? null
+    );
+  }
+
+  /** Lock the given notes against other processes for a read-modify-write. */
+  private lockNotes(noteIds: string[]): Promise<ReleaseLock> {
+    return acquireLocks(this.rootDir, this.getLockNames(noteIds), this.lockTimeout);
+  }
+
+  /**
+   * Lock the whole store, for operations that scan the notes root (creating,
+   * importing, moving), plus any notes they rewrite. The store lock is always
+   * taken before note locks.
+   */
+  private lockStore(noteIds: string[] = []): Promise<ReleaseLock> {
+    return acquireLocks(this.rootDir, [STORE_LOCK, ...this.getLockNames(noteIds)], this.lockTimeout);
+  }
+
+  /** Note locks are keyed by path from the notes root so notebook stores share them. */
+  private getLockNames(noteIds: string[]): string[] {
+    const names = noteIds.map((noteId) =>
+      formatRelativePath(path.relative(this.rootDir, path.join(this.notesDir, noteId))),
+    );
+    return [...new Set(names)].sort();
+  }
+
+  /**
+   * Runs after every successful mutation. Commit failures are logged rather
+   * than returned: the mutation itself has already succeeded on disk.
+   */
+  private recordChange(message: string): void {
+    logDebug(message);
+    this.noteCache.clear();
+    // Only an index that searches already use is kept current, so the next one need not re-read the change.
+    if (fs.existsSync(getSearchIndexPath(this.rootDir))) {
+      try {
+        this.refreshSearchIndex();
+      } catch (error) {
+        logError('Error updating search index:', error);
+      }
+    }
+    if (!this.commit) {
+      return;
+    }
+
+    try {
+      this.commit(this.rootDir, message);
+    } catch (error) {
+      logError('Error committing change:', error);
+    }
+  }
+
+  /** Index entries are keyed from the notes root, so every notebook shares one index. */
+  private getIndexPath(fullPath: string): string {
+    return formatRelativePath(path.relative(this.rootDir, fullPath));
+  }
+
+  /**
+   * Bring the saved search index up to date, re-reading only notes whose
+   * files changed since they were indexed (all of them when there is no
+   * index yet), and save it when anything did. With rebuild, the saved index
+   * is ignored.
+   */
+  private refreshSearchIndex(rebuild = false): SearchIndex {
+    const index = (!rebuild && SearchIndex.read(this.rootDir)) || new SearchIndex();
+    const live = new Set<string>();
+    let changed = rebuild;
+
+    for (const record of getAllMarkdownFiles(this.rootDir)) {
+      const notePath = this.getIndexPath(record.fullPath);
+      live.add(notePath);
+      if (!rebuild && getNoteSignature(record.fullPath) === index.getSignature(notePath)) {
+        continue;
+      }
+
+      // The cache holds notes with IDs relative to notesDir, which only matches these records outside a notebook.
+      const note = this.notebook ? parseNoteFile(record.fullPath, record.relativePath) : this.noteCache.load(record);
+      // Parsing can backfill the sidecar, so sign the files afterwards.
+      const signature = getNoteSignature(record.fullPath);
+      if (note && signature) {
+        index.set(notePath, signature, note);
+      } else {
+        index.delete(notePath);
+      }
+      changed = true;
+    }
+
+    for (const notePath of index.paths()) {
+      if (!live.has(notePath)) {
+        index.delete(notePath);
+        changed = true;
+      }
+    }
+
+    if (changed) {
+      try {
+        index.write(this.rootDir);
+      } catch (error) {
+        logError('Error writing search index:', error);
+      }
+    }
+    return index;
+  }
+
+  /**
+   * Trash lives under the notes root and mirrors its layout, so notebook
+   * stores get their own subtree.
+   */
+  private getTrashDirectory(): string {
+    return path.join(this.rootDir, INTERNAL_DIRECTORY, 'trash', this.notebook ?? '');
+  }
+
   private getRelativePath(fullPath: string): string {
     return formatRelativePath(path.relative(this.notesDir, fullPath));
   }
 }
+
+function normalizeNotebookName(name: string): string {
+  const normalized = normalizeDirectoryInput(name);
+  if (!normalized || normalized.includes('/') || normalized.startsWith('.')) {
+    throw new Error(`Invalid notebook name: ${name}`);
+  }
+
+  return normalized;
+}
+
+/** A new note's content: its `# title` heading, then the body unless that already opens with it. */
+function getInitialContent(title: string, body: string | undefined): string {
+  if (body === undefined) {
+    return `# ${title}\n\n`;
+  }
+
+  const content = normalizeLineEndings(body);
+  return extractHeadingTitle(content) === title ? content : `# ${title}\n\n${content}`;
+}
+
+/** A comment for a new note, anchored by its exact text or by offsets into the content. */
+function buildNewComment(content: string, comment: NewNoteComment, created: string): NoteComment {
+  const range = comment.exact !== undefined ? getUniqueMatchRange(content, comment.exact) : null;
+  if (comment.exact !== undefined && !range) {
+    throw new Error(`Comment text not found exactly once in the note: ${comment.exact}`);
+  }
+
+  return {
+    id: ulid(),
+    author: comment.author,
+    created,
+    content: comment.content,
+    status: 'attached',
+    anchor: buildAnchorFromRange(content, range?.from ?? comment.from ?? 0, range?.to ?? comment.to ?? 0, 1),
+  };
+}
+
+/**
+ * When an edit changes the heading title, keep the filename in step with it.
+ * An unrelated note already holding the new name is never overwritten; the
+ * moved note gets a numeric suffix instead.
+ */
+function getRetitledFilePath(fullPath: string, currentNote: Note, updatedContent: string): string {
+  const nextTitle = extractHeadingTitle(updatedContent);
+  if (!nextTitle || nextTitle === currentNote.title) {
+    return fullPath;
+  }
+
+  const destinationPath = getTitledFilePath(fullPath, nextTitle, currentNote.created, currentNote.title);
+  if (path.resolve(destinationPath) === path.resolve(fullPath)) {
+    return fullPath;
+  }
+
+  if (fs.existsSync(destinationPath) || fs.existsSync(getNoteSidecarPath(destinationPath))) {
+    return generateUniqueFilePath(path.dirname(destinationPath), path.basename(destinationPath, '.md'));
+  }
+
+  return destinationPath;
+}
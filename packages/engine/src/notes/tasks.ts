@@ -0,0 +1,44 @@
+import type { NoteTask } from '../types.js';
+
+const TASK_PATTERN = /^(\s*(?:[-*+]|\d+[.)])\s+\[)([ xX])\](?=\s|$)\s*(.*)$/;
+const FENCE_PATTERN = /^\s*(```|~~~)/;
+
+/**
+ * Markdown task list items (`- [ ] todo`, `- [x] done`) in document order.
+ * Line numbers are 1-based, matching the line edit helpers; tasks inside
+ * fenced code blocks are ignored.
+ */
+export function extractTasks(content: string): NoteTask[] {
+  const tasks: NoteTask[] = [];
+  let fence: string | null = null;
+
+  content.split('\n').forEach((text, index) => {
+    const fenceMatch = text.match(FENCE_PATTERN);
+    if (fenceMatch) {
+      if (!fence) {
+        fence = fenceMatch[1];
+      } else if (fenceMatch[1] === fence) {
+        fence = null;
+      }
+      return;
+    }
+
+    const match = fence ? null : text.match(TASK_PATTERN);
+    if (match) {
+      tasks.push({ line: index + 1, text: match[3].trimEnd(), done: match[2] !== ' ' });
+    }
+  });
+
+  return tasks;
+}
+
+/** Rewrite a task line's checkbox; lines that are not tasks come back unchanged. */
+export function setTaskLineDone(line: string, done: boolean): string {
+  const match = line.match(TASK_PATTERN);
+  if (!match) {
+    return line;
+  }
+
+  const markIndex = match[1].length;
+  return `${line.slice(0, markIndex)}${done ? 'x' : ' '}${line.slice(markIndex + 1)}`;
+}
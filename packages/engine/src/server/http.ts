@@ -0,0 +1,315 @@
+import type { IncomingMessage, ServerResponse } from 'node:http';
+import type { Note, SortField } from '../types.js';
+import type { NoteStore } from '../notes/store.js';
+import { search } from '../notes/search.js';
+import { serializeNoteJSON } from '../notes/serialization.js';
+import { SORT_FIELDS, parseSortFields } from '../storage/searches.js';
+import { isRecord } from '../utils/validation.js';
+import { logError } from '../utils/log.js';
+
+/** Request bodies larger than this are rejected with 413. */
+export const MAX_BODY_BYTES = 1024 * 1024;
+
+export type NotesApiHandler = (req: IncomingMessage, res: ServerResponse) => Promise<void>;
+
+class HttpError extends Error {
+  status: number;
+
+  constructor(status: number, message: string) {
+    super(message);
+    this.status = status;
+  }
+}
+
+function sendJSON(res: ServerResponse, status: number, body: string): void {
+  res.writeHead(status, {
+    'Content-Type': 'application/json; charset=utf-8',
+    'Content-Length': Buffer.byteLength(body),
+  });
+  res.end(body);
+}
+
+function sendNote(res: ServerResponse, status: number, note: Note): void {
+  sendJSON(res, status, serializeNoteJSON(note));
+}
+
+function sendNotes(res: ServerResponse, notes: Note[]): void {
+  sendJSON(res, 200, `[${notes.map((note) => serializeNoteJSON(note)).join(',')}]`);
+}
+
+/** Methods that change notes; only these need a JSON content type. */
+const WRITE_METHODS = new Set(['POST', 'PUT', 'DELETE']);
+const LOCAL_HOSTNAMES = ['localhost', '127.0.0.1', '[::1]'];
+
+/**
+ * The API has no authentication, so a request must name a local host on the
+ * port it arrived on, or a DNS rebinding page could reach it under its own
+ * name. Port 80 may be left out, as browsers do.
+ */
+function checkHost(req: IncomingMessage): void {
+  const host = req.headers.host?.toLowerCase();
+  const port = req.socket.localPort;
+  const allowed = LOCAL_HOSTNAMES.flatMap((name) => (port === 80 ? [name, `${name}:80`] : [`${name}:${port}`]));
+  if (!host || !allowed.includes(host)) {
+    throw new HttpError(403, `Host not allowed: ${req.headers.host ?? '(none)'}`);
+  }
+}
+
+/**
+ * A browser sends `text/plain` and form posts cross-site without asking, but
+ * must ask before sending JSON, so a write in any other type is refused.
+ */
+function checkContentType(req: IncomingMessage, method: string): void {
+  if (!WRITE_METHODS.has(method)) {
+    return;
+  }
+
+  const mediaType = (req.headers['content-type'] ?? '').split(';')[0].trim().toLowerCase();
+  if (mediaType !== 'application/json') {
+    throw new HttpError(415, 'Content-Type must be application/json');
+  }
+}
+
+async function readJSONBody(req: IncomingMessage): Promise<Record<string, unknown>> {
+  const chunks: Buffer[] = [];
+  let size = 0;
+  for await (const chunk of req) {
+    size += (chunk as Buffer).length;
+    if (size > MAX_BODY_BYTES) {
+      throw new HttpError(413, 'Request body too large');
+    }
+    chunks.push(chunk as Buffer);
+  }
+
+  let body: unknown;
+  try {
+    body = JSON.parse(Buffer.concat(chunks).toString('utf-8') || '{}');
+  } catch {
+    throw new HttpError(400, 'Request body must be JSON');
+  }
+
+  if (!isRecord(body)) {
+    throw new HttpError(400, 'Request body must be a JSON object');
+  }
+  return body;
+}
+
+function optionalString(body: Record<string, unknown>, key: string): string | undefined {
+  const value = body[key];
+  if (value === undefined) {
+    return undefined;
+  }
+  if (typeof value !== 'string') {
+    throw new HttpError(400, `${key} must be a string`);
+  }
+  return value;
+}
+
+function optionalTags(body: Record<string, unknown>): string[] | undefined {
+  const value = body.tags;
+  if (value === undefined) {
+    return undefined;
+  }
+  if (!Array.isArray(value) || !value.every((tag) => typeof tag === 'string')) {
+    throw new HttpError(400, 'tags must be an array of strings');
+  }
+  return value;
+}
+
+function getListTags(params: URLSearchParams): string[] | undefined {
+  const tags = params.get('tags');
+  return tags ? tags.split(',').map((tag) => tag.trim()).filter(Boolean) : undefined;
+}
+
+function getLimit(params: URLSearchParams): number | undefined {
+  const raw = params.get('limit');
+  if (raw === null) {
+    return undefined;
+  }
+
+  const limit = Number(raw);
+  if (!Number.isInteger(limit) || limit < 0) {
+    throw new HttpError(400, 'limit must be a non-negative integer');
+  }
+  return limit;
+}
+
+/** Store errors carry no status; a missing note is the only one that is not a bad request. */
+function toHttpError(error: string | undefined, fallback: string): HttpError {
+  const message = error ?? fallback;
+  return new HttpError(message === 'Note not found' ? 404 : 400, message);
+}
+
+async function requireNote(store: NoteStore, noteId: string): Promise<Note> {
+  const note = await store.getNote(noteId);
+  if (!note) {
+    throw new HttpError(404, 'Note not found');
+  }
+  return note;
+}
+
+interface NoteChanges {
+  content?: string;
+  tags?: string[];
+  meta?: Record<string, unknown>;
+  due?: string | null;
+}
+
+/** The editable fields of a request body, checked before anything is written. */
+function readNoteChanges(body: Record<string, unknown>): NoteChanges {
+  const meta = body.meta;
+  if (meta !== undefined && !isRecord(meta)) {
+    throw new HttpError(400, 'meta must be an object');
+  }
+
+  return {
+    content: optionalString(body, 'content'),
+    tags: optionalTags(body),
+    meta,
+    due: body.due === null ? null : optionalString(body, 'due'),
+  };
+}
+
+/**
+ * Apply the editable fields of a request body to a note, content first so
+ * a retitle is settled before metadata is written to the renamed file.
+ */
+async function applyNoteChanges(store: NoteStore, note: Note, changes: NoteChanges): Promise<Note> {
+  const { content, tags, meta, due } = changes;
+  let current = note;
+  if (content !== undefined) {
+    const result = await store.updateNote({ noteId: current.id, content });
+    if (!result.success || !result.note) {
+      throw toHttpError(result.error, 'Failed to update note');
+    }
+    current = result.note;
+  }
+
+  if (tags !== undefined || meta !== undefined || due !== undefined) {
+    const result = await store.updateNoteMetadata({ noteId: current.id, tags, meta, due });
+    if (!result.success || !result.note) {
+      throw toHttpError(result.error, 'Failed to update note metadata');
+    }
+    current = result.note;
+  }
+
+  return current;
+}
+
+async function route(store: NoteStore, req: IncomingMessage, res: ServerResponse): Promise<void> {
+  const url = new URL(req.url ?? '/', 'http://localhost');
+  const method = req.method ?? 'GET';
+  const params = url.searchParams;
+  checkHost(req);
+  checkContentType(req, method);
+
+  if (url.pathname === '/search') {
+    if (method !== 'GET') {
+      throw new HttpError(405, 'Method not allowed');
+    }
+    const { notes } = await store.listNotes();
+    sendNotes(res, search(notes, { query: params.get('q') ?? undefined, tags: getListTags(params), limit: getLimit(params) }));
+    return;
+  }
+
+  if (url.pathname === '/notes' || url.pathname === '/notes/') {
+    if (method === 'GET') {
+      const { notes } = await store.listNotes();
+      let sortBy: SortField[];
+      try {
+        sortBy = parseSortFields(params.get('sort') ?? 'created');
+      } catch {
+        throw new HttpError(400, `sort must be a comma-separated list of: ${SORT_FIELDS.join(', ')}`);
+      }
+      sendNotes(res, search(notes, { tags: getListTags(params), limit: getLimit(params), sortBy }));
+      return;
+    }
+
+    if (method === 'POST') {
+      const body = await readJSONBody(req);
+      const title = optionalString(body, 'title');
+      if (!title) {
+        throw new HttpError(400, 'title is required');
+      }
+
+      // A single createNote, so a rejected field leaves no note behind.
+      const directory = optionalString(body, 'directory') ?? '';
+      const { due, ...changes } = readNoteChanges(body);
+      const created = await store.createNote({ title, directory, ...changes, due: due ?? undefined });
+      if (!created.success || !created.note) {
+        throw toHttpError(created.error, 'Failed to create note');
+      }
+      sendNote(res, 201, created.note);
+      return;
+    }
+
+    throw new HttpError(405, 'Method not allowed');
+  }
+
+  if (url.pathname.startsWith('/notes/')) {
+    // Note IDs are paths, so everything after the prefix is the ID, slashes included.
+    let noteId: string;
+    try {
+      noteId = decodeURIComponent(url.pathname.slice('/notes/'.length));
+    } catch {
+      throw new HttpError(400, 'Malformed note ID in path');
+    }
+
+    if (method === 'GET') {
+      sendNote(res, 200, await requireNote(store, noteId));
+      return;
+    }
+
+    if (method === 'PUT') {
+      const body = await readJSONBody(req);
+      const changes = readNoteChanges(body);
+      sendNote(res, 200, await applyNoteChanges(store, await requireNote(store, noteId), changes));
+      return;
+    }
+
+    if (method === 'DELETE') {
+      const result = await store.deleteNote({ noteId, purge: params.get('purge') === 'true' });
+      if (!result.success) {
+        throw toHttpError(result.error, 'Failed to delete note');
+      }
+      res.writeHead(204);
+      res.end();
+      return;
+    }
+
+    throw new HttpError(405, 'Method not allowed');
+  }
+
+  throw new HttpError(404, 'Not found');
+}
+
+/**
+ * A small REST API over a store, for `http.createServer`:
+ *
+ *   GET    /notes            list (?tags=a,b&limit=&sort=)
+ *   POST   /notes            create from {title, directory?, content?, tags?, meta?, due?}
+ *   GET    /notes/{id}       one note
+ *   PUT    /notes/{id}       update {content?, tags?, meta?, due?}
+ *   DELETE /notes/{id}       move to trash (?purge=true deletes)
+ *   GET    /search?q=        search (?tags=&limit=)
+ *
+ * Notes are sent in their JSON serialization; errors as `{"error": "..."}`.
+ * Requests must be addressed to localhost, and writes (including DELETE)
+ * must be sent as `application/json`.
+ */
+export function createNotesApiHandler(store: NoteStore): NotesApiHandler {
+  return async (req, res) => {
+    try {
+      await route(store, req, res);
+    } catch (error) {
+      const status = error instanceof HttpError ? error.status : 500;
+      const message = error instanceof Error ? error.message : 'Unknown error';
+      if (status === 500) {
+        logError('Error handling request:', error);
+      }
+      if (!res.headersSent) {
+        sendJSON(res, status, JSON.stringify({ error: message }));
+      }
+    }
+  };
+}
@@ -0,0 +1,4 @@
+export { createNotesApiHandler, MAX_BODY_BYTES } from './http.js';
+export type { NotesApiHandler } from './http.js';
+export { MCP_PROTOCOL_VERSION, MCP_TOOLS, callMcpTool, handleMcpMessage } from './mcp.js';
+export type { McpTool, McpToolResult, JsonRpcRequest, JsonRpcResponse } from './mcp.js';
@@ -0,0 +1,391 @@
+import type { CommentAnchor, Note, SortField } from '../types.js';
+import type { NoteStore } from '../notes/store.js';
+import { search } from '../notes/search.js';
+import { serializeNoteJSON } from '../notes/serialization.js';
+import { buildAnchorFromRange, getUniqueMatchRange } from '../comments/anchoring.js';
+import { SORT_FIELDS } from '../storage/searches.js';
+import { isRecord } from '../utils/validation.js';
+import { logError } from '../utils/log.js';
+
+/** The Model Context Protocol revision this server speaks. */
+export const MCP_PROTOCOL_VERSION = '2024-11-05';
+
+const SERVER_INFO = { name: 'agentnotes', version: '1.0.0' };
+
+export interface McpTool {
+  name: string;
+  description: string;
+  inputSchema: Record<string, unknown>;
+}
+
+export interface McpToolResult {
+  content: Array<{ type: 'text'; text: string }>;
+  isError?: boolean;
+}
+
+export interface JsonRpcRequest {
+  jsonrpc: '2.0';
+  id?: string | number | null;
+  method: string;
+  params?: unknown;
+}
+
+export interface JsonRpcResponse {
+  jsonrpc: '2.0';
+  id: string | number | null;
+  result?: unknown;
+  error?: { code: number; message: string };
+}
+
+const tagsProperty = {
+  type: 'array',
+  items: { type: 'string' },
+  description: 'Only notes with all of these tags',
+};
+
+/** Tool inputs mirror the flags of the matching CLI commands. */
+export const MCP_TOOLS: McpTool[] = [
+  {
+    name: 'list_notes',
+    description: 'List notes, oldest first by default (like `agentnotes list`).',
+    inputSchema: {
+      type: 'object',
+      properties: {
+        tags: tagsProperty,
+        limit: { type: 'integer', minimum: 0, description: 'Max notes to return (default 20)' },
+        sort: { type: 'string', enum: SORT_FIELDS, description: 'Sort field (default created)' },
+        reverse: { type: 'boolean', description: 'Reverse the order, so newest first by default' },
+      },
+    },
+  },
+  {
+    name: 'search_notes',
+    description: 'Search note titles and content (like `agentnotes search`).',
+    inputSchema: {
+      type: 'object',
+      properties: {
+        query: { type: 'string', description: 'Text to search for' },
+        tags: tagsProperty,
+        limit: { type: 'integer', minimum: 0, description: 'Max notes to return (default 10)' },
+      },
+      required: ['query'],
+    },
+  },
+  {
+    name: 'get_note',
+    description: 'Get a note with its content and comments, by ID, title or alias.',
+    inputSchema: {
+      type: 'object',
+      properties: {
+        note: { type: 'string', description: 'Note ID, title or alias' },
+      },
+      required: ['note'],
+    },
+  },
+  {
+    name: 'add_note',
+    description: 'Create a note (like `agentnotes add`).',
+    inputSchema: {
+      type: 'object',
+      properties: {
+        title: { type: 'string' },
+        content: { type: 'string', description: 'Markdown body; the title heading is added unless it opens with it' },
+        tags: { type: 'array', items: { type: 'string' } },
+        directory: { type: 'string', description: 'Folder to create the note in' },
+      },
+      required: ['title'],
+    },
+  },
+  {
+    name: 'add_comment',
+    description:
+      'Comment on a note (like `agentnotes comment add`). Anchor it with exact text, a 1-based line, or from/to offsets.',
+    inputSchema: {
+      type: 'object',
+      properties: {
+        note: { type: 'string', description: 'Note ID, title or alias' },
+        content: { type: 'string', description: 'Comment text' },
+        author: { type: 'string' },
+        exact: { type: 'string', description: 'Anchor to this text, which must appear once' },
+        line: { type: 'integer', minimum: 1, description: 'Anchor to this whole line' },
+        from: { type: 'integer', minimum: 0, description: 'Start character offset' },
+        to: { type: 'integer', minimum: 0, description: 'End character offset' },
+        quote: { type: 'string', description: 'Text from/to must cover; the comment is refused on a mismatch' },
+      },
+      required: ['note', 'content'],
+    },
+  },
+];
+
+class ToolInputError extends Error {}
+
+function getString(args: Record<string, unknown>, key: string, required = false): string | undefined {
+  const value = args[key];
+  if (value === undefined || value === null) {
+    if (required) {
+      throw new ToolInputError(`${key} is required`);
+    }
+    return undefined;
+  }
+  if (typeof value !== 'string') {
+    throw new ToolInputError(`${key} must be a string`);
+  }
+  return value;
+}
+
+function getInteger(args: Record<string, unknown>, key: string, min: number): number | undefined {
+  const value = args[key];
+  if (value === undefined || value === null) {
+    return undefined;
+  }
+  if (typeof value !== 'number' || !Number.isInteger(value) || value < min) {
+    throw new ToolInputError(`${key} must be an integer of at least ${min}`);
+  }
+  return value;
+}
+
+function getBoolean(args: Record<string, unknown>, key: string): boolean | undefined {
+  const value = args[key];
+  if (value === undefined || value === null) {
+    return undefined;
+  }
+  if (typeof value !== 'boolean') {
+    throw new ToolInputError(`${key} must be a boolean`);
+  }
+  return value;
+}
+
+function getTags(args: Record<string, unknown>): string[] | undefined {
+  const value = args.tags;
+  if (value === undefined || value === null) {
+    return undefined;
+  }
+  if (!Array.isArray(value) || !value.every((tag) => typeof tag === 'string')) {
+    throw new ToolInputError('tags must be an array of strings');
+  }
+  return value;
+}
+
+function toSummary(note: Note): Record<string, unknown> {
+  return {
+    id: note.id,
+    title: note.title,
+    tags: note.tags,
+    directory: note.directory,
+    created: note.created,
+    updated: note.updated,
+    commentCount: note.comments.length,
+    ...(note.due ? { due: note.due } : {}),
+  };
+}
+
+function textResult(text: string): McpToolResult {
+  return { content: [{ type: 'text', text }] };
+}
+
+function errorResult(message: string): McpToolResult {
+  return { content: [{ type: 'text', text: message }], isError: true };
+}
+
+async function resolveNote(store: NoteStore, ref: string): Promise<Note> {
+  const result = await store.findNote(ref);
+  if (result.note) {
+    return result.note;
+  }
+
+  const candidates = result.candidates.map((note) => `${note.title} [${note.id}]`).join(', ');
+  if (result.ambiguous) {
+    throw new ToolInputError(`Several notes match "${ref}": ${candidates}`);
+  }
+  throw new ToolInputError(`Note not found: ${ref}${candidates ? ` (did you mean: ${candidates})` : ''}`);
+}
+
+/** Character range of a 1-based line, without its newline. */
+function getLineRange(content: string, line: number): { from: number; to: number } {
+  const lines = content.split('\n');
+  if (line > lines.length) {
+    throw new ToolInputError(`line must be between 1 and ${lines.length}`);
+  }
+
+  const from = lines.slice(0, line - 1).reduce((offset, text) => offset + text.length + 1, 0);
+  return { from, to: from + lines[line - 1].length };
+}
+
+function buildCommentAnchor(note: Note, args: Record<string, unknown>): CommentAnchor {
+  const exact = getString(args, 'exact');
+  const line = getInteger(args, 'line', 1);
+  const from = getInteger(args, 'from', 0);
+  const to = getInteger(args, 'to', 0);
+  const given = [exact !== undefined, line !== undefined, from !== undefined || to !== undefined].filter(Boolean);
+  if (given.length !== 1) {
+    throw new ToolInputError('Specify exactly one of exact, line, or from and to');
+  }
+
+  let range: { from: number; to: number };
+  if (exact !== undefined) {
+    const match = getUniqueMatchRange(note.content, exact);
+    if (!match) {
+      throw new ToolInputError(
+        note.content.includes(exact)
+          ? 'Exact text appears more than once; use line or from/to instead'
+          : 'Exact text not found in note',
+      );
+    }
+    range = match;
+  } else if (line !== undefined) {
+    range = getLineRange(note.content, line);
+  } else if (from !== undefined && to !== undefined) {
+    range = { from, to };
+  } else {
+    throw new ToolInputError('from and to must be given together');
+  }
+
+  const quote = getString(args, 'quote');
+  if (quote !== undefined && from === undefined) {
+    throw new ToolInputError('quote only applies to from/to offsets');
+  }
+
+  try {
+    return buildAnchorFromRange(note.content, range.from, range.to, note.commentRev, quote);
+  } catch (error) {
+    throw new ToolInputError(error instanceof Error ? error.message : String(error));
+  }
+}
+
+type ToolHandler = (store: NoteStore, args: Record<string, unknown>) => Promise<McpToolResult>;
+
+const TOOL_HANDLERS: Record<string, ToolHandler> = {
+  async list_notes(store, args) {
+    const sort = getString(args, 'sort') ?? 'created';
+    if (!SORT_FIELDS.includes(sort as SortField)) {
+      throw new ToolInputError(`sort must be one of: ${SORT_FIELDS.join(', ')}`);
+    }
+
+    const { notes } = await store.listNotes();
+    const listed = search(notes, {
+      tags: getTags(args),
+      limit: getInteger(args, 'limit', 0) ?? 20,
+      sortBy: sort as SortField,
+      reverse: getBoolean(args, 'reverse'),
+    });
+    return textResult(JSON.stringify(listed.map(toSummary), null, 2));
+  },
+
+  async search_notes(store, args) {
+    const { notes } = await store.listNotes();
+    const found = search(notes, {
+      query: getString(args, 'query', true),
+      tags: getTags(args),
+      limit: getInteger(args, 'limit', 0) ?? 10,
+    });
+    return textResult(JSON.stringify(found.map(toSummary), null, 2));
+  },
+
+  async get_note(store, args) {
+    return textResult(serializeNoteJSON(await resolveNote(store, getString(args, 'note', true)!)));
+  },
+
+  async add_note(store, args) {
+    const title = getString(args, 'title', true)!;
+    const content = getString(args, 'content');
+    const tags = getTags(args);
+    const directory = getString(args, 'directory') ?? '';
+
+    // Every argument is checked above, so a bad one leaves no note behind.
+    const created = await store.createNote({ title, directory, content, tags });
+    if (!created.success || !created.note) {
+      return errorResult(created.error ?? 'Failed to create note');
+    }
+
+    return textResult(JSON.stringify(toSummary(created.note), null, 2));
+  },
+
+  async add_comment(store, args) {
+    const note = await resolveNote(store, getString(args, 'note', true)!);
+    const content = getString(args, 'content', true)!;
+    const result = await store.addComment({
+      noteId: note.id,
+      content,
+      author: getString(args, 'author') ?? '',
+      anchor: buildCommentAnchor(note, args),
+      expectedQuote: getString(args, 'quote'),
+    });
+    if (!result.success || !result.note) {
+      return errorResult(result.error ?? 'Failed to add comment');
+    }
+
+    const existing = new Set(note.comments.map((comment) => comment.id));
+    const comment = result.note.comments.find((entry) => !existing.has(entry.id));
+    return textResult(JSON.stringify({ noteId: result.note.id, comment }, null, 2));
+  },
+};
+
+/**
+ * Run one tool against the store. Bad input and failed operations come back
+ * as `isError` results, which MCP clients show to the model rather than
+ * treating as protocol errors.
+ */
+export async function callMcpTool(store: NoteStore, name: string, args: unknown = {}): Promise<McpToolResult> {
+  const handler = TOOL_HANDLERS[name];
+  if (!handler) {
+    return errorResult(`Unknown tool: ${name}`);
+  }
+  if (!isRecord(args)) {
+    return errorResult('Tool arguments must be an object');
+  }
+
+  try {
+    return await handler(store, args);
+  } catch (error) {
+    if (error instanceof ToolInputError) {
+      return errorResult(error.message);
+    }
+    logError(`Error running tool ${name}:`, error);
+    return errorResult(error instanceof Error ? error.message : 'Unknown error');
+  }
+}
+
+function respond(id: JsonRpcRequest['id'], result: unknown): JsonRpcResponse {
+  return { jsonrpc: '2.0', id: id ?? null, result };
+}
+
+function respondError(id: JsonRpcRequest['id'], code: number, message: string): JsonRpcResponse {
+  return { jsonrpc: '2.0', id: id ?? null, error: { code, message } };
+}
+
+/**
+ * Handle one JSON-RPC message from an MCP client. Returns the response to
+ * send, or null for notifications. Transport-agnostic: the CLI feeds it lines
+ * from stdin, tests call it directly.
+ */
+export async function handleMcpMessage(store: NoteStore, message: unknown): Promise<JsonRpcResponse | null> {
+  if (!isRecord(message) || message.jsonrpc !== '2.0' || typeof message.method !== 'string') {
+    return respondError(isRecord(message) ? (message.id as JsonRpcRequest['id']) : null, -32600, 'Invalid request');
+  }
+
+  const request = message as unknown as JsonRpcRequest;
+  if (request.id === undefined) {
+    return null;
+  }
+
+  const params = isRecord(request.params) ? request.params : {};
+  switch (request.method) {
+    case 'initialize':
+      return respond(request.id, {
+        protocolVersion: MCP_PROTOCOL_VERSION,
+        capabilities: { tools: {} },
+        serverInfo: SERVER_INFO,
+      });
+    case 'ping':
+      return respond(request.id, {});
+    case 'tools/list':
+      return respond(request.id, { tools: MCP_TOOLS });
+    case 'tools/call':
+      if (typeof params.name !== 'string') {
+        return respondError(request.id, -32602, 'Tool name is required');
+      }
+      return respond(request.id, await callMcpTool(store, params.name, params.arguments ?? {}));
+    default:
+      return respondError(request.id, -32601, `Method not found: ${request.method}`);
+  }
+}
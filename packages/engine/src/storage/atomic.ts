@@ -0,0 +1,47 @@
+import fs from 'node:fs';
+import path from 'node:path';
+import { logDebug } from '../utils/log.js';
+
+export const DEFAULT_FILE_MODE = 0o644;
+
+/** Temp files start with a dot and end in `.tmp`, so no note scan ever lists them. */
+function getTempPath(filePath: string): string {
+  const suffix = `${process.pid}.${Math.random().toString(36).slice(2, 10)}`;
+  return path.join(path.dirname(filePath), `.${path.basename(filePath)}.${suffix}.tmp`);
+}
+
+function getExistingMode(filePath: string): number {
+  try {
+    return fs.statSync(filePath).mode & 0o777;
+  } catch {
+    return DEFAULT_FILE_MODE;
+  }
+}
+
+/**
+ * Write a file so readers see either the old content or the new, never a
+ * truncated mix: the data goes to a temp file in the same directory, is
+ * flushed, and is renamed over the target. An existing file keeps its mode;
+ * new files get 0644. The temp file is removed if anything fails.
+ */
+export function writeFileAtomic(filePath: string, content: string): void {
+  const tempPath = getTempPath(filePath);
+  const mode = getExistingMode(filePath);
+
+  try {
+    const fd = fs.openSync(tempPath, 'wx', mode);
+    try {
+      fs.writeFileSync(fd, content, 'utf-8');
+      fs.fsyncSync(fd);
+    } finally {
+      fs.closeSync(fd);
+    }
+    // The open mode is filtered by the umask; set it explicitly.
+    fs.chmodSync(tempPath, mode);
+    fs.renameSync(tempPath, filePath);
+    logDebug(`wrote ${filePath}`);
+  } catch (error) {
+    fs.rmSync(tempPath, { force: true });
+    throw error;
+  }
+}
@@ -0,0 +1,64 @@
+import fs from 'node:fs';
+import path from 'node:path';
+import { INTERNAL_DIRECTORY, cleanupEmptyParentDirectories } from './filesystem.js';
+
+const ATTACHMENTS_DIRECTORY = 'attachments';
+const TRASH_DIRECTORY = 'trash';
+
+/**
+ * Files attached to a note live outside the notes tree so they are never
+ * read as notes: `.agentnotes/attachments/<note path>/` for live notes and
+ * `.agentnotes/trash/.attachments/<trash path>/` while a note is in the trash.
+ */
+export function getAttachmentsDirectory(rootDir: string, notePath: string): string {
+  const trashDir = path.join(rootDir, INTERNAL_DIRECTORY, TRASH_DIRECTORY);
+  const relativeToTrash = path.relative(trashDir, notePath);
+  const inTrash = !relativeToTrash.startsWith('..') && !path.isAbsolute(relativeToTrash);
+
+  const [baseDir, relativePath] = inTrash
+    ? [path.join(trashDir, `.${ATTACHMENTS_DIRECTORY}`), relativeToTrash]
+    : [path.join(rootDir, INTERNAL_DIRECTORY, ATTACHMENTS_DIRECTORY), path.relative(rootDir, notePath)];
+  return path.join(baseDir, relativePath.replace(/\.md$/i, ''));
+}
+
+/**
+ * Copy a file into a note's attachments directory, adding `-2`, `-3`, ...
+ * before the extension when the name is taken. Returns the stored name.
+ */
+export function copyAttachment(sourcePath: string, attachmentsDir: string): string {
+  const extension = path.extname(sourcePath);
+  const baseName = path.basename(sourcePath, extension);
+  let name = `${baseName}${extension}`;
+  let suffix = 2;
+  while (fs.existsSync(path.join(attachmentsDir, name))) {
+    name = `${baseName}-${suffix}${extension}`;
+    suffix += 1;
+  }
+
+  fs.mkdirSync(attachmentsDir, { recursive: true });
+  fs.copyFileSync(sourcePath, path.join(attachmentsDir, name));
+  return name;
+}
+
+/** Move a note's attachments along with its file. */
+export function moveAttachments(rootDir: string, fromNotePath: string, toNotePath: string): void {
+  const fromDir = getAttachmentsDirectory(rootDir, fromNotePath);
+  if (!fs.existsSync(fromDir)) {
+    return;
+  }
+
+  const toDir = getAttachmentsDirectory(rootDir, toNotePath);
+  fs.mkdirSync(path.dirname(toDir), { recursive: true });
+  fs.renameSync(fromDir, toDir);
+  cleanupEmptyParentDirectories(path.dirname(fromDir), path.join(rootDir, INTERNAL_DIRECTORY));
+}
+
+export function removeAttachments(rootDir: string, notePath: string): void {
+  const dir = getAttachmentsDirectory(rootDir, notePath);
+  if (!fs.existsSync(dir)) {
+    return;
+  }
+
+  fs.rmSync(dir, { recursive: true, force: true });
+  cleanupEmptyParentDirectories(path.dirname(dir), path.join(rootDir, INTERNAL_DIRECTORY));
+}
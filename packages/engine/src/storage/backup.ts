@@ -0,0 +1,282 @@
+import fs from 'node:fs';
+import path from 'node:path';
+import zlib from 'node:zlib';
+import { INTERNAL_DIRECTORY, getAllMarkdownFiles } from './filesystem.js';
+
+/** Written as the archive's first entry; never extracted into the store. */
+export const BACKUP_MANIFEST_NAME = 'agentnotes-backup.json';
+export const BACKUP_FORMAT_VERSION = 1;
+
+const BLOCK_SIZE = 512;
+
+export interface BackupManifest {
+  format: number;
+  /** When the backup was taken, ISO 8601. */
+  created: string;
+  noteCount: number;
+}
+
+interface TarEntry {
+  name: string;
+  type: 'file' | 'directory';
+  mode: number;
+  mtime: Date;
+  data: Buffer;
+}
+
+/**
+ * Everything under the notes root that makes up the store: notes, sidecars,
+ * folders and `.agentnotes` (trash, templates, attachments, config, saved
+ * searches). Lock files and other dot-folders such as `.git` are left out.
+ */
+function collectBackupEntries(root: string, exclude: string, relativeDir = ''): TarEntry[] {
+  const entries: TarEntry[] = [];
+  const directory = path.join(root, relativeDir);
+  for (const entry of fs.readdirSync(directory, { withFileTypes: true })) {
+    const relativePath = relativeDir ? `${relativeDir}/${entry.name}` : entry.name;
+    const fullPath = path.join(root, relativePath);
+    const inInternal = relativePath.split('/')[0] === INTERNAL_DIRECTORY;
+    if (entry.name.startsWith('.') && !inInternal) {
+      continue;
+    }
+    if (relativePath === `${INTERNAL_DIRECTORY}/locks` || path.resolve(fullPath) === exclude) {
+      continue;
+    }
+
+    const stats = fs.statSync(fullPath);
+    if (entry.isDirectory()) {
+      entries.push({
+        name: relativePath,
+        type: 'directory',
+        mode: stats.mode & 0o777,
+        mtime: stats.mtime,
+        data: Buffer.alloc(0),
+      });
+      entries.push(...collectBackupEntries(root, exclude, relativePath));
+    } else if (entry.isFile()) {
+      entries.push({
+        name: relativePath,
+        type: 'file',
+        mode: stats.mode & 0o777,
+        mtime: stats.mtime,
+        data: fs.readFileSync(fullPath),
+      });
+    }
+  }
+  return entries;
+}
+
+function writeOctal(header: Buffer, value: number, offset: number, length: number): void {
+  header.write(value.toString(8).padStart(length - 1, '0') + '\0', offset, length, 'ascii');
+}
+
+/** Split a path into ustar's 155-byte prefix and 100-byte name, or null when it cannot be. */
+function splitUstarName(name: string): { prefix: string; name: string } | null {
+  if (Buffer.byteLength(name) <= 100) {
+    return { prefix: '', name };
+  }
+  for (let index = name.indexOf('/'); index >= 0; index = name.indexOf('/', index + 1)) {
+    const prefix = name.slice(0, index);
+    const rest = name.slice(index + 1);
+    if (Buffer.byteLength(prefix) <= 155 && Buffer.byteLength(rest) <= 100) {
+      return { prefix, name: rest };
+    }
+  }
+  return null;
+}
+
+function createHeader(name: string, prefix: string, type: string, mode: number, size: number, mtime: Date): Buffer {
+  const header = Buffer.alloc(BLOCK_SIZE);
+  header.write(name, 0, 100, 'utf-8');
+  writeOctal(header, mode, 100, 8);
+  writeOctal(header, 0, 108, 8);
+  writeOctal(header, 0, 116, 8);
+  writeOctal(header, size, 124, 12);
+  writeOctal(header, Math.floor(mtime.getTime() / 1000), 136, 12);
+  header.write(type, 156, 1, 'ascii');
+  header.write('ustar\u000000', 257, 8, 'ascii');
+  header.write(prefix, 345, 155, 'utf-8');
+
+  // The checksum is computed with its own field read as spaces.
+  header.fill(' ', 148, 156);
+  const checksum = header.reduce((sum, byte) => sum + byte, 0);
+  header.write(checksum.toString(8).padStart(6, '0') + '\0 ', 148, 8, 'ascii');
+  return header;
+}
+
+function padToBlock(data: Buffer): Buffer {
+  const remainder = data.length % BLOCK_SIZE;
+  return remainder === 0 ? data : Buffer.concat([data, Buffer.alloc(BLOCK_SIZE - remainder)]);
+}
+
+/** A pax record is `<length> path=<value>\n`, where the length counts itself. */
+function createPaxPathRecord(value: string): Buffer {
+  const body = ` path=${value}\n`;
+  let length = Buffer.byteLength(body);
+  length += String(length + String(length).length).length;
+  return Buffer.from(`${length}${body}`, 'utf-8');
+}
+
+function createTar(entries: TarEntry[]): Buffer {
+  const blocks: Buffer[] = [];
+  for (const entry of entries) {
+    const name = entry.type === 'directory' ? `${entry.name}/` : entry.name;
+    let split = splitUstarName(name);
+    if (!split) {
+      // Paths ustar cannot hold go in a pax extended header before the entry.
+      const record = createPaxPathRecord(name);
+      blocks.push(createHeader('PaxHeader', '', 'x', 0o644, record.length, entry.mtime), padToBlock(record));
+      split = { prefix: '', name: name.slice(0, 100) };
+    }
+
+    const typeFlag = entry.type === 'directory' ? '5' : '0';
+    blocks.push(createHeader(split.name, split.prefix, typeFlag, entry.mode, entry.data.length, entry.mtime));
+    blocks.push(padToBlock(entry.data));
+  }
+  blocks.push(Buffer.alloc(BLOCK_SIZE * 2));
+  return Buffer.concat(blocks);
+}
+
+function readString(buffer: Buffer, offset: number, length: number): string {
+  const field = buffer.subarray(offset, offset + length);
+  const end = field.indexOf(0);
+  return field.subarray(0, end >= 0 ? end : length).toString('utf-8');
+}
+
+function readOctal(buffer: Buffer, offset: number, length: number): number {
+  const value = readString(buffer, offset, length).trim();
+  return value ? parseInt(value, 8) : 0;
+}
+
+function parseTar(archive: Buffer): TarEntry[] {
+  const entries: TarEntry[] = [];
+  let paxPath: string | null = null;
+  let offset = 0;
+  while (offset + BLOCK_SIZE <= archive.length) {
+    const header = archive.subarray(offset, offset + BLOCK_SIZE);
+    if (header.every((byte) => byte === 0)) {
+      break;
+    }
+
+    const size = readOctal(header, 124, 12);
+    const type = readString(header, 156, 1);
+    const data = archive.subarray(offset + BLOCK_SIZE, offset + BLOCK_SIZE + size);
+    offset += BLOCK_SIZE + Math.ceil(size / BLOCK_SIZE) * BLOCK_SIZE;
+
+    if (type === 'x') {
+      const match = data.toString('utf-8').match(/(?:^|\n)\d+ path=([^\n]*)\n/);
+      paxPath = match ? match[1] : null;
+      continue;
+    }
+
+    const prefix = readString(header, 345, 155);
+    const name = paxPath ?? (prefix ? `${prefix}/${readString(header, 0, 100)}` : readString(header, 0, 100));
+    paxPath = null;
+    if (type !== '0' && type !== '' && type !== '5') {
+      continue;
+    }
+
+    entries.push({
+      name: name.replace(/\/$/, ''),
+      type: type === '5' ? 'directory' : 'file',
+      mode: readOctal(header, 100, 8),
+      mtime: new Date(readOctal(header, 136, 12) * 1000),
+      data: Buffer.from(data),
+    });
+  }
+  return entries;
+}
+
+/**
+ * Write a gzipped tar of the whole notes root to outFile, with a manifest
+ * recording when it was taken and how many notes it holds.
+ */
+export function createBackup(root: string, outFile: string, now = new Date()): BackupManifest {
+  const manifest: BackupManifest = {
+    format: BACKUP_FORMAT_VERSION,
+    created: now.toISOString(),
+    noteCount: getAllMarkdownFiles(root).length,
+  };
+  const entries: TarEntry[] = [
+    {
+      name: BACKUP_MANIFEST_NAME,
+      type: 'file',
+      mode: 0o644,
+      mtime: now,
+      data: Buffer.from(`${JSON.stringify(manifest, null, 2)}\n`),
+    },
+    ...collectBackupEntries(root, path.resolve(outFile)),
+  ];
+
+  fs.mkdirSync(path.dirname(path.resolve(outFile)), { recursive: true });
+  fs.writeFileSync(outFile, zlib.gzipSync(createTar(entries)));
+  return manifest;
+}
+
+/** The manifest of a backup file, without extracting anything. */
+export function readBackupManifest(file: string): BackupManifest {
+  return parseBackupManifest(parseTar(zlib.gunzipSync(fs.readFileSync(file))));
+}
+
+function parseBackupManifest(entries: TarEntry[]): BackupManifest {
+  const entry = entries.find((candidate) => candidate.name === BACKUP_MANIFEST_NAME);
+  if (!entry) {
+    throw new Error('Not an agentnotes backup: no manifest');
+  }
+
+  const manifest = JSON.parse(entry.data.toString('utf-8')) as BackupManifest;
+  if (manifest.format > BACKUP_FORMAT_VERSION) {
+    throw new Error(`Backup format ${manifest.format} is newer than this version of agentnotes reads`);
+  }
+  return manifest;
+}
+
+/** Whether a directory holds any file; empty folders left by locks or mkdir do not count. */
+function hasAnyFiles(directory: string): boolean {
+  if (!fs.existsSync(directory)) {
+    return false;
+  }
+  return fs.readdirSync(directory, { withFileTypes: true }).some((entry) =>
+    entry.isDirectory() ? hasAnyFiles(path.join(directory, entry.name)) : true,
+  );
+}
+
+/**
+ * Extract a backup into root. A root that already has files is refused
+ * unless force is set, in which case files from the backup replace theirs
+ * and everything else is left in place.
+ */
+export function restoreBackup(file: string, root: string, options: { force?: boolean } = {}): BackupManifest {
+  const entries = parseTar(zlib.gunzipSync(fs.readFileSync(file)));
+  const manifest = parseBackupManifest(entries);
+
+  if (!options.force && hasAnyFiles(root)) {
+    throw new Error(`Notes directory is not empty: ${root}`);
+  }
+
+  const resolvedRoot = path.resolve(root);
+  for (const entry of entries) {
+    if (entry.name === BACKUP_MANIFEST_NAME) {
+      continue;
+    }
+
+    const target = path.resolve(resolvedRoot, entry.name);
+    const relative = path.relative(resolvedRoot, target);
+    if (!relative || relative.startsWith('..') || path.isAbsolute(relative)) {
+      throw new Error(`Backup entry is outside the notes directory: ${entry.name}`);
+    }
+  }
+
+  for (const entry of entries.filter((candidate) => candidate.name !== BACKUP_MANIFEST_NAME)) {
+    const target = path.join(resolvedRoot, entry.name);
+    if (entry.type === 'directory') {
+      fs.mkdirSync(target, { recursive: true });
+      continue;
+    }
+    fs.mkdirSync(path.dirname(target), { recursive: true });
+    fs.writeFileSync(target, entry.data, { mode: entry.mode || 0o644 });
+    // Notes without a sidecar timestamp fall back to the file's mtime.
+    fs.utimesSync(target, entry.mtime, entry.mtime);
+  }
+  return manifest;
+}
@@ -0,0 +1,128 @@
+import fs from 'node:fs';
+import os from 'node:os';
+import path from 'node:path';
+import type { SortField } from '../types.js';
+import { isRecord } from '../utils/validation.js';
+import { parseYaml } from '../utils/yaml.js';
+import { INTERNAL_DIRECTORY } from './filesystem.js';
+import { SORT_FIELDS } from './searches.js';
+
+/**
+ * User defaults for the CLI. Unset fields leave each command's own default
+ * in place, and flags given on the command line always win.
+ */
+export interface AgentNotesConfig {
+  /** Default --limit for note listings. */
+  limit: number | null;
+  /** Default --sort for note listings. */
+  sort: SortField | null;
+  /** Default --author for new comments. */
+  author: string;
+  /** Editor command, used instead of $EDITOR. */
+  editor: string;
+  /** Colored terminal output. */
+  color: boolean;
+  /** Reject tags no note uses yet on add and edit, unless --new-tag is passed. */
+  strictTags: boolean;
+}
+
+export interface LoadedConfig {
+  config: AgentNotesConfig;
+  /** Config files that were found and applied, lowest precedence first. */
+  sources: string[];
+}
+
+export const DEFAULT_CONFIG: AgentNotesConfig = {
+  limit: null,
+  sort: null,
+  author: '',
+  editor: '',
+  color: true,
+  strictTags: false,
+};
+
+const CONFIG_FILE_NAME = 'config.yml';
+
+/** Per-notes-directory config, which overrides the user config. */
+export function getNotesConfigPath(notesRoot: string): string {
+  return path.join(notesRoot, INTERNAL_DIRECTORY, CONFIG_FILE_NAME);
+}
+
+/** `$XDG_CONFIG_HOME/agentnotes/config.yml`, by default under `~/.config`. */
+export function getUserConfigPath(): string {
+  const configHome = process.env.XDG_CONFIG_HOME || path.join(os.homedir(), '.config');
+  return path.join(configHome, 'agentnotes', CONFIG_FILE_NAME);
+}
+
+/**
+ * Validate a parsed config document. Unknown keys and wrongly typed values
+ * are errors naming the file, so a typo is not silently ignored.
+ */
+export function parseConfig(text: string, source: string): Partial<AgentNotesConfig> {
+  const parsed = parseYaml(text);
+  if (parsed === null || parsed === undefined || (isRecord(parsed) && Object.keys(parsed).length === 0)) {
+    return {};
+  }
+  if (!isRecord(parsed)) {
+    throw new Error(`Invalid config in ${source}: expected a mapping of settings`);
+  }
+
+  const config: Partial<AgentNotesConfig> = {};
+  const fail = (message: string): never => {
+    throw new Error(`Invalid config in ${source}: ${message}`);
+  };
+
+  for (const [key, value] of Object.entries(parsed)) {
+    switch (key) {
+      case 'limit':
+        if (typeof value !== 'number' || !Number.isInteger(value) || value < 0) {
+          fail('limit must be a non-negative integer');
+        }
+        config.limit = value as number;
+        break;
+      case 'sort':
+        if (!SORT_FIELDS.includes(value as SortField)) {
+          fail(`sort must be one of: ${SORT_FIELDS.join(', ')}`);
+        }
+        config.sort = value as SortField;
+        break;
+      case 'author':
+      case 'editor':
+        if (typeof value !== 'string') {
+          fail(`${key} must be a string`);
+        }
+        config[key] = value as string;
+        break;
+      case 'color':
+      case 'strictTags':
+        if (typeof value !== 'boolean') {
+          fail(`${key} must be true or false`);
+        }
+        config[key] = value as boolean;
+        break;
+      default:
+        fail(`unknown setting "${key}"`);
+    }
+  }
+
+  return config;
+}
+
+/**
+ * Load the user config, then the notes directory's own config on top of the
+ * built-in defaults. Missing files are skipped.
+ */
+export function loadConfig(notesRoot: string, userConfigPath: string = getUserConfigPath()): LoadedConfig {
+  const config = { ...DEFAULT_CONFIG };
+  const sources: string[] = [];
+
+  for (const filePath of [userConfigPath, getNotesConfigPath(notesRoot)]) {
+    if (!fs.existsSync(filePath)) {
+      continue;
+    }
+    Object.assign(config, parseConfig(fs.readFileSync(filePath, 'utf-8'), filePath));
+    sources.push(filePath);
+  }
+
+  return { config, sources };
+}
@@ -2,20 +2,76 @@ import fs from 'node:fs';
 import path from 'node:path';
 import type { Note } from '../types.js';
 import { normalizeTags } from '../utils/normalization.js';
-import { toNumberValue, toStringArray } from '../utils/validation.js';
-import { parseMarkdownContent, extractNoteTitle } from './markdown.js';
+import { toIsoDate, toNumberValue, toStringArray } from '../utils/validation.js';
+import { normalizeMeta } from '../utils/meta.js';
+import { normalizeAliases } from '../utils/aliases.js';
+import { parseMarkdownContent, extractNoteTitle, getLegacyMeta } from './markdown.js';
+import { slugifyTitle } from '../utils/slugify.js';
+import { writeFileAtomic } from './atomic.js';
 import {
   getNoteSidecarPath,
+  migrateSidecarData,
   readSidecarData,
   writeSidecarData,
   parseComments,
 } from './sidecar.js';
+import { logError } from '../utils/log.js';
+
+/** Folder inside the notes root reserved for agentnotes' own data, such as the trash. */
+export const INTERNAL_DIRECTORY = '.agentnotes';
+const DUE_DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
 
 export interface MarkdownFileRecord {
   fullPath: string;
   relativePath: string;
 }
 
+/**
+ * Walk up from startDir, like git looking for `.git`, to the nearest
+ * directory holding an `.agentnotes` folder. Null when there is none up to
+ * the filesystem root.
+ */
+export function findNotesRoot(startDir: string): string | null {
+  let current = path.resolve(startDir);
+  while (true) {
+    if (fs.existsSync(path.join(current, INTERNAL_DIRECTORY))) {
+      return current;
+    }
+
+    const parent = path.dirname(current);
+    if (parent === current) {
+      return null;
+    }
+    current = parent;
+  }
+}
+
+export interface NotesRootSources {
+  /** An explicit directory, such as the CLI's --dir. */
+  dir?: string;
+  /** The directory from the environment, such as AGENTNOTES_DIR. */
+  envDir?: string;
+  /** Where discovery starts and the last fallback; the process cwd by default. */
+  cwd?: string;
+  /** Look upwards from cwd for an `.agentnotes` folder. */
+  discover?: boolean;
+}
+
+/**
+ * The notes root in order of precedence: the explicit directory, then the
+ * environment's, then the nearest ancestor of cwd with `.agentnotes` when
+ * discovering, then cwd itself. Relative paths resolve against cwd and the
+ * directory is created when missing.
+ */
+export function resolveNotesRoot(sources: NotesRootSources = {}): string {
+  const cwd = sources.cwd ?? process.cwd();
+  const chosen =
+    sources.dir || sources.envDir || ((sources.discover ?? true) && findNotesRoot(cwd)) || cwd;
+  const notesRoot = path.resolve(cwd, chosen);
+  fs.mkdirSync(notesRoot, { recursive: true });
+  return notesRoot;
+}
+
 export function formatRelativePath(inputPath: string): string {
   return inputPath.replace(/\\/g, '/');
 }
@@ -37,7 +93,7 @@ export function normalizeDirectoryInput(value: string): string | null {
   }
 
   for (const segment of segments) {
-    if (segment === '.' || segment === '..') {
+    if (segment.startsWith('.')) {
       return null;
     }
   }
@@ -74,7 +130,9 @@ export function getAllMarkdownFiles(dir: string, baseDir = dir): MarkdownFileRec
     const fullPath = path.join(dir, entry.name);
 
     if (entry.isDirectory()) {
-      files.push(...getAllMarkdownFiles(fullPath, baseDir));
+      if (!entry.name.startsWith('.')) {
+        files.push(...getAllMarkdownFiles(fullPath, baseDir));
+      }
       continue;
     }
 
@@ -97,7 +155,7 @@ export function getAllDirectories(dir: string, baseDir = dir): string[] {
   const entries = fs.readdirSync(dir, { withFileTypes: true });
 
   for (const entry of entries) {
-    if (!entry.isDirectory()) {
+    if (!entry.isDirectory() || entry.name.startsWith('.')) {
       continue;
     }
 
@@ -115,8 +173,14 @@ export function generateUniqueFilePath(targetDir: string, baseName: string): str
   let candidateName = `${normalizedBaseName}${extension}`;
   let candidatePath = path.join(targetDir, candidateName);
   let suffix = 2;
-
-  while (fs.existsSync(candidatePath) || fs.existsSync(getNoteSidecarPath(candidatePath))) {
+  // Names are compared case-insensitively so notes stay distinct on macOS and Windows.
+  const taken = new Set(
+    fs.existsSync(targetDir) ? fs.readdirSync(targetDir).map((entry) => entry.toLowerCase()) : [],
+  );
+  const isTaken = (filePath: string) =>
+    taken.has(path.basename(filePath).toLowerCase()) || fs.existsSync(filePath);
+
+  while (isTaken(candidatePath) || isTaken(getNoteSidecarPath(candidatePath))) {
     candidateName = `${normalizedBaseName}-${suffix}${extension}`;
     candidatePath = path.join(targetDir, candidateName);
     suffix += 1;
@@ -125,6 +189,35 @@ export function generateUniqueFilePath(targetDir: string, baseName: string): str
   return candidatePath;
 }
 
+const DATE_PREFIX_PATTERN = /^(\d{4}-\d{2}-\d{2})-/;
+
+/**
+ * Path a note should live at for a given title: same directory, the original
+ * date prefix (or the creation date), and the title slug. Returns the current
+ * path unchanged when it already carries that slug, including a `-N`
+ * collision suffix from generateUniqueFilePath. Given the title the file was
+ * named for, a suffix that is just the end of that title's slug (renaming
+ * "Meeting 2024" to "Meeting") is not mistaken for a collision suffix.
+ */
+export function getTitledFilePath(fullPath: string, title: string, created: string, previousTitle?: string): string {
+  const currentBaseName = path.basename(fullPath, '.md');
+  const datePrefix = currentBaseName.match(DATE_PREFIX_PATTERN)?.[1] ?? created.slice(0, 10);
+  const baseName = `${datePrefix}-${slugifyTitle(title)}`;
+
+  if (currentBaseName === baseName) {
+    return fullPath;
+  }
+
+  const suffix = currentBaseName.slice(baseName.length);
+  const namedForPrevious =
+    previousTitle !== undefined && currentBaseName === `${datePrefix}-${slugifyTitle(previousTitle)}`;
+  if (currentBaseName.startsWith(baseName) && /^-\d+$/.test(suffix) && !namedForPrevious) {
+    return fullPath;
+  }
+
+  return path.join(path.dirname(fullPath), `${baseName}.md`);
+}
+
 export function cleanupEmptyParentDirectories(startDir: string, stopDir: string): void {
   let current = startDir;
   const resolvedStop = path.resolve(stopDir);
@@ -201,9 +294,12 @@ export function compareNotes(a: Note, b: Note): number {
 
 export function parseNoteFile(filePath: string, relativePath = ''): Note | null {
   try {
-    const { content, legacyData, hasLegacyFrontmatter } = parseMarkdownContent(filePath);
     const sidecarPath = getNoteSidecarPath(filePath);
-    const sidecarData = readSidecarData(filePath);
+    const { content, legacyData, hasLegacyFrontmatter } = parseMarkdownContent(
+      filePath,
+      !fs.existsSync(sidecarPath),
+    );
+    const sidecarData = migrateSidecarData(readSidecarData(filePath));
     const normalizedRelativePath = formatRelativePath(
       relativePath || path.basename(filePath),
     );
@@ -222,6 +318,23 @@ export function parseNoteFile(filePath: string, relativePath = ''): Note | null
       defaultRev,
     );
     const commentRev = comments.length > 0 ? Math.max(1, declaredRev) : declaredRev;
+    const stats = fs.statSync(filePath);
+    const fileCreated = stats.birthtimeMs > 0 ? stats.birthtime : stats.mtime;
+    const created = toIsoDate(
+      sidecarData.created ?? legacyData.created,
+      fileCreated.toISOString(),
+    );
+    const updated = toIsoDate(
+      sidecarData.updated ?? legacyData.updated,
+      stats.mtime.toISOString(),
+    );
+    const meta = normalizeMeta(sidecarData.meta) ?? getLegacyMeta(legacyData);
+    const due = typeof sidecarData.due === 'string' && DUE_DATE_PATTERN.test(sidecarData.due)
+      ? sidecarData.due
+      : undefined;
+    const aliases = normalizeAliases(sidecarData.aliases ?? legacyData.aliases);
+    const attachments = toStringArray(sidecarData.attachments);
+    const archivedAt = sidecarData.archived_at ? toIsoDate(sidecarData.archived_at, '') : '';
     const normalizedComments = comments.map((comment) => ({
       ...comment,
       anchor: {
@@ -232,17 +345,23 @@ export function parseNoteFile(filePath: string, relativePath = ''): Note | null
 
     if (!fs.existsSync(sidecarPath) || hasLegacyFrontmatter) {
       try {
-        writeSidecarData(filePath, tags, normalizedComments, commentRev);
+        writeSidecarData(filePath, tags, normalizedComments, commentRev, {
+          created,
+          updated,
+          meta,
+          due,
+          aliases,
+        });
       } catch (error) {
-        console.error(`Error writing note metadata sidecar ${sidecarPath}:`, error);
+        logError(`Error writing note metadata sidecar ${sidecarPath}:`, error);
       }
     }
 
     if (hasLegacyFrontmatter) {
       try {
-        fs.writeFileSync(filePath, content, 'utf-8');
+        writeFileAtomic(filePath, content);
       } catch (error) {
-        console.error(`Error rewriting legacy note ${filePath}:`, error);
+        logError(`Error rewriting legacy note ${filePath}:`, error);
       }
     }
 
@@ -253,12 +372,21 @@ export function parseNoteFile(filePath: string, relativePath = ''): Note | null
       commentRev,
       comments: normalizedComments,
       content,
+      created,
+      updated,
       filename: path.basename(filePath),
       relativePath: normalizedRelativePath,
       directory: directory === '.' ? '' : directory,
+      ...(meta ? { meta } : {}),
+      ...(due ? { due } : {}),
+      ...(aliases.length > 0 ? { aliases } : {}),
+      ...(attachments.length > 0 ? { attachments } : {}),
+      ...(sidecarData.encrypted === true ? { encrypted: true } : {}),
+      ...(archivedAt ? { archivedAt } : {}),
+      ...(sidecarData.pinned === true ? { pinned: true } : {}),
     };
   } catch (error) {
-    console.error(`Error parsing note file ${filePath}:`, error);
+    logError(`Error parsing note file ${filePath}:`, error);
     return null;
   }
 }
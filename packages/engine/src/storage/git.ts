@@ -0,0 +1,129 @@
+import { execFileSync } from 'node:child_process';
+import path from 'node:path';
+import type { NoteRevision } from '../types.js';
+import { logWarning } from '../utils/log.js';
+
+/**
+ * Records a change to the notes directory. NoteStore calls this after each
+ * successful mutation; tests can pass a stub instead of shelling out to git.
+ */
+export type CommitFunction = (notesDir: string, message: string) => void;
+
+function runGit(cwd: string, args: string[]): string {
+  return execFileSync('git', args, {
+    cwd,
+    encoding: 'utf-8',
+    stdio: ['ignore', 'pipe', 'pipe'],
+  });
+}
+
+export function isInsideGitWorktree(dir: string): boolean {
+  try {
+    return runGit(dir, ['rev-parse', '--is-inside-work-tree']).trim() === 'true';
+  } catch {
+    return false;
+  }
+}
+
+/**
+ * Read access to a file's git history. NoteStore uses it for note history;
+ * tests can pass a stub instead of a real repository.
+ */
+export interface HistoryReader {
+  /** Commits that touched the file, newest first; null when it is not in a git worktree. */
+  log(filePath: string): NoteRevision[] | null;
+  /** The file's content at a revision, or null if git cannot produce it. */
+  show(filePath: string, revision: NoteRevision): string | null;
+}
+
+const RECORD_SEPARATOR = '\x1e';
+const FIELD_SEPARATOR = '\x1f';
+
+function parseLogOutput(output: string): NoteRevision[] {
+  return output
+    .split(RECORD_SEPARATOR)
+    .map((record) => record.trim())
+    .filter(Boolean)
+    .map((record) => {
+      // --name-only puts the file's path at that commit after the header line.
+      const [header, ...paths] = record.split('\n');
+      const [commit, date, message] = header.split(FIELD_SEPARATOR);
+      return { commit, date, message: message ?? '', path: paths.find((line) => line.trim()) ?? '' };
+    });
+}
+
+export function createGitHistoryReader(): HistoryReader {
+  return {
+    log(filePath) {
+      const dir = path.dirname(filePath);
+      if (!isInsideGitWorktree(dir)) {
+        return null;
+      }
+
+      try {
+        const output = runGit(dir, [
+          'log',
+          '--follow',
+          '--name-only',
+          `--format=${RECORD_SEPARATOR}%H${FIELD_SEPARATOR}%aI${FIELD_SEPARATOR}%s`,
+          '--',
+          path.basename(filePath),
+        ]);
+        return parseLogOutput(output);
+      } catch {
+        // A repository without commits has no history yet.
+        return [];
+      }
+    },
+
+    show(filePath, revision) {
+      try {
+        return runGit(path.dirname(filePath), ['show', `${revision.commit}:${revision.path}`]);
+      } catch {
+        return null;
+      }
+    },
+  };
+}
+
+/** Rebuilt from the notes whenever it is missing, so never worth a commit. */
+const UNCOMMITTED_PATHS = [':(exclude).agentnotes/index.json'];
+
+/**
+ * Stage and commit everything under notesDir. Changes staged elsewhere in the
+ * repository are left out of the commit.
+ */
+export function commitNotesDirectory(notesDir: string, message: string): void {
+  runGit(notesDir, ['add', '-A', '--', '.', ...UNCOMMITTED_PATHS]);
+
+  try {
+    runGit(notesDir, ['diff', '--cached', '--quiet', '--', '.']);
+    return;
+  } catch {
+    // A non-zero exit means there are staged changes to commit.
+  }
+
+  runGit(notesDir, ['commit', '--quiet', '-m', message, '--', '.']);
+}
+
+/**
+ * A CommitFunction that commits to git when the notes directory is inside a
+ * worktree. When git is missing or the directory is not tracked, it warns once
+ * and skips.
+ */
+export function createGitCommitter(): CommitFunction {
+  let available: boolean | null = null;
+
+  return (notesDir, message) => {
+    if (available === null) {
+      available = isInsideGitWorktree(notesDir);
+      if (!available) {
+        logWarning(`Git auto-commit skipped: ${notesDir} is not inside a git worktree`);
+      }
+    }
+
+    if (available) {
+      commitNotesDirectory(notesDir, message);
+    }
+  };
+}
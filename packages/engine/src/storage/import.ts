@@ -0,0 +1,60 @@
+import fs from 'node:fs';
+import path from 'node:path';
+import { ulid } from 'ulid';
+import type { NoteComment, NoteMeta } from '../types.js';
+import { normalizeTags } from '../utils/normalization.js';
+import { slugifyTitle } from '../utils/slugify.js';
+import { toIsoDate, toNumberValue, toStringArray, toStringValue } from '../utils/validation.js';
+import { extractHeadingTitle, getLegacyMeta, parseMarkdownContent } from './markdown.js';
+import { parseComments } from './sidecar.js';
+
+/** A markdown file from outside the notes directory, read as a new note. */
+export interface ImportedNoteData {
+  title: string;
+  content: string;
+  tags: string[];
+  created: string;
+  updated: string;
+  meta?: NoteMeta;
+  comments: NoteComment[];
+  commentRev: number;
+}
+
+/**
+ * Read a markdown file for import without touching it. Frontmatter supplies
+ * the title, tags, timestamps, comments and custom fields when present;
+ * otherwise the title is the first heading or the filename, and timestamps
+ * come from the file. Content gets a `# Title` heading if it has none, and
+ * comments without an ID are given a new one.
+ */
+export function readImportSource(filePath: string): ImportedNoteData {
+  const { content, legacyData } = parseMarkdownContent(filePath);
+  const stats = fs.statSync(filePath);
+  const fileCreated = stats.birthtimeMs > 0 ? stats.birthtime : stats.mtime;
+
+  const title =
+    toStringValue(legacyData.title).trim() ||
+    extractHeadingTitle(content) ||
+    path.basename(filePath, path.extname(filePath));
+  const body = extractHeadingTitle(content) === null ? `# ${title}\n\n${content}`.trimEnd() : content;
+  const commentRev = Math.max(1, toNumberValue(legacyData.comment_rev, 1));
+  const comments = parseComments(legacyData.comments, body, commentRev).map((comment) =>
+    comment.id ? comment : { ...comment, id: ulid() },
+  );
+
+  return {
+    title,
+    content: body,
+    tags: normalizeTags(toStringArray(legacyData.tags)),
+    created: toIsoDate(legacyData.created, fileCreated.toISOString()),
+    updated: toIsoDate(legacyData.updated, stats.mtime.toISOString()),
+    meta: getLegacyMeta(legacyData),
+    comments,
+    commentRev: comments.length > 0 ? commentRev : 0,
+  };
+}
+
+/** The `<date>-<slug>.md` filename an imported note is written under. */
+export function getImportFileName(data: ImportedNoteData): string {
+  return `${data.created.slice(0, 10)}-${slugifyTitle(data.title)}.md`;
+}
@@ -1,28 +1,95 @@
 export {
   parseMarkdownContent,
   extractNoteTitle,
+  extractHeadingTitle,
+  replaceNoteTitle,
+  stripFrontmatter,
+  getLegacyMeta,
 } from './markdown.js';
 export type { LegacyFrontmatterData, ParsedMarkdownNote } from './markdown.js';
 
 export {
+  NOTE_SCHEMA_VERSION,
+  getSidecarSchema,
+  migrateSidecarData,
   getNoteSidecarPath,
+  getSidecarMetadata,
   readSidecarData,
   writeSidecarData,
   parseComments,
   toCommentRecord,
 } from './sidecar.js';
-export type { NoteSidecarData } from './sidecar.js';
+export type { NoteSidecarData, NoteSidecarMetadata } from './sidecar.js';
 
 export {
+  INTERNAL_DIRECTORY,
+  findNotesRoot,
+  resolveNotesRoot,
   formatRelativePath,
   normalizeDirectoryInput,
   resolveNotesPath,
   getAllMarkdownFiles,
   getAllDirectories,
   generateUniqueFilePath,
+  getTitledFilePath,
   cleanupEmptyParentDirectories,
   findNoteRecordById,
   compareNotes,
   parseNoteFile,
 } from './filesystem.js';
-export type { MarkdownFileRecord } from './filesystem.js';
+export type { MarkdownFileRecord, NotesRootSources } from './filesystem.js';
+
+export { isInsideGitWorktree, commitNotesDirectory, createGitCommitter, createGitHistoryReader } from './git.js';
+export type { CommitFunction, HistoryReader } from './git.js';
+
+export {
+  getSavedSearchesPath,
+  isValidSavedSearchName,
+  readSavedSearches,
+  writeSavedSearches,
+  normalizeSearchOptions,
+  resolveSearchDates,
+  SORT_FIELDS,
+  parseSortFields,
+} from './searches.js';
+
+export {
+  DEFAULT_CONFIG,
+  getNotesConfigPath,
+  getUserConfigPath,
+  parseConfig,
+  loadConfig,
+} from './config.js';
+export type { AgentNotesConfig, LoadedConfig } from './config.js';
+
+export { DEFAULT_FILE_MODE, writeFileAtomic } from './atomic.js';
+
+export {
+  DEFAULT_LOCK_TIMEOUT_MS,
+  STALE_LOCK_MS,
+  getLocksDirectory,
+  getLockPath,
+  acquireLock,
+  acquireLocks,
+} from './lock.js';
+export type { ReleaseLock } from './lock.js';
+
+export { NotesWatcher, DEFAULT_WATCH_DEBOUNCE_MS } from './watcher.js';
+export type { NotesWatcherOptions } from './watcher.js';
+
+export {
+  BACKUP_MANIFEST_NAME,
+  BACKUP_FORMAT_VERSION,
+  createBackup,
+  readBackupManifest,
+  restoreBackup,
+} from './backup.js';
+export type { BackupManifest } from './backup.js';
+
+export { readImportSource, getImportFileName } from './import.js';
+export type { ImportedNoteData } from './import.js';
+
+export { getTemplatesDirectory, listTemplates, readTemplate, renderTemplate } from './templates.js';
+export type { TemplateValues } from './templates.js';
+
+export { getAttachmentsDirectory, copyAttachment, moveAttachments, removeAttachments } from './attachments.js';
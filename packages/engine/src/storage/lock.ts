@@ -0,0 +1,200 @@
+import fs from 'node:fs';
+import os from 'node:os';
+import path from 'node:path';
+import { INTERNAL_DIRECTORY } from './filesystem.js';
+
+export const DEFAULT_LOCK_TIMEOUT_MS = 5000;
+
+/**
+ * A lock file this old is left over from a crashed process and is taken over,
+ * unless it names a process on this host that is still running.
+ */
+export const STALE_LOCK_MS = 30_000;
+
+/** How often a held lock's mtime is touched, so a slow holder never looks stale. */
+const REFRESH_INTERVAL_MS = STALE_LOCK_MS / 3;
+
+const RETRY_INTERVAL_MS = 20;
+
+/** Releases a held lock. Safe to call more than once. */
+export type ReleaseLock = () => void;
+
+/**
+ * Lock files live under `.agentnotes/locks/`, which carries its own
+ * `.gitignore` so a lock held during an auto-commit is never committed.
+ */
+export function getLocksDirectory(rootDir: string): string {
+  return path.join(rootDir, INTERNAL_DIRECTORY, 'locks');
+}
+
+/** Lock file for a name such as a note path; slashes are encoded to keep it flat. */
+export function getLockPath(rootDir: string, name: string): string {
+  return path.join(getLocksDirectory(rootDir), `${encodeURIComponent(name)}.lock`);
+}
+
+function ensureLocksDirectory(rootDir: string): void {
+  const locksDir = getLocksDirectory(rootDir);
+  fs.mkdirSync(locksDir, { recursive: true });
+  const ignorePath = path.join(locksDir, '.gitignore');
+  if (!fs.existsSync(ignorePath)) {
+    fs.writeFileSync(ignorePath, '*\n', 'utf-8');
+  }
+}
+
+/**
+ * Whether the lock file names a process on this host that is still running.
+ * Holders on other hosts, as on a network share, cannot be checked and rely
+ * on their refreshed mtime instead.
+ */
+function isHolderRunning(lockPath: string): boolean {
+  try {
+    const [pid, , hostname] = fs.readFileSync(lockPath, 'utf-8').split('\n');
+    if (hostname !== os.hostname() || !/^\d+$/.test(pid)) {
+      return false;
+    }
+    process.kill(Number(pid), 0);
+    return true;
+  } catch (error) {
+    // EPERM means the process exists but belongs to another user.
+    return (error as NodeJS.ErrnoException).code === 'EPERM';
+  }
+}
+
+function isStale(lockPath: string): boolean {
+  try {
+    return Date.now() - fs.statSync(lockPath).mtimeMs > STALE_LOCK_MS && !isHolderRunning(lockPath);
+  } catch {
+    return false;
+  }
+}
+
+function readLockFile(lockPath: string): string | null {
+  try {
+    return fs.readFileSync(lockPath, 'utf-8');
+  } catch {
+    return null;
+  }
+}
+
+/**
+ * Move a stale lock aside so that only one waiter can take it over. If the
+ * file moved turns out to be fresh, another waiter took the lock over first,
+ * and it is put back.
+ */
+function takeOverStaleLock(lockPath: string): void {
+  const asidePath = `${lockPath}.${process.pid}.stale`;
+  try {
+    fs.renameSync(lockPath, asidePath);
+  } catch {
+    return;
+  }
+
+  if (!isStale(asidePath)) {
+    try {
+      fs.linkSync(asidePath, lockPath);
+    } catch {
+      // Someone holds the lock again by now; theirs stands.
+    }
+  }
+  fs.rmSync(asidePath, { force: true });
+}
+
+function describeHolder(lockPath: string): string {
+  try {
+    const pid = fs.readFileSync(lockPath, 'utf-8').trim().split('\n')[0];
+    return pid ? ` (held by pid ${pid})` : '';
+  } catch {
+    return '';
+  }
+}
+
+function sleep(ms: number): Promise<void> {
+  return new Promise((resolve) => setTimeout(resolve, ms));
+}
+
+/**
+ * Take an advisory lock by exclusively creating its lock file, polling until
+ * timeoutMs has passed. Other agentnotes processes (the CLI, the GUI) honour
+ * the same files; nothing stops an editor from writing the note meanwhile.
+ */
+export async function acquireLock(
+  rootDir: string,
+  name: string,
+  timeoutMs: number = DEFAULT_LOCK_TIMEOUT_MS,
+): Promise<ReleaseLock> {
+  const lockPath = getLockPath(rootDir, name);
+  const deadline = Date.now() + timeoutMs;
+  ensureLocksDirectory(rootDir);
+
+  for (;;) {
+    const contents = `${process.pid}\n${new Date().toISOString()}\n${os.hostname()}\n`;
+    try {
+      fs.writeFileSync(lockPath, contents, { flag: 'wx' });
+      const refresher = setInterval(() => {
+        try {
+          if (readLockFile(lockPath) === contents) {
+            const now = new Date();
+            fs.utimesSync(lockPath, now, now);
+          }
+        } catch {
+          // Released or taken over in between; there is nothing left to refresh.
+        }
+      }, REFRESH_INTERVAL_MS);
+      refresher.unref();
+
+      let released = false;
+      return () => {
+        if (!released) {
+          released = true;
+          clearInterval(refresher);
+          // A lock taken over from this process belongs to its new holder.
+          if (readLockFile(lockPath) === contents) {
+            fs.rmSync(lockPath, { force: true });
+          }
+        }
+      };
+    } catch (error) {
+      if ((error as NodeJS.ErrnoException).code !== 'EEXIST') {
+        throw error;
+      }
+    }
+
+    if (isStale(lockPath)) {
+      takeOverStaleLock(lockPath);
+      continue;
+    }
+
+    if (Date.now() >= deadline) {
+      throw new Error(
+        `Timed out waiting for lock on ${name}${describeHolder(lockPath)}; ` +
+          `if no other agentnotes process is running, remove ${lockPath}`,
+      );
+    }
+
+    await sleep(RETRY_INTERVAL_MS);
+  }
+}
+
+/**
+ * Take several locks in order, releasing the ones already held if a later
+ * one times out. Callers pass names in a fixed order to avoid deadlocks.
+ */
+export async function acquireLocks(rootDir: string, names: string[], timeoutMs?: number): Promise<ReleaseLock> {
+  const held: ReleaseLock[] = [];
+  const releaseAll = () => {
+    for (const release of held.reverse()) {
+      release();
+    }
+  };
+
+  try {
+    for (const name of names) {
+      held.push(await acquireLock(rootDir, name, timeoutMs));
+    }
+  } catch (error) {
+    releaseAll();
+    throw error;
+  }
+
+  return releaseAll;
+}
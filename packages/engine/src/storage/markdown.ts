@@ -1,7 +1,9 @@
 import fs from 'node:fs';
 import path from 'node:path';
 import matter from 'gray-matter';
-import { normalizeContent } from '../utils/normalization.js';
+import { normalizeContent, normalizeLineEndings } from '../utils/normalization.js';
+import type { NoteMeta } from '../types.js';
+import { normalizeMeta } from '../utils/meta.js';
 import { isRecord } from '../utils/validation.js';
 
 const LEGACY_FRONTMATTER_FIELDS = new Set([
@@ -13,6 +15,7 @@ const LEGACY_FRONTMATTER_FIELDS = new Set([
   'source',
   'comment_rev',
   'comments',
+  'aliases',
 ]);
 
 export interface LegacyFrontmatterData extends Record<string, unknown> {
@@ -24,6 +27,7 @@ export interface LegacyFrontmatterData extends Record<string, unknown> {
   source?: unknown;
   comment_rev?: unknown;
   comments?: unknown;
+  aliases?: unknown;
 }
 
 export interface ParsedMarkdownNote {
@@ -46,12 +50,59 @@ function hasLegacyFrontmatter(data: unknown): data is LegacyFrontmatterData {
   return false;
 }
 
-export function parseMarkdownContent(filePath: string): ParsedMarkdownNote {
-  const rawContent = fs.readFileSync(filePath, 'utf-8');
-  const normalizedRawContent = rawContent.replace(/\r\n/g, '\n');
-  const parsed = matter(normalizedRawContent);
+/**
+ * Frontmatter keys that are not note fields become custom metadata when a
+ * legacy note is migrated. Values are passed through JSON so dates that YAML
+ * parsed become ISO strings.
+ */
+export function getLegacyMeta(legacyData: LegacyFrontmatterData): NoteMeta | undefined {
+  const custom = Object.fromEntries(
+    Object.entries(legacyData).filter(([key]) => !LEGACY_FRONTMATTER_FIELDS.has(key)),
+  );
+  return normalizeMeta(JSON.parse(JSON.stringify(custom)));
+}
+
+/**
+ * Split off a leading YAML block. A body that merely opens with a `---` rule
+ * can hold anything up to the next rule, so YAML that fails to parse means
+ * there is no frontmatter rather than an unreadable note. Passing options
+ * keeps gray-matter from returning a cached result.
+ */
+function parseFrontmatter(rawContent: string): { data: unknown; content: string } | null {
+  if (!rawContent.startsWith('---\n')) {
+    return null;
+  }
+
+  try {
+    const parsed = matter(rawContent, {});
+    return { data: parsed.data, content: parsed.content };
+  } catch {
+    return null;
+  }
+}
+
+/**
+ * Content without a leading YAML block, for output that wants only the prose.
+ * A block must hold at least one key; a body opening with a rule is kept.
+ */
+export function stripFrontmatter(content: string): string {
+  const parsed = parseFrontmatter(content);
+  if (!parsed || !isRecord(parsed.data) || Object.keys(parsed.data).length === 0) {
+    return content;
+  }
+  return normalizeContent(parsed.content).replace(/^\n+/, '');
+}
 
-  if (normalizedRawContent.startsWith('---\n') && hasLegacyFrontmatter(parsed.data)) {
+/**
+ * Read a markdown file, splitting off legacy frontmatter when allowed. Notes
+ * that already have a sidecar never carry frontmatter, so for them the whole
+ * file is body, even when it opens with something that looks like YAML.
+ */
+export function parseMarkdownContent(filePath: string, allowFrontmatter = true): ParsedMarkdownNote {
+  const rawContent = normalizeLineEndings(fs.readFileSync(filePath, 'utf-8'));
+  const parsed = allowFrontmatter ? parseFrontmatter(rawContent) : null;
+
+  if (parsed && hasLegacyFrontmatter(parsed.data)) {
     return {
       content: normalizeContent(parsed.content),
       legacyData: parsed.data,
@@ -60,13 +111,13 @@ export function parseMarkdownContent(filePath: string): ParsedMarkdownNote {
   }
 
   return {
-    content: normalizeContent(normalizedRawContent),
+    content: normalizeContent(rawContent),
     legacyData: {},
     hasLegacyFrontmatter: false,
   };
 }
 
-export function extractNoteTitle(content: string, filePath: string): string {
+export function extractHeadingTitle(content: string): string | null {
   const firstLineBreak = content.indexOf('\n');
   const firstLine = firstLineBreak >= 0 ? content.slice(0, firstLineBreak) : content;
   const headingMatch = firstLine.match(/^#\s+(.+?)\s*$/);
@@ -74,5 +125,20 @@ export function extractNoteTitle(content: string, filePath: string): string {
     return headingMatch[1].trim();
   }
 
-  return path.basename(filePath, '.md');
+  return null;
+}
+
+export function extractNoteTitle(content: string, filePath: string): string {
+  return extractHeadingTitle(content) ?? path.basename(filePath, '.md');
+}
+
+export function replaceNoteTitle(content: string, title: string): string {
+  const lines = content.split('\n');
+  if (/^#\s+/.test(lines[0])) {
+    lines[0] = `# ${title}`;
+  } else {
+    lines.unshift(`# ${title}`);
+  }
+
+  return lines.join('\n');
 }
@@ -0,0 +1,170 @@
+import fs from 'node:fs';
+import path from 'node:path';
+import { writeFileAtomic } from './atomic.js';
+import type { SavedSearch, SearchOptions, SortField } from '../types.js';
+import { isRecord, toStringArray } from '../utils/validation.js';
+import { parseDateExpression } from '../utils/dates.js';
+import { parseYaml, toYaml } from '../utils/yaml.js';
+import { INTERNAL_DIRECTORY } from './filesystem.js';
+
+const SAVED_SEARCH_NAME_PATTERN = /^[A-Za-z0-9][A-Za-z0-9_-]*$/;
+export const SORT_FIELDS: SortField[] = ['created', 'updated', 'title', 'due', 'relevance'];
+const DATE_FIELDS = ['createdAfter', 'createdBefore', 'updatedAfter', 'updatedBefore'] as const;
+
+/**
+ * Parse a comma-separated sort spec such as `due,title`. Throws on unknown
+ * fields so a typo is reported rather than silently sorting by created.
+ */
+export function parseSortFields(value: string): SortField[] {
+  const fields = value
+    .split(',')
+    .map((field) => field.trim())
+    .filter(Boolean);
+  if (fields.length === 0) {
+    throw new Error(`Sort must name at least one of: ${SORT_FIELDS.join(', ')}`);
+  }
+
+  for (const field of fields) {
+    if (!SORT_FIELDS.includes(field as SortField)) {
+      throw new Error(`Unknown sort field: ${field} (expected ${SORT_FIELDS.join(', ')})`);
+    }
+  }
+  return fields as SortField[];
+}
+
+export function getSavedSearchesPath(notesRoot: string): string {
+  return path.join(notesRoot, INTERNAL_DIRECTORY, 'searches.yml');
+}
+
+export function isValidSavedSearchName(name: string): boolean {
+  return SAVED_SEARCH_NAME_PATTERN.test(name);
+}
+
+/**
+ * Saved searches are a YAML mapping of name to SearchOptions. A missing file
+ * is an empty set.
+ */
+export function readSavedSearches(notesRoot: string): SavedSearch[] {
+  const filePath = getSavedSearchesPath(notesRoot);
+  if (!fs.existsSync(filePath)) {
+    return [];
+  }
+
+  const parsed = parseYaml(fs.readFileSync(filePath, 'utf-8'));
+  if (!isRecord(parsed)) {
+    return [];
+  }
+
+  return Object.entries(parsed)
+    .filter(([name]) => isValidSavedSearchName(name))
+    .map(([name, options]) => ({ name, options: normalizeSearchOptions(options) }));
+}
+
+export function writeSavedSearches(notesRoot: string, searches: SavedSearch[]): void {
+  const filePath = getSavedSearchesPath(notesRoot);
+  fs.mkdirSync(path.dirname(filePath), { recursive: true });
+
+  const document: Record<string, SearchOptions> = {};
+  for (const search of searches) {
+    document[search.name] = search.options;
+  }
+
+  writeFileAtomic(filePath, toYaml(document));
+}
+
+/** Keep only well-formed SearchOptions fields from parsed YAML. */
+export function normalizeSearchOptions(value: unknown): SearchOptions {
+  if (!isRecord(value)) {
+    return {};
+  }
+
+  const options: SearchOptions = {};
+  if (typeof value.query === 'string' && value.query) {
+    options.query = value.query;
+  }
+  if (value.regex === true) {
+    options.regex = true;
+  }
+  if (value.boolean === true) {
+    options.boolean = true;
+  }
+  if (value.caseSensitive === true) {
+    options.caseSensitive = true;
+  }
+  if (value.wholeWord === true) {
+    options.wholeWord = true;
+  }
+
+  const tags = toStringArray(value.tags);
+  if (tags.length > 0) {
+    options.tags = tags;
+  }
+
+  const meta = toStringArray(value.meta);
+  if (meta.length > 0) {
+    options.meta = meta;
+  }
+
+  for (const field of DATE_FIELDS) {
+    const bound = value[field];
+    if (typeof bound === 'string' && bound) {
+      options[field] = bound;
+    }
+  }
+
+  for (const field of ['minPriority', 'maxPriority'] as const) {
+    if (typeof value[field] === 'number' && Number.isInteger(value[field])) {
+      options[field] = value[field] as number;
+    }
+  }
+
+  if (typeof value.offset === 'number' && Number.isInteger(value.offset) && value.offset > 0) {
+    options.offset = value.offset;
+  }
+  // A limit of 0 is kept: a saved search with it shows every match rather
+  // than falling back to the limit it is run with.
+  if (typeof value.limit === 'number' && Number.isInteger(value.limit) && value.limit >= 0) {
+    options.limit = value.limit;
+  }
+  if (SORT_FIELDS.includes(value.sortBy as SortField)) {
+    options.sortBy = value.sortBy as SortField;
+  } else if (
+    Array.isArray(value.sortBy) &&
+    value.sortBy.length > 0 &&
+    value.sortBy.every((field) => SORT_FIELDS.includes(field as SortField))
+  ) {
+    options.sortBy = value.sortBy as SortField[];
+  }
+  if (value.reverse === true) {
+    options.reverse = true;
+  }
+  if (value.includeArchived === true) {
+    options.includeArchived = true;
+  }
+  if (value.onlyArchived === true) {
+    options.onlyArchived = true;
+  }
+  if (value.onlyPinned === true) {
+    options.onlyPinned = true;
+  }
+  if (value.pinnedFirst === true) {
+    options.pinnedFirst = true;
+  }
+
+  return options;
+}
+
+/**
+ * Resolve relative date bounds (`7d`) against now so a saved search stays
+ * relative each time it runs. Throws for unparseable expressions.
+ */
+export function resolveSearchDates(options: SearchOptions, now: Date = new Date()): SearchOptions {
+  const resolved: SearchOptions = { ...options };
+  for (const field of DATE_FIELDS) {
+    const bound = options[field];
+    if (bound) {
+      resolved[field] = parseDateExpression(bound, field.endsWith('Before') ? 'end' : 'start', now);
+    }
+  }
+  return resolved;
+}
@@ -1,6 +1,7 @@
 import fs from 'node:fs';
 import path from 'node:path';
-import type { CommentAnchor, CommentStatus, NoteComment } from '../types.js';
+import { writeFileAtomic } from './atomic.js';
+import type { CommentAnchor, CommentStatus, Note, NoteComment, NoteMeta } from '../types.js';
 import { normalizeTags } from '../utils/normalization.js';
 import { normalizeAffinity, normalizeStatus } from '../utils/normalization.js';
 import {
@@ -11,11 +12,58 @@ import {
   toStringValue,
 } from '../utils/validation.js';
 import { getUniqueMatchRange } from '../comments/anchoring.js';
+import { logError } from '../utils/log.js';
+
+/**
+ * Version of the sidecar layout this build reads and writes. Sidecars written
+ * before the field existed have none and count as version 1.
+ */
+export const NOTE_SCHEMA_VERSION = 2;
 
 export interface NoteSidecarData extends Record<string, unknown> {
+  schema?: unknown;
   tags?: unknown;
   comment_rev?: unknown;
   comments?: unknown;
+  created?: unknown;
+  updated?: unknown;
+  meta?: unknown;
+  due?: unknown;
+  aliases?: unknown;
+  attachments?: unknown;
+  encrypted?: unknown;
+  archived_at?: unknown;
+  pinned?: unknown;
+}
+
+export interface NoteSidecarMetadata {
+  created?: string;
+  updated?: string;
+  meta?: NoteMeta;
+  due?: string;
+  aliases?: string[];
+  attachments?: string[];
+  encrypted?: boolean;
+  archivedAt?: string;
+  pinned?: boolean;
+}
+
+/**
+ * The sidecar fields a write should carry over from the note when it only
+ * changes tags or comments.
+ */
+export function getSidecarMetadata(note: Note): NoteSidecarMetadata {
+  return {
+    created: note.created,
+    updated: note.updated,
+    meta: note.meta,
+    due: note.due,
+    aliases: note.aliases,
+    attachments: note.attachments,
+    encrypted: note.encrypted,
+    archivedAt: note.archivedAt,
+    pinned: note.pinned,
+  };
 }
 
 export function getNoteSidecarPath(notePath: string): string {
@@ -24,6 +72,33 @@ export function getNoteSidecarPath(notePath: string): string {
   return `${extensionlessPath}.json`;
 }
 
+/** The schema version a sidecar declares, 1 when it has none. */
+export function getSidecarSchema(data: NoteSidecarData): number {
+  const schema = toNumberValue(data.schema, 1);
+  return Number.isInteger(schema) && schema >= 1 ? schema : 1;
+}
+
+/**
+ * Upgrades from each schema version to the next, keyed by the version they
+ * upgrade from. Version 2 only added the `schema` field itself.
+ */
+const SIDECAR_MIGRATIONS: Record<number, (data: NoteSidecarData) => NoteSidecarData> = {
+  1: (data) => data,
+};
+
+/**
+ * Bring sidecar data written by an older version up to NOTE_SCHEMA_VERSION
+ * in memory. Data from a newer version is returned as it is, since there is
+ * no way to know what it changed.
+ */
+export function migrateSidecarData(data: NoteSidecarData): NoteSidecarData {
+  let migrated = data;
+  for (let version = getSidecarSchema(data); version < NOTE_SCHEMA_VERSION; version += 1) {
+    migrated = SIDECAR_MIGRATIONS[version](migrated);
+  }
+  return { ...migrated, schema: Math.max(getSidecarSchema(data), NOTE_SCHEMA_VERSION) };
+}
+
 export function readSidecarData(filePath: string): NoteSidecarData {
   const sidecarPath = getNoteSidecarPath(filePath);
   if (!fs.existsSync(sidecarPath)) {
@@ -35,7 +110,7 @@ export function readSidecarData(filePath: string): NoteSidecarData {
     const parsedData = JSON.parse(rawData) as unknown;
     return isRecord(parsedData) ? parsedData : {};
   } catch (error) {
-    console.error(`Error reading note metadata sidecar ${sidecarPath}:`, error);
+    logError(`Error reading note metadata sidecar ${sidecarPath}:`, error);
     return {};
   }
 }
@@ -45,11 +120,13 @@ export function writeSidecarData(
   tags: string[],
   comments: NoteComment[],
   commentRev: number,
+  metadata: NoteSidecarMetadata = {},
 ): void {
   const sidecarPath = getNoteSidecarPath(filePath);
   const normalizedTags = normalizeTags(tags);
   const normalizedCommentRev = Math.max(0, Math.floor(commentRev));
   const payload: Record<string, unknown> = {
+    schema: NOTE_SCHEMA_VERSION,
     tags: normalizedTags,
     comments: comments.map((comment) => toCommentRecord(comment)),
   };
@@ -58,7 +135,43 @@ export function writeSidecarData(
     payload.comment_rev = normalizedCommentRev;
   }
 
-  fs.writeFileSync(sidecarPath, `${JSON.stringify(payload, null, 2)}\n`, 'utf-8');
+  if (metadata.created) {
+    payload.created = metadata.created;
+  }
+
+  if (metadata.updated) {
+    payload.updated = metadata.updated;
+  }
+
+  if (metadata.due) {
+    payload.due = metadata.due;
+  }
+
+  if (metadata.aliases && metadata.aliases.length > 0) {
+    payload.aliases = metadata.aliases;
+  }
+
+  if (metadata.attachments && metadata.attachments.length > 0) {
+    payload.attachments = metadata.attachments;
+  }
+
+  if (metadata.encrypted) {
+    payload.encrypted = true;
+  }
+
+  if (metadata.archivedAt) {
+    payload.archived_at = metadata.archivedAt;
+  }
+
+  if (metadata.pinned) {
+    payload.pinned = true;
+  }
+
+  if (metadata.meta && Object.keys(metadata.meta).length > 0) {
+    payload.meta = metadata.meta;
+  }
+
+  writeFileAtomic(sidecarPath, `${JSON.stringify(payload, null, 2)}\n`);
 }
 
 function parseCommentAnchor(
@@ -160,6 +273,10 @@ function parseCommentEntry(
     content: toStringValue(source.content),
     status: normalizeStatus(source.status, hasRange) as CommentStatus,
     anchor,
+    ...(source.resolved === true
+      ? { resolved: true, resolvedAt: toIsoDate(source.resolved_at, fallbackIso) }
+      : {}),
+    ...(source.edited !== undefined ? { edited: toIsoDate(source.edited, fallbackIso) } : {}),
   };
 }
 
@@ -196,5 +313,7 @@ export function toCommentRecord(comment: NoteComment): Record<string, unknown> {
     content: comment.content,
     status: comment.status,
     anchor: toAnchorRecord(comment.anchor),
+    ...(comment.resolved ? { resolved: true, resolved_at: comment.resolvedAt } : {}),
+    ...(comment.edited ? { edited: comment.edited } : {}),
   };
 }
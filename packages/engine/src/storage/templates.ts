@@ -0,0 +1,48 @@
+import fs from 'node:fs';
+import path from 'node:path';
+import { INTERNAL_DIRECTORY } from './filesystem.js';
+
+const TEMPLATE_NAME_PATTERN = /^[A-Za-z0-9][A-Za-z0-9_-]*$/;
+const PLACEHOLDER_PATTERN = /\{\{\s*(title|date|id)\s*\}\}/g;
+
+export interface TemplateValues {
+  title: string;
+  date: string;
+  id: string;
+}
+
+export function getTemplatesDirectory(notesRoot: string): string {
+  return path.join(notesRoot, INTERNAL_DIRECTORY, 'templates');
+}
+
+/** Template names, without the .md extension, sorted alphabetically. */
+export function listTemplates(notesRoot: string): string[] {
+  const templatesDir = getTemplatesDirectory(notesRoot);
+  if (!fs.existsSync(templatesDir)) {
+    return [];
+  }
+
+  return fs
+    .readdirSync(templatesDir, { withFileTypes: true })
+    .filter((entry) => entry.isFile() && entry.name.toLowerCase().endsWith('.md'))
+    .map((entry) => entry.name.slice(0, -3))
+    .filter((name) => TEMPLATE_NAME_PATTERN.test(name))
+    .sort((a, b) => a.localeCompare(b));
+}
+
+export function readTemplate(notesRoot: string, name: string): string | null {
+  if (!TEMPLATE_NAME_PATTERN.test(name)) {
+    return null;
+  }
+
+  const templatePath = path.join(getTemplatesDirectory(notesRoot), `${name}.md`);
+  return fs.existsSync(templatePath) ? fs.readFileSync(templatePath, 'utf-8') : null;
+}
+
+/**
+ * Fill `{{title}}`, `{{date}}`, and `{{id}}` placeholders. Anything else in
+ * braces is left as written.
+ */
+export function renderTemplate(template: string, values: TemplateValues): string {
+  return template.replace(PLACEHOLDER_PATTERN, (_, key: keyof TemplateValues) => values[key]);
+}
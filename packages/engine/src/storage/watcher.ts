@@ -0,0 +1,177 @@
+import fs from 'node:fs';
+import path from 'node:path';
+import { logError } from '../utils/log.js';
+import { INTERNAL_DIRECTORY, getAllMarkdownFiles } from './filesystem.js';
+import { getNoteSidecarPath } from './sidecar.js';
+
+export const DEFAULT_WATCH_DEBOUNCE_MS = 250;
+
+export interface NotesWatcherOptions {
+  /** Quiet time after the last change before onChange runs. */
+  debounceMs?: number;
+  /**
+   * Poll the notes' modification times this often instead of using
+   * fs.watch, for network and other filesystems where events go missing.
+   */
+  pollIntervalMs?: number;
+}
+
+/**
+ * Changes the app should not reload for: agentnotes' own data (locks, trash)
+ * and the temp files written on the way to an atomic rename.
+ */
+function isIgnoredChange(relativePath: string): boolean {
+  const parts = relativePath.split(/[\\/]/);
+  if (parts[0] === INTERNAL_DIRECTORY) {
+    return true;
+  }
+
+  const name = parts[parts.length - 1] ?? '';
+  return name.startsWith('.') && name.endsWith('.tmp');
+}
+
+function listDirectories(root: string): string[] {
+  const directories = [root];
+  for (const entry of fs.readdirSync(root, { withFileTypes: true })) {
+    if (entry.isDirectory() && !entry.name.startsWith('.') && entry.name !== 'node_modules') {
+      directories.push(...listDirectories(path.join(root, entry.name)));
+    }
+  }
+  return directories;
+}
+
+/** Every note and sidecar with its size and mtime; any edit, add or delete changes it. */
+function getDirectorySnapshot(root: string): string {
+  const entries: string[] = [];
+  for (const record of getAllMarkdownFiles(root)) {
+    for (const filePath of [record.fullPath, getNoteSidecarPath(record.fullPath)]) {
+      try {
+        const stats = fs.statSync(filePath);
+        entries.push(`${filePath}:${stats.size}:${stats.mtimeMs}`);
+      } catch {
+        entries.push(`${filePath}:missing`);
+      }
+    }
+  }
+  return entries.join('\n');
+}
+
+/**
+ * Watches a notes directory and calls onChange once per burst of external
+ * writes. Recursive fs.watch is used where the platform has it; elsewhere each
+ * folder gets its own watcher, re-scanned after every burst so new folders are
+ * picked up. With pollIntervalMs the directory is polled instead.
+ */
+export class NotesWatcher {
+  private root: string;
+  private onChange: () => void;
+  private debounceMs: number;
+  private pollIntervalMs: number | undefined;
+  private watchers = new Map<string, fs.FSWatcher>();
+  private timer: NodeJS.Timeout | null = null;
+  private poller: NodeJS.Timeout | null = null;
+
+  constructor(root: string, onChange: () => void, options: NotesWatcherOptions = {}) {
+    this.root = root;
+    this.onChange = onChange;
+    this.debounceMs = options.debounceMs ?? DEFAULT_WATCH_DEBOUNCE_MS;
+    this.pollIntervalMs = options.pollIntervalMs;
+  }
+
+  start(): void {
+    if (!fs.existsSync(this.root)) {
+      return;
+    }
+
+    if (this.pollIntervalMs !== undefined) {
+      this.poll();
+      return;
+    }
+
+    try {
+      this.watch(this.root, true);
+    } catch {
+      this.watchEachDirectory();
+    }
+  }
+
+  close(): void {
+    if (this.timer) {
+      clearTimeout(this.timer);
+      this.timer = null;
+    }
+
+    if (this.poller) {
+      clearInterval(this.poller);
+      this.poller = null;
+    }
+
+    for (const watcher of this.watchers.values()) {
+      watcher.close();
+    }
+    this.watchers.clear();
+  }
+
+  private poll(): void {
+    let snapshot = getDirectorySnapshot(this.root);
+    this.poller = setInterval(() => {
+      try {
+        const next = getDirectorySnapshot(this.root);
+        if (next !== snapshot) {
+          snapshot = next;
+          this.schedule(true);
+        }
+      } catch (error) {
+        logError('Error polling notes directory:', error);
+      }
+    }, this.pollIntervalMs);
+  }
+
+  private watch(directory: string, recursive: boolean): void {
+    const watcher = fs.watch(directory, { recursive }, (_event, filename) => {
+      const relativePath = path.relative(this.root, path.join(directory, filename ? String(filename) : ''));
+      if (!isIgnoredChange(relativePath)) {
+        this.schedule(recursive);
+      }
+    });
+    watcher.on('error', () => {
+      watcher.close();
+      this.watchers.delete(directory);
+    });
+    this.watchers.set(directory, watcher);
+  }
+
+  private watchEachDirectory(): void {
+    const directories = new Set(listDirectories(this.root));
+    for (const [directory, watcher] of this.watchers) {
+      if (!directories.has(directory)) {
+        watcher.close();
+        this.watchers.delete(directory);
+      }
+    }
+
+    for (const directory of directories) {
+      if (!this.watchers.has(directory)) {
+        this.watch(directory, false);
+      }
+    }
+  }
+
+  private schedule(recursive: boolean): void {
+    if (this.timer) {
+      clearTimeout(this.timer);
+    }
+
+    this.timer = setTimeout(() => {
+      this.timer = null;
+      if (!recursive) {
+        try {
+          this.watchEachDirectory();
+        } catch (error) {
+          logError('Error rescanning notes directory:', error);
+        }
+      }
+      this.onChange();
+    }, this.debounceMs);
+  }
+}
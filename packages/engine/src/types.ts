@@ -18,8 +18,33 @@ export interface NoteComment {
   content: string;
   status: CommentStatus;
   anchor: CommentAnchor;
+  resolved?: boolean;
+  resolvedAt?: string;
+  /** When the comment text was last changed, if ever. */
+  edited?: string;
 }
 
+/** Which comments `collectComments` keeps; unset fields match everything. */
+export interface CommentFilter {
+  /** Case-insensitive exact match on the author. */
+  author?: string;
+  resolved?: boolean;
+  /** ISO timestamps bounding the comment's creation time. */
+  createdAfter?: string;
+  createdBefore?: string;
+}
+
+/** A comment together with the note it belongs to. */
+export interface CommentWithNote {
+  note: Note;
+  comment: NoteComment;
+  /** 1-based line where the anchor starts, or null when it cannot be placed. */
+  line: number | null;
+}
+
+/** Custom metadata fields, e.g. `status` or `url`. Values are JSON scalars for CLI-set fields. */
+export type NoteMeta = Record<string, unknown>;
+
 export interface Note {
   id: string;
   title: string;
@@ -27,9 +52,24 @@ export interface Note {
   commentRev: number;
   comments: NoteComment[];
   content: string;
+  created: string;
+  updated: string;
   filename: string;
   relativePath: string;
   directory: string;
+  meta?: NoteMeta;
+  /** Due date as `YYYY-MM-DD` (UTC, like note filenames). */
+  due?: string;
+  /** Short lowercase names that resolve to this note in lookups. */
+  aliases?: string[];
+  /** File names in the note's `.agentnotes/attachments/` directory. */
+  attachments?: string[];
+  /** The body below the title heading is stored encrypted; see notes/crypto. */
+  encrypted?: boolean;
+  /** When the note was archived; archived notes are left out of search by default. */
+  archivedAt?: string;
+  /** Pinned notes are listed ahead of the rest, whatever the sort. */
+  pinned?: boolean;
 }
 
 export interface NotesListResult {
@@ -38,12 +78,25 @@ export interface NotesListResult {
   noDirectory: boolean;
 }
 
+export interface NotebookSummary {
+  name: string;
+  noteCount: number;
+}
+
 export interface CommentMutationResult {
   success: boolean;
   note?: Note;
   error?: string;
 }
 
+export interface NoteLookupResult {
+  note: Note | null;
+  /** True when several notes matched the query equally well. */
+  ambiguous: boolean;
+  /** The competing matches when ambiguous, otherwise equally close fuzzy suggestions. */
+  candidates: Note[];
+}
+
 export interface OperationResult {
   success: boolean;
   error?: string;
@@ -58,6 +111,8 @@ export interface AddCommentPayload {
   content: string;
   author: string;
   anchor: CommentAnchor;
+  /** Text the anchor range must cover in the current content; see buildAnchorFromRange. */
+  expectedQuote?: string;
 }
 
 export interface DeleteCommentPayload {
@@ -65,23 +120,158 @@ export interface DeleteCommentPayload {
   commentId: string;
 }
 
+export interface EditCommentPayload {
+  noteId: string;
+  commentId: string;
+  content: string;
+}
+
+export interface ReattachCommentPayload {
+  noteId: string;
+  commentId: string;
+}
+
+export interface SetCommentResolvedPayload {
+  noteId: string;
+  commentId: string;
+  resolved: boolean;
+}
+
+export interface NoteAliasPayload {
+  noteId: string;
+  alias: string;
+}
+
+export interface SetTaskDonePayload {
+  noteId: string;
+  /** 1-based line number of the task in the note content. */
+  line: number;
+  done: boolean;
+}
+
 export interface UpdateNotePayload {
   noteId: string;
   content: string;
+  /** Required for encrypted notes; `content` is plaintext and is re-encrypted on write. */
+  passphrase?: string;
+  /** Encrypt a plaintext note with `passphrase` in the same write as the edit. */
+  encrypt?: boolean;
+}
+
+export interface SetNoteArchivedPayload {
+  noteId: string;
+  archived: boolean;
+}
+
+export interface SetNotePinnedPayload {
+  noteId: string;
+  pinned: boolean;
+}
+
+export interface SetNoteEncryptionPayload {
+  noteId: string;
+  passphrase: string;
+  /** True to encrypt the note body, false to store it as plaintext again. */
+  encrypted: boolean;
 }
 
 export interface UpdateNoteMetadataPayload {
   noteId: string;
-  tags: string[];
+  tags?: string[];
+  /** Custom fields to set; a null value removes the field. */
+  meta?: Record<string, unknown>;
+  /** `YYYY-MM-DD` to set the due date, null to clear it. */
+  due?: string | null;
 }
 
 export interface CreateNotePayload {
   title: string;
   directory: string;
+  /** Name of a template in `.agentnotes/templates/` to seed the content from. */
+  template?: string;
+  /** Body below the `# title` heading; content that already opens with that heading is kept as is. */
+  content?: string;
+  tags?: string[];
+  meta?: NoteMeta;
+  /** Due date, `YYYY-MM-DD` or any form `parseDueDate` accepts. */
+  due?: string;
+  /** Comments anchored against the new note's content. */
+  comments?: NewNoteComment[];
+  /** Encrypt the body before its first write. Cannot be combined with comments. */
+  passphrase?: string;
+  /**
+   * Return the note in the target directory that already has exactly this
+   * title instead of creating another. The check runs under the store lock,
+   * so concurrent callers get the same note.
+   */
+  reuseExisting?: boolean;
+}
+
+export interface CreateNoteResult extends CommentMutationResult {
+  /** Set when reuseExisting found the note rather than creating it. */
+  existing?: boolean;
+}
+
+export interface CloneNotePayload {
+  noteId: string;
+  /** Defaults to the source note's title; the clone gets its own filename either way. */
+  title?: string;
+  /** Copy comments too, under new IDs. */
+  withComments?: boolean;
+  /** Copy custom fields such as priority and source too. */
+  withFields?: boolean;
+}
+
+/** A comment given with a new note, anchored by `exact` text or by `from`/`to` offsets. */
+export interface NewNoteComment {
+  content: string;
+  author: string;
+  exact?: string;
+  from?: number;
+  to?: number;
+}
+
+export interface ImportNotesPayload {
+  /** Directory of markdown files to import, searched recursively. */
+  sourceDirectory: string;
+  /** Tags added to every imported note. */
+  tags?: string[];
+  /** Report what would be imported without writing anything. */
+  dryRun?: boolean;
+}
+
+export interface ImportedNoteEntry {
+  /** Source file, relative to the import directory. */
+  source: string;
+  noteId: string;
+}
+
+export interface SkippedImportEntry {
+  source: string;
+  reason: string;
+}
+
+export interface ImportNotesResult {
+  success: boolean;
+  error?: string;
+  imported: ImportedNoteEntry[];
+  skipped: SkippedImportEntry[];
 }
 
 export interface DeleteNotePayload {
   noteId: string;
+  purge?: boolean;
+}
+
+export interface RestoreNotePayload {
+  noteId: string;
+}
+
+export interface MergeNotesPayload {
+  sourceId: string;
+  targetId: string;
+  /** Leave the source in place instead of moving it to the trash. */
+  keepSource?: boolean;
 }
 
 export interface MoveNotePayload {
@@ -89,6 +279,52 @@ export interface MoveNotePayload {
   directory: string;
 }
 
+export interface MoveNoteToNotebookPayload {
+  noteId: string;
+  /** Target notebook, created if it does not exist yet. */
+  notebook: string;
+}
+
+export interface RenameNotePayload {
+  noteId: string;
+  title: string;
+}
+
+export interface AttachFilePayload {
+  noteId: string;
+  /** File to copy into the note's attachments directory. */
+  filePath: string;
+}
+
+export interface AttachFileResult extends CommentMutationResult {
+  /** Stored name, suffixed when the original name was taken. */
+  attachment?: string;
+}
+
+export interface RetagNotesPayload {
+  noteIds: string[];
+  add?: string[];
+  remove?: string[];
+  /** Report which notes would change without writing anything. */
+  dryRun?: boolean;
+}
+
+export interface RetagNotesResult extends OperationResult {
+  /** Notes whose tags changed (or would change, for a dry run). */
+  changed: string[];
+}
+
+export interface RenameTagPayload {
+  from: string;
+  to: string;
+  dryRun?: boolean;
+}
+
+export interface DeleteTagPayload {
+  tag: string;
+  dryRun?: boolean;
+}
+
 export interface CreateDirectoryPayload {
   path: string;
 }
@@ -97,17 +333,186 @@ export interface DeleteDirectoryPayload {
   path: string;
 }
 
-export type SortField = 'created' | 'updated' | 'title';
+/** `relevance` ranks by how well notes match the query; see scoreNote. */
+export type SortField = 'created' | 'updated' | 'title' | 'due' | 'relevance';
 
 export interface SearchOptions {
   query?: string;
+  /** Treat query as a regular expression matched against title and content. */
+  regex?: boolean;
+  /** Parse query as AND/OR/NOT terms matched against title, content and tags. */
+  boolean?: boolean;
+  /** Match the query's case exactly; by default case is ignored. */
+  caseSensitive?: boolean;
+  /** Match the query only as a whole word, so `cat` skips "category". */
+  wholeWord?: boolean;
   tags?: string[];
+  /** Custom field filters: `key` requires the field, `key=value` also matches its value. */
+  meta?: string[];
+  /** ISO timestamp bounds, all inclusive. */
+  createdAfter?: string;
+  createdBefore?: string;
+  updatedAfter?: string;
+  updatedBefore?: string;
+  /** Matches to skip after sorting, before the limit applies; for paging. */
+  offset?: number;
   limit?: number;
-  sortBy?: SortField;
+  /**
+   * A list sorts by each field in turn, later fields breaking ties. Defaults
+   * to relevance when there is a query and created otherwise.
+   */
+  sortBy?: SortField | SortField[];
   reverse?: boolean;
+  /** Inclusive bounds on the `priority` field; notes without one are left out. */
+  minPriority?: number;
+  maxPriority?: number;
+  /** Archived notes are skipped unless this is set. */
+  includeArchived?: boolean;
+  /** Keep only archived notes; implies includeArchived. */
+  onlyArchived?: boolean;
+  /** Keep only pinned notes. */
+  onlyPinned?: boolean;
+  /** Sort pinned notes ahead of the rest, in either direction. */
+  pinnedFirst?: boolean;
+}
+
+export interface SavedSearch {
+  name: string;
+  /** Date bounds may hold relative expressions such as `7d`, resolved when run. */
+  options: SearchOptions;
+}
+
+export interface SaveSearchPayload {
+  name: string;
+  options: SearchOptions;
+}
+
+export interface SavedSearchRunResult extends OperationResult {
+  notes?: Note[];
+  /** The options the search ran with, for highlighting the query in snippets. */
+  options?: SearchOptions;
 }
 
 export interface TagCount {
   tag: string;
   count: number;
 }
+
+export interface NoteStatsEntry {
+  id: string;
+  title: string;
+  created: string;
+}
+
+export interface NoteTask {
+  /** 1-based line number in the note content. */
+  line: number;
+  text: string;
+  done: boolean;
+}
+
+export type NoteProblemKind =
+  | 'missing-sidecar'
+  | 'invalid-sidecar'
+  | 'frontmatter'
+  | 'missing-timestamp'
+  | 'newer-schema'
+  | 'missing-title'
+  | 'filename-mismatch'
+  | 'comment-range'
+  | 'duplicate-alias'
+  | 'duplicate-id';
+
+/** Something `doctor` found wrong with a note's files. */
+export interface NoteProblem {
+  noteId: string;
+  kind: NoteProblemKind;
+  message: string;
+  /** Whether `diagnoseNotes({ fix: true })` can repair it. */
+  fixable: boolean;
+  /** Set when this run repaired it. */
+  fixed?: boolean;
+}
+
+export interface DiagnoseNotesPayload {
+  fix?: boolean;
+}
+
+export interface DiagnoseNotesResult extends OperationResult {
+  problems: NoteProblem[];
+}
+
+export interface NormalizeCommentsResult extends OperationResult {
+  /** Notes whose sidecar comments were rewritten. */
+  changed: string[];
+}
+
+export interface MigrateNotesResult extends OperationResult {
+  /** Notes whose sidecars were rewritten at the current schema version. */
+  migrated: string[];
+}
+
+export interface ReindexResult extends OperationResult {
+  /** Notes in the rebuilt search index. */
+  noteCount: number;
+}
+
+/** A commit that touched a note's file, as listed by `git log --follow`. */
+export interface NoteRevision {
+  commit: string;
+  /** Author date, ISO 8601. */
+  date: string;
+  message: string;
+  /** The file's path at that commit, relative to the repository root. */
+  path: string;
+}
+
+export interface NoteHistoryResult extends OperationResult {
+  /** Newest first. */
+  revisions?: NoteRevision[];
+}
+
+export interface NoteRevisionResult extends OperationResult {
+  revision?: NoteRevision;
+  content?: string;
+}
+
+export interface DiffLine {
+  type: 'equal' | 'add' | 'remove';
+  text: string;
+}
+
+/** One `@@ -oldStart,oldLines +newStart,newLines @@` block of a unified diff. */
+export interface DiffHunk {
+  oldStart: number;
+  oldLines: number;
+  newStart: number;
+  newLines: number;
+  lines: DiffLine[];
+}
+
+export interface NoteStats {
+  totalNotes: number;
+  totalWords: number;
+  totalComments: number;
+  untaggedNotes: number;
+  topTags: TagCount[];
+  oldest: NoteStatsEntry | null;
+  newest: NoteStatsEntry | null;
+  notesPerMonth: { month: string; count: number }[];
+}
+
+export type AgendaBucket = 'overdue' | 'today' | 'week' | 'later';
+
+export interface AgendaGroup {
+  bucket: AgendaBucket;
+  notes: Note[];
+}
+
+export interface TagTreeNode {
+  /** Last path segment, e.g. `alpha` for `project/alpha`. */
+  name: string;
+  tag: string;
+  count: number;
+  children: TagTreeNode[];
+}
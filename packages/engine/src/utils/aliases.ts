@@ -0,0 +1,32 @@
+const ALIAS_PATTERN = /^[a-z0-9][a-z0-9._-]*$/;
+
+/**
+ * Aliases are lowercase slugs such as `k8s-runbook`. Returns null for values
+ * that cannot be an alias, including ones ending in `.md` that would read as
+ * a note ID.
+ */
+export function normalizeAlias(value: string): string | null {
+  const alias = value.trim().toLocaleLowerCase();
+  if (!ALIAS_PATTERN.test(alias) || alias.endsWith('.md')) {
+    return null;
+  }
+
+  return alias;
+}
+
+/** Valid aliases from stored data, deduplicated in their original order. */
+export function normalizeAliases(value: unknown): string[] {
+  if (!Array.isArray(value)) {
+    return [];
+  }
+
+  const aliases: string[] = [];
+  for (const entry of value) {
+    const alias = typeof entry === 'string' ? normalizeAlias(entry) : null;
+    if (alias && !aliases.includes(alias)) {
+      aliases.push(alias);
+    }
+  }
+
+  return aliases;
+}
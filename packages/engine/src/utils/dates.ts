@@ -0,0 +1,100 @@
+const MINUTE_MS = 60 * 1000;
+const HOUR_MS = 60 * MINUTE_MS;
+const DAY_MS = 24 * HOUR_MS;
+
+const RELATIVE_UNITS: Record<string, number> = {
+  h: HOUR_MS,
+  d: DAY_MS,
+  w: 7 * DAY_MS,
+};
+
+const DATE_ONLY = /^\d{4}-\d{2}-\d{2}$/;
+const DATE_PREFIX = /^\d{4}-\d{2}-\d{2}(?:$|[T ])/;
+
+/**
+ * Parse a date filter into an ISO timestamp. Accepts relative offsets back
+ * from now (`24h`, `7d`, `2w`), dates (`2024-01-02`, read as UTC like note
+ * filenames), and full ISO timestamps. A date used as an `end` bound covers
+ * the whole day.
+ */
+export function parseDateExpression(
+  value: string,
+  bound: 'start' | 'end' = 'start',
+  now: Date = new Date(),
+): string {
+  const trimmed = value.trim();
+
+  const relative = trimmed.match(/^(\d+)([hdw])$/);
+  if (relative) {
+    const offset = Number(relative[1]) * RELATIVE_UNITS[relative[2]];
+    return new Date(now.getTime() - offset).toISOString();
+  }
+
+  const parsed = DATE_PREFIX.test(trimmed) ? Date.parse(trimmed) : Number.NaN;
+  if (Number.isNaN(parsed)) {
+    throw new Error(`Invalid date: ${value} (use YYYY-MM-DD, an ISO timestamp, or 24h/7d/2w)`);
+  }
+
+  if (DATE_ONLY.test(trimmed) && bound === 'end') {
+    return new Date(parsed + DAY_MS - 1).toISOString();
+  }
+
+  return new Date(parsed).toISOString();
+}
+
+/**
+ * Parse a due date given as `YYYY-MM-DD` or an ISO timestamp, returning the
+ * UTC calendar day.
+ */
+export function parseDueDate(value: string): string {
+  const trimmed = value.trim();
+  const parsed = DATE_PREFIX.test(trimmed) ? Date.parse(trimmed) : Number.NaN;
+  if (Number.isNaN(parsed)) {
+    throw new Error(`Invalid due date: ${value} (use YYYY-MM-DD)`);
+  }
+
+  return new Date(parsed).toISOString().slice(0, 10);
+}
+
+function plural(count: number, unit: string): string {
+  return `${count} ${unit}${count === 1 ? '' : 's'} ago`;
+}
+
+/**
+ * Humanize a timestamp relative to now: "just now", "3 hours ago",
+ * "yesterday", "2 weeks ago". Anything a year or more old, or unparseable,
+ * is shown as its date instead.
+ */
+export function formatRelativeTime(value: string | Date, now: Date = new Date()): string {
+  const time = value instanceof Date ? value.getTime() : Date.parse(value);
+  if (Number.isNaN(time)) {
+    return String(value);
+  }
+
+  const elapsed = Math.max(0, now.getTime() - time);
+  const days = Math.floor(elapsed / DAY_MS);
+
+  if (elapsed < MINUTE_MS) {
+    return 'just now';
+  }
+  if (elapsed < HOUR_MS) {
+    return plural(Math.floor(elapsed / MINUTE_MS), 'minute');
+  }
+  if (elapsed < DAY_MS) {
+    return plural(Math.floor(elapsed / HOUR_MS), 'hour');
+  }
+  if (days === 1) {
+    return 'yesterday';
+  }
+  if (days < 7) {
+    return plural(days, 'day');
+  }
+  if (days < 30) {
+    return plural(Math.floor(days / 7), 'week');
+  }
+  if (days < 365) {
+    return plural(Math.floor(days / 30), 'month');
+  }
+
+  return new Date(time).toISOString().slice(0, 10);
+}
@@ -0,0 +1,130 @@
+import type { DiffHunk, DiffLine } from '../types.js';
+import { normalizeLineEndings } from './normalization.js';
+
+export const DEFAULT_DIFF_CONTEXT = 3;
+
+function splitLines(text: string): string[] {
+  const normalized = normalizeLineEndings(text);
+  if (!normalized) {
+    return [];
+  }
+
+  const lines = normalized.split('\n');
+  // A final newline ends the last line rather than starting an empty one.
+  if (lines[lines.length - 1] === '') {
+    lines.pop();
+  }
+  return lines;
+}
+
+/**
+ * Line diff of two texts. As in comment remapping, the common prefix and
+ * suffix are matched first; only the lines between them go through the
+ * longest-common-subsequence table, which keeps typical edits cheap.
+ */
+export function diffLines(oldText: string, newText: string): DiffLine[] {
+  const oldLines = splitLines(oldText);
+  const newLines = splitLines(newText);
+
+  let prefix = 0;
+  while (prefix < oldLines.length && prefix < newLines.length && oldLines[prefix] === newLines[prefix]) {
+    prefix += 1;
+  }
+
+  let suffix = 0;
+  while (
+    suffix < oldLines.length - prefix &&
+    suffix < newLines.length - prefix &&
+    oldLines[oldLines.length - 1 - suffix] === newLines[newLines.length - 1 - suffix]
+  ) {
+    suffix += 1;
+  }
+
+  const a = oldLines.slice(prefix, oldLines.length - suffix);
+  const b = newLines.slice(prefix, newLines.length - suffix);
+
+  // lengths[i][j] is the LCS length of a[i..] and b[j..].
+  const lengths = Array.from({ length: a.length + 1 }, () => new Uint32Array(b.length + 1));
+  for (let i = a.length - 1; i >= 0; i -= 1) {
+    for (let j = b.length - 1; j >= 0; j -= 1) {
+      lengths[i][j] = a[i] === b[j] ? lengths[i + 1][j + 1] + 1 : Math.max(lengths[i + 1][j], lengths[i][j + 1]);
+    }
+  }
+
+  const result: DiffLine[] = oldLines.slice(0, prefix).map((text) => ({ type: 'equal', text }));
+  let i = 0;
+  let j = 0;
+  while (i < a.length || j < b.length) {
+    if (i < a.length && j < b.length && a[i] === b[j]) {
+      result.push({ type: 'equal', text: a[i] });
+      i += 1;
+      j += 1;
+    } else if (i < a.length && (j === b.length || lengths[i + 1][j] >= lengths[i][j + 1])) {
+      // Removals go first, so a replaced line reads as - then +.
+      result.push({ type: 'remove', text: a[i] });
+      i += 1;
+    } else {
+      result.push({ type: 'add', text: b[j] });
+      j += 1;
+    }
+  }
+
+  for (const text of oldLines.slice(oldLines.length - suffix)) {
+    result.push({ type: 'equal', text });
+  }
+  return result;
+}
+
+/**
+ * Group a line diff into unified-diff hunks with `context` unchanged lines
+ * around each change. Changes closer than twice the context share a hunk.
+ * Returns an empty list when the texts have the same lines.
+ */
+export function getDiffHunks(oldText: string, newText: string, context = DEFAULT_DIFF_CONTEXT): DiffHunk[] {
+  const lines = diffLines(oldText, newText);
+  const hunks: DiffHunk[] = [];
+  // Old and new line counts before lines[counted].
+  let oldBefore = 0;
+  let newBefore = 0;
+  let counted = 0;
+  let index = 0;
+
+  while (index < lines.length) {
+    if (lines[index].type === 'equal') {
+      index += 1;
+      continue;
+    }
+
+    const start = Math.max(0, index - context);
+    let lastChange = index;
+    for (let next = index; next < lines.length && next - lastChange <= 2 * context; next += 1) {
+      if (lines[next].type !== 'equal') {
+        lastChange = next;
+      }
+    }
+    const end = Math.min(lines.length, lastChange + context + 1);
+
+    for (const line of lines.slice(counted, start)) {
+      oldBefore += line.type === 'add' ? 0 : 1;
+      newBefore += line.type === 'remove' ? 0 : 1;
+    }
+
+    const hunkLines = lines.slice(start, end);
+    const oldCount = hunkLines.filter((line) => line.type !== 'add').length;
+    const newCount = hunkLines.filter((line) => line.type !== 'remove').length;
+    hunks.push({
+      // An empty side points at the line before it, as in `diff -u`.
+      oldStart: oldCount > 0 ? oldBefore + 1 : oldBefore,
+      oldLines: oldCount,
+      newStart: newCount > 0 ? newBefore + 1 : newBefore,
+      newLines: newCount,
+      lines: hunkLines,
+    });
+    oldBefore += oldCount;
+    newBefore += newCount;
+    counted = end;
+    index = end;
+  }
+
+  return hunks;
+}
@@ -1,5 +1,11 @@
-export { slugify } from './slugify.js';
-export { normalizeTags, normalizeContent, normalizeStatus, normalizeAffinity } from './normalization.js';
+export { slugify, slugifyTitle } from './slugify.js';
+export {
+  normalizeTags,
+  normalizeContent,
+  normalizeLineEndings,
+  normalizeStatus,
+  normalizeAffinity,
+} from './normalization.js';
 export { toTitleCase } from './formatting.js';
 export {
   isRecord,
@@ -9,3 +15,21 @@ export {
   toIsoDate,
   toStringArray,
 } from './validation.js';
+export { toYaml, parseYaml } from './yaml.js';
+export { parseDateExpression, parseDueDate, formatRelativeTime } from './dates.js';
+export { insertLineInContent, replaceLineInContent, deleteLineInContent } from './lines.js';
+export {
+  isValidMetaKey,
+  isValidPriority,
+  getMetaChangesError,
+  parseMetaValue,
+  normalizeMeta,
+  applyMetaChanges,
+  MIN_PRIORITY,
+  MAX_PRIORITY,
+} from './meta.js';
+export { normalizeAlias, normalizeAliases } from './aliases.js';
+export { parseTagList, addTagsToList, removeTagsFromList, renameTagInList } from './tags.js';
+export { diffLines, getDiffHunks, DEFAULT_DIFF_CONTEXT } from './diff.js';
+export { setLogLevel, getLogLevel, logError, logWarning, logDebug } from './log.js';
+export type { LogLevel } from './log.js';
@@ -0,0 +1,37 @@
+import { normalizeLineEndings } from './normalization.js';
+
+/**
+ * Line-based content edits used by `edit --insert/--replace-line/--delete-line`.
+ * Line numbers are 1-based. Inserting past the end appends. Content and the
+ * new text may use any line endings; the result uses `\n`.
+ */
+export function insertLineInContent(content: string, lineNum: number, text: string): string {
+  const lines = splitLines(content);
+  const index = Math.max(0, Math.min(lineNum - 1, lines.length));
+  lines.splice(index, 0, normalizeLineEndings(text));
+  return lines.join('\n');
+}
+
+export function replaceLineInContent(content: string, lineNum: number, text: string): string {
+  const lines = splitLines(content);
+  const index = lineNum - 1;
+  if (index < 0 || index >= lines.length) {
+    throw new Error(`Line ${lineNum} out of range (1-${lines.length})`);
+  }
+  lines[index] = normalizeLineEndings(text);
+  return lines.join('\n');
+}
+
+export function deleteLineInContent(content: string, lineNum: number): string {
+  const lines = splitLines(content);
+  const index = lineNum - 1;
+  if (index < 0 || index >= lines.length) {
+    throw new Error(`Line ${lineNum} out of range (1-${lines.length})`);
+  }
+  lines.splice(index, 1);
+  return lines.join('\n');
+}
+
+function splitLines(content: string): string[] {
+  return normalizeLineEndings(content).split('\n');
+}
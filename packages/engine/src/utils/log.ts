@@ -0,0 +1,36 @@
+/**
+ * Diagnostics go to stderr through here, so callers like the CLI can hide or
+ * expand them with one setting. Command output never does.
+ */
+export type LogLevel = 'quiet' | 'normal' | 'verbose';
+
+let currentLevel: LogLevel = 'normal';
+
+export function setLogLevel(level: LogLevel): void {
+  currentLevel = level;
+}
+
+export function getLogLevel(): LogLevel {
+  return currentLevel;
+}
+
+/** A failure that was recovered from or reported in an operation's result. Hidden when quiet. */
+export function logError(message: string, ...details: unknown[]): void {
+  if (currentLevel !== 'quiet') {
+    console.error(message, ...details);
+  }
+}
+
+/** Something the user may want to fix, such as a skipped git commit. Hidden when quiet. */
+export function logWarning(message: string): void {
+  if (currentLevel !== 'quiet') {
+    console.warn(message);
+  }
+}
+
+/** Tracing for debugging, such as which files a store operation wrote. Shown only when verbose. */
+export function logDebug(message: string): void {
+  if (currentLevel === 'verbose') {
+    console.error(`debug: ${message}`);
+  }
+}
@@ -0,0 +1,82 @@
+import type { NoteMeta } from '../types.js';
+import { isRecord } from './validation.js';
+
+const META_KEY_PATTERN = /^[A-Za-z_][A-Za-z0-9_-]*$/;
+
+/** Bounds of the `priority` field. */
+export const MIN_PRIORITY = 0;
+export const MAX_PRIORITY = 10;
+
+export function isValidMetaKey(key: string): boolean {
+  return META_KEY_PATTERN.test(key);
+}
+
+export function isValidPriority(value: unknown): value is number {
+  return Number.isInteger(value) && (value as number) >= MIN_PRIORITY && (value as number) <= MAX_PRIORITY;
+}
+
+/**
+ * Why a set of field changes cannot be stored, or null when it can: keys must
+ * be well-formed and `priority` must be within bounds. Null values unset.
+ */
+export function getMetaChangesError(changes: Record<string, unknown>): string | null {
+  const invalidKey = Object.keys(changes).find((key) => !isValidMetaKey(key));
+  if (invalidKey !== undefined) {
+    return `Invalid metadata key: ${invalidKey}`;
+  }
+
+  const priority = changes.priority;
+  if (priority !== undefined && priority !== null && !isValidPriority(priority)) {
+    return `Priority must be an integer from ${MIN_PRIORITY} to ${MAX_PRIORITY}`;
+  }
+  return null;
+}
+
+/**
+ * Read a command-line value as the JSON scalar it looks like: `true`/`false`
+ * become booleans, plain decimal numbers become numbers, the rest stay strings.
+ */
+export function parseMetaValue(raw: string): string | number | boolean {
+  if (raw === 'true' || raw === 'false') {
+    return raw === 'true';
+  }
+
+  if (/^-?(?:0|[1-9]\d*)(?:\.\d+)?$/.test(raw)) {
+    return Number(raw);
+  }
+
+  return raw;
+}
+
+/** Keep well-formed keys from parsed data; an empty result is undefined. */
+export function normalizeMeta(value: unknown): NoteMeta | undefined {
+  if (!isRecord(value) || Array.isArray(value)) {
+    return undefined;
+  }
+
+  const meta: NoteMeta = {};
+  for (const [key, entry] of Object.entries(value)) {
+    if (isValidMetaKey(key) && entry !== undefined && entry !== null) {
+      meta[key] = entry;
+    }
+  }
+
+  return Object.keys(meta).length > 0 ? meta : undefined;
+}
+
+/** Apply set/unset changes, where a null value removes the key. */
+export function applyMetaChanges(
+  meta: NoteMeta | undefined,
+  changes: Record<string, unknown>,
+): NoteMeta | undefined {
+  const next: NoteMeta = { ...meta };
+  for (const [key, value] of Object.entries(changes)) {
+    if (value === null || value === undefined) {
+      delete next[key];
+    } else {
+      next[key] = value;
+    }
+  }
+
+  return normalizeMeta(next);
+}
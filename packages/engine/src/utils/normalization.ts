@@ -22,8 +22,16 @@ export function normalizeTags(tags: string[]): string[] {
   return normalized;
 }
 
+/**
+ * Notes are kept with `\n` line endings in memory and on disk; CRLF and bare
+ * CR from other platforms are converted on the way in.
+ */
+export function normalizeLineEndings(text: string): string {
+  return text.replace(/\r\n?/g, '\n');
+}
+
 export function normalizeContent(content: string): string {
-  return content.replace(/\r\n/g, '\n').replace(/\n+$/, '');
+  return normalizeLineEndings(content).replace(/\n+$/, '');
 }
 
 export function normalizeStatus(value: unknown, hasRange: boolean): CommentStatus {
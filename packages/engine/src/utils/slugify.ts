@@ -1,19 +1,33 @@
+import { createHash } from 'node:crypto';
+
+/** Latin letters that do not decompose into an ASCII base plus accents. */
+const TRANSLITERATIONS: Record<string, string> = {
+  ß: 'ss',
+  æ: 'ae',
+  œ: 'oe',
+  ø: 'o',
+  đ: 'd',
+  ð: 'd',
+  þ: 'th',
+  ł: 'l',
+  ı: 'i',
+};
+
 export function slugify(value: string): string {
-  const normalized = value.trim().toLocaleLowerCase();
+  // NFKD splits accented letters into base + combining mark, so "é" keeps its "e".
+  const normalized = value.trim().toLocaleLowerCase().normalize('NFKD');
   let result = '';
   let prevDash = false;
 
   for (const char of normalized) {
-    const isAsciiLower = char >= 'a' && char <= 'z';
-    const isDigit = char >= '0' && char <= '9';
-
-    if (isAsciiLower || isDigit) {
-      result += char;
+    const ascii = TRANSLITERATIONS[char] ?? char;
+    if (/^[a-z0-9]+$/.test(ascii)) {
+      result += ascii;
       prevDash = false;
       continue;
     }
 
-    if (char === ' ' || char === '-' || char === '_') {
+    if (/\s/.test(char) || char === '-' || char === '_') {
       if (!prevDash && result.length > 0) {
         result += '-';
         prevDash = true;
@@ -23,3 +37,22 @@ export function slugify(value: string): string {
 
   return result.replace(/-+$/g, '');
 }
+
+/**
+ * Slug used in note filenames. Titles with nothing to transliterate, such as
+ * CJK or emoji, get a short hash of the title so the filename still differs
+ * per title; blank titles fall back to "note".
+ */
+export function slugifyTitle(title: string): string {
+  const slug = slugify(title);
+  if (slug) {
+    return slug;
+  }
+
+  const trimmed = title.trim();
+  if (!trimmed) {
+    return 'note';
+  }
+
+  return `note-${createHash('sha256').update(trimmed).digest('hex').slice(0, 8)}`;
+}
@@ -0,0 +1,32 @@
+/** Split a comma-separated tag list, dropping blanks. */
+export function parseTagList(value: string): string[] {
+  return value
+    .split(',')
+    .map((tag) => tag.trim())
+    .filter(Boolean);
+}
+
+/** Append tags not already present, compared case-insensitively. */
+export function addTagsToList(existing: string[], toAdd: string[]): string[] {
+  const lower = new Set(existing.map((tag) => tag.toLocaleLowerCase()));
+  const result = [...existing];
+  for (const tag of toAdd) {
+    if (!lower.has(tag.toLocaleLowerCase())) {
+      result.push(tag);
+      lower.add(tag.toLocaleLowerCase());
+    }
+  }
+  return result;
+}
+
+/** Drop tags, compared case-insensitively. */
+export function removeTagsFromList(existing: string[], toRemove: string[]): string[] {
+  const lower = new Set(toRemove.map((tag) => tag.toLocaleLowerCase()));
+  return existing.filter((tag) => !lower.has(tag.toLocaleLowerCase()));
+}
+
+/** Replace a tag in place, compared case-insensitively; duplicates are left to normalizeTags. */
+export function renameTagInList(existing: string[], from: string, to: string): string[] {
+  const key = from.toLocaleLowerCase();
+  return existing.map((tag) => (tag.toLocaleLowerCase() === key ? to : tag));
+}
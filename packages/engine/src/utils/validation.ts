@@ -19,6 +19,10 @@ export function toOptionalNonNegativeInt(value: unknown): number | undefined {
 }
 
 export function toIsoDate(value: unknown, fallback: string): string {
+  if (value instanceof Date) {
+    return Number.isNaN(value.getTime()) ? fallback : value.toISOString();
+  }
+
   if (typeof value !== 'string') {
     return fallback;
   }
@@ -0,0 +1,97 @@
+import matter from 'gray-matter';
+import { isRecord } from './validation.js';
+
+const PLAIN_KEY_PATTERN = /^[A-Za-z_][A-Za-z0-9_-]*$/;
+
+/**
+ * Serialize plain data (records, arrays, strings, numbers, booleans) to YAML.
+ * Strings are emitted double-quoted, except multi-line strings, which use a
+ * literal block when that can represent them exactly.
+ */
+export function toYaml(value: unknown): string {
+  return `${emitNode(value, 0).join('\n')}\n`;
+}
+
+/**
+ * Parse a YAML document with the front-matter parser notes already use.
+ * Passing options keeps gray-matter from sharing cached results.
+ */
+export function parseYaml(text: string): unknown {
+  return matter(`---\n${text.replace(/\s*$/, '')}\n---\n`, {}).data;
+}
+
+function emitNode(value: unknown, indent: number): string[] {
+  const pad = ' '.repeat(indent);
+
+  if (Array.isArray(value)) {
+    if (value.length === 0) {
+      return [`${pad}[]`];
+    }
+    return value.flatMap((item) => emitEntry(`${pad}- `, item, indent + 2, true));
+  }
+
+  if (isRecord(value)) {
+    const entries = Object.entries(value).filter(([, entry]) => entry !== undefined);
+    if (entries.length === 0) {
+      return [`${pad}{}`];
+    }
+    return entries.flatMap(([key, entry]) =>
+      emitEntry(`${pad}${formatKey(key)}: `, entry, indent + 2, false),
+    );
+  }
+
+  return [`${pad}${formatScalar(value)}`];
+}
+
+function emitEntry(prefix: string, value: unknown, childIndent: number, inSequence: boolean): string[] {
+  const head = prefix.trimEnd();
+
+  if (typeof value === 'string' && canUseLiteralBlock(value)) {
+    const pad = ' '.repeat(childIndent);
+    return [`${head} |-`, ...value.split('\n').map((line) => (line ? `${pad}${line}` : ''))];
+  }
+
+  const isEmptyCollection =
+    (Array.isArray(value) && value.length === 0) ||
+    (isRecord(value) && !Array.isArray(value) && Object.keys(value).length === 0);
+
+  if (!isRecord(value) || isEmptyCollection) {
+    return [`${prefix}${emitNode(value, 0)[0]}`];
+  }
+
+  const lines = emitNode(value, childIndent);
+  if (inSequence && !Array.isArray(value)) {
+    return [`${prefix}${lines[0].slice(childIndent)}`, ...lines.slice(1)];
+  }
+
+  return [head, ...lines];
+}
+
+function canUseLiteralBlock(value: string): boolean {
+  return (
+    value.includes('\n') &&
+    !value.endsWith('\n') &&
+    !/^[ \t]/.test(value) &&
+    !/[\r\t]/.test(value)
+  );
+}
+
+function formatKey(key: string): string {
+  return PLAIN_KEY_PATTERN.test(key) ? key : JSON.stringify(key);
+}
+
+function formatScalar(value: unknown): string {
+  if (value === null || value === undefined) {
+    return 'null';
+  }
+
+  if (typeof value === 'number') {
+    return Number.isFinite(value) ? String(value) : 'null';
+  }
+
+  if (typeof value === 'boolean') {
+    return String(value);
+  }
+
+  return JSON.stringify(String(value));
+}
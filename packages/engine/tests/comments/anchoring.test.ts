@@ -1,5 +1,5 @@
 import { describe, it, expect } from 'vitest';
-import { hashQuote, buildAnchorFromRange, getUniqueMatchRange } from '../../src/comments/anchoring.js';
+import { hashQuote, buildAnchorFromRange, buildAnchorFromLines, getUniqueMatchRange } from '../../src/comments/anchoring.js';
 
 describe('hashQuote', () => {
   it('produces a consistent 16-char hex hash', () => {
@@ -50,6 +50,16 @@ describe('buildAnchorFromRange', () => {
     expect(anchor.endAffinity).toBe('before');
   });
 
+  it('accepts an expected quote that the range covers', () => {
+    expect(buildAnchorFromRange('hello world', 6, 11, 1, 'world').quote).toBe('world');
+  });
+
+  it('throws when the range does not cover the expected quote', () => {
+    expect(() => buildAnchorFromRange('hello world', 5, 10, 1, 'world')).toThrow(
+      'Quote mismatch: 5:10 covers " worl", expected "world"',
+    );
+  });
+
   it('throws for negative from', () => {
     expect(() => buildAnchorFromRange('hello', -1, 3, 1)).toThrow('Invalid comment anchor range');
   });
@@ -63,6 +73,11 @@ describe('buildAnchorFromRange', () => {
     expect(() => buildAnchorFromRange('hello', 0, 10, 1)).toThrow('Invalid comment anchor range');
   });
 
+  it('throws for non-numeric offsets', () => {
+    expect(() => buildAnchorFromRange('hello', Number.NaN, 3, 1)).toThrow('Invalid comment anchor range');
+    expect(() => buildAnchorFromRange('hello', 0, Number.NaN, 1)).toThrow('Invalid comment anchor range');
+  });
+
   it('floors fractional from/to', () => {
     const content = 'hello world';
     const anchor = buildAnchorFromRange(content, 0.7, 5.9, 1);
@@ -76,6 +91,30 @@ describe('buildAnchorFromRange', () => {
   });
 });
 
+describe('buildAnchorFromLines', () => {
+  const content = '# Title\n\nfirst line\nsecond line\nthird';
+
+  it('covers one line without its newline', () => {
+    const anchor = buildAnchorFromLines(content, 3, 3, 2);
+    expect(anchor.quote).toBe('first line');
+    expect(anchor.from).toBe(9);
+    expect(anchor.to).toBe(19);
+    expect(anchor.rev).toBe(2);
+  });
+
+  it('covers an inclusive range of lines, including the last', () => {
+    expect(buildAnchorFromLines(content, 3, 5, 0).quote).toBe('first line\nsecond line\nthird');
+    expect(buildAnchorFromLines(content, 1, 1, 0).quote).toBe('# Title');
+  });
+
+  it('rejects reversed, out-of-range and empty line ranges', () => {
+    expect(() => buildAnchorFromLines(content, 4, 3, 0)).toThrow('Start line 4 is after end line 3');
+    expect(() => buildAnchorFromLines(content, 5, 6, 0)).toThrow('Line 6 is past the end of the note (5 lines)');
+    expect(() => buildAnchorFromLines(content, 0, 1, 0)).toThrow('Line numbers must be positive integers');
+    expect(() => buildAnchorFromLines(content, 2, 2, 0)).toThrow('Line 2 is empty');
+  });
+});
+
 describe('getUniqueMatchRange', () => {
   it('returns range for unique text', () => {
     const result = getUniqueMatchRange('hello world', 'world');
@@ -0,0 +1,86 @@
+import { describe, it, expect } from 'vitest';
+import { collectComments, getCommentLine } from '../../src/comments/collect.js';
+import { buildAnchorFromRange } from '../../src/comments/anchoring.js';
+import { remapCommentsForEdit } from '../../src/comments/transformation.js';
+import type { NoteComment } from '../../src/types.js';
+import { makeNote } from '../helpers.js';
+
+const content = '# Plan\n\nfirst line\nsecond line';
+
+function makeComment(overrides: Partial<NoteComment> & { quote?: string }): NoteComment {
+  const { quote = 'second', ...rest } = overrides;
+  const from = content.indexOf(quote);
+  return {
+    id: 'c1',
+    author: 'claude',
+    created: '2024-01-05T00:00:00.000Z',
+    content: 'comment',
+    status: 'attached',
+    anchor: buildAnchorFromRange(content, from, from + quote.length, 1),
+    ...rest,
+  };
+}
+
+describe('collectComments', () => {
+  const notes = [
+    makeNote({
+      id: 'a.md',
+      content,
+      commentRev: 1,
+      comments: [
+        makeComment({ id: 'late', created: '2024-01-09T00:00:00.000Z', quote: 'first' }),
+        makeComment({ id: 'early', author: 'Bob', created: '2024-01-02T00:00:00.000Z' }),
+      ],
+    }),
+    makeNote({
+      id: 'b.md',
+      content,
+      commentRev: 1,
+      comments: [makeComment({ id: 'done', resolved: true, created: '2024-01-03T00:00:00.000Z' })],
+    }),
+  ];
+
+  it('joins comments to their notes, oldest first within each note, with the anchored line', () => {
+    expect(collectComments(notes).map(({ note, comment, line }) => [note.id, comment.id, line])).toEqual([
+      ['a.md', 'early', 4],
+      ['a.md', 'late', 3],
+      ['b.md', 'done', 4],
+    ]);
+  });
+
+  it('filters by author, ignoring case', () => {
+    expect(collectComments(notes, { author: 'bob' }).map((entry) => entry.comment.id)).toEqual(['early']);
+  });
+
+  it('filters by resolved state', () => {
+    expect(collectComments(notes, { resolved: true }).map((entry) => entry.comment.id)).toEqual(['done']);
+    expect(collectComments(notes, { resolved: false }).map((entry) => entry.comment.id)).toEqual(['early', 'late']);
+  });
+
+  it('filters by creation time, bounds included', () => {
+    const filter = { createdAfter: '2024-01-03T00:00:00.000Z', createdBefore: '2024-01-09T00:00:00.000Z' };
+    expect(collectComments(notes, filter).map((entry) => entry.comment.id)).toEqual(['late', 'done']);
+  });
+
+  it('gives no line for a detached comment', () => {
+    const detached = makeNote({ id: 'c.md', content, commentRev: 1, comments: [makeComment({ status: 'detached' })] });
+    expect(collectComments([detached])[0].line).toBeNull();
+  });
+});
+
+describe('getCommentLine', () => {
+  it('follows the anchor to its new line after a line is inserted above it', () => {
+    const note = makeNote({ id: 'a.md', content, commentRev: 1, comments: [makeComment({})] });
+    expect(getCommentLine(note, note.comments[0])).toBe(4);
+
+    const edited = content.replace('first line', 'inserted line\nfirst line');
+    const { comments, nextRev } = remapCommentsForEdit(note.comments, content, edited, note.commentRev);
+    const after = { ...note, content: edited, commentRev: nextRev, comments };
+    expect(getCommentLine(after, comments[0])).toBe(5);
+  });
+
+  it('is null for an encrypted note, whose plaintext is not loaded', () => {
+    const note = { ...makeNote({ id: 'a.md', content, commentRev: 1, comments: [makeComment({})] }), encrypted: true };
+    expect(getCommentLine(note, note.comments[0])).toBeNull();
+  });
+});
@@ -0,0 +1,23 @@
+import type { Note } from '../src/types.js';
+
+/**
+ * An in-memory note for tests that don't touch the store. The filename and
+ * relative path follow the ID unless given.
+ */
+export function makeNote(overrides: Partial<Note> = {}): Note {
+  const id = overrides.id ?? 'test.md';
+  return {
+    id,
+    title: 'Test Note',
+    tags: [],
+    commentRev: 0,
+    comments: [],
+    content: '# Test Note\n\nSome content',
+    created: '2024-01-01T00:00:00.000Z',
+    updated: '2024-01-01T00:00:00.000Z',
+    filename: id,
+    relativePath: id,
+    directory: '',
+    ...overrides,
+  };
+}
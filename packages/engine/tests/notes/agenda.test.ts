@@ -0,0 +1,40 @@
+import { describe, it, expect } from 'vitest';
+import { buildAgenda } from '../../src/notes/agenda.js';
+import { makeNote } from '../helpers.js';
+
+describe('buildAgenda', () => {
+  const now = new Date('2024-06-10T15:00:00.000Z');
+
+  it('groups dated notes by how soon they are due', () => {
+    const notes = [
+      makeNote({ id: 'later.md', due: '2024-07-01' }),
+      makeNote({ id: 'week-end.md', due: '2024-06-17' }),
+      makeNote({ id: 'today.md', due: '2024-06-10' }),
+      makeNote({ id: 'overdue.md', due: '2024-06-09' }),
+      makeNote({ id: 'week.md', due: '2024-06-11' }),
+    ];
+
+    expect(buildAgenda(notes, now).map((group) => [group.bucket, group.notes.map((n) => n.id)])).toEqual([
+      ['overdue', ['overdue.md']],
+      ['today', ['today.md']],
+      ['week', ['week.md', 'week-end.md']],
+      ['later', ['later.md']],
+    ]);
+  });
+
+  it('leaves out undated notes and empty groups', () => {
+    const notes = [
+      makeNote({ id: 'none.md' }),
+      makeNote({ id: 'b.md', due: '2024-06-20' }),
+      makeNote({ id: 'a.md', due: '2024-06-20' }),
+    ];
+    const groups = buildAgenda(notes, now);
+    expect(groups).toHaveLength(1);
+    expect(groups[0].bucket).toBe('later');
+    expect(groups[0].notes.map((n) => n.id)).toEqual(['a.md', 'b.md']);
+  });
+
+  it('returns no groups when nothing is due', () => {
+    expect(buildAgenda([makeNote({ id: 'none.md' })], now)).toEqual([]);
+  });
+});
@@ -0,0 +1,72 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import { NoteCache } from '../../src/notes/cache.js';
+
+let tempDir: string;
+
+beforeEach(() => {
+  tempDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-cache-'));
+});
+
+afterEach(() => {
+  fs.rmSync(tempDir, { recursive: true, force: true });
+});
+
+function writeNote(name: string, content: string) {
+  const fullPath = path.join(tempDir, name);
+  fs.writeFileSync(fullPath, content, 'utf-8');
+  return { fullPath, relativePath: name };
+}
+
+describe('NoteCache', () => {
+  it('returns copies of cached notes', () => {
+    const cache = new NoteCache();
+    const record = writeNote('a.md', '# A');
+
+    const first = cache.load(record)!;
+    first.tags.push('mutated');
+    const second = cache.load(record)!;
+
+    expect(second.tags).toEqual([]);
+    expect(cache.size).toBe(1);
+  });
+
+  it('reparses a note after it changes on disk', () => {
+    const cache = new NoteCache();
+    const record = writeNote('a.md', '# A');
+    cache.load(record);
+
+    fs.writeFileSync(record.fullPath, '# A changed', 'utf-8');
+    expect(cache.load(record)!.title).toBe('A changed');
+  });
+
+  it('reparses a note after its sidecar changes', () => {
+    const cache = new NoteCache();
+    const record = writeNote('a.md', '# A');
+    cache.load(record);
+
+    const sidecarPath = path.join(tempDir, 'a.json');
+    const sidecar = JSON.parse(fs.readFileSync(sidecarPath, 'utf-8'));
+    fs.writeFileSync(sidecarPath, JSON.stringify({ ...sidecar, tags: ['fresh'] }), 'utf-8');
+    expect(cache.load(record)!.tags).toEqual(['fresh']);
+  });
+
+  it('drops entries that are no longer listed', () => {
+    const cache = new NoteCache();
+    const a = writeNote('a.md', '# A');
+    const b = writeNote('b.md', '# B');
+    cache.load(a);
+    cache.load(b);
+
+    cache.retain([a]);
+    expect(cache.size).toBe(1);
+  });
+
+  it('returns null for missing files', () => {
+    const cache = new NoteCache();
+    expect(cache.load({ fullPath: path.join(tempDir, 'gone.md'), relativePath: 'gone.md' })).toBeNull();
+    expect(cache.size).toBe(0);
+  });
+});
@@ -0,0 +1,59 @@
+import { describe, it, expect } from 'vitest';
+import {
+  decryptNoteContent,
+  decryptText,
+  encryptNoteContent,
+  encryptText,
+} from '../../src/notes/crypto.js';
+
+describe('encryptText', () => {
+  it('round-trips through decryptText', () => {
+    const payload = encryptText('secret ✓ text', 'hunter2');
+    expect(payload).toMatch(/^[A-Za-z0-9+/]+=*$/);
+    expect(decryptText(payload, 'hunter2')).toBe('secret ✓ text');
+  });
+
+  it('uses a fresh salt and IV each time', () => {
+    expect(encryptText('same', 'key')).not.toBe(encryptText('same', 'key'));
+  });
+
+  it('fails with the wrong passphrase', () => {
+    const payload = encryptText('secret', 'right');
+    expect(() => decryptText(payload, 'wrong')).toThrow('Wrong passphrase');
+  });
+
+  it('fails when the payload was altered', () => {
+    const data = Buffer.from(encryptText('secret', 'key'), 'base64');
+    data[data.length - 1] ^= 1;
+    expect(() => decryptText(data.toString('base64'), 'key')).toThrow('Wrong passphrase');
+  });
+
+  it('rejects payloads that are not ours', () => {
+    expect(() => decryptText('not encrypted', 'key')).toThrow('malformed');
+  });
+
+  it('rejects an empty passphrase', () => {
+    expect(() => encryptText('secret', '')).toThrow('Passphrase cannot be empty');
+  });
+});
+
+describe('encryptNoteContent', () => {
+  it('keeps the title heading readable and hides the body', () => {
+    const content = '# Diary\n\nDear diary, today I wrote tests.';
+    const encrypted = encryptNoteContent(content, 'key');
+
+    expect(encrypted.startsWith('# Diary\n\n')).toBe(true);
+    expect(encrypted).not.toContain('Dear diary');
+    expect(decryptNoteContent(encrypted, 'key')).toBe(content);
+  });
+
+  it('encrypts all of a note without a heading', () => {
+    const encrypted = encryptNoteContent('just text', 'key');
+    expect(encrypted).not.toContain('just text');
+    expect(decryptNoteContent(encrypted, 'key')).toBe('just text');
+  });
+
+  it('round-trips a heading-only note', () => {
+    expect(decryptNoteContent(encryptNoteContent('# Title', 'key'), 'key')).toBe('# Title');
+  });
+});
@@ -0,0 +1,64 @@
+import { describe, it, expect } from 'vitest';
+import { escapeCSVField, escapeTSVField, serializeNotesCSV, serializeNotesTSV } from '../../src/notes/csv.js';
+import { makeNote } from '../helpers.js';
+
+describe('escapeCSVField', () => {
+  it('leaves plain fields alone', () => {
+    expect(escapeCSVField('plain text')).toBe('plain text');
+  });
+
+  it('quotes commas, quotes and line breaks', () => {
+    expect(escapeCSVField('a, b')).toBe('"a, b"');
+    expect(escapeCSVField('say "hi"')).toBe('"say ""hi"""');
+    expect(escapeCSVField('two\nlines')).toBe('"two\nlines"');
+  });
+});
+
+describe('serializeNotesCSV', () => {
+  it('writes a header and one row per note without content', () => {
+    const csv = serializeNotesCSV([
+      makeNote({
+        id: 'work/plan.md',
+        title: 'Plan, "final"',
+        content: '# Plan\n\nBody, with a comma',
+        tags: ['work', 'q1'],
+        updated: '2024-01-02T00:00:00.000Z',
+        meta: { priority: 2, source: 'meeting' },
+      }),
+    ]);
+
+    expect(csv).toBe(
+      'id,title,tags,created,updated,priority,source,comment_count\r\n' +
+        'work/plan.md,"Plan, ""final""",work;q1,2024-01-01T00:00:00.000Z,2024-01-02T00:00:00.000Z,2,meeting,0\r\n',
+    );
+    expect(csv).not.toContain('Body');
+  });
+
+  it('leaves missing priority and source empty', () => {
+    const [, row] = serializeNotesCSV([makeNote()]).split('\r\n');
+    expect(row).toBe('test.md,Test Note,,2024-01-01T00:00:00.000Z,2024-01-01T00:00:00.000Z,,,0');
+  });
+});
+
+describe('escapeTSVField', () => {
+  it('escapes tabs, line breaks and backslashes instead of quoting', () => {
+    expect(escapeTSVField('plain "quoted", text')).toBe('plain "quoted", text');
+    expect(escapeTSVField('a\tb\nc\r\\d')).toBe('a\\tb\\nc\\r\\\\d');
+  });
+});
+
+describe('serializeNotesTSV', () => {
+  const notes = [makeNote({ id: 'work/plan.md', title: 'Plan\tA', tags: ['work', 'q1'], meta: { priority: 2 } })];
+
+  it('writes a header and one line per note', () => {
+    expect(serializeNotesTSV(notes)).toBe(
+      'id\tcreated\tpriority\ttags\ttitle\n' + 'work/plan.md\t2024-01-01T00:00:00.000Z\t2\twork,q1\tPlan\\tA\n',
+    );
+  });
+
+  it('leaves the header out on request', () => {
+    expect(serializeNotesTSV([makeNote()], { header: false })).toBe(
+      'test.md\t2024-01-01T00:00:00.000Z\t\t\tTest Note\n',
+    );
+  });
+});
@@ -0,0 +1,119 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import { checkDuplicateAliases, checkDuplicateIds, checkNote, checkNoteFiles } from '../../src/notes/doctor.js';
+import { makeNote } from '../helpers.js';
+
+let tempDir: string;
+
+beforeEach(() => {
+  tempDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-doctor-'));
+});
+
+afterEach(() => {
+  fs.rmSync(tempDir, { recursive: true, force: true });
+});
+
+function writeNote(name: string, content: string, sidecar?: string) {
+  const fullPath = path.join(tempDir, name);
+  fs.writeFileSync(fullPath, content);
+  if (sidecar !== undefined) {
+    fs.writeFileSync(fullPath.replace(/\.md$/, '.json'), sidecar);
+  }
+  return { fullPath, relativePath: name };
+}
+
+describe('checkNoteFiles', () => {
+  it('reports a missing sidecar and a leading block that is not frontmatter', () => {
+    const { problems, readable } = checkNoteFiles(writeNote('a.md', '---\n: not yaml [\n---\nbody'));
+    expect(readable).toBe(false);
+    expect(problems.map((problem) => problem.kind)).toEqual(['missing-sidecar', 'frontmatter']);
+  });
+
+  it('reports a sidecar that is not JSON', () => {
+    const { problems, readable } = checkNoteFiles(writeNote('a.md', '# A', '{ nope'));
+    expect(readable).toBe(false);
+    expect(problems[0].kind).toBe('invalid-sidecar');
+  });
+
+  it('reports missing timestamps', () => {
+    const sidecar = '{"tags":[],"created":"2024-01-01T00:00:00.000Z"}';
+    const { problems, readable } = checkNoteFiles(writeNote('a.md', '# A', sidecar));
+    expect(readable).toBe(true);
+    expect(problems).toMatchObject([{ kind: 'missing-timestamp', message: 'Sidecar has no updated timestamp' }]);
+  });
+
+  it('reports a sidecar written by a newer schema version', () => {
+    const sidecar = '{"schema":99,"tags":[],"created":"2024-01-01T00:00:00.000Z","updated":"2024-01-01T00:00:00.000Z"}';
+    const { problems } = checkNoteFiles(writeNote('a.md', '# A', sidecar));
+    expect(problems).toMatchObject([{ kind: 'newer-schema', fixable: false }]);
+  });
+});
+
+describe('checkNote', () => {
+  const fullPath = (name: string) => path.join(tempDir, name);
+
+  it('accepts a note whose filename matches its title', () => {
+    const note = makeNote({ id: '2024-01-01-plan.md', title: 'Plan', content: '# Plan' });
+    expect(checkNote(note, fullPath('2024-01-01-plan.md'))).toEqual([]);
+  });
+
+  it('reports a filename that drifted from the title', () => {
+    const note = makeNote({ id: '2024-01-01-plan.md', title: 'Roadmap', content: '# Roadmap' });
+    expect(checkNote(note, fullPath('2024-01-01-plan.md'))).toMatchObject([
+      { kind: 'filename-mismatch', message: 'Filename does not match the title; expected 2024-01-01-roadmap.md' },
+    ]);
+  });
+
+  it('leaves hand-named files alone', () => {
+    const note = makeNote({ id: 'ideas.md', title: 'Roadmap', content: '# Roadmap' });
+    expect(checkNote(note, fullPath('ideas.md'))).toEqual([]);
+  });
+
+  it('reports a missing title and out-of-range comment anchors', () => {
+    const note = makeNote({
+      id: '2024-01-01-plan.md',
+      content: 'short',
+      comments: [
+        {
+          id: 'c1',
+          author: 'me',
+          created: '2024-01-01T00:00:00.000Z',
+          content: 'x',
+          status: 'attached',
+          anchor: { from: 2, to: 40, rev: 1 },
+        },
+      ],
+    });
+    expect(checkNote(note, fullPath('2024-01-01-plan.md')).map((problem) => problem.kind)).toEqual([
+      'missing-title',
+      'comment-range',
+    ]);
+  });
+});
+
+describe('checkDuplicateAliases', () => {
+  it('reports aliases shared by several notes', () => {
+    const problems = checkDuplicateAliases([
+      makeNote({ id: 'a.md', aliases: ['plan'] }),
+      makeNote({ id: 'b.md', aliases: ['plan', 'other'] }),
+    ]);
+    expect(problems).toMatchObject([
+      { noteId: 'a.md', kind: 'duplicate-alias', message: 'Alias "plan" is also used by b.md' },
+    ]);
+  });
+});
+
+describe('checkDuplicateIds', () => {
+  it('reports IDs that differ only in case against the first of them', () => {
+    const records = ['Plan.md', 'plan.md', 'work/plan.md', 'PLAN.md'].map((relativePath) => ({
+      fullPath: path.join(tempDir, relativePath),
+      relativePath,
+    }));
+    expect(checkDuplicateIds(records).map((problem) => [problem.noteId, problem.message])).toEqual([
+      ['plan.md', 'ID differs from Plan.md only in case; the files collide on case-insensitive filesystems'],
+      ['PLAN.md', 'ID differs from Plan.md only in case; the files collide on case-insensitive filesystems'],
+    ]);
+  });
+});
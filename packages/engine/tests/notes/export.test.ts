@@ -0,0 +1,106 @@
+import { describe, it, expect } from 'vitest';
+import { exportHTML, exportMarkdown } from '../../src/notes/export.js';
+import { makeNote } from '../helpers.js';
+
+describe('exportMarkdown', () => {
+  const notes = [
+    makeNote({
+      id: 'a.md',
+      title: 'Alpha',
+      content: '# Alpha\n\nFirst body',
+      tags: ['work', 'k8s'],
+      updated: '2024-01-02T00:00:00.000Z',
+      due: '2024-06-01',
+    }),
+    makeNote({ id: 'b.md', title: 'Beta', content: 'No heading here' }),
+  ];
+
+  it('writes each note with its title, metadata and content', () => {
+    expect(exportMarkdown(notes)).toBe(
+      [
+        '# Alpha',
+        '',
+        '- **ID:** a.md',
+        '- **Tags:** #work #k8s',
+        '- **Created:** 2024-01-01T00:00:00.000Z',
+        '- **Updated:** 2024-01-02T00:00:00.000Z',
+        '- **Due:** 2024-06-01',
+        '',
+        'First body',
+        '',
+        '---',
+        '',
+        '# Beta',
+        '',
+        '- **ID:** b.md',
+        '- **Created:** 2024-01-01T00:00:00.000Z',
+        '- **Updated:** 2024-01-01T00:00:00.000Z',
+        '',
+        'No heading here',
+        '',
+      ].join('\n'),
+    );
+  });
+
+  it('prepends a table of contents with unique anchors', () => {
+    const twins = ['a.md', 'b.md'].map((id) => makeNote({ id, title: 'Same', content: '# Same' }));
+    const output = exportMarkdown(twins, { toc: true });
+    expect(output.startsWith('# Table of Contents\n\n- [Same](#same)\n- [Same](#same-1)\n\n---\n\n# Same')).toBe(true);
+  });
+
+  it('returns an empty document for no notes', () => {
+    expect(exportMarkdown([], { toc: true })).toBe('');
+  });
+});
+
+describe('exportHTML', () => {
+  const notes = [
+    makeNote({
+      id: 'projects/alpha.md',
+      title: 'Alpha',
+      content: '# Alpha\n\nSee [[Beta]] and [[Missing]].',
+      tags: ['work'],
+      created: '2024-03-01T00:00:00.000Z',
+      comments: [
+        {
+          id: 'c1',
+          author: 'reviewer',
+          created: '2024-03-02T00:00:00.000Z',
+          content: 'Link <b>this</b>',
+          status: 'attached',
+          anchor: { from: 13, to: 17, rev: 1, quote: 'Beta' },
+        },
+      ],
+    }),
+    makeNote({ id: 'beta.md', title: 'Beta', content: '# Beta\n\nPlain' }),
+  ];
+  const files = exportHTML(notes, { title: 'My Notes' });
+  const byPath = new Map(files.map((file) => [file.path, file.content]));
+
+  it('writes an index, a stylesheet, and a page per note at its ID', () => {
+    expect(files.map((file) => file.path)).toEqual(['index.html', 'style.css', 'projects/alpha.html', 'beta.html']);
+  });
+
+  it('renders note content with relative links and stylesheet paths', () => {
+    const page = byPath.get('projects/alpha.html')!;
+    expect(page).toContain('<link rel="stylesheet" href="../style.css">');
+    expect(page).toContain('<article>\n<h1 id="alpha">Alpha</h1>\n<p class="meta">');
+    expect(page).toContain('See <a href="../beta.html">Beta</a> and <span class="broken-link">Missing</span>.');
+    expect(page).toContain('<a class="tag" href="../index.html#tag-work">#work</a>');
+  });
+
+  it('lists comments with their quotes, escaped', () => {
+    const page = byPath.get('projects/alpha.html')!;
+    expect(page).toContain(
+      '<li id="comment-1"><blockquote>Beta</blockquote><p><strong>reviewer</strong>: Link &lt;b&gt;this&lt;/b&gt;</p></li>',
+    );
+  });
+
+  it('indexes notes by tag and by date', () => {
+    const index = byPath.get('index.html')!;
+    expect(index).toContain('<title>My Notes</title>');
+    expect(index).toContain('<h3 id="tag-work">#work</h3>\n<ul>\n<li><a href="projects/alpha.html">Alpha</a></li>\n</ul>');
+    expect(index).toContain('<h3 id="untagged">Untagged</h3>');
+    expect(index.indexOf('2024-03-01')).toBeLessThan(index.indexOf('2024-01-01'));
+  });
+});
@@ -0,0 +1,46 @@
+import { describe, it, expect } from 'vitest';
+import { getCreatedDrift, getIdTimestamp } from '../../src/notes/ids.js';
+
+describe('getIdTimestamp', () => {
+  it('reads the UTC date prefix of a note filename', () => {
+    expect(getIdTimestamp('work/2026-03-07-standup.md')).toEqual({
+      time: '2026-03-07T00:00:00.000Z',
+      precision: 'date',
+    });
+  });
+
+  it('decodes a ULID filename to the millisecond', () => {
+    expect(getIdTimestamp('01ARYZ6S41TSV4RRFFQ69G5FAV.md')).toEqual({
+      time: new Date(1469918176385).toISOString(),
+      precision: 'ulid',
+    });
+  });
+
+  it('throws for IDs without a timestamp', () => {
+    expect(() => getIdTimestamp('ideas.md')).toThrow('Note ID has no timestamp: ideas.md');
+    expect(() => getIdTimestamp('2026-02-31-impossible.md')).toThrow();
+  });
+});
+
+describe('getCreatedDrift', () => {
+  const day = getIdTimestamp('2026-03-07-standup.md');
+
+  it('is zero anywhere within the prefixed day', () => {
+    expect(getCreatedDrift('2026-03-07T00:00:00.000Z', day)).toBe(0);
+    expect(getCreatedDrift('2026-03-07T23:59:59.999Z', day)).toBe(0);
+  });
+
+  it('measures how far outside the day created falls', () => {
+    expect(getCreatedDrift('2026-03-06T23:00:00.000Z', day)).toBe(60 * 60 * 1000);
+    expect(getCreatedDrift('2026-03-08T00:00:00.999Z', day)).toBe(1000);
+  });
+
+  it('compares against the exact time of a ULID', () => {
+    const stamp = getIdTimestamp('01ARYZ6S41TSV4RRFFQ69G5FAV');
+    expect(getCreatedDrift(new Date(1469918176385 + 5000).toISOString(), stamp)).toBe(5000);
+  });
+
+  it('treats an unreadable created value as an infinite drift', () => {
+    expect(getCreatedDrift('', day)).toBe(Number.POSITIVE_INFINITY);
+  });
+});
@@ -0,0 +1,54 @@
+import { describe, it, expect } from 'vitest';
+import { parseNoteInput } from '../../src/notes/input.js';
+
+describe('parseNoteInput', () => {
+  it('reads every field, turning priority and source into custom fields', () => {
+    const input = parseNoteInput(
+      JSON.stringify({
+        title: ' Spec ',
+        tags: ['work'],
+        priority: 5,
+        source: 'https://example.com',
+        content: 'body',
+        comments: [{ content: 'why?', exact: 'body' }, { content: 'range', author: 'bob', from: 0, to: 2 }],
+      }),
+      'agent',
+    );
+    expect(input).toEqual({
+      title: 'Spec',
+      tags: ['work'],
+      meta: { priority: 5, source: 'https://example.com' },
+      content: 'body',
+      comments: [
+        { content: 'why?', author: 'agent', exact: 'body' },
+        { content: 'range', author: 'bob', from: 0, to: 2 },
+      ],
+    });
+  });
+
+  it('requires a title', () => {
+    expect(() => parseNoteInput('{"content":"body"}', '')).toThrow('"title" is required');
+    expect(() => parseNoteInput('{"title":"  "}', '')).toThrow('"title" is required');
+  });
+
+  it('rejects unknown keys, at the top level and in comments', () => {
+    expect(() => parseNoteInput('{"title":"A","tilte":"B","due":"x"}', '')).toThrow('Unknown note fields: tilte, due');
+    expect(() => parseNoteInput('{"title":"A","comments":[{"content":"c","exact":"A","line":1}]}', '')).toThrow(
+      'Unknown comments[0] field: line',
+    );
+  });
+
+  it('rejects malformed values', () => {
+    expect(() => parseNoteInput('[1]', '')).toThrow('Note JSON must be an object');
+    expect(() => parseNoteInput('{title', '')).toThrow('Note JSON is not valid');
+    expect(() => parseNoteInput('{"title":"A","tags":"work"}', '')).toThrow('"tags" must be an array of strings');
+    expect(() => parseNoteInput('{"title":"A","priority":11}', '')).toThrow('"priority" must be an integer from 0 to 10');
+    expect(() => parseNoteInput('{"title":"A","priority":2.5}', '')).toThrow('"priority"');
+    expect(() => parseNoteInput('{"title":"A","comments":[{"content":"c"}]}', '')).toThrow(
+      'comments[0] needs exact text or integer from and to offsets',
+    );
+    expect(() => parseNoteInput('{"title":"A","comments":[{"content":"c","exact":"A","from":0,"to":1}]}', '')).toThrow(
+      'either exact or from/to',
+    );
+  });
+});
@@ -0,0 +1,73 @@
+import { describe, it, expect } from 'vitest';
+import { collectLinkedNotes, extractLinks, resolveLinkTarget } from '../../src/notes/links.js';
+import type { Note } from '../../src/types.js';
+import { makeNote } from '../helpers.js';
+
+describe('extractLinks', () => {
+  it('finds wiki links in order', () => {
+    expect(extractLinks('See [[Alpha]] and [[docs/beta.md]].')).toEqual(['Alpha', 'docs/beta.md']);
+  });
+
+  it('strips labels and deduplicates', () => {
+    expect(extractLinks('[[Alpha|the first]] then [[ Alpha ]]')).toEqual(['Alpha']);
+  });
+
+  it('ignores empty and multi-line brackets', () => {
+    expect(extractLinks('[[]] [[split\nlink]] [[ok]]')).toEqual(['ok']);
+  });
+});
+
+describe('resolveLinkTarget', () => {
+  const notes = [
+    makeNote({ id: '2024-01-01-alpha.md', title: 'Alpha', filename: '2024-01-01-alpha.md' }),
+    makeNote({ id: 'docs/2024-01-02-beta-notes.md', title: 'Beta Notes', filename: '2024-01-02-beta-notes.md' }),
+    makeNote({ id: 'a/2024-01-03-dup.md', title: 'Dup', filename: '2024-01-03-dup.md' }),
+    makeNote({ id: 'b/2024-01-03-dup.md', title: 'Dup', filename: '2024-01-03-dup.md' }),
+  ];
+
+  it('resolves by id with or without extension', () => {
+    expect(resolveLinkTarget(notes, 'docs/2024-01-02-beta-notes.md')?.title).toBe('Beta Notes');
+    expect(resolveLinkTarget(notes, 'docs/2024-01-02-beta-notes')?.title).toBe('Beta Notes');
+  });
+
+  it('resolves by title case-insensitively', () => {
+    expect(resolveLinkTarget(notes, 'alpha')?.id).toBe('2024-01-01-alpha.md');
+  });
+
+  it('resolves by title slug', () => {
+    expect(resolveLinkTarget(notes, 'beta-notes')?.id).toBe('docs/2024-01-02-beta-notes.md');
+  });
+
+  it('does not resolve ambiguous or unknown references', () => {
+    expect(resolveLinkTarget(notes, 'Dup')).toBeNull();
+    expect(resolveLinkTarget(notes, 'gamma')).toBeNull();
+  });
+});
+
+describe('collectLinkedNotes', () => {
+  const a = makeNote({ id: 'a.md', filename: 'a.md', title: 'A', content: '# A\n\n[[B]] and [[C]]' });
+  const b = makeNote({ id: 'b.md', filename: 'b.md', title: 'B', content: '# B\n\n[[A]] then [[D]]' });
+  const c = makeNote({ id: 'c.md', filename: 'c.md', title: 'C', content: '# C\n\n[[D]]' });
+  const d = makeNote({ id: 'd.md', filename: 'd.md', title: 'D', content: '# D\n\n[[missing]]' });
+  const notes = [a, b, c, d];
+  const ids = (found: Note[]) => found.map((note) => note.id);
+
+  it('walks links breadth first to the given depth', () => {
+    expect(ids(collectLinkedNotes(notes, a, { depth: 1 }))).toEqual(['a.md', 'b.md', 'c.md']);
+    expect(ids(collectLinkedNotes(notes, a, { depth: 2 }))).toEqual(['a.md', 'b.md', 'c.md', 'd.md']);
+    expect(ids(collectLinkedNotes(notes, a, { depth: 0 }))).toEqual(['a.md']);
+  });
+
+  it('visits each note once despite cycles', () => {
+    expect(ids(collectLinkedNotes(notes, b, { depth: 10 }))).toEqual(['b.md', 'a.md', 'd.md', 'c.md']);
+  });
+
+  it('stops at maxNotes', () => {
+    expect(ids(collectLinkedNotes(notes, a, { depth: 5, maxNotes: 2 }))).toEqual(['a.md', 'b.md']);
+  });
+
+  it('does not follow links in encrypted notes', () => {
+    const locked = { ...a, encrypted: true };
+    expect(ids(collectLinkedNotes(notes, locked, { depth: 3 }))).toEqual(['a.md']);
+  });
+});
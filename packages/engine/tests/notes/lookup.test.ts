@@ -0,0 +1,135 @@
+import { describe, it, expect } from 'vitest';
+import { lookupNote, findSimilarTag, levenshtein, scoreFuzzyMatch, filterNotesFuzzy } from '../../src/notes/lookup.js';
+import { makeNote } from '../helpers.js';
+
+const notes = [
+  makeNote({ id: '2024-01-01-kubernetes.md', title: 'Kubernetes' }),
+  makeNote({ id: '2024-01-02-meeting-notes.md', title: 'Meeting Notes' }),
+  makeNote({ id: '2024-01-03-grocery-list.md', title: 'Grocery List' }),
+  makeNote({ id: '2024-01-04-cat.md', title: 'Cat' }),
+  makeNote({ id: '2024-01-05-car.md', title: 'Car' }),
+];
+
+describe('levenshtein', () => {
+  it('counts insertions, deletions, and substitutions', () => {
+    expect(levenshtein('kitten', 'sitting')).toBe(3);
+    expect(levenshtein('', 'abc')).toBe(3);
+    expect(levenshtein('same', 'same')).toBe(0);
+  });
+});
+
+describe('findSimilarTag', () => {
+  const tags = ['kubernetes', 'kafka', 'ops/oncall'];
+
+  it('suggests the closest existing tag for a typo', () => {
+    expect(findSimilarTag('kubernets', tags)).toBe('kubernetes');
+    expect(findSimilarTag('ops/oncal', tags)).toBe('ops/oncall');
+  });
+
+  it('returns null for existing tags, compared case-insensitively', () => {
+    expect(findSimilarTag('Kubernetes', tags)).toBeNull();
+  });
+
+  it('returns null when nothing is close', () => {
+    expect(findSimilarTag('terraform', tags)).toBeNull();
+  });
+
+  it('breaks ties alphabetically', () => {
+    expect(findSimilarTag('cat', ['cot', 'bat'])).toBe('bat');
+  });
+});
+
+describe('lookupNote', () => {
+  it('matches by exact ID', () => {
+    expect(lookupNote(notes, '2024-01-04-cat.md').note?.title).toBe('Cat');
+  });
+
+  it('resolves an exact alias before titles', () => {
+    const aliased = [...notes, makeNote({ id: 'runbook.md', title: 'Runbook', aliases: ['cat'] })];
+    expect(lookupNote(aliased, 'Cat').note?.id).toBe('runbook.md');
+  });
+
+  it('prefers an exact title over a contains match', () => {
+    const withPrefix = [makeNote({ id: 'a.md', title: 'Cats and Dogs' }), ...notes];
+    expect(lookupNote(withPrefix, 'cat').note?.title).toBe('Cat');
+  });
+
+  it('matches a title substring before trying fuzzy matches', () => {
+    expect(lookupNote(notes, 'grocery').note?.title).toBe('Grocery List');
+  });
+
+  it('falls back to the closest title for typos', () => {
+    const result = lookupNote(notes, 'kubernets');
+    expect(result.note?.title).toBe('Kubernetes');
+    expect(result.candidates).toEqual([]);
+  });
+
+  it('reports equally close titles as candidates', () => {
+    const result = lookupNote(notes, 'cax');
+    expect(result.note).toBeNull();
+    expect(result.candidates.map((note) => note.title)).toEqual(['Car', 'Cat']);
+  });
+
+  it('returns nothing when no title is close', () => {
+    expect(lookupNote(notes, 'zzzzzz')).toEqual({ note: null, ambiguous: false, candidates: [] });
+  });
+
+  it('returns a single substring match', () => {
+    const result = lookupNote(notes, 'meeting');
+    expect(result.note?.title).toBe('Meeting Notes');
+    expect(result.ambiguous).toBe(false);
+  });
+
+  it('reports several substring matches as ambiguous', () => {
+    const result = lookupNote(notes, 'ca');
+    expect(result.note).toBeNull();
+    expect(result.ambiguous).toBe(true);
+    expect(result.candidates.map((note) => note.title)).toEqual(['Cat', 'Car']);
+  });
+
+  it('lets an exact title win over other substring matches', () => {
+    const result = lookupNote([...notes, makeNote({ id: 'b.md', title: 'Cat Facts' })], 'cat');
+    expect(result.note?.title).toBe('Cat');
+    expect(result.ambiguous).toBe(false);
+  });
+
+  it('lets an exact ID win even when titles also match', () => {
+    const result = lookupNote([...notes, makeNote({ id: 'car.md', title: 'Car' })], 'car.md');
+    expect(result.note?.id).toBe('car.md');
+  });
+
+  it('reports duplicate exact titles as ambiguous', () => {
+    const result = lookupNote([...notes, makeNote({ id: 'b.md', title: 'Cat' })], 'Cat');
+    expect(result.ambiguous).toBe(true);
+    expect(result.candidates.map((note) => note.id)).toEqual(['2024-01-04-cat.md', 'b.md']);
+  });
+
+  it('does not flag fuzzy suggestions as ambiguous', () => {
+    expect(lookupNote(notes, 'cax').ambiguous).toBe(false);
+  });
+});
+
+describe('scoreFuzzyMatch', () => {
+  it('matches characters in order, ignoring case and query spaces', () => {
+    expect(scoreFuzzyMatch('Meeting Notes', 'mtn')).not.toBeNull();
+    expect(scoreFuzzyMatch('Meeting Notes', 'meeting notes')).not.toBeNull();
+    expect(scoreFuzzyMatch('Meeting Notes', 'ntm')).toBeNull();
+    expect(scoreFuzzyMatch('Anything', '')).toBe(0);
+  });
+
+  it('prefers word starts and consecutive runs', () => {
+    expect(scoreFuzzyMatch('Meeting Notes', 'mn')!).toBeGreaterThan(scoreFuzzyMatch('Meeting Notes', 'in')!);
+    expect(scoreFuzzyMatch('Grocery List', 'gro')!).toBeGreaterThan(scoreFuzzyMatch('Grocery List', 'gcy')!);
+  });
+});
+
+describe('filterNotesFuzzy', () => {
+  it('keeps matching notes, best first', () => {
+    expect(filterNotesFuzzy(notes, 'cat').map((note) => note.title)).toEqual(['Cat']);
+    expect(filterNotesFuzzy(notes, 'nt').map((note) => note.title)).toEqual(['Meeting Notes', 'Kubernetes']);
+  });
+
+  it('keeps every note in order for an empty query', () => {
+    expect(filterNotesFuzzy(notes, '')).toEqual(notes);
+  });
+});
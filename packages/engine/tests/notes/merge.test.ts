@@ -0,0 +1,32 @@
+import { describe, it, expect } from 'vitest';
+import { mergeNoteContent } from '../../src/notes/merge.js';
+import { makeNote } from '../helpers.js';
+
+describe('mergeNoteContent', () => {
+  const target = makeNote({ id: 't.md', title: 'Target', content: '# Target\n\nExisting' });
+  const source = makeNote({ id: 's.md', title: 'Source', content: '# Source\n\nMoved body' });
+
+  it('appends the source body under a merged-from heading', () => {
+    expect(mergeNoteContent(target, source).content).toBe(
+      '# Target\n\nExisting\n\n## Merged from Source\n\nMoved body',
+    );
+  });
+
+  it('maps body offsets onto the appended body', () => {
+    const { content, mapSourceOffset } = mergeNoteContent(target, source);
+    const from = source.content.indexOf('body');
+    expect(content.slice(mapSourceOffset(from), mapSourceOffset(from + 4))).toBe('body');
+  });
+
+  it('maps title offsets onto the title in the new heading', () => {
+    const { content, mapSourceOffset } = mergeNoteContent(target, source);
+    expect(content.slice(mapSourceOffset(2), mapSourceOffset(8))).toBe('Source');
+  });
+
+  it('keeps content without a heading whole', () => {
+    const plain = makeNote({ id: 'p.md', title: 'p', content: 'no heading' });
+    const { content, mapSourceOffset } = mergeNoteContent(target, plain);
+    expect(content.endsWith('## Merged from p\n\nno heading')).toBe(true);
+    expect(content.slice(mapSourceOffset(0), mapSourceOffset(2))).toBe('no');
+  });
+});
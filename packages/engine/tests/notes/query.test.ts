@@ -0,0 +1,79 @@
+import { describe, it, expect } from 'vitest';
+import { parseQuery, evaluateQuery } from '../../src/notes/query.js';
+
+function matches(query: string, text: string): boolean {
+  return evaluateQuery(parseQuery(query), (term) => text.includes(term));
+}
+
+describe('parseQuery', () => {
+  it('parses a single term', () => {
+    expect(parseQuery('alpha')).toEqual({ type: 'term', value: 'alpha' });
+  });
+
+  it('binds NOT tighter than AND, and AND tighter than OR', () => {
+    expect(parseQuery('a OR b AND NOT c')).toEqual({
+      type: 'or',
+      left: { type: 'term', value: 'a' },
+      right: {
+        type: 'and',
+        left: { type: 'term', value: 'b' },
+        right: { type: 'not', operand: { type: 'term', value: 'c' } },
+      },
+    });
+  });
+
+  it('treats adjacent terms as AND', () => {
+    expect(parseQuery('a b')).toEqual(parseQuery('a AND b'));
+    expect(parseQuery('a NOT b')).toEqual(parseQuery('a AND NOT b'));
+  });
+
+  it('keeps quoted phrases as one term', () => {
+    expect(parseQuery('"service mesh" OR ingress')).toEqual({
+      type: 'or',
+      left: { type: 'term', value: 'service mesh' },
+      right: { type: 'term', value: 'ingress' },
+    });
+  });
+
+  it('treats quoted and lowercase operators as terms', () => {
+    expect(parseQuery('"AND"')).toEqual({ type: 'term', value: 'AND' });
+    expect(parseQuery('this or that')).toEqual(parseQuery('this AND or AND that'));
+  });
+
+  it('rejects malformed queries', () => {
+    expect(() => parseQuery('')).toThrow('Invalid query');
+    expect(() => parseQuery('a AND')).toThrow('Invalid query');
+    expect(() => parseQuery('(a OR b')).toThrow('unmatched "("');
+    expect(() => parseQuery('a OR b)')).toThrow('unmatched ")"');
+    expect(() => parseQuery('OR a')).toThrow('Invalid query');
+    expect(() => parseQuery('"open phrase')).toThrow('unterminated quote');
+  });
+});
+
+describe('evaluateQuery', () => {
+  const query = 'kubernetes AND (ingress OR gateway) NOT deprecated';
+
+  it('evaluates nested groups', () => {
+    expect(matches(query, 'kubernetes ingress notes')).toBe(true);
+    expect(matches(query, 'kubernetes gateway notes')).toBe(true);
+    expect(matches(query, 'kubernetes notes')).toBe(false);
+    expect(matches(query, 'kubernetes ingress deprecated')).toBe(false);
+  });
+
+  it('evaluates deeply nested groups', () => {
+    const nested = '((a OR b) AND (c OR (d AND NOT e)))';
+    expect(matches(nested, 'a c')).toBe(true);
+    expect(matches(nested, 'b d')).toBe(true);
+    expect(matches(nested, 'b d e')).toBe(false);
+    expect(matches(nested, 'c d')).toBe(false);
+  });
+
+  it('matches quoted phrases as a whole', () => {
+    expect(matches('"service mesh"', 'a service mesh')).toBe(true);
+    expect(matches('"service mesh"', 'mesh for a service')).toBe(false);
+  });
+
+  it('supports double negation', () => {
+    expect(matches('NOT NOT a', 'a')).toBe(true);
+  });
+});
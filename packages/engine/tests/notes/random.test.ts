@@ -0,0 +1,50 @@
+import { describe, it, expect } from 'vitest';
+import { createSeededRandom, pickRandomNotes } from '../../src/notes/random.js';
+import type { Note } from '../../src/types.js';
+import { makeNote } from '../helpers.js';
+
+const notes = ['a.md', 'b.md', 'c.md', 'd.md', 'e.md'].map((id) => makeNote({ id }));
+const ids = (picked: Note[]) => picked.map((note) => note.id);
+
+describe('createSeededRandom', () => {
+  it('repeats the same sequence for the same seed', () => {
+    const first = createSeededRandom(42);
+    const second = createSeededRandom(42);
+    const values = Array.from({ length: 5 }, () => first());
+    expect(Array.from({ length: 5 }, () => second())).toEqual(values);
+    expect(values.every((value) => value >= 0 && value < 1)).toBe(true);
+  });
+
+  it('differs between seeds', () => {
+    expect(createSeededRandom(1)()).not.toBe(createSeededRandom(2)());
+  });
+});
+
+describe('pickRandomNotes', () => {
+  it('is reproducible with a seeded source', () => {
+    const first = pickRandomNotes(notes, 3, createSeededRandom(7));
+    const second = pickRandomNotes(notes, 3, createSeededRandom(7));
+    expect(ids(second)).toEqual(ids(first));
+  });
+
+  it('returns distinct notes', () => {
+    const picked = pickRandomNotes(notes, 5, createSeededRandom(3));
+    expect(new Set(ids(picked)).size).toBe(5);
+  });
+
+  it('caps the count at the number of notes', () => {
+    expect(pickRandomNotes(notes, 10)).toHaveLength(5);
+    expect(pickRandomNotes([], 1)).toEqual([]);
+    expect(pickRandomNotes(notes, 0)).toEqual([]);
+  });
+
+  it('uses the random source to choose', () => {
+    expect(ids(pickRandomNotes(notes, 1, () => 0))).toEqual(['a.md']);
+    expect(ids(pickRandomNotes(notes, 1, () => 0.99))).toEqual(['e.md']);
+  });
+
+  it('does not reorder the input', () => {
+    pickRandomNotes(notes, 3, createSeededRandom(9));
+    expect(ids(notes)).toEqual(['a.md', 'b.md', 'c.md', 'd.md', 'e.md']);
+  });
+});
@@ -0,0 +1,36 @@
+import { describe, it, expect } from 'vitest';
+import { getReadingMinutes, getWordCount } from '../../src/notes/reading.js';
+
+describe('getWordCount', () => {
+  it('counts plain prose', () => {
+    expect(getWordCount('one two  three\nfour')).toBe(4);
+  });
+
+  it('ignores heading markers, bullets, quotes and rules', () => {
+    expect(getWordCount('# Title here\n\n- first item\n* [x] done task\n1. numbered\n> quoted line\n\n---')).toBe(9);
+  });
+
+  it('drops code fences but counts code and strips emphasis', () => {
+    expect(getWordCount('```ts\nconst x\n```\n**bold** and `code`')).toBe(5);
+  });
+
+  it('keeps link labels and drops urls and images', () => {
+    expect(getWordCount('see [the docs](https://example.com/a-b) ![logo](x.png) [[Other Note|other]]')).toBe(4);
+  });
+
+  it('ignores punctuation-only tokens', () => {
+    expect(getWordCount('a - b — c ...')).toBe(3);
+  });
+});
+
+describe('getReadingMinutes', () => {
+  it('rounds up to whole minutes', () => {
+    expect(getReadingMinutes(412)).toBe(3);
+    expect(getReadingMinutes(400, 200)).toBe(2);
+    expect(getReadingMinutes(10, 250)).toBe(1);
+  });
+
+  it('is zero for no words', () => {
+    expect(getReadingMinutes(0)).toBe(0);
+  });
+});
@@ -0,0 +1,77 @@
+import { describe, it, expect } from 'vitest';
+import { getHeadingAnchor, renderInline, renderMarkdown } from '../../src/notes/render.js';
+
+describe('getHeadingAnchor', () => {
+  it('lowercases, drops punctuation, and hyphenates spaces', () => {
+    expect(getHeadingAnchor('Hello, World! (v2)')).toBe('hello-world-v2');
+    expect(getHeadingAnchor('Café notes')).toBe('café-notes');
+  });
+
+  it('suffixes repeated anchors', () => {
+    const used = new Map<string, number>();
+    expect(getHeadingAnchor('Notes', used)).toBe('notes');
+    expect(getHeadingAnchor('Notes', used)).toBe('notes-1');
+    expect(getHeadingAnchor('notes', used)).toBe('notes-2');
+  });
+});
+
+describe('renderInline', () => {
+  it('renders emphasis, code, and links', () => {
+    expect(renderInline('**bold** and *em* and `a<b>` and ~~old~~')).toBe(
+      '<strong>bold</strong> and <em>em</em> and <code>a&lt;b&gt;</code> and <del>old</del>',
+    );
+    expect(renderInline('[site](https://example.com "Home") ![pic](a.png)')).toBe(
+      '<a href="https://example.com" title="Home">site</a> <img src="a.png" alt="pic">',
+    );
+  });
+
+  it('escapes raw HTML and unsafe link schemes', () => {
+    expect(renderInline('<script>x</script>')).toBe('&lt;script&gt;x&lt;/script&gt;');
+    expect(renderInline('[x](javascript:alert(1))')).toContain('href="#"');
+  });
+
+  it('leaves underscores inside words alone', () => {
+    expect(renderInline('snake_case_name')).toBe('snake_case_name');
+  });
+
+  it('links wiki references through the resolver', () => {
+    const resolveLink = (ref: string) => (ref === 'Known' ? 'known.html' : null);
+    expect(renderInline('[[Known|label]] [[Unknown]]', { resolveLink })).toBe(
+      '<a href="known.html">label</a> <span class="broken-link">Unknown</span>',
+    );
+  });
+});
+
+describe('renderMarkdown', () => {
+  it('renders headings, paragraphs, rules, and quotes', () => {
+    expect(renderMarkdown('# Title\n\nline one\nline two\n\n---\n\n> quoted\n> more')).toBe(
+      [
+        '<h1 id="title">Title</h1>',
+        '<p>line one\nline two</p>',
+        '<hr>',
+        '<blockquote>\n<p>quoted\nmore</p>\n</blockquote>',
+      ].join('\n'),
+    );
+  });
+
+  it('renders fenced code without interpreting it', () => {
+    expect(renderMarkdown('```ts\nconst a = **b**;\n```')).toBe(
+      '<pre><code class="language-ts">const a = **b**;</code></pre>',
+    );
+  });
+
+  it('renders nested, ordered, and task lists', () => {
+    expect(renderMarkdown('- one\n  - nested\n- [x] done\n\n3. three\n4. four')).toBe(
+      [
+        '<ul>',
+        '<li>one\n<ul>\n<li>nested</li>\n</ul></li>',
+        '<li class="task"><input type="checkbox" disabled checked> done</li>',
+        '</ul>',
+        '<ol start="3">',
+        '<li>three</li>',
+        '<li>four</li>',
+        '</ol>',
+      ].join('\n'),
+    );
+  });
+});
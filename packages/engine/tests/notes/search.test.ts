@@ -1,21 +1,15 @@
 import { describe, it, expect } from 'vitest';
-import { search, getAllTags, getSortedTags } from '../../src/notes/search.js';
-import type { Note } from '../../src/types.js';
-
-function makeNote(overrides: Partial<Note> = {}): Note {
-  return {
-    id: 'test.md',
-    title: 'Test Note',
-    tags: [],
-    commentRev: 0,
-    comments: [],
-    content: '# Test Note\n\nSome content',
-    filename: 'test.md',
-    relativePath: 'test.md',
-    directory: '',
-    ...overrides,
-  };
-}
+import {
+  search,
+  compileSearchPattern,
+  getAllTags,
+  getSortedTags,
+  getTagTree,
+  matchesTag,
+  scoreNote,
+} from '../../src/notes/search.js';
+import type { Note, SortField } from '../../src/types.js';
+import { makeNote } from '../helpers.js';
 
 describe('search', () => {
   const notes = [
@@ -55,6 +49,17 @@ describe('search', () => {
     expect(result).toHaveLength(1);
   });
 
+  it('treats a limit of 0 as no limit', () => {
+    expect(search(notes, { limit: 0 })).toHaveLength(3);
+    expect(search(notes, { query: 'alpha', limit: 0 }).map((n) => n.id)).toEqual(['a.md', 'b.md']);
+  });
+
+  it('skips offset matches after sorting, then applies limit', () => {
+    expect(search(notes, { sortBy: 'title', offset: 1, limit: 1 }).map((n) => n.title)).toEqual(['Beta']);
+    expect(search(notes, { sortBy: 'title', offset: 2 }).map((n) => n.title)).toEqual(['Gamma']);
+    expect(search(notes, { offset: 5 })).toEqual([]);
+  });
+
   it('sorts by title', () => {
     const result = search(notes, { sortBy: 'title' });
     expect(result.map((n) => n.title)).toEqual(['Alpha', 'Beta', 'Gamma']);
@@ -64,6 +69,376 @@ describe('search', () => {
     const result = search(notes, { sortBy: 'title', reverse: true });
     expect(result.map((n) => n.title)).toEqual(['Gamma', 'Beta', 'Alpha']);
   });
+
+  it('sorts by updated timestamp', () => {
+    const touched = [
+      makeNote({ id: 'a.md', relativePath: 'a.md', updated: '2024-03-01T00:00:00.000Z' }),
+      makeNote({ id: 'b.md', relativePath: 'b.md', updated: '2024-01-01T00:00:00.000Z' }),
+      makeNote({ id: 'c.md', relativePath: 'c.md', updated: '2024-02-01T00:00:00.000Z' }),
+    ];
+    const result = search(touched, { sortBy: 'updated', reverse: true });
+    expect(result.map((n) => n.id)).toEqual(['a.md', 'c.md', 'b.md']);
+  });
+
+  it('sorts by due date with undated notes last', () => {
+    const scheduled = [
+      makeNote({ id: 'a.md', relativePath: 'a.md' }),
+      makeNote({ id: 'b.md', relativePath: 'b.md', due: '2024-06-02' }),
+      makeNote({ id: 'c.md', relativePath: 'c.md', due: '2024-06-01' }),
+    ];
+    expect(search(scheduled, { sortBy: 'due' }).map((n) => n.id)).toEqual(['c.md', 'b.md', 'a.md']);
+    expect(search(scheduled, { sortBy: 'due', reverse: true }).map((n) => n.id)).toEqual(['b.md', 'c.md', 'a.md']);
+  });
+
+  it('breaks ties with later sort fields, then by path', () => {
+    const tied = [
+      makeNote({ id: 'd.md', relativePath: 'd.md', title: 'Same', due: '2024-06-01' }),
+      makeNote({ id: 'c.md', relativePath: 'c.md', title: 'Other', due: '2024-06-01' }),
+      makeNote({ id: 'b.md', relativePath: 'b.md', title: 'Same', due: '2024-06-01' }),
+      makeNote({ id: 'a.md', relativePath: 'a.md', title: 'Same' }),
+    ];
+    expect(search(tied, { sortBy: ['due', 'title'] }).map((n) => n.id)).toEqual(['c.md', 'b.md', 'd.md', 'a.md']);
+    expect(search(tied, { sortBy: ['due', 'title'], reverse: true }).map((n) => n.id)).toEqual([
+      'd.md',
+      'b.md',
+      'c.md',
+      'a.md',
+    ]);
+    expect(search(tied, { sortBy: 'title' }).map((n) => n.id)).toEqual(['c.md', 'a.md', 'b.md', 'd.md']);
+  });
+});
+
+describe('search with custom fields', () => {
+  const notes = [
+    makeNote({ id: 'a.md', relativePath: 'a.md', meta: { status: 'open', points: 3 } }),
+    makeNote({ id: 'b.md', relativePath: 'b.md', meta: { status: 'done' } }),
+    makeNote({ id: 'c.md', relativePath: 'c.md' }),
+  ];
+
+  it('filters by field presence', () => {
+    expect(search(notes, { meta: ['status'] }).map((n) => n.id)).toEqual(['a.md', 'b.md']);
+  });
+
+  it('filters by field value', () => {
+    expect(search(notes, { meta: ['status=open'] }).map((n) => n.id)).toEqual(['a.md']);
+    expect(search(notes, { meta: ['points=3'] }).map((n) => n.id)).toEqual(['a.md']);
+  });
+
+  it('requires every filter to match', () => {
+    expect(search(notes, { meta: ['status', 'points'] }).map((n) => n.id)).toEqual(['a.md']);
+  });
+});
+
+describe('search with regex', () => {
+  const notes = [
+    makeNote({ id: 'a.md', title: 'Alpha', content: '# Alpha\n\nTODO: write more', relativePath: 'a.md' }),
+    makeNote({ id: 'b.md', title: 'Beta', content: '# Beta\n\nnothing TODO: here', relativePath: 'b.md' }),
+    makeNote({ id: 'c.md', title: 'Release 2.0', relativePath: 'c.md' }),
+    makeNote({ id: 'd.md', title: 'Delta', tags: ['project-x'], relativePath: 'd.md' }),
+  ];
+
+  it('anchors ^ at line starts in content', () => {
+    const result = search(notes, { query: '^TODO:', regex: true });
+    expect(result.map((n) => n.id)).toEqual(['a.md']);
+  });
+
+  it('matches titles', () => {
+    const result = search(notes, { query: '\\d+\\.\\d+', regex: true });
+    expect(result.map((n) => n.id)).toEqual(['c.md']);
+  });
+
+  it('matches tags, as plain queries do', () => {
+    expect(search(notes, { query: '^project-\\w$', regex: true }).map((n) => n.id)).toEqual(['d.md']);
+  });
+
+  it('is case-insensitive', () => {
+    expect(search(notes, { query: '^todo', regex: true })).toHaveLength(1);
+  });
+
+  it('treats regex syntax literally without the flag', () => {
+    expect(search(notes, { query: '^TODO:' })).toHaveLength(0);
+  });
+
+  it('throws for invalid patterns', () => {
+    expect(() => search(notes, { query: '(unclosed', regex: true })).toThrow(SyntaxError);
+    expect(() => compileSearchPattern('[')).toThrow(/Invalid regular expression/);
+  });
+});
+
+describe('search with boolean queries', () => {
+  const notes = [
+    makeNote({ id: 'a.md', title: 'Ingress', content: '# Ingress\n\nkubernetes ingress setup', relativePath: 'a.md' }),
+    makeNote({ id: 'b.md', title: 'Old Gateway', content: '# Old Gateway\n\nkubernetes gateway', tags: ['deprecated'], relativePath: 'b.md' }),
+    makeNote({ id: 'c.md', title: 'Nomad', content: '# Nomad\n\nnomad ingress', relativePath: 'c.md' }),
+  ];
+
+  it('evaluates operators against title, content, and tags', () => {
+    const result = search(notes, {
+      query: 'kubernetes AND (ingress OR gateway) NOT deprecated',
+      boolean: true,
+    });
+    expect(result.map((n) => n.id)).toEqual(['a.md']);
+  });
+
+  it('matches terms case-insensitively', () => {
+    expect(search(notes, { query: 'NOMAD OR Gateway', boolean: true })).toHaveLength(2);
+  });
+
+  it('throws for malformed queries', () => {
+    expect(() => search(notes, { query: '(ingress', boolean: true })).toThrow('Invalid query');
+  });
+});
+
+describe('search with case and word matching', () => {
+  const notes = [
+    makeNote({ id: 'a.md', title: 'Cat care', content: '# Cat care\n\nfeeding the cat', relativePath: 'a.md' }),
+    makeNote({ id: 'b.md', title: 'Taxonomy', content: '# Taxonomy\n\none category per note', relativePath: 'b.md' }),
+    makeNote({ id: 'c.md', title: 'Languages', content: '# Languages\n\nnotes on C++ and Rust', relativePath: 'c.md' }),
+  ];
+
+  it('matches partial words in any case by default', () => {
+    expect(search(notes, { query: 'CAT' }).map((n) => n.id)).toEqual(['a.md', 'b.md']);
+  });
+
+  it('matches the exact case with caseSensitive', () => {
+    expect(search(notes, { query: 'Cat', caseSensitive: true }).map((n) => n.id)).toEqual(['a.md']);
+    expect(search(notes, { query: 'CAT', caseSensitive: true })).toHaveLength(0);
+  });
+
+  it('excludes "category" when searching "cat" as a whole word', () => {
+    expect(search(notes, { query: 'cat', wholeWord: true }).map((n) => n.id)).toEqual(['a.md']);
+  });
+
+  it('matches whole words that end in punctuation', () => {
+    expect(search(notes, { query: 'c++', wholeWord: true }).map((n) => n.id)).toEqual(['c.md']);
+    expect(search(notes, { query: 'rus', wholeWord: true })).toHaveLength(0);
+  });
+
+  it('applies both to boolean terms', () => {
+    const result = search(notes, { query: 'cat OR rust', boolean: true, wholeWord: true, caseSensitive: true });
+    expect(result.map((n) => n.id)).toEqual(['a.md']);
+  });
+
+  it('applies both to regex queries', () => {
+    expect(search(notes, { query: 'cat(egory)?', regex: true, wholeWord: true })).toHaveLength(2);
+    expect(search(notes, { query: 'ca.', regex: true, wholeWord: true }).map((n) => n.id)).toEqual(['a.md']);
+    expect(search(notes, { query: '^CAT', regex: true, caseSensitive: true })).toHaveLength(0);
+  });
+});
+
+describe('search by relevance', () => {
+  const notes = [
+    makeNote({ id: 'a.md', relativePath: 'a.md', title: 'Groceries', content: '# Groceries\n\ndeploy deploy notes' }),
+    makeNote({ id: 'b.md', relativePath: 'b.md', title: 'Deploy checklist', content: '# Deploy checklist' }),
+    makeNote({ id: 'c.md', relativePath: 'c.md', title: 'Misc', tags: ['deploy'], content: '# Misc' }),
+    makeNote({ id: 'd.md', relativePath: 'd.md', title: 'Other', content: '# Other\n\none deploy' }),
+  ];
+
+  it('ranks a title match above content-only matches', () => {
+    expect(search(notes, { query: 'deploy', sortBy: 'relevance' }).map((n) => n.id)).toEqual([
+      'b.md',
+      'c.md',
+      'a.md',
+      'd.md',
+    ]);
+  });
+
+  it('is the default order when there is a query', () => {
+    expect(search(notes, { query: 'deploy' })[0].id).toBe('b.md');
+    expect(search(notes).map((n) => n.id)).toEqual(['a.md', 'b.md', 'c.md', 'd.md']);
+  });
+
+  it('can be reversed', () => {
+    expect(search(notes, { query: 'deploy', sortBy: 'relevance', reverse: true })[0].id).toBe('d.md');
+  });
+
+  it('scores occurrences, title hits and tag hits', () => {
+    expect(scoreNote(notes[0], { query: 'deploy' })).toBe(2);
+    expect(scoreNote(notes[1], { query: 'deploy' })).toBe(6);
+    expect(scoreNote(notes[2], { query: 'deploy' })).toBe(3);
+    expect(scoreNote(notes[0], { query: 'deploy OR groceries', boolean: true })).toBe(8);
+    expect(scoreNote(notes[0], { query: 'dep.oy', regex: true })).toBe(2);
+    expect(scoreNote(notes[0], {})).toBe(0);
+  });
+});
+
+describe('search with date ranges', () => {
+  const notes = [
+    makeNote({ id: 'a.md', created: '2024-01-01T00:00:00.000Z', updated: '2024-01-05T00:00:00.000Z', relativePath: 'a.md' }),
+    makeNote({ id: 'b.md', created: '2024-01-02T00:00:00.000Z', updated: '2024-01-02T00:00:00.000Z', relativePath: 'b.md' }),
+    makeNote({ id: 'c.md', created: '2024-01-03T00:00:00.000Z', updated: '2024-01-03T00:00:00.000Z', relativePath: 'c.md' }),
+  ];
+
+  const ids = (result: Note[]) => result.map((n) => n.id);
+
+  it('includes notes created exactly at the lower bound', () => {
+    expect(ids(search(notes, { createdAfter: '2024-01-02T00:00:00.000Z' }))).toEqual(['b.md', 'c.md']);
+  });
+
+  it('includes notes created exactly at the upper bound', () => {
+    expect(ids(search(notes, { createdBefore: '2024-01-02T00:00:00.000Z' }))).toEqual(['a.md', 'b.md']);
+  });
+
+  it('combines both bounds', () => {
+    const result = search(notes, {
+      createdAfter: '2024-01-02T00:00:00.000Z',
+      createdBefore: '2024-01-02T00:00:00.000Z',
+    });
+    expect(ids(result)).toEqual(['b.md']);
+  });
+
+  it('filters on updated independently of created', () => {
+    expect(ids(search(notes, { updatedAfter: '2024-01-04T00:00:00.000Z' }))).toEqual(['a.md']);
+    expect(ids(search(notes, { updatedBefore: '2024-01-02T00:00:00.000Z' }))).toEqual(['b.md']);
+  });
+});
+
+describe('search with priority bounds', () => {
+  const notes = [
+    makeNote({ id: 'low.md', relativePath: 'low.md', meta: { priority: 0 } }),
+    makeNote({ id: 'mid.md', relativePath: 'mid.md', meta: { priority: 5 } }),
+    makeNote({ id: 'high.md', relativePath: 'high.md', meta: { priority: 10 } }),
+    makeNote({ id: 'none.md', relativePath: 'none.md' }),
+    makeNote({ id: 'text.md', relativePath: 'text.md', meta: { priority: 'high' } }),
+  ];
+  const ids = (options: { minPriority?: number; maxPriority?: number }) =>
+    search(notes, options).map((note) => note.id).sort();
+
+  it('includes both bounds', () => {
+    expect(ids({ minPriority: 5 })).toEqual(['high.md', 'mid.md']);
+    expect(ids({ maxPriority: 5 })).toEqual(['low.md', 'mid.md']);
+    expect(ids({ minPriority: 5, maxPriority: 5 })).toEqual(['mid.md']);
+    expect(ids({ minPriority: 0, maxPriority: 10 })).toEqual(['high.md', 'low.md', 'mid.md']);
+  });
+
+  it('leaves out notes without a numeric priority once a bound is set', () => {
+    expect(ids({})).toHaveLength(5);
+    expect(ids({ minPriority: 0 })).not.toContain('none.md');
+    expect(ids({ minPriority: 6, maxPriority: 9 })).toEqual([]);
+  });
+});
+
+describe('search with archived notes', () => {
+  const notes = [
+    makeNote({ id: 'a.md', relativePath: 'a.md' }),
+    makeNote({ id: 'b.md', relativePath: 'b.md', archivedAt: '2024-02-01T00:00:00.000Z' }),
+  ];
+
+  const ids = (result: Note[]) => result.map((n) => n.id);
+
+  it('leaves archived notes out by default', () => {
+    expect(ids(search(notes))).toEqual(['a.md']);
+  });
+
+  it('includes them with includeArchived', () => {
+    expect(ids(search(notes, { includeArchived: true }))).toEqual(['a.md', 'b.md']);
+  });
+
+  it('keeps only archived notes with onlyArchived', () => {
+    expect(ids(search(notes, { onlyArchived: true }))).toEqual(['b.md']);
+  });
+});
+
+describe('search with pinned notes', () => {
+  const notes = [
+    makeNote({
+      id: 'a.md',
+      relativePath: 'a.md',
+      title: 'Alpha',
+      updated: '2024-01-03T00:00:00.000Z',
+      due: '2024-03-01',
+    }),
+    makeNote({
+      id: 'b.md',
+      relativePath: 'b.md',
+      title: 'Beta',
+      updated: '2024-01-01T00:00:00.000Z',
+      due: '2024-04-01',
+      content: 'plan',
+      pinned: true,
+    }),
+    makeNote({
+      id: 'c.md',
+      relativePath: 'c.md',
+      title: 'Gamma',
+      updated: '2024-01-02T00:00:00.000Z',
+      content: 'plan plan',
+    }),
+    makeNote({ id: 'd.md', relativePath: 'd.md', title: 'Delta', updated: '2024-01-04T00:00:00.000Z', pinned: true }),
+  ];
+
+  const ids = (result: Note[]) => result.map((n) => n.id);
+
+  // Only b and c mention "plan", so relevance sorts just those two.
+  const cases: [SortField, string[], string[]][] = [
+    ['created', ['b.md', 'd.md', 'a.md', 'c.md'], ['d.md', 'b.md', 'c.md', 'a.md']],
+    ['updated', ['b.md', 'd.md', 'c.md', 'a.md'], ['d.md', 'b.md', 'a.md', 'c.md']],
+    ['title', ['b.md', 'd.md', 'a.md', 'c.md'], ['d.md', 'b.md', 'c.md', 'a.md']],
+    ['due', ['b.md', 'd.md', 'a.md', 'c.md'], ['b.md', 'd.md', 'a.md', 'c.md']],
+    ['relevance', ['b.md', 'c.md'], ['b.md', 'c.md']],
+  ];
+
+  for (const [field, forward, reversed] of cases) {
+    it(`sorts pinned notes ahead by ${field}, in either direction`, () => {
+      const options = { sortBy: field, pinnedFirst: true, query: field === 'relevance' ? 'plan' : undefined };
+      expect(ids(search(notes, options))).toEqual(forward);
+      expect(ids(search(notes, { ...options, reverse: true }))).toEqual(reversed);
+    });
+  }
+
+  it('keeps the usual order without pinnedFirst', () => {
+    expect(ids(search(notes, { sortBy: 'title' }))).toEqual(['a.md', 'b.md', 'd.md', 'c.md']);
+  });
+
+  it('keeps only pinned notes with onlyPinned', () => {
+    expect(ids(search(notes, { onlyPinned: true }))).toEqual(['b.md', 'd.md']);
+  });
+});
+
+describe('nested tags', () => {
+  const notes = [
+    makeNote({ id: 'a.md', tags: ['project/alpha'], relativePath: 'a.md' }),
+    makeNote({ id: 'b.md', tags: ['project/beta', 'project/beta/ui'], relativePath: 'b.md' }),
+    makeNote({ id: 'c.md', tags: ['projects'], relativePath: 'c.md' }),
+    makeNote({ id: 'd.md', tags: ['Project'], relativePath: 'd.md' }),
+  ];
+
+  it('matches a tag and its descendants', () => {
+    expect(matchesTag('project/alpha', 'project')).toBe(true);
+    expect(matchesTag('Project/Alpha', 'project/alpha')).toBe(true);
+    expect(matchesTag('projects', 'project')).toBe(false);
+    expect(matchesTag('project', 'project/alpha')).toBe(false);
+  });
+
+  it('filters by parent tag', () => {
+    const result = search(notes, { tags: ['project'] });
+    expect(result.map((n) => n.id)).toEqual(['a.md', 'b.md', 'd.md']);
+  });
+
+  it('rolls counts up to parents once per note', () => {
+    const tags = getAllTags(notes, true);
+    expect(tags.get('project')).toBe(3);
+    expect(tags.get('project/beta')).toBe(1);
+    expect(tags.get('project/beta/ui')).toBe(1);
+  });
+
+  it('does not roll up by default', () => {
+    expect(getAllTags(notes).get('project')).toBe(1);
+  });
+
+  it('builds a tag tree with aggregated counts', () => {
+    const tree = getTagTree(notes);
+    expect(tree.map((node) => [node.tag, node.count])).toEqual([
+      ['project', 3],
+      ['projects', 1],
+    ]);
+    const project = tree[0];
+    expect(project.children.map((node) => [node.name, node.count])).toEqual([
+      ['alpha', 1],
+      ['beta', 1],
+    ]);
+    expect(project.children[1].children.map((node) => node.tag)).toEqual(['project/beta/ui']);
+  });
 });
 
 describe('getAllTags', () => {
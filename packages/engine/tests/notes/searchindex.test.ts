@@ -0,0 +1,101 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import {
+  SearchIndex,
+  getIndexQuery,
+  getNoteTerms,
+  getSearchIndexPath,
+  getTextTerms,
+} from '../../src/notes/searchindex.js';
+import { makeNote } from '../helpers.js';
+
+describe('getTextTerms', () => {
+  it('lists each lowercased three-character run once', () => {
+    expect(getTextTerms('AbcAbc')).toEqual(['abc', 'bca', 'cab']);
+    expect(getTextTerms('ab')).toEqual([]);
+  });
+});
+
+describe('getNoteTerms', () => {
+  it('covers the title, content and tags without runs across them', () => {
+    const terms = getNoteTerms(makeNote({ title: 'Plan', content: 'xyz', tags: ['work'] }));
+    expect(terms.has('pla')).toBe(true);
+    expect(terms.has('xyz')).toBe(true);
+    expect(terms.has('wor')).toBe(true);
+    expect(terms.has('anx')).toBe(false);
+  });
+});
+
+describe('getIndexQuery', () => {
+  it('reads a plain query as one term and parses boolean ones', () => {
+    expect(getIndexQuery({ query: 'a b' })).toEqual({ type: 'term', value: 'a b' });
+    expect(getIndexQuery({ query: 'a OR b', boolean: true })).toMatchObject({ type: 'or' });
+  });
+
+  it('cannot narrow regexes, empty queries or unparsable boolean ones', () => {
+    expect(getIndexQuery({ query: 'a.*b', regex: true })).toBeNull();
+    expect(getIndexQuery({})).toBeNull();
+    expect(getIndexQuery({ query: '(a', boolean: true })).toBeNull();
+  });
+});
+
+describe('SearchIndex', () => {
+  let tempDir: string;
+
+  beforeEach(() => {
+    tempDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-index-'));
+  });
+
+  afterEach(() => {
+    fs.rmSync(tempDir, { recursive: true, force: true });
+  });
+
+  function buildIndex(): SearchIndex {
+    const index = new SearchIndex();
+    index.set('kube.md', 'sig-1', makeNote({ title: 'Kubernetes', content: 'ingress controller' }));
+    index.set('docs/gateway.md', 'sig-2', makeNote({ title: 'Gateway', content: 'deprecated api' }));
+    return index;
+  }
+
+  it('rules out notes missing part of a term', () => {
+    const index = buildIndex();
+    const term = getIndexQuery({ query: 'Ingress' })!;
+    expect(index.mayMatch('kube.md', term)).toBe(true);
+    expect(index.mayMatch('docs/gateway.md', term)).toBe(false);
+    expect(index.mayMatch('unknown.md', term)).toBe(true);
+  });
+
+  it('never rules out short terms or NOT', () => {
+    const index = buildIndex();
+    expect(index.mayMatch('kube.md', getIndexQuery({ query: 'zz' })!)).toBe(true);
+    expect(index.mayMatch('kube.md', getIndexQuery({ query: 'NOT ingress', boolean: true })!)).toBe(true);
+  });
+
+  it('combines AND and OR', () => {
+    const index = buildIndex();
+    const either = getIndexQuery({ query: 'ingress OR deprecated', boolean: true })!;
+    const both = getIndexQuery({ query: 'ingress AND deprecated', boolean: true })!;
+    expect(index.mayMatch('docs/gateway.md', either)).toBe(true);
+    expect(index.mayMatch('docs/gateway.md', both)).toBe(false);
+  });
+
+  it('round-trips through the index file', () => {
+    buildIndex().write(tempDir);
+    expect(fs.existsSync(getSearchIndexPath(tempDir))).toBe(true);
+
+    const read = SearchIndex.read(tempDir)!;
+    expect(read.paths()).toEqual(['kube.md', 'docs/gateway.md']);
+    expect(read.getSignature('docs/gateway.md')).toBe('sig-2');
+    expect(read.mayMatch('docs/gateway.md', getIndexQuery({ query: 'ingress' })!)).toBe(false);
+    expect(read.mayMatch('kube.md', getIndexQuery({ query: 'ingress' })!)).toBe(true);
+  });
+
+  it('reads a missing or other-version file as no index', () => {
+    expect(SearchIndex.read(tempDir)).toBeNull();
+    fs.mkdirSync(path.dirname(getSearchIndexPath(tempDir)), { recursive: true });
+    fs.writeFileSync(getSearchIndexPath(tempDir), JSON.stringify({ version: 99, notes: [], terms: {} }));
+    expect(SearchIndex.read(tempDir)).toBeNull();
+  });
+});
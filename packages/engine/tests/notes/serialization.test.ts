@@ -0,0 +1,61 @@
+import { describe, it, expect } from 'vitest';
+import matter from 'gray-matter';
+import { serializeNoteFrontmatter, serializeNoteJSON, serializeNoteYAML } from '../../src/notes/serialization.js';
+import type { Note } from '../../src/types.js';
+
+const note: Note = {
+  id: 'projects/2024-01-15-demo.md',
+  title: 'Demo',
+  tags: ['work'],
+  commentRev: 2,
+  comments: [
+    {
+      id: 'c1',
+      author: 'agent',
+      created: '2024-01-15T10:00:00.000Z',
+      content: 'Check this',
+      status: 'attached',
+      anchor: { from: 2, to: 6, rev: 2, startAffinity: 'after', endAffinity: 'before', quote: 'Demo' },
+    },
+  ],
+  content: '# Demo\n\nBody text',
+  created: '2024-01-15T09:00:00.000Z',
+  updated: '2024-01-16T09:00:00.000Z',
+  filename: '2024-01-15-demo.md',
+  relativePath: 'projects/2024-01-15-demo.md',
+  directory: 'projects',
+};
+
+describe('serializeNoteJSON', () => {
+  it('serializes the full note including comment anchors', () => {
+    const parsed = JSON.parse(serializeNoteJSON(note));
+    expect(parsed).toEqual(note);
+    expect(parsed.comments[0].anchor.quote).toBe('Demo');
+  });
+});
+
+describe('serializeNoteYAML', () => {
+  it('emits sidecar fields plus content', () => {
+    const data = matter(`---\n${serializeNoteYAML(note)}---\n`).data;
+    expect(data.id).toBe(note.id);
+    expect(data.tags).toEqual(['work']);
+    expect(data.comment_rev).toBe(2);
+    expect(data.comments[0].anchor.start_affinity).toBe('after');
+    expect(data.content).toBe(note.content);
+  });
+});
+
+describe('serializeNoteFrontmatter', () => {
+  it('emits the sidecar fields in a frontmatter block without content', () => {
+    const output = serializeNoteFrontmatter(note);
+    expect(output.startsWith('---\n')).toBe(true);
+    expect(output.endsWith('---\n')).toBe(true);
+
+    const parsed = matter(output);
+    expect(parsed.data.id).toBe(note.id);
+    expect(parsed.data.title).toBe('Demo');
+    expect(parsed.data.comments[0].anchor.quote).toBe('Demo');
+    expect(parsed.data.content).toBeUndefined();
+    expect(parsed.content).toBe('');
+  });
+});
@@ -0,0 +1,75 @@
+import { describe, it, expect } from 'vitest';
+import { extractSnippet, getSearchSnippet } from '../../src/notes/snippets.js';
+import { makeNote } from '../helpers.js';
+
+function highlighted(snippet: { text: string; highlights: { from: number; to: number }[] }) {
+  return snippet.highlights.map((range) => snippet.text.slice(range.from, range.to));
+}
+
+describe('extractSnippet', () => {
+  it('returns the matching line with highlights', () => {
+    const snippet = extractSnippet('# Title\n\nfirst line\n  the Deploy step, then deploy again\n', [/deploy/i])!;
+    expect(snippet.text).toBe('the Deploy step, then deploy again');
+    expect(highlighted(snippet)).toEqual(['Deploy', 'deploy']);
+  });
+
+  it('prefers body matches over the title line', () => {
+    const snippet = extractSnippet('# Deploy Notes\n\nhow to deploy', [/deploy/i])!;
+    expect(snippet.text).toBe('how to deploy');
+  });
+
+  it('falls back to the title line', () => {
+    expect(extractSnippet('# Deploy Notes\n\nbody', [/deploy/i])!.text).toBe('# Deploy Notes');
+  });
+
+  it('caps long lines with ellipses around the match', () => {
+    const line = `${'a'.repeat(100)} needle ${'b'.repeat(100)}`;
+    const snippet = extractSnippet(`# T\n\n${line}`, [/needle/i], 40)!;
+    expect(snippet.text.startsWith('…')).toBe(true);
+    expect(snippet.text.endsWith('…')).toBe(true);
+    expect(snippet.text.length).toBe(42);
+    expect(highlighted(snippet)).toEqual(['needle']);
+  });
+
+  it('returns null without a match', () => {
+    expect(extractSnippet('# T\n\nbody', [/missing/i])).toBeNull();
+  });
+});
+
+describe('getSearchSnippet', () => {
+  const note = makeNote({ content: '# Infra\n\nkubernetes ingress (v2)', tags: ['ops/oncall'] });
+
+  it('treats plain queries literally', () => {
+    const snippet = getSearchSnippet(note, { query: '(v2)' });
+    expect(snippet).toEqual({ kind: 'content', text: 'kubernetes ingress (v2)', highlights: [{ from: 19, to: 23 }] });
+  });
+
+  it('highlights every positive boolean term', () => {
+    const snippet = getSearchSnippet(note, { query: 'kubernetes AND ingress NOT helm', boolean: true });
+    expect(snippet?.kind).toBe('content');
+    if (snippet?.kind === 'content') {
+      expect(highlighted(snippet)).toEqual(['kubernetes', 'ingress']);
+    }
+  });
+
+  it('highlights only whole-word matches with wholeWord', () => {
+    const snippet = getSearchSnippet(
+      makeNote({ content: '# Pets\n\na category for each cat' }),
+      { query: 'cat', wholeWord: true },
+    );
+    expect(snippet?.kind === 'content' && highlighted(snippet)).toEqual(['cat']);
+  });
+
+  it('uses regex patterns in regex mode', () => {
+    const snippet = getSearchSnippet(note, { query: 'v\\d', regex: true });
+    expect(snippet?.kind === 'content' && highlighted(snippet)).toEqual(['v2']);
+  });
+
+  it('reports the tag for tag-only matches', () => {
+    expect(getSearchSnippet(note, { query: 'oncall' })).toEqual({ kind: 'tag', tag: 'ops/oncall' });
+  });
+
+  it('returns null without a query', () => {
+    expect(getSearchSnippet(note, {})).toBeNull();
+  });
+});
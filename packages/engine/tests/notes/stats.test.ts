@@ -0,0 +1,67 @@
+import { describe, it, expect } from 'vitest';
+import { computeStats } from '../../src/notes/stats.js';
+import type { NoteComment } from '../../src/types.js';
+import { makeNote } from '../helpers.js';
+
+function makeComment(id: string): NoteComment {
+  return {
+    id,
+    author: 'tester',
+    created: '2024-01-01T00:00:00.000Z',
+    content: 'comment',
+    status: 'attached',
+    anchor: { from: 0, to: 0, rev: 0 },
+  };
+}
+
+describe('computeStats', () => {
+  it('returns zeroed stats for no notes', () => {
+    expect(computeStats([])).toEqual({
+      totalNotes: 0,
+      totalWords: 0,
+      totalComments: 0,
+      untaggedNotes: 0,
+      topTags: [],
+      oldest: null,
+      newest: null,
+      notesPerMonth: [],
+    });
+  });
+
+  it('aggregates words, comments, tags, and months', () => {
+    const withComments = makeNote({
+      id: 'b.md',
+      created: '2024-03-15T00:00:00.000Z',
+      content: 'one two\nthree',
+      tags: ['work'],
+    });
+    withComments.comments = [makeComment('c1'), makeComment('c2')];
+    const notes = [
+      withComments,
+      makeNote({ id: 'a.md', created: '2024-01-02T00:00:00.000Z', content: '# Title  here', tags: ['work', 'ideas'] }),
+      makeNote({ id: 'c.md', created: '2024-03-01T00:00:00.000Z', content: '' }),
+    ];
+
+    const stats = computeStats(notes);
+
+    expect(stats.totalNotes).toBe(3);
+    expect(stats.totalWords).toBe(6);
+    expect(stats.totalComments).toBe(2);
+    expect(stats.untaggedNotes).toBe(1);
+    expect(stats.topTags).toEqual([
+      { tag: 'work', count: 2 },
+      { tag: 'ideas', count: 1 },
+    ]);
+    expect(stats.oldest?.id).toBe('a.md');
+    expect(stats.newest?.id).toBe('b.md');
+    expect(stats.notesPerMonth).toEqual([
+      { month: '2024-01', count: 1 },
+      { month: '2024-03', count: 2 },
+    ]);
+  });
+
+  it('caps the number of top tags', () => {
+    const notes = [makeNote({ id: 'a.md', content: '', tags: ['x', 'y', 'z'] })];
+    expect(computeStats(notes, 2).topTags.map((tc) => tc.tag)).toEqual(['x', 'y']);
+  });
+});
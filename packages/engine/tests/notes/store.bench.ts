@@ -0,0 +1,68 @@
+import { bench, describe, beforeAll, afterAll } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import { NoteStore } from '../../src/notes/store.js';
+import { search } from '../../src/notes/search.js';
+
+const NOTE_COUNT = 1000;
+let tempDir: string;
+let warmStore: NoteStore;
+let indexedDir: string;
+
+beforeAll(async () => {
+  tempDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-bench-'));
+  for (let i = 0; i < NOTE_COUNT; i++) {
+    const body = `# Note ${i}\n\nSome body text for note ${i} with a [[Note ${(i + 1) % NOTE_COUNT}]] link.\n`;
+    fs.writeFileSync(path.join(tempDir, `2024-01-01-note-${i}.md`), body, 'utf-8');
+  }
+
+  warmStore = new NoteStore({ notesDirectory: tempDir });
+
+  indexedDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-bench-index-'));
+  for (let i = 0; i < NOTE_COUNT; i++) {
+    const body = `# Note ${i}\n\nSome body text for note ${i} about topic-${i % 97}.\n`;
+    fs.writeFileSync(path.join(indexedDir, `2024-01-01-note-${i}.md`), body, 'utf-8');
+  }
+  // Also writes the sidecars, so neither benchmark pays for backfilling them.
+  await new NoteStore({ notesDirectory: indexedDir }).rebuildSearchIndex();
+});
+
+afterAll(() => {
+  fs.rmSync(tempDir, { recursive: true, force: true });
+  fs.rmSync(indexedDir, { recursive: true, force: true });
+});
+
+describe(`listNotes with ${NOTE_COUNT} notes`, () => {
+  bench('cold (new store each call)', async () => {
+    await new NoteStore({ notesDirectory: tempDir }).listNotes();
+  });
+
+  bench('warm (cached parses)', async () => {
+    await warmStore.listNotes();
+  });
+});
+
+describe(`getBacklinks with ${NOTE_COUNT} notes`, () => {
+  bench('cold (new store each call)', async () => {
+    await new NoteStore({ notesDirectory: tempDir }).getBacklinks('2024-01-01-note-0.md');
+  });
+
+  bench('warm (cached parses)', async () => {
+    await warmStore.getBacklinks('2024-01-01-note-0.md');
+  });
+});
+
+describe(`search for a rare term with ${NOTE_COUNT} notes`, () => {
+  // Cold stores, as each CLI run is; the query matches about 1% of notes.
+  const options = { query: 'topic-42.' };
+
+  bench('linear scan (listNotes)', async () => {
+    const { notes } = await new NoteStore({ notesDirectory: indexedDir }).listNotes();
+    search(notes, options);
+  });
+
+  bench('search index (listSearchCandidates)', async () => {
+    search(await new NoteStore({ notesDirectory: indexedDir }).listSearchCandidates(options), options);
+  });
+});
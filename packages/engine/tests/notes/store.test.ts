@@ -3,6 +3,12 @@ import fs from 'node:fs';
 import path from 'node:path';
 import os from 'node:os';
 import { NoteStore } from '../../src/notes/store.js';
+import { buildAnchorFromRange } from '../../src/comments/anchoring.js';
+import { acquireLock } from '../../src/storage/lock.js';
+import type { HistoryReader } from '../../src/storage/git.js';
+import { NOTE_SCHEMA_VERSION } from '../../src/storage/sidecar.js';
+import type { NoteRevision } from '../../src/types.js';
+import { deleteLineInContent, insertLineInContent } from '../../src/utils/lines.js';
 
 let tempDir: string;
 let store: NoteStore;
@@ -31,6 +37,72 @@ describe('NoteStore', () => {
       expect(result.note!.content).toContain('# My Test Note');
     });
 
+    it('creates a note with content, tags, fields and comments in one write', async () => {
+      const result = await store.createNote({
+        title: 'Spec',
+        directory: '',
+        content: 'Ship the parser.\n',
+        tags: ['work'],
+        meta: { priority: 3 },
+        comments: [
+          { content: 'Which parser?', author: 'agent', exact: 'parser' },
+          { content: 'Heading', author: 'agent', from: 2, to: 6 },
+        ],
+      });
+      expect(result.success).toBe(true);
+      expect(result.note!.content).toBe('# Spec\n\nShip the parser.');
+      expect(result.note!.tags).toEqual(['work']);
+      expect(result.note!.meta).toEqual({ priority: 3 });
+      expect(result.note!.commentRev).toBe(1);
+      expect(result.note!.comments.map((comment) => comment.anchor.quote)).toEqual(['parser', 'Spec']);
+    });
+
+    it('reuses a note with exactly the same title in the same directory', async () => {
+      const first = await store.createNote({ title: '2024-03-01', directory: '', reuseExisting: true });
+      expect(first.existing).toBeUndefined();
+
+      const again = await Promise.all([
+        store.createNote({ title: '2024-03-01', directory: '', reuseExisting: true }),
+        store.createNote({ title: '2024-03-01', directory: '', reuseExisting: true }),
+      ]);
+      expect(again.map((result) => [result.existing, result.note!.id])).toEqual([
+        [true, first.note!.id],
+        [true, first.note!.id],
+      ]);
+
+      const elsewhere = await store.createNote({ title: '2024-03-01', directory: 'journal', reuseExisting: true });
+      expect(elsewhere.existing).toBeUndefined();
+      expect((await store.createNote({ title: '2024-03-01 notes', directory: '', reuseExisting: true })).existing)
+        .toBeUndefined();
+      expect((await store.listNotes()).notes).toHaveLength(3);
+    });
+
+    it('rejects a priority outside 0-10', async () => {
+      for (const priority of [-1, 11, 99, 2.5, 'high']) {
+        const result = await store.createNote({ title: 'Urgent', directory: '', meta: { priority } });
+        expect(result.error).toBe('Priority must be an integer from 0 to 10');
+      }
+      expect((await store.listNotes()).notes).toEqual([]);
+      expect((await store.createNote({ title: 'Urgent', directory: '', meta: { priority: 10 } })).success).toBe(true);
+    });
+
+    it('keeps content that already opens with the title heading', async () => {
+      const result = await store.createNote({ title: 'Spec', directory: '', content: '# Spec\n\nbody' });
+      expect(result.note!.content).toBe('# Spec\n\nbody');
+    });
+
+    it('rejects a comment whose text is not in the content without creating the note', async () => {
+      const result = await store.createNote({
+        title: 'Spec',
+        directory: '',
+        content: 'body',
+        comments: [{ content: 'x', author: '', exact: 'missing' }],
+      });
+      expect(result.success).toBe(false);
+      expect(result.error).toContain('not found exactly once');
+      expect((await store.listNotes()).notes).toEqual([]);
+    });
+
     it('creates sidecar json file', async () => {
       const result = await store.createNote({ title: 'Sidecar Test', directory: '' });
       expect(result.success).toBe(true);
@@ -39,12 +111,79 @@ describe('NoteStore', () => {
       expect(fs.existsSync(jsonPath)).toBe(true);
     });
 
+    it('records created and updated timestamps in the sidecar', async () => {
+      const result = await store.createNote({ title: 'Stamped', directory: '' });
+      const sidecar = JSON.parse(
+        fs.readFileSync(path.join(tempDir, result.note!.filename.replace(/\.md$/, '.json')), 'utf-8'),
+      );
+      expect(sidecar.created).toBe(result.note!.created);
+      expect(sidecar.updated).toBe(result.note!.updated);
+      expect(Number.isNaN(Date.parse(result.note!.created))).toBe(false);
+    });
+
+    it('names files for non-ASCII titles', async () => {
+      const accented = await store.createNote({ title: 'Café résumé', directory: '' });
+      expect(accented.note!.filename).toMatch(/^\d{4}-\d{2}-\d{2}-cafe-resume\.md$/);
+
+      const cjk = await store.createNote({ title: '会議メモ', directory: '' });
+      expect(cjk.note!.filename).toMatch(/^\d{4}-\d{2}-\d{2}-note-[0-9a-f]{8}\.md$/);
+    });
+
     it('rejects empty title', async () => {
       const result = await store.createNote({ title: '', directory: '' });
       expect(result.success).toBe(false);
       expect(result.error).toContain('Title cannot be empty');
     });
 
+    it('rejects whitespace-only title without writing a file', async () => {
+      const result = await store.createNote({ title: '  \t ', directory: '' });
+      expect(result.success).toBe(false);
+      expect(result.error).toContain('Title cannot be empty');
+      expect(fs.readdirSync(tempDir).filter((name) => name.endsWith('.md'))).toEqual([]);
+    });
+
+    it('keeps same-day notes with the same title apart', async () => {
+      const first = await store.createNote({ title: 'Standup', directory: '' });
+      const second = await store.createNote({ title: 'Standup', directory: '' });
+      expect(second.success).toBe(true);
+      expect(second.note!.id).toBe(first.note!.id.replace(/\.md$/, '-2.md'));
+
+      await store.updateNote({ noteId: second.note!.id, content: '# Standup\n\nsecond' });
+      expect((await store.getNote(first.note!.id))!.content).toBe('# Standup');
+      expect((await store.getNote(second.note!.id))!.content).toBe('# Standup\n\nsecond');
+      expect((await store.listNotes()).notes).toHaveLength(2);
+    });
+
+    it('seeds content from a template', async () => {
+      const templatesDir = path.join(tempDir, '.agentnotes', 'templates');
+      fs.mkdirSync(templatesDir, { recursive: true });
+      fs.writeFileSync(path.join(templatesDir, 'meeting.md'), '# {{title}}\n\nID: {{id}}\n', 'utf-8');
+
+      const result = await store.createNote({ title: 'Standup', directory: '', template: 'meeting' });
+
+      expect(result.success).toBe(true);
+      expect(result.note!.title).toBe('Standup');
+      expect(result.note!.content).toBe(`# Standup\n\nID: ${result.note!.id}`);
+    });
+
+    it('writes templates with CRLF line endings as LF', async () => {
+      const templatesDir = path.join(tempDir, '.agentnotes', 'templates');
+      fs.mkdirSync(templatesDir, { recursive: true });
+      fs.writeFileSync(path.join(templatesDir, 'dos.md'), '# {{title}}\r\n\r\n- item\r\n', 'utf-8');
+
+      const result = await store.createNote({ title: 'Dos', directory: '', template: 'dos' });
+
+      expect(result.note!.content).toBe('# Dos\n\n- item');
+      expect(fs.readFileSync(path.join(tempDir, result.note!.id), 'utf-8')).not.toContain('\r');
+    });
+
+    it('rejects unknown templates', async () => {
+      const result = await store.createNote({ title: 'Nope', directory: '', template: 'missing' });
+      expect(result.success).toBe(false);
+      expect(result.error).toBe('Template not found: missing');
+      expect((await store.listNotes()).notes).toHaveLength(0);
+    });
+
     it('creates note in subdirectory', async () => {
       const result = await store.createNote({ title: 'Sub Note', directory: 'projects' });
       expect(result.success).toBe(true);
@@ -93,6 +232,61 @@ describe('NoteStore', () => {
   });
 
   describe('updateNote', () => {
+    it('round-trips content that opens with a rule or frontmatter-like block', async () => {
+      const created = await store.createNote({ title: 'Rules', directory: '' });
+      for (const content of ['---\n\n# Rules\n\nbody', '***\n\n# Rules', '---\ntitle: Other\n---\n# Rules']) {
+        const result = await store.updateNote({ noteId: created.note!.id, content });
+        expect(result.note!.content).toBe(content);
+        expect((await store.getNote(result.note!.id))!.content).toBe(content);
+      }
+    });
+
+    it('stores CRLF content with LF line endings', async () => {
+      const created = await store.createNote({ title: 'Pasted', directory: '' });
+      const result = await store.updateNote({
+        noteId: created.note!.id,
+        content: '# Pasted\r\n\r\nline one\r\nline two\r\n',
+      });
+
+      expect(result.note!.content).toBe('# Pasted\n\nline one\nline two');
+      expect(fs.readFileSync(path.join(tempDir, created.note!.id), 'utf-8')).toBe('# Pasted\n\nline one\nline two');
+    });
+
+    it('anchors comments on notes written with CRLF by other tools', async () => {
+      const created = await store.createNote({ title: 'Windows', directory: '' });
+      fs.writeFileSync(path.join(tempDir, created.note!.id), '# Windows\r\n\r\nfirst\r\nsecond\r\n', 'utf-8');
+
+      const note = (await store.getNote(created.note!.id))!;
+      expect(note.content).toBe('# Windows\n\nfirst\nsecond');
+
+      const from = note.content.indexOf('second');
+      const result = await store.addComment({
+        noteId: note.id,
+        content: 'Check',
+        author: '',
+        anchor: buildAnchorFromRange(note.content, from, from + 6, note.commentRev),
+      });
+      expect(result.note!.comments[0].anchor.quote).toBe('second');
+    });
+
+    it('refuses a comment whose range no longer covers the expected quote', async () => {
+      const created = await store.createNote({ title: 'Moving', directory: '', content: 'alpha beta' });
+      const note = created.note!;
+      const from = note.content.indexOf('beta');
+      const anchor = buildAnchorFromRange(note.content, from, from + 4, note.commentRev);
+      await store.updateNote({ noteId: note.id, content: '# Moving\n\nzalpha beta' });
+
+      const result = await store.addComment({
+        noteId: note.id,
+        content: 'Check',
+        author: '',
+        anchor,
+        expectedQuote: 'beta',
+      });
+      expect(result.success).toBe(false);
+      expect(result.error).toBe('Quote mismatch: 16:20 covers " bet", expected "beta"');
+    });
+
     it('updates note content', async () => {
       const created = await store.createNote({ title: 'Update Me', directory: '' });
       const result = await store.updateNote({
@@ -103,6 +297,38 @@ describe('NoteStore', () => {
       expect(result.note!.content).toContain('New content here');
     });
 
+    it('renames the file when the heading title changes', async () => {
+      const created = await store.createNote({ title: 'Draft Title', directory: '' });
+      const oldPath = path.join(tempDir, created.note!.id);
+      const datePrefix = created.note!.filename.slice(0, 10);
+
+      const result = await store.updateNote({
+        noteId: created.note!.id,
+        content: '# Final Title\n\nBody',
+      });
+      expect(result.success).toBe(true);
+      expect(result.note!.filename).toBe(`${datePrefix}-final-title.md`);
+      expect(fs.existsSync(path.join(tempDir, result.note!.id))).toBe(true);
+      expect(fs.existsSync(oldPath)).toBe(false);
+      expect(fs.existsSync(oldPath.replace(/\.md$/, '.json'))).toBe(false);
+    });
+
+    it('does not clobber a note that already has the new slug', async () => {
+      const existing = await store.createNote({ title: 'Taken', directory: '' });
+      const created = await store.createNote({ title: 'Mine', directory: '' });
+
+      const result = await store.updateNote({ noteId: created.note!.id, content: '# Taken\n\nmine' });
+      expect(result.note!.filename).toBe(existing.note!.filename.replace(/\.md$/, '-2.md'));
+      const untouched = await store.getNote(existing.note!.id);
+      expect(untouched!.content).toBe('# Taken');
+    });
+
+    it('keeps the filename when the heading is removed', async () => {
+      const created = await store.createNote({ title: 'Headed', directory: '' });
+      const result = await store.updateNote({ noteId: created.note!.id, content: 'no heading now' });
+      expect(result.note!.id).toBe(created.note!.id);
+    });
+
     it('remaps comments when content changes', async () => {
       const created = await store.createNote({ title: 'Comment Test', directory: '' });
       const noteId = created.note!.id;
@@ -124,6 +350,169 @@ describe('NoteStore', () => {
       expect(result.success).toBe(true);
       expect(result.note!.comments[0].anchor.from).toBe(4);
     });
+
+    describe('line edits', () => {
+      async function createAnchoredNote() {
+        const created = await store.createNote({ title: 'Lines', directory: '' });
+        const noteId = created.note!.id;
+        const content = '# Lines\n\nfirst line\nanchored text\nlast line';
+        await store.updateNote({ noteId, content });
+        const from = content.indexOf('anchored text');
+        await store.addComment({
+          noteId,
+          content: 'on the second line',
+          author: 'test',
+          anchor: buildAnchorFromRange(content, from, from + 'anchored text'.length, 0),
+        });
+        return (await store.getNote(noteId))!;
+      }
+
+      it('shifts an anchor when a line is inserted before it', async () => {
+        const note = await createAnchoredNote();
+        const before = note.comments[0].anchor;
+
+        const result = await store.updateNote({
+          noteId: note.id,
+          content: insertLineInContent(note.content, 3, 'inserted'),
+        });
+
+        const comment = result.note!.comments[0];
+        expect(comment.status).toBe('attached');
+        expect(comment.anchor.from).toBe(before.from + 'inserted\n'.length);
+        expect(result.note!.content.slice(comment.anchor.from, comment.anchor.to)).toBe('anchored text');
+      });
+
+      it('keeps a detached comment when its line is deleted', async () => {
+        const note = await createAnchoredNote();
+
+        const result = await store.updateNote({
+          noteId: note.id,
+          content: deleteLineInContent(note.content, 4),
+        });
+
+        expect(result.note!.comments).toHaveLength(1);
+        expect(result.note!.comments[0].status).toBe('detached');
+      });
+    });
+  });
+
+  describe('setTaskDone', () => {
+    it('checks and unchecks a task line', async () => {
+      const created = await store.createNote({ title: 'Todo', directory: '' });
+      const noteId = created.note!.id;
+      await store.updateNote({ noteId, content: '# Todo\n\n- [ ] first\n- [ ] second' });
+
+      const checked = await store.setTaskDone({ noteId, line: 4, done: true });
+      expect(checked.success).toBe(true);
+      expect(checked.note!.content).toBe('# Todo\n\n- [ ] first\n- [x] second');
+
+      const unchecked = await store.setTaskDone({ noteId, line: 4, done: false });
+      expect(unchecked.note!.content).toBe('# Todo\n\n- [ ] first\n- [ ] second');
+    });
+
+    it('rejects lines without a task', async () => {
+      const created = await store.createNote({ title: 'No Tasks', directory: '' });
+      const result = await store.setTaskDone({ noteId: created.note!.id, line: 1, done: true });
+      expect(result.success).toBe(false);
+      expect(result.error).toBe('No task on line 1');
+    });
+  });
+
+  describe('timestamps', () => {
+    it('bumps updated only when content changes', async () => {
+      const created = await store.createNote({ title: 'Touch Me', directory: '' });
+      const noteId = created.note!.id;
+      const original = created.note!;
+
+      const unchanged = await store.updateNote({ noteId, content: original.content });
+      expect(unchanged.note!.updated).toBe(original.updated);
+
+      await new Promise((resolve) => setTimeout(resolve, 5));
+      const changed = await store.updateNote({ noteId, content: `${original.content}\nmore` });
+      expect(changed.note!.created).toBe(original.created);
+      expect(changed.note!.updated > original.updated).toBe(true);
+    });
+
+    it('falls back to legacy frontmatter timestamps', async () => {
+      fs.writeFileSync(
+        path.join(tempDir, 'legacy.md'),
+        '---\ncreated: 2023-05-01T10:00:00Z\nupdated: 2023-05-02T10:00:00Z\n---\n# Legacy',
+      );
+      const note = await store.getNote('legacy.md');
+      expect(note!.created).toBe('2023-05-01T10:00:00.000Z');
+      expect(note!.updated).toBe('2023-05-02T10:00:00.000Z');
+    });
+  });
+
+  describe('renameNote', () => {
+    it('retitles the note and moves it to the new slug', async () => {
+      const created = await store.createNote({ title: 'Old Name', directory: 'docs' });
+      await store.updateNoteMetadata({ noteId: created.note!.id, tags: ['keep'] });
+      const oldPath = path.join(tempDir, created.note!.id);
+
+      const result = await store.renameNote({ noteId: created.note!.id, title: 'New Name' });
+      expect(result.success).toBe(true);
+      expect(result.note!.title).toBe('New Name');
+      expect(result.note!.filename).toBe(created.note!.filename.replace('old-name', 'new-name'));
+      expect(result.note!.directory).toBe('docs');
+      expect(result.note!.tags).toEqual(['keep']);
+      expect(result.note!.created).toBe(created.note!.created);
+      expect(fs.existsSync(oldPath)).toBe(false);
+      expect(fs.existsSync(oldPath.replace(/\.md$/, '.json'))).toBe(false);
+      expect(fs.existsSync(path.join(tempDir, result.note!.id))).toBe(true);
+    });
+
+    it('drops a number that ended the old title from the filename', async () => {
+      const created = await store.createNote({ title: 'Meeting 2024', directory: '' });
+      expect(created.note!.filename).toMatch(/-meeting-2024\.md$/);
+
+      const renamed = await store.renameNote({ noteId: created.note!.id, title: 'Meeting' });
+      expect(renamed.note!.filename).toMatch(/\d-meeting\.md$/);
+
+      const edited = await store.createNote({ title: 'Sprint 3', directory: '' });
+      const updated = await store.updateNote({ noteId: edited.note!.id, content: '# Sprint\n\nbody' });
+      expect(updated.note!.filename).toMatch(/\d-sprint\.md$/);
+    });
+
+    it('keeps the filename when only the title casing changes', async () => {
+      const created = await store.createNote({ title: 'Case Test', directory: '' });
+      const result = await store.renameNote({ noteId: created.note!.id, title: 'case test' });
+      expect(result.success).toBe(true);
+      expect(result.note!.id).toBe(created.note!.id);
+      expect(result.note!.title).toBe('case test');
+    });
+
+    it('remaps comments through the title change', async () => {
+      const created = await store.createNote({ title: 'Anchor', directory: '' });
+      await store.updateNote({ noteId: created.note!.id, content: '# Anchor\n\nbody text' });
+      await store.addComment({
+        noteId: created.note!.id,
+        content: 'on body',
+        author: 'test',
+        anchor: { from: 10, to: 14, rev: 0 },
+      });
+
+      const result = await store.renameNote({ noteId: created.note!.id, title: 'Longer Anchor' });
+      const comment = result.note!.comments[0];
+      expect(result.note!.content.slice(comment.anchor.from, comment.anchor.to)).toBe('body');
+      expect(comment.status).toBe('attached');
+    });
+
+    it('refuses to overwrite an existing note', async () => {
+      await store.createNote({ title: 'Taken', directory: '' });
+      const other = await store.createNote({ title: 'Other', directory: '' });
+      const result = await store.renameNote({ noteId: other.note!.id, title: 'Taken' });
+      expect(result.success).toBe(false);
+      expect(result.error).toContain('already exists');
+      expect(await store.getNote(other.note!.id)).not.toBeNull();
+    });
+
+    it('rejects empty titles', async () => {
+      const created = await store.createNote({ title: 'Keep', directory: '' });
+      const result = await store.renameNote({ noteId: created.note!.id, title: '   ' });
+      expect(result.success).toBe(false);
+      expect(result.error).toContain('Title cannot be empty');
+    });
   });
 
   describe('updateNoteMetadata', () => {
@@ -136,98 +525,1481 @@ describe('NoteStore', () => {
       expect(result.success).toBe(true);
       expect(result.note!.tags).toEqual(['important', 'test']);
     });
-  });
 
-  describe('deleteNote', () => {
-    it('deletes a note and its sidecar', async () => {
-      const created = await store.createNote({ title: 'Delete Me', directory: '' });
+    it('sets and unsets custom fields with their types', async () => {
+      const created = await store.createNote({ title: 'Custom', directory: '' });
       const noteId = created.note!.id;
-      const result = await store.deleteNote({ noteId });
+
+      await store.updateNoteMetadata({ noteId, meta: { status: 'open', points: 3, blocked: false } });
+      const result = await store.updateNoteMetadata({ noteId, meta: { blocked: null } });
+
       expect(result.success).toBe(true);
+      expect(result.note!.meta).toEqual({ status: 'open', points: 3 });
+      expect((await store.getNote(noteId))!.meta).toEqual({ status: 'open', points: 3 });
+    });
+
+    it('keeps custom fields through tag, content, and comment changes', async () => {
+      const created = await store.createNote({ title: 'Sticky', directory: '' });
+      const noteId = created.note!.id;
+      await store.updateNoteMetadata({ noteId, meta: { url: 'https://example.com' } });
+
+      await store.updateNoteMetadata({ noteId, tags: ['kept'] });
+      await store.updateNote({ noteId, content: '# Sticky\n\nbody' });
+      await store.addComment({ noteId, content: 'c', author: 'a', anchor: { from: 2, to: 8, rev: 1 } });
 
       const note = await store.getNote(noteId);
-      expect(note).toBeNull();
+      expect(note!.tags).toEqual(['kept']);
+      expect(note!.meta).toEqual({ url: 'https://example.com' });
     });
 
-    it('returns error for nonexistent note', async () => {
-      const result = await store.deleteNote({ noteId: 'nope.md' });
+    it('sets and clears the due date', async () => {
+      const created = await store.createNote({ title: 'Deadline', directory: '' });
+      const noteId = created.note!.id;
+
+      const set = await store.updateNoteMetadata({ noteId, due: '2024-06-01' });
+      expect(set.note!.due).toBe('2024-06-01');
+
+      await store.updateNoteMetadata({ noteId, tags: ['kept'] });
+      expect((await store.getNote(noteId))!.due).toBe('2024-06-01');
+
+      const cleared = await store.updateNoteMetadata({ noteId, due: null });
+      expect(cleared.note!.due).toBeUndefined();
+    });
+
+    it('rejects invalid due dates', async () => {
+      const created = await store.createNote({ title: 'Bad Due', directory: '' });
+      const result = await store.updateNoteMetadata({ noteId: created.note!.id, due: 'soon' });
       expect(result.success).toBe(false);
+      expect(result.error).toBe('Invalid due date: soon (use YYYY-MM-DD)');
+    });
+
+    it('rejects invalid field names', async () => {
+      const created = await store.createNote({ title: 'Bad Key', directory: '' });
+      const result = await store.updateNoteMetadata({ noteId: created.note!.id, meta: { 'a b': 1 } });
+      expect(result.success).toBe(false);
+      expect(result.error).toBe('Invalid metadata key: a b');
+    });
+
+    it('rejects an out-of-range priority but allows clearing it', async () => {
+      const created = await store.createNote({ title: 'Ranked', directory: '', meta: { priority: 3 } });
+      const noteId = created.note!.id;
+      const result = await store.updateNoteMetadata({ noteId, meta: { priority: 11 } });
+      expect(result.error).toBe('Priority must be an integer from 0 to 10');
+      expect((await store.getNote(noteId))!.meta).toEqual({ priority: 3 });
+
+      const cleared = await store.updateNoteMetadata({ noteId, meta: { priority: null } });
+      expect(cleared.note!.meta).toBeUndefined();
+    });
+
+    it('migrates unknown legacy frontmatter keys into custom fields', async () => {
+      fs.writeFileSync(
+        path.join(tempDir, 'legacy.md'),
+        '---\ntags: [old]\nstatus: draft\npoints: 5\n---\n# Legacy',
+      );
+      const note = await store.getNote('legacy.md');
+      expect(note!.tags).toEqual(['old']);
+      expect(note!.meta).toEqual({ status: 'draft', points: 5 });
+      expect(fs.readFileSync(path.join(tempDir, 'legacy.md'), 'utf-8')).toBe('# Legacy');
     });
   });
 
-  describe('moveNote', () => {
-    it('moves a note to a new directory', async () => {
-      const created = await store.createNote({ title: 'Move Me', directory: '' });
-      const result = await store.moveNote({
-        noteId: created.note!.id,
-        directory: 'archive',
+  describe('retagNotes', () => {
+    it('adds and removes tags across notes, skipping unchanged ones', async () => {
+      const a = await store.createNote({ title: 'A', directory: '' });
+      const b = await store.createNote({ title: 'B', directory: '' });
+      await store.updateNoteMetadata({ noteId: a.note!.id, tags: ['old', 'keep'] });
+      await store.updateNoteMetadata({ noteId: b.note!.id, tags: ['New'] });
+      const bUpdated = (await store.getNote(b.note!.id))!.updated;
+
+      const result = await store.retagNotes({
+        noteIds: [a.note!.id, b.note!.id],
+        add: ['new'],
+        remove: ['OLD'],
       });
-      expect(result.success).toBe(true);
-      expect(result.note!.directory).toBe('archive');
+
+      expect(result).toEqual({ success: true, changed: [a.note!.id] });
+      expect((await store.getNote(a.note!.id))!.tags).toEqual(['keep', 'new']);
+      const untouched = await store.getNote(b.note!.id);
+      expect(untouched!.tags).toEqual(['New']);
+      expect(untouched!.updated).toBe(bUpdated);
+    });
+
+    it('reports changes without writing on a dry run', async () => {
+      const created = await store.createNote({ title: 'Dry', directory: '' });
+      const result = await store.retagNotes({ noteIds: [created.note!.id], add: ['x'], dryRun: true });
+      expect(result.changed).toEqual([created.note!.id]);
+      expect((await store.getNote(created.note!.id))!.tags).toEqual([]);
+    });
+
+    it('fails without writing when a note is missing', async () => {
+      const created = await store.createNote({ title: 'Real', directory: '' });
+      const result = await store.retagNotes({ noteIds: [created.note!.id, 'missing.md'], add: ['x'] });
+      expect(result.success).toBe(false);
+      expect(result.error).toBe('Note not found: missing.md');
+      expect((await store.getNote(created.note!.id))!.tags).toEqual([]);
     });
   });
 
-  describe('addComment', () => {
-    it('adds a comment to a note', async () => {
-      const created = await store.createNote({ title: 'Comment Here', directory: '' });
-      const result = await store.addComment({
-        noteId: created.note!.id,
-        content: 'Nice section!',
-        author: 'tester',
-        anchor: { from: 2, to: 14, rev: 0, startAffinity: 'after', endAffinity: 'before' },
-      });
-      expect(result.success).toBe(true);
-      expect(result.note!.comments).toHaveLength(1);
-      expect(result.note!.comments[0].content).toBe('Nice section!');
+  describe('attachFile', () => {
+    let sourceDir: string;
+
+    beforeEach(() => {
+      sourceDir = createTempDir();
+      fs.writeFileSync(path.join(sourceDir, 'diagram.png'), 'png bytes');
     });
 
-    it('rejects anchor revision mismatch', async () => {
-      const created = await store.createNote({ title: 'Rev Mismatch', directory: '' });
-      const result = await store.addComment({
-        noteId: created.note!.id,
-        content: 'comment',
-        author: 'test',
-        anchor: { from: 0, to: 5, rev: 99 },
-      });
+    afterEach(() => {
+      fs.rmSync(sourceDir, { recursive: true, force: true });
+    });
+
+    it('copies the file in and records it, suffixing taken names', async () => {
+      const created = await store.createNote({ title: 'Design', directory: '' });
+      const noteId = created.note!.id;
+
+      const first = await store.attachFile({ noteId, filePath: path.join(sourceDir, 'diagram.png') });
+      const second = await store.attachFile({ noteId, filePath: path.join(sourceDir, 'diagram.png') });
+
+      expect(first.attachment).toBe('diagram.png');
+      expect(second.attachment).toBe('diagram-2.png');
+      expect(second.note!.attachments).toEqual(['diagram.png', 'diagram-2.png']);
+      const dir = store.getAttachmentsDirectory(noteId);
+      expect(dir).toBe(path.join(tempDir, '.agentnotes', 'attachments', noteId.replace(/\.md$/, '')));
+      expect(fs.readFileSync(path.join(dir, 'diagram-2.png'), 'utf-8')).toBe('png bytes');
+    });
+
+    it('rejects a missing source file', async () => {
+      const created = await store.createNote({ title: 'Design', directory: '' });
+      const result = await store.attachFile({ noteId: created.note!.id, filePath: path.join(sourceDir, 'nope') });
       expect(result.success).toBe(false);
-      expect(result.error).toContain('revision mismatch');
+      expect(result.error).toContain('File not found');
+    });
+
+    it('moves attachments with the note and removes them on purge', async () => {
+      const created = await store.createNote({ title: 'Design', directory: '' });
+      await store.attachFile({ noteId: created.note!.id, filePath: path.join(sourceDir, 'diagram.png') });
+
+      const renamed = await store.renameNote({ noteId: created.note!.id, title: 'Architecture' });
+      const renamedId = renamed.note!.id;
+      expect(renamed.note!.attachments).toEqual(['diagram.png']);
+      expect(fs.existsSync(path.join(store.getAttachmentsDirectory(renamedId), 'diagram.png'))).toBe(true);
+      expect(fs.existsSync(store.getAttachmentsDirectory(created.note!.id))).toBe(false);
+
+      await store.deleteNote({ noteId: renamedId });
+      await store.restoreNote({ noteId: renamedId });
+      expect(fs.existsSync(path.join(store.getAttachmentsDirectory(renamedId), 'diagram.png'))).toBe(true);
+
+      await store.deleteNote({ noteId: renamedId, purge: true });
+      expect(fs.existsSync(path.join(tempDir, '.agentnotes', 'attachments'))).toBe(false);
     });
   });
 
-  describe('deleteComment', () => {
-    it('deletes a comment by ID', async () => {
-      const created = await store.createNote({ title: 'Del Comment', directory: '' });
-      const addResult = await store.addComment({
-        noteId: created.note!.id,
-        content: 'remove me',
-        author: 'test',
-        anchor: { from: 2, to: 13, rev: 0 },
-      });
-      const commentId = addResult.note!.comments[0].id;
+  describe('setNoteArchived', () => {
+    it('archives a note without touching its updated time, and unarchives it', async () => {
+      const created = await store.createNote({ title: 'Done', directory: '' });
+      const noteId = created.note!.id;
 
-      const result = await store.deleteComment({
-        noteId: created.note!.id,
-        commentId,
-      });
-      expect(result.success).toBe(true);
-      expect(result.note!.comments).toHaveLength(0);
+      const archived = await store.setNoteArchived({ noteId, archived: true });
+      expect(archived.success).toBe(true);
+      expect(archived.note!.archivedAt).toBeDefined();
+      expect(archived.note!.updated).toBe(created.note!.updated);
+      expect((await store.listNotes()).notes.map((note) => note.id)).toContain(noteId);
+
+      const again = await store.setNoteArchived({ noteId, archived: true });
+      expect(again.error).toBe('Note is already archived');
+
+      const restored = await store.setNoteArchived({ noteId, archived: false });
+      expect(restored.note!.archivedAt).toBeUndefined();
     });
   });
 
-  describe('createDirectory', () => {
-    it('creates a directory', async () => {
-      const result = await store.createDirectory({ path: 'new-folder' });
-      expect(result.success).toBe(true);
-      expect(fs.existsSync(path.join(tempDir, 'new-folder'))).toBe(true);
+  describe('setNotePinned', () => {
+    it('pins a note without touching its updated time, and unpins it', async () => {
+      const created = await store.createNote({ title: 'Reference', directory: '' });
+      const noteId = created.note!.id;
+
+      const pinned = await store.setNotePinned({ noteId, pinned: true });
+      expect(pinned.success).toBe(true);
+      expect(pinned.note!.pinned).toBe(true);
+      expect(pinned.note!.updated).toBe(created.note!.updated);
+      expect((await store.updateNote({ noteId, content: '# Reference\n\nmore' })).note!.pinned).toBe(true);
+
+      const again = await store.setNotePinned({ noteId, pinned: true });
+      expect(again.error).toBe('Note is already pinned');
+
+      const unpinned = await store.setNotePinned({ noteId, pinned: false });
+      expect(unpinned.note!.pinned).toBeUndefined();
     });
   });
 
-  describe('deleteDirectory', () => {
-    it('deletes a directory', async () => {
-      await store.createDirectory({ path: 'to-delete' });
-      const result = await store.deleteDirectory({ path: 'to-delete' });
-      expect(result.success).toBe(true);
-      expect(fs.existsSync(path.join(tempDir, 'to-delete'))).toBe(false);
+  describe('setNoteEncryption', () => {
+    it('encrypts the body, edits through the passphrase and decrypts again', async () => {
+      const created = await store.createNote({ title: 'Diary', directory: '' });
+      const noteId = created.note!.id;
+      await store.updateNote({ noteId, content: '# Diary\n\nFirst entry' });
+
+      const encrypted = await store.setNoteEncryption({ noteId, passphrase: 'key', encrypted: true });
+      expect(encrypted.success).toBe(true);
+      expect(encrypted.note!.encrypted).toBe(true);
+      expect(encrypted.note!.title).toBe('Diary');
+      expect(fs.readFileSync(path.join(tempDir, noteId), 'utf-8')).not.toContain('First entry');
+
+      const locked = await store.updateNote({ noteId, content: '# Diary\n\nSecond entry' });
+      expect(locked.success).toBe(false);
+      expect(locked.error).toContain('passphrase is required');
+
+      const updated = await store.updateNote({ noteId, content: '# Diary\n\nSecond entry', passphrase: 'key' });
+      expect(updated.note!.encrypted).toBe(true);
+      expect(updated.note!.content).not.toContain('Second entry');
+
+      const wrong = await store.setNoteEncryption({ noteId, passphrase: 'nope', encrypted: false });
+      expect(wrong.success).toBe(false);
+      expect(wrong.error).toContain('Wrong passphrase');
+
+      const decrypted = await store.setNoteEncryption({ noteId, passphrase: 'key', encrypted: false });
+      expect(decrypted.note!.encrypted).toBeUndefined();
+      expect(decrypted.note!.content).toBe('# Diary\n\nSecond entry');
+    });
+
+    it('rejects encrypting an already encrypted note', async () => {
+      const created = await store.createNote({ title: 'Diary', directory: '' });
+      await store.setNoteEncryption({ noteId: created.note!.id, passphrase: 'key', encrypted: true });
+      const again = await store.setNoteEncryption({ noteId: created.note!.id, passphrase: 'key', encrypted: true });
+      expect(again.error).toBe('Note is already encrypted');
+    });
+
+    it('leaves no body text in the sidecar, refusing notes with comments', async () => {
+      const created = await store.createNote({ title: 'Diary', directory: '', content: 'secret plans' });
+      const noteId = created.note!.id;
+      const from = created.note!.content.indexOf('secret plans');
+      const commented = await store.addComment({
+        noteId,
+        content: 'Check',
+        author: '',
+        anchor: buildAnchorFromRange(created.note!.content, from, from + 12, created.note!.commentRev),
+      });
+
+      const refused = await store.setNoteEncryption({ noteId, passphrase: 'key', encrypted: true });
+      expect(refused.success).toBe(false);
+      expect(refused.error).toBe('Note has comments quoting its text; delete them before encrypting');
+
+      await store.deleteComment({ noteId, commentId: commented.note!.comments[0].id });
+      const encrypted = await store.setNoteEncryption({ noteId, passphrase: 'key', encrypted: true });
+      expect(encrypted.success).toBe(true);
+
+      const note = encrypted.note!;
+      const comment = await store.addComment({
+        noteId,
+        content: 'Check',
+        author: '',
+        anchor: buildAnchorFromRange(note.content, 0, 4, note.commentRev),
+      });
+      expect(comment.error).toBe('Cannot comment on an encrypted note; decrypt it first');
+
+      const sidecar = fs.readFileSync(path.join(tempDir, noteId.replace(/\.md$/, '.json')), 'utf-8');
+      expect(sidecar).not.toContain('secret');
+      expect(sidecar).not.toContain('plans');
+    });
+
+    it('creates a note encrypted from its first write', async () => {
+      const created = await store.createNote({
+        title: 'Diary',
+        directory: '',
+        content: 'secret plans',
+        tags: ['private'],
+        due: '2026-11-01',
+        passphrase: 'key',
+      });
+      expect(created.success).toBe(true);
+      expect(created.note!.encrypted).toBe(true);
+      expect(created.note!.tags).toEqual(['private']);
+      expect(created.note!.due).toBe('2026-11-01');
+      expect(fs.readFileSync(path.join(tempDir, created.note!.id), 'utf-8')).not.toContain('secret plans');
+
+      const decrypted = await store.setNoteEncryption({ noteId: created.note!.id, passphrase: 'key', encrypted: false });
+      expect(decrypted.note!.content).toBe('# Diary\n\nsecret plans');
+    });
+
+    it('encrypts in the same write as a retitling edit', async () => {
+      const created = await store.createNote({ title: 'Diary', directory: '' });
+      const updated = await store.updateNote({
+        noteId: created.note!.id,
+        content: '# Journal\n\nsecret plans',
+        passphrase: 'key',
+        encrypt: true,
+      });
+      expect(updated.success).toBe(true);
+      expect(updated.note!.id).not.toBe(created.note!.id);
+      expect(updated.note!.title).toBe('Journal');
+      expect(updated.note!.encrypted).toBe(true);
+      expect(fs.readFileSync(path.join(tempDir, updated.note!.id), 'utf-8')).not.toContain('secret plans');
+
+      const again = await store.updateNote({
+        noteId: updated.note!.id,
+        content: '# Journal\n\nmore',
+        passphrase: 'key',
+        encrypt: true,
+      });
+      expect(again.error).toBe('Note is already encrypted');
+    });
+  });
+
+  describe('renameTag', () => {
+    it('renames a tag case-insensitively and dedups an existing target', async () => {
+      const a = await store.createNote({ title: 'A', directory: '' });
+      const b = await store.createNote({ title: 'B', directory: '' });
+      const c = await store.createNote({ title: 'C', directory: '' });
+      await store.updateNoteMetadata({ noteId: a.note!.id, tags: ['JS', 'web'] });
+      await store.updateNoteMetadata({ noteId: b.note!.id, tags: ['js', 'javascript'] });
+      await store.updateNoteMetadata({ noteId: c.note!.id, tags: ['other'] });
+      const cUpdated = (await store.getNote(c.note!.id))!.updated;
+
+      const result = await store.renameTag({ from: 'js', to: 'javascript' });
+
+      expect(result.success).toBe(true);
+      expect(result.changed.sort()).toEqual([a.note!.id, b.note!.id].sort());
+      expect((await store.getNote(a.note!.id))!.tags).toEqual(['javascript', 'web']);
+      expect((await store.getNote(b.note!.id))!.tags).toEqual(['javascript']);
+      expect((await store.getNote(c.note!.id))!.updated).toBe(cUpdated);
+    });
+
+    it('rejects an empty tag', async () => {
+      const result = await store.renameTag({ from: 'a', to: ' ' });
+      expect(result).toEqual({ success: false, error: 'Tag cannot be empty', changed: [] });
+    });
+  });
+
+  describe('deleteTag', () => {
+    it('strips a tag from every note that has it', async () => {
+      const a = await store.createNote({ title: 'A', directory: '' });
+      const b = await store.createNote({ title: 'B', directory: '' });
+      await store.updateNoteMetadata({ noteId: a.note!.id, tags: ['Draft', 'keep'] });
+      await store.updateNoteMetadata({ noteId: b.note!.id, tags: ['keep'] });
+
+      const dry = await store.deleteTag({ tag: 'draft', dryRun: true });
+      expect(dry.changed).toEqual([a.note!.id]);
+      expect((await store.getNote(a.note!.id))!.tags).toEqual(['Draft', 'keep']);
+
+      const result = await store.deleteTag({ tag: 'draft' });
+      expect(result.changed).toEqual([a.note!.id]);
+      expect((await store.getNote(a.note!.id))!.tags).toEqual(['keep']);
+    });
+  });
+
+  describe('importNotes', () => {
+    let sourceDir: string;
+
+    beforeEach(() => {
+      sourceDir = createTempDir();
+      fs.mkdirSync(path.join(sourceDir, 'work'));
+      fs.writeFileSync(path.join(sourceDir, 'plain notes.md'), 'Just a body');
+      fs.writeFileSync(
+        path.join(sourceDir, 'work', 'meeting.md'),
+        '---\ntitle: Standup\ntags: [team]\ncreated: 2024-02-03T04:05:06.000Z\nowner: sam\n---\nNotes here',
+      );
+    });
+
+    afterEach(() => {
+      fs.rmSync(sourceDir, { recursive: true, force: true });
+    });
+
+    it('imports plain files and frontmatter, keeping folders', async () => {
+      const result = await store.importNotes({ sourceDirectory: sourceDir, tags: ['imported'] });
+      expect(result.success).toBe(true);
+      expect(result.skipped).toEqual([]);
+      expect(result.imported.map((entry) => entry.source).sort()).toEqual(['plain notes.md', 'work/meeting.md']);
+
+      const meeting = await store.getNote('work/2024-02-03-standup.md');
+      expect(meeting!.title).toBe('Standup');
+      expect(meeting!.content).toBe('# Standup\n\nNotes here');
+      expect(meeting!.tags).toEqual(['team', 'imported']);
+      expect(meeting!.created).toBe('2024-02-03T04:05:06.000Z');
+      expect(meeting!.meta).toEqual({ owner: 'sam' });
+
+      const plainId = result.imported.find((entry) => entry.source === 'plain notes.md')!.noteId;
+      const plain = await store.getNote(plainId);
+      expect(plain!.title).toBe('plain notes');
+      expect(plain!.content).toBe('# plain notes\n\nJust a body');
+      expect(fs.readFileSync(path.join(sourceDir, 'work', 'meeting.md'), 'utf-8')).toContain('title: Standup');
+    });
+
+    it('writes nothing on a dry run', async () => {
+      const result = await store.importNotes({ sourceDirectory: sourceDir, dryRun: true });
+      expect(result.imported).toHaveLength(2);
+      expect((await store.listNotes()).notes).toEqual([]);
+    });
+
+    it('skips files whose note filename is taken', async () => {
+      await store.importNotes({ sourceDirectory: sourceDir });
+      const again = await store.importNotes({ sourceDirectory: sourceDir });
+      expect(again.imported).toEqual([]);
+      expect(again.skipped.map((entry) => entry.reason)).toContain('work/2024-02-03-standup.md already exists');
+    });
+
+    it('rejects a missing directory', async () => {
+      const result = await store.importNotes({ sourceDirectory: path.join(sourceDir, 'nope') });
+      expect(result.success).toBe(false);
+      expect(result.error).toContain('Import directory not found');
+    });
+  });
+
+  describe('mergeNotes', () => {
+    async function createWithContent(title: string, content: string): Promise<string> {
+      const created = await store.createNote({ title, directory: '' });
+      await store.updateNote({ noteId: created.note!.id, content });
+      return created.note!.id;
+    }
+
+    it('appends content, moves comments onto the new offsets, and trashes the source', async () => {
+      const targetId = await createWithContent('Target', '# Target\n\nAlpha text');
+      const sourceId = await createWithContent('Source', '# Source\n\nBeta text here');
+      await store.updateNoteMetadata({ noteId: targetId, tags: ['a'] });
+      await store.updateNoteMetadata({ noteId: sourceId, tags: ['b', 'a'] });
+
+      const targetNote = (await store.getNote(targetId))!;
+      const sourceNote = (await store.getNote(sourceId))!;
+      const alphaFrom = targetNote.content.indexOf('Alpha');
+      const betaFrom = sourceNote.content.indexOf('text here');
+      await store.addComment({
+        noteId: targetId,
+        content: 'on alpha',
+        author: 'a',
+        anchor: buildAnchorFromRange(targetNote.content, alphaFrom, alphaFrom + 5, targetNote.commentRev),
+      });
+      await store.addComment({
+        noteId: sourceId,
+        content: 'on text here',
+        author: 'a',
+        anchor: buildAnchorFromRange(sourceNote.content, betaFrom, betaFrom + 9, sourceNote.commentRev),
+      });
+
+      const result = await store.mergeNotes({ sourceId, targetId });
+      expect(result.success).toBe(true);
+
+      const merged = result.note!;
+      expect(merged.content).toBe('# Target\n\nAlpha text\n\n## Merged from Source\n\nBeta text here');
+      expect(merged.tags).toEqual(['a', 'b']);
+      const quoted = merged.comments.map((c) => merged.content.slice(c.anchor.from, c.anchor.to));
+      expect(quoted).toEqual(['Alpha', 'text here']);
+      expect(merged.comments.every((c) => c.status === 'attached')).toBe(true);
+
+      expect(await store.getNote(sourceId)).toBeNull();
+      expect((await store.listTrash()).map((n) => n.title)).toEqual(['Source']);
+    });
+
+    it('keeps the source and copies its comments with --keep-source', async () => {
+      const targetId = await createWithContent('Keep Target', '# Keep Target');
+      const sourceId = await createWithContent('Keep Source', '# Keep Source\n\nbody');
+      const sourceNote = (await store.getNote(sourceId))!;
+      const added = await store.addComment({
+        noteId: sourceId,
+        content: 'c',
+        author: 'a',
+        anchor: buildAnchorFromRange(sourceNote.content, sourceNote.content.indexOf('body'), sourceNote.content.length, sourceNote.commentRev),
+      });
+
+      const result = await store.mergeNotes({ sourceId, targetId, keepSource: true });
+      expect(result.success).toBe(true);
+      expect((await store.getNote(sourceId))!.comments).toHaveLength(1);
+      expect(result.note!.comments).toHaveLength(1);
+      expect(result.note!.comments[0].id).not.toBe(added.note!.comments[0].id);
+    });
+
+    it('moves aliases to the target', async () => {
+      const targetId = await createWithContent('Alias Target', '# Alias Target');
+      const sourceId = await createWithContent('Alias Source', '# Alias Source');
+      await store.addAlias({ noteId: sourceId, alias: 'moved' });
+
+      await store.mergeNotes({ sourceId, targetId });
+      expect((await store.findNote('moved')).note?.id).toBe(targetId);
+    });
+
+    it('refuses to merge a note into itself', async () => {
+      const noteId = await createWithContent('Self', '# Self');
+      const result = await store.mergeNotes({ sourceId: noteId, targetId: noteId });
+      expect(result.error).toBe('Cannot merge a note into itself');
+    });
+  });
+
+  describe('deleteNote', () => {
+    it('deletes a note and its sidecar', async () => {
+      const created = await store.createNote({ title: 'Delete Me', directory: '' });
+      const noteId = created.note!.id;
+      const result = await store.deleteNote({ noteId });
+      expect(result.success).toBe(true);
+
+      const note = await store.getNote(noteId);
+      expect(note).toBeNull();
+    });
+
+    it('returns error for nonexistent note', async () => {
+      const result = await store.deleteNote({ noteId: 'nope.md' });
+      expect(result.success).toBe(false);
+    });
+
+    it('moves the note into the trash', async () => {
+      const created = await store.createNote({ title: 'Trash Me', directory: 'work' });
+      await store.deleteNote({ noteId: created.note!.id });
+
+      const trashPath = path.join(tempDir, '.agentnotes', 'trash', created.note!.id);
+      expect(fs.existsSync(trashPath)).toBe(true);
+      expect(fs.existsSync(trashPath.replace(/\.md$/, '.json'))).toBe(true);
+
+      const listed = await store.listNotes();
+      expect(listed.notes).toHaveLength(0);
+      expect(listed.directories).toEqual([]);
+    });
+
+    it('hard-removes the note with purge', async () => {
+      const created = await store.createNote({ title: 'Purge Me', directory: '' });
+      await store.deleteNote({ noteId: created.note!.id, purge: true });
+
+      expect(await store.listTrash()).toEqual([]);
+      expect(fs.existsSync(path.join(tempDir, created.note!.id))).toBe(false);
+    });
+  });
+
+  describe('commit hook', () => {
+    let messages: string[];
+    let committing: NoteStore;
+
+    beforeEach(() => {
+      messages = [];
+      committing = new NoteStore({
+        notesDirectory: tempDir,
+        commit: (_dir, message) => messages.push(message),
+      });
+    });
+
+    it('commits after each mutation', async () => {
+      const created = await committing.createNote({ title: 'Audited', directory: '' });
+      await committing.updateNote({ noteId: created.note!.id, content: '# Audited\n\nbody' });
+      await committing.addComment({
+        noteId: created.note!.id,
+        content: 'note',
+        author: 'me',
+        anchor: { from: 11, to: 15, rev: 0 },
+      });
+      await committing.deleteNote({ noteId: created.note!.id });
+
+      expect(messages).toEqual([
+        'add note: Audited',
+        'update note: Audited',
+        'add comment: Audited',
+        'delete note: Audited',
+      ]);
+    });
+
+    it('commits a bulk retag once', async () => {
+      const a = await committing.createNote({ title: 'One', directory: '' });
+      const b = await committing.createNote({ title: 'Two', directory: '' });
+      messages.length = 0;
+
+      await committing.retagNotes({ noteIds: [a.note!.id, b.note!.id], add: ['x'] });
+      await committing.retagNotes({ noteIds: [a.note!.id, b.note!.id], add: ['x'] });
+      expect(messages).toEqual(['retag 2 notes']);
+    });
+
+    it('does not commit failed mutations', async () => {
+      await committing.updateNote({ noteId: 'missing.md', content: 'x' });
+      await committing.deleteNote({ noteId: 'missing.md' });
+      expect(messages).toEqual([]);
+    });
+
+    it('keeps the mutation when the commit throws', async () => {
+      const failing = new NoteStore({
+        notesDirectory: tempDir,
+        commit: () => {
+          throw new Error('git exploded');
+        },
+      });
+
+      const result = await failing.createNote({ title: 'Still Saved', directory: '' });
+      expect(result.success).toBe(true);
+      expect(fs.existsSync(path.join(tempDir, result.note!.id))).toBe(true);
+    });
+
+    it('passes the notes root to notebook stores', async () => {
+      const dirs: string[] = [];
+      const rooted = new NoteStore({
+        notesDirectory: tempDir,
+        commit: (dir) => dirs.push(dir),
+      });
+
+      await rooted.withNotebook('work').createNote({ title: 'In Work', directory: '' });
+      expect(dirs).toEqual([tempDir]);
+    });
+  });
+
+  describe('diagnoseNotes', () => {
+    it('reports problems without changing anything, then fixes them', async () => {
+      const created = await store.createNote({ title: 'Plan', directory: '' });
+      const noteId = created.note!.id;
+      fs.writeFileSync(path.join(tempDir, noteId), '# Roadmap\n\nbody');
+      fs.writeFileSync(path.join(tempDir, 'loose.md'), '# Loose');
+
+      const report = await store.diagnoseNotes();
+      expect(report.problems.map((problem) => [problem.kind, problem.fixed])).toEqual([
+        ['filename-mismatch', undefined],
+        ['missing-sidecar', undefined],
+      ]);
+      expect(fs.existsSync(path.join(tempDir, 'loose.json'))).toBe(false);
+
+      const fixed = await store.diagnoseNotes({ fix: true });
+      expect(fixed.problems.every((problem) => problem.fixed)).toBe(true);
+      expect(fs.existsSync(path.join(tempDir, 'loose.json'))).toBe(true);
+      expect(await store.getNote(noteId.replace('plan', 'roadmap'))).not.toBeNull();
+      expect((await store.diagnoseNotes()).problems).toEqual([]);
+    });
+
+    it('finds notes whose IDs differ only in case and gives one a fresh ID', async () => {
+      fs.writeFileSync(path.join(tempDir, 'Plan.md'), '# Plan\n\nupper');
+      fs.writeFileSync(path.join(tempDir, 'plan.md'), '# Plan\n\nlower');
+
+      const check = store.checkNoteIds();
+      expect(check.success).toBe(false);
+      expect(check.error).toBe('Duplicate note IDs: Plan.md and plan.md');
+      const report = await store.diagnoseNotes();
+      expect(report.problems.filter((problem) => problem.kind === 'duplicate-id')).toMatchObject([
+        { noteId: 'plan.md' },
+      ]);
+
+      const reassigned = await store.reassignNoteId('plan.md');
+      expect(reassigned.note?.id).toBe('plan-2.md');
+      expect(reassigned.note?.content).toBe('# Plan\n\nlower');
+      expect(fs.existsSync(path.join(tempDir, 'plan-2.json'))).toBe(true);
+      expect(store.checkNoteIds().success).toBe(true);
+    });
+
+    it('reassigns duplicate IDs when fixing', async () => {
+      fs.writeFileSync(path.join(tempDir, 'Plan.md'), '# Plan');
+      fs.writeFileSync(path.join(tempDir, 'plan.md'), '# Plan');
+      fs.writeFileSync(path.join(tempDir, 'PLAN-2.md'), '# Plan');
+
+      const fixed = await store.diagnoseNotes({ fix: true });
+      expect(fixed.problems.find((problem) => problem.kind === 'duplicate-id')?.fixed).toBe(true);
+      expect(fs.existsSync(path.join(tempDir, 'plan-3.md'))).toBe(true);
+      expect(store.checkNoteIds().success).toBe(true);
+    });
+  });
+
+  describe('normalizeAllComments', () => {
+    it('rewrites legacy anchors in canonical form and reports the notes it changed', async () => {
+      fs.writeFileSync(path.join(tempDir, 'legacy.md'), '# Legacy\n\nalpha beta');
+      fs.writeFileSync(
+        path.join(tempDir, 'legacy.json'),
+        JSON.stringify({
+          tags: [],
+          comments: [
+            { id: 'c1', author: 'a', created: '2024-01-01T00:00:00.000Z', content: 'x', anchor: { start: 10, end: 15, rev: 2 } },
+            { id: 'c2', author: 'b', created: '2024-01-01T00:00:00.000Z', content: 'y', anchor: { exact: 'beta' } },
+          ],
+        }),
+      );
+      await store.createNote({ title: 'Clean', directory: '' });
+
+      const result = await store.normalizeAllComments();
+      expect(result).toEqual({ success: true, changed: ['legacy.md'] });
+
+      const sidecar = JSON.parse(fs.readFileSync(path.join(tempDir, 'legacy.json'), 'utf-8'));
+      expect(sidecar.comment_rev).toBe(2);
+      expect(sidecar.comments.map((comment: { anchor: unknown }) => comment.anchor)).toMatchObject([
+        { from: 10, to: 15, rev: 2, quote: 'alpha' },
+        { from: 16, to: 20, quote: 'beta' },
+      ]);
+      expect(sidecar.comments[0].status).toBe('attached');
+
+      expect(await store.normalizeAllComments()).toEqual({ success: true, changed: [] });
+    });
+  });
+
+  describe('migrateAllNotes', () => {
+    it('rewrites version 1 sidecars with the current schema and keeps their fields', async () => {
+      fs.writeFileSync(path.join(tempDir, 'old.md'), '# Old\n\nbody');
+      fs.writeFileSync(
+        path.join(tempDir, 'old.json'),
+        JSON.stringify({ tags: ['keep'], created: '2024-01-01T00:00:00.000Z', updated: '2024-01-02T00:00:00.000Z' }),
+      );
+      await store.createNote({ title: 'Current', directory: '' });
+
+      expect((await store.getNote('old.md'))?.tags).toEqual(['keep']);
+      const result = await store.migrateAllNotes();
+      expect(result).toEqual({ success: true, migrated: ['old.md'] });
+
+      const sidecar = JSON.parse(fs.readFileSync(path.join(tempDir, 'old.json'), 'utf-8'));
+      expect(sidecar).toMatchObject({ schema: NOTE_SCHEMA_VERSION, tags: ['keep'], created: '2024-01-01T00:00:00.000Z' });
+      expect(await store.migrateAllNotes()).toEqual({ success: true, migrated: [] });
+    });
+  });
+
+  describe('history', () => {
+    const revisions: NoteRevision[] = [
+      { commit: 'bbb', date: '2024-01-02T00:00:00Z', message: 'update note: Plan', path: 'notes/plan.md' },
+      { commit: 'aaa', date: '2024-01-01T00:00:00Z', message: 'add note: Plan', path: 'notes/plan.md' },
+    ];
+
+    function storeWithHistory(history: HistoryReader): NoteStore {
+      return new NoteStore({ notesDirectory: tempDir, history });
+    }
+
+    it('lists revisions and reads content at one of them', async () => {
+      const shown: string[] = [];
+      const historic = storeWithHistory({
+        log: () => revisions,
+        show: (_file, revision) => {
+          shown.push(revision.commit);
+          return '# Plan\n\nold body\n';
+        },
+      });
+      const created = await historic.createNote({ title: 'Plan', directory: '' });
+
+      expect(historic.getNoteHistory(created.note!.id).revisions).toEqual(revisions);
+      const second = historic.getNoteRevision(created.note!.id, 2);
+      expect(second.content).toBe('# Plan\n\nold body');
+      expect(shown).toEqual(['aaa']);
+      expect(historic.getNoteRevision(created.note!.id, 3).error).toBe('Revision 3 out of range (1-2)');
+    });
+
+    it('reports history as unavailable outside git', async () => {
+      const plain = storeWithHistory({ log: () => null, show: () => null });
+      const created = await plain.createNote({ title: 'Plan', directory: '' });
+
+      const result = plain.getNoteHistory(created.note!.id);
+      expect(result.success).toBe(false);
+      expect(result.error).toContain('History unavailable');
+      expect(plain.getCommittedContent(created.note!.id)).toBeNull();
+    });
+  });
+
+  describe('search index', () => {
+    it('narrows candidates with the index and keeps it current across changes', async () => {
+      const kube = await store.createNote({ title: 'Kubernetes', directory: '', content: 'ingress controller' });
+      await store.createNote({ title: 'Gardening', directory: '', content: 'tomatoes' });
+
+      const first = await store.listSearchCandidates({ query: 'ingress' });
+      expect(first.map((note) => note.title)).toEqual(['Kubernetes']);
+      expect(fs.existsSync(path.join(tempDir, '.agentnotes', 'index.json'))).toBe(true);
+
+      await store.updateNote({ noteId: kube.note!.id, content: '# Kubernetes\n\ngateway api' });
+      expect(await store.listSearchCandidates({ query: 'ingress' })).toEqual([]);
+      expect((await store.listSearchCandidates({ query: 'gateway' })).map((note) => note.title)).toEqual([
+        'Kubernetes',
+      ]);
+    });
+
+    it('re-reads notes changed behind its back', async () => {
+      const created = await store.createNote({ title: 'Plan', directory: '', content: 'alpha' });
+      await store.listSearchCandidates({ query: 'alpha' });
+
+      const filePath = path.join(tempDir, created.note!.id);
+      fs.writeFileSync(filePath, '# Plan\n\nbeta and more');
+      const other = new NoteStore({ notesDirectory: tempDir });
+      expect((await other.listSearchCandidates({ query: 'beta' })).map((note) => note.id)).toEqual([created.note!.id]);
+    });
+
+    it('returns every note when the index cannot narrow the query', async () => {
+      await store.createNote({ title: 'One', directory: '' });
+      await store.createNote({ title: 'Two', directory: '' });
+      expect(await store.listSearchCandidates({ query: 'T.o', regex: true })).toHaveLength(2);
+      expect(await store.listSearchCandidates({})).toHaveLength(2);
+    });
+
+    it('shares one index across notebooks and keeps notebook IDs', async () => {
+      await store.createNote({ title: 'Root Note', directory: '', content: 'shared words' });
+      const work = store.withNotebook('work');
+      const created = await work.createNote({ title: 'Work Note', directory: '', content: 'shared words' });
+
+      const candidates = await work.listSearchCandidates({ query: 'shared' });
+      expect(candidates.map((note) => note.id)).toEqual([created.note!.id]);
+      expect((await store.listSearchCandidates({ query: 'shared' })).map((note) => note.title).sort()).toEqual([
+        'Root Note',
+        'Work Note',
+      ]);
+    });
+
+    it('rebuilds the index from scratch', async () => {
+      await store.createNote({ title: 'One', directory: '' });
+      const indexPath = path.join(tempDir, '.agentnotes', 'index.json');
+      fs.mkdirSync(path.dirname(indexPath), { recursive: true });
+      fs.writeFileSync(indexPath, '{ broken');
+
+      expect(await store.rebuildSearchIndex()).toEqual({ success: true, noteCount: 1 });
+      expect(JSON.parse(fs.readFileSync(indexPath, 'utf-8')).notes).toHaveLength(1);
+    });
+  });
+
+  describe('saved searches', () => {
+    it('lists nothing before any search is saved', async () => {
+      expect(await store.listSavedSearches()).toEqual([]);
+    });
+
+    it('saves, runs, and deletes a search', async () => {
+      await store.createNote({ title: 'Daily Standup', directory: '' });
+      await store.createNote({ title: 'Groceries', directory: '' });
+
+      const saved = await store.saveSearch({ name: 'standup', options: { query: 'standup' } });
+      expect(saved.success).toBe(true);
+      expect((await store.listSavedSearches()).map((entry) => entry.name)).toEqual(['standup']);
+
+      const run = await store.runSavedSearch('standup');
+      expect(run.notes!.map((note) => note.title)).toEqual(['Daily Standup']);
+      expect(run.options!.query).toBe('standup');
+
+      expect((await store.deleteSavedSearch('standup')).success).toBe(true);
+      expect(await store.listSavedSearches()).toEqual([]);
+    });
+
+    it('rejects duplicate and invalid names', async () => {
+      await store.saveSearch({ name: 'mine', options: {} });
+      expect((await store.saveSearch({ name: 'mine', options: {} })).error).toContain('already exists');
+      expect((await store.saveSearch({ name: 'has space', options: {} })).success).toBe(false);
+    });
+
+    it('rejects options that would fail when run', async () => {
+      const result = await store.saveSearch({ name: 'bad', options: { query: '(', regex: true } });
+      expect(result.success).toBe(false);
+      expect((await store.saveSearch({ name: 'when', options: { createdAfter: 'soon' } })).error).toContain(
+        'Invalid date',
+      );
+    });
+
+    it('keeps relative dates relative', async () => {
+      await store.saveSearch({ name: 'week', options: { createdAfter: '7d' } });
+      expect((await store.listSavedSearches())[0].options.createdAfter).toBe('7d');
+      await store.createNote({ title: 'Fresh', directory: '' });
+      expect((await store.runSavedSearch('week')).notes).toHaveLength(1);
+    });
+
+    it('fills options the search did not save from the defaults it is run with', async () => {
+      for (const title of ['Alpha', 'Beta', 'Gamma']) {
+        await store.createNote({ title, directory: '' });
+      }
+      await store.saveSearch({ name: 'any', options: {} });
+      await store.saveSearch({ name: 'two', options: { limit: 2, sortBy: 'title' } });
+      await store.saveSearch({ name: 'all', options: { limit: 0 } });
+      expect((await store.listSavedSearches())[0].options).toEqual({});
+
+      expect((await store.runSavedSearch('any', { limit: 1 })).notes).toHaveLength(1);
+      expect((await store.runSavedSearch('any')).notes).toHaveLength(3);
+      const two = await store.runSavedSearch('two', { limit: 1, sortBy: 'created' });
+      expect(two.notes!.map((note) => note.title)).toEqual(['Alpha', 'Beta']);
+      expect((await store.runSavedSearch('all', { limit: 1 })).notes).toHaveLength(3);
+    });
+
+    it('returns an error for unknown searches', async () => {
+      expect((await store.runSavedSearch('nope')).success).toBe(false);
+      expect((await store.deleteSavedSearch('nope')).success).toBe(false);
+    });
+  });
+
+  describe('trash', () => {
+    it('lists trashed notes by their original ID', async () => {
+      const created = await store.createNote({ title: 'Trashed', directory: 'work' });
+      await store.deleteNote({ noteId: created.note!.id });
+
+      const trashed = await store.listTrash();
+      expect(trashed.map((note) => note.id)).toEqual([created.note!.id]);
+      expect(trashed[0].title).toBe('Trashed');
+    });
+
+    it('restores a note with its comments', async () => {
+      const created = await store.createNote({ title: 'Restore Me', directory: 'work' });
+      await store.updateNote({ noteId: created.note!.id, content: '# Restore Me\n\nbody' });
+      await store.addComment({
+        noteId: created.note!.id,
+        content: 'keep',
+        author: 'me',
+        anchor: { from: 14, to: 18, rev: 0 },
+      });
+      await store.deleteNote({ noteId: created.note!.id });
+
+      const result = await store.restoreNote({ noteId: created.note!.id });
+      expect(result.success).toBe(true);
+      expect(result.note!.id).toBe(created.note!.id);
+      expect(result.note!.comments).toHaveLength(1);
+      expect(await store.listTrash()).toEqual([]);
+      expect(fs.existsSync(path.join(tempDir, '.agentnotes', 'trash', 'work'))).toBe(false);
+    });
+
+    it('refuses to restore over a live note', async () => {
+      const created = await store.createNote({ title: 'Twice', directory: '' });
+      await store.deleteNote({ noteId: created.note!.id });
+      fs.writeFileSync(path.join(tempDir, created.note!.id), '# Twice');
+
+      const result = await store.restoreNote({ noteId: created.note!.id });
+      expect(result.success).toBe(false);
+      expect(result.error).toContain('already exists');
+      expect(await store.listTrash()).toHaveLength(1);
+    });
+
+    it('returns error for a note not in the trash', async () => {
+      const result = await store.restoreNote({ noteId: 'nope.md' });
+      expect(result.success).toBe(false);
+    });
+
+    it('does not expose trashed notes through getNote', async () => {
+      const created = await store.createNote({ title: 'Hidden', directory: '' });
+      await store.deleteNote({ noteId: created.note!.id });
+
+      expect(await store.getNote(`.agentnotes/trash/${created.note!.id}`)).toBeNull();
+    });
+  });
+
+  describe('cloneNote', () => {
+    async function createSource() {
+      const created = await store.createNote({
+        title: 'Weekly Plan',
+        directory: 'plans',
+        content: 'Ship the release',
+        tags: ['work'],
+        meta: { priority: 3, source: 'standup' },
+      });
+      await store.addComment({
+        noteId: created.note!.id,
+        content: 'Which release?',
+        author: 'sam',
+        anchor: { from: 24, to: 31, rev: 0 },
+      });
+      return (await store.getNote(created.note!.id))!;
+    }
+
+    it('copies content and tags into a distinct file with the same title', async () => {
+      const source = await createSource();
+      const result = await store.cloneNote({ noteId: source.id });
+
+      expect(result.success).toBe(true);
+      const clone = result.note!;
+      expect(clone.id).not.toBe(source.id);
+      expect(clone.directory).toBe('plans');
+      expect(clone.title).toBe('Weekly Plan');
+      expect(clone.content).toBe(source.content);
+      expect(clone.tags).toEqual(['work']);
+      expect(clone.comments).toEqual([]);
+      expect(clone.meta).toBeUndefined();
+      expect(Date.parse(clone.created)).toBeGreaterThanOrEqual(Date.parse(source.created));
+    });
+
+    it('retitles the clone and copies fields and comments under new IDs on request', async () => {
+      const source = await createSource();
+      const result = await store.cloneNote({
+        noteId: source.id,
+        title: 'Next Week',
+        withComments: true,
+        withFields: true,
+      });
+
+      const clone = result.note!;
+      expect(clone.title).toBe('Next Week');
+      expect(clone.content).toBe('# Next Week\n\nShip the release');
+      expect(clone.meta).toEqual({ priority: 3, source: 'standup' });
+      expect(clone.comments).toHaveLength(1);
+      expect(clone.comments[0].id).not.toBe(source.comments[0].id);
+      expect(clone.comments[0].anchor.quote).toBe('release');
+      expect((await store.getNote(source.id))!.comments[0].id).toBe(source.comments[0].id);
+    });
+
+    it('rejects a missing note or a blank title', async () => {
+      expect((await store.cloneNote({ noteId: 'nope.md' })).error).toBe('Note not found');
+      const source = await createSource();
+      expect((await store.cloneNote({ noteId: source.id, title: '  ' })).error).toBe('Title cannot be empty');
+    });
+  });
+
+  describe('moveNote', () => {
+    it('moves a note to a new directory', async () => {
+      const created = await store.createNote({ title: 'Move Me', directory: '' });
+      const result = await store.moveNote({
+        noteId: created.note!.id,
+        directory: 'archive',
+      });
+      expect(result.success).toBe(true);
+      expect(result.note!.directory).toBe('archive');
+    });
+  });
+
+  describe('addComment', () => {
+    it('adds a comment to a note', async () => {
+      const created = await store.createNote({ title: 'Comment Here', directory: '' });
+      const result = await store.addComment({
+        noteId: created.note!.id,
+        content: 'Nice section!',
+        author: 'tester',
+        anchor: { from: 2, to: 14, rev: 0, startAffinity: 'after', endAffinity: 'before' },
+      });
+      expect(result.success).toBe(true);
+      expect(result.note!.comments).toHaveLength(1);
+      expect(result.note!.comments[0].content).toBe('Nice section!');
+    });
+
+    it('rejects anchor revision mismatch', async () => {
+      const created = await store.createNote({ title: 'Rev Mismatch', directory: '' });
+      const result = await store.addComment({
+        noteId: created.note!.id,
+        content: 'comment',
+        author: 'test',
+        anchor: { from: 0, to: 5, rev: 99 },
+      });
+      expect(result.success).toBe(false);
+      expect(result.error).toContain('revision mismatch');
+    });
+  });
+
+  describe('deleteComment', () => {
+    it('deletes a comment by ID', async () => {
+      const created = await store.createNote({ title: 'Del Comment', directory: '' });
+      const addResult = await store.addComment({
+        noteId: created.note!.id,
+        content: 'remove me',
+        author: 'test',
+        anchor: { from: 2, to: 13, rev: 0 },
+      });
+      const commentId = addResult.note!.comments[0].id;
+
+      const result = await store.deleteComment({
+        noteId: created.note!.id,
+        commentId,
+      });
+      expect(result.success).toBe(true);
+      expect(result.note!.comments).toHaveLength(0);
+    });
+  });
+
+  describe('editComment', () => {
+    it('changes the text but keeps the comment identity and anchor', async () => {
+      const created = await store.createNote({ title: 'Typo Note', directory: '' });
+      const added = await store.addComment({
+        noteId: created.note!.id,
+        content: 'teh typo',
+        author: 'writer',
+        anchor: { from: 2, to: 6, rev: 0 },
+      });
+      const original = added.note!.comments[0];
+
+      const result = await store.editComment({
+        noteId: created.note!.id,
+        commentId: original.id,
+        content: 'the typo',
+      });
+
+      expect(result.success).toBe(true);
+      const edited = result.note!.comments[0];
+      expect(edited.content).toBe('the typo');
+      expect(edited.edited).toBeTruthy();
+      expect(edited.id).toBe(original.id);
+      expect(edited.author).toBe('writer');
+      expect(edited.created).toBe(original.created);
+      expect(edited.anchor).toEqual(original.anchor);
+    });
+
+    it('rejects empty content', async () => {
+      const created = await store.createNote({ title: 'Empty Edit', directory: '' });
+      const added = await store.addComment({
+        noteId: created.note!.id,
+        content: 'keep me',
+        author: 'writer',
+        anchor: { from: 2, to: 7, rev: 0 },
+      });
+
+      const result = await store.editComment({
+        noteId: created.note!.id,
+        commentId: added.note!.comments[0].id,
+        content: '   ',
+      });
+
+      expect(result.success).toBe(false);
+      expect(result.error).toBe('Comment content is required');
+      expect((await store.getNote(created.note!.id))!.comments[0].content).toBe('keep me');
+    });
+
+    it('returns an error for unknown comments', async () => {
+      const created = await store.createNote({ title: 'No Comment', directory: '' });
+      const result = await store.editComment({
+        noteId: created.note!.id,
+        commentId: 'missing',
+        content: 'text',
+      });
+      expect(result.success).toBe(false);
+      expect(result.error).toBe('Comment not found');
+    });
+  });
+
+  describe('reattachComment', () => {
+    async function addQuotedComment(content: string, quote: string) {
+      const created = await store.createNote({ title: 'Reattach', directory: '' });
+      const noteId = created.note!.id;
+      const updated = await store.updateNote({ noteId, content });
+      const from = content.indexOf(quote);
+      const added = await store.addComment({
+        noteId,
+        content: 'about this',
+        author: 'test',
+        anchor: { from, to: from + quote.length, rev: updated.note!.commentRev },
+      });
+      return { noteId, commentId: added.note!.comments[0].id };
+    }
+
+    it('re-anchors a stale comment to its quote', async () => {
+      const { noteId, commentId } = await addQuotedComment('# Reattach\n\nkeep this quote', 'this quote');
+      const rewritten = await store.updateNote({ noteId, content: '# Reattach\n\nthis quote first\n\nother' });
+      expect(rewritten.note!.comments[0].status).not.toBe('attached');
+
+      const result = await store.reattachComment({ noteId, commentId });
+
+      expect(result.success).toBe(true);
+      const comment = result.note!.comments[0];
+      expect(comment.status).toBe('attached');
+      expect(comment.anchor.rev).toBe(result.note!.commentRev);
+      expect(result.note!.content.slice(comment.anchor.from, comment.anchor.to)).toBe('this quote');
+    });
+
+    it('reports a missing quote', async () => {
+      const { noteId, commentId } = await addQuotedComment('# Reattach\n\nvanishing words', 'vanishing');
+      await store.updateNote({ noteId, content: '# Reattach\n\nsomething else' });
+
+      const result = await store.reattachComment({ noteId, commentId });
+      expect(result.success).toBe(false);
+      expect(result.error).toBe('Quoted text not found in the note');
+    });
+
+    it('reports an ambiguous quote', async () => {
+      const { noteId, commentId } = await addQuotedComment('# Reattach\n\necho once', 'echo');
+      await store.updateNote({ noteId, content: '# Reattach\n\nnew echo and echo' });
+
+      const result = await store.reattachComment({ noteId, commentId });
+      expect(result.success).toBe(false);
+      expect(result.error).toBe('Quoted text appears more than once in the note');
+    });
+  });
+
+  describe('setCommentResolved', () => {
+    async function addReviewComment() {
+      const created = await store.createNote({ title: 'Review Me', directory: '' });
+      const added = await store.addComment({
+        noteId: created.note!.id,
+        content: 'please fix',
+        author: 'reviewer',
+        anchor: { from: 2, to: 11, rev: 0 },
+      });
+      return { noteId: created.note!.id, commentId: added.note!.comments[0].id };
+    }
+
+    it('resolves and reopens a comment', async () => {
+      const { noteId, commentId } = await addReviewComment();
+
+      const resolved = await store.setCommentResolved({ noteId, commentId, resolved: true });
+      expect(resolved.success).toBe(true);
+      expect(resolved.note!.comments[0].resolved).toBe(true);
+      expect(resolved.note!.comments[0].resolvedAt).toBeTruthy();
+      expect(resolved.note!.comments[0].content).toBe('please fix');
+
+      const reopened = await store.setCommentResolved({ noteId, commentId, resolved: false });
+      expect(reopened.success).toBe(true);
+      expect(reopened.note!.comments[0].resolved).toBeUndefined();
+      expect(reopened.note!.comments[0].resolvedAt).toBeUndefined();
+    });
+
+    it('keeps the original resolution time when resolved again', async () => {
+      const { noteId, commentId } = await addReviewComment();
+      const first = await store.setCommentResolved({ noteId, commentId, resolved: true });
+      const second = await store.setCommentResolved({ noteId, commentId, resolved: true });
+      expect(second.note!.comments[0].resolvedAt).toBe(first.note!.comments[0].resolvedAt);
+    });
+
+    it('returns an error for unknown comments', async () => {
+      const { noteId } = await addReviewComment();
+      const result = await store.setCommentResolved({ noteId, commentId: 'nope', resolved: true });
+      expect(result.success).toBe(false);
+      expect(result.error).toBe('Comment not found');
+    });
+  });
+
+  describe('createDirectory', () => {
+    it('creates a directory', async () => {
+      const result = await store.createDirectory({ path: 'new-folder' });
+      expect(result.success).toBe(true);
+      expect(fs.existsSync(path.join(tempDir, 'new-folder'))).toBe(true);
+    });
+  });
+
+  describe('deleteDirectory', () => {
+    it('deletes a directory', async () => {
+      await store.createDirectory({ path: 'to-delete' });
+      const result = await store.deleteDirectory({ path: 'to-delete' });
+      expect(result.success).toBe(true);
+      expect(fs.existsSync(path.join(tempDir, 'to-delete'))).toBe(false);
+    });
+  });
+
+  describe('findNote', () => {
+    it('finds by ID and by misspelled title', async () => {
+      const created = await store.createNote({ title: 'Architecture', directory: '' });
+      expect((await store.findNote(created.note!.id)).note?.id).toBe(created.note!.id);
+      expect((await store.findNote('architecure')).note?.id).toBe(created.note!.id);
+    });
+
+    it('returns no note for unknown queries', async () => {
+      await store.createNote({ title: 'Architecture', directory: '' });
+      const result = await store.findNote('missing.md');
+      expect(result.note).toBeNull();
+    });
+  });
+
+  describe('aliases', () => {
+    it('adds an alias that findNote resolves', async () => {
+      const created = await store.createNote({ title: 'Kubernetes Runbook', directory: '' });
+      const result = await store.addAlias({ noteId: created.note!.id, alias: 'K8s-Runbook' });
+
+      expect(result.success).toBe(true);
+      expect(result.note!.aliases).toEqual(['k8s-runbook']);
+      expect((await store.findNote('k8s-runbook')).note?.id).toBe(created.note!.id);
+    });
+
+    it('keeps aliases when the note is retitled', async () => {
+      const created = await store.createNote({ title: 'Old Title', directory: '' });
+      await store.addAlias({ noteId: created.note!.id, alias: 'stable' });
+      await store.renameNote({ noteId: created.note!.id, title: 'New Title' });
+
+      expect((await store.findNote('stable')).note?.title).toBe('New Title');
+    });
+
+    it('keeps aliases through tag and field updates', async () => {
+      const created = await store.createNote({ title: 'Tagged', directory: '' });
+      await store.addAlias({ noteId: created.note!.id, alias: 'kept' });
+      await store.updateNoteMetadata({ noteId: created.note!.id, tags: ['x'], meta: { status: 'open' } });
+
+      expect((await store.findNote('kept')).note?.id).toBe(created.note!.id);
+    });
+
+    it('rejects aliases used by another note, even in another notebook', async () => {
+      const first = await store.withNotebook('work').createNote({ title: 'First', directory: '' });
+      const second = await store.createNote({ title: 'Second', directory: '' });
+      await store.withNotebook('work').addAlias({ noteId: first.note!.id, alias: 'shared' });
+
+      const result = await store.addAlias({ noteId: second.note!.id, alias: 'shared' });
+      expect(result.success).toBe(false);
+      expect(result.error).toBe(`Alias already used by ${path.join('work', first.note!.id)}`);
+    });
+
+    it('rejects invalid aliases', async () => {
+      const created = await store.createNote({ title: 'Bad Alias', directory: '' });
+      const result = await store.addAlias({ noteId: created.note!.id, alias: 'two words' });
+      expect(result.success).toBe(false);
+      expect(result.error).toBe('Invalid alias: two words');
+    });
+
+    it('removes an alias', async () => {
+      const created = await store.createNote({ title: 'Removable', directory: '' });
+      await store.addAlias({ noteId: created.note!.id, alias: 'gone' });
+
+      const result = await store.removeAlias({ noteId: created.note!.id, alias: 'gone' });
+      expect(result.success).toBe(true);
+      expect(result.note!.aliases).toBeUndefined();
+
+      const again = await store.removeAlias({ noteId: created.note!.id, alias: 'gone' });
+      expect(again.error).toBe('Note has no alias gone');
+    });
+  });
+
+  describe('resolveLink', () => {
+    it('resolves a link reference to a note', async () => {
+      const created = await store.createNote({ title: 'Link Target', directory: 'docs' });
+      const note = await store.resolveLink('link-target');
+      expect(note!.id).toBe(created.note!.id);
+      expect(await store.resolveLink('missing')).toBeNull();
+    });
+  });
+
+  describe('getBacklinks', () => {
+    async function createWithContent(title: string, body: string, directory = ''): Promise<string> {
+      const created = await store.createNote({ title, directory });
+      await store.updateNote({ noteId: created.note!.id, content: `# ${title}\n\n${body}` });
+      return created.note!.id;
+    }
+
+    it('returns notes linking to the target', async () => {
+      const targetId = await createWithContent('Target', 'self link [[Target]]');
+      await createWithContent('Linker', 'see [[target]]');
+      await createWithContent('Unrelated', 'see [[elsewhere]]');
+
+      const backlinks = await store.getBacklinks(targetId);
+      expect(backlinks.map((n) => n.title)).toEqual(['Linker']);
+    });
+
+    it('ignores links that are ambiguous between same-titled notes', async () => {
+      const firstId = await createWithContent('Shared', 'one', 'a');
+      await createWithContent('Shared', 'two', 'b');
+      await createWithContent('Linker', '[[Shared]]');
+
+      expect(await store.getBacklinks(firstId)).toEqual([]);
+    });
+
+    it('returns empty for unknown notes', async () => {
+      expect(await store.getBacklinks('missing.md')).toEqual([]);
+    });
+  });
+
+  describe('locking', () => {
+    it('keeps every change when two stores update a note concurrently', async () => {
+      const created = await store.createNote({ title: 'Shared', directory: '' });
+      const noteId = created.note!.id;
+      const other = new NoteStore({ notesDirectory: tempDir });
+
+      const writers = [store, other].map(async (writer, index) => {
+        for (let i = 0; i < 5; i++) {
+          const result = await writer.updateNoteMetadata({ noteId, meta: { [`w${index}-${i}`]: i } });
+          expect(result.error).toBeUndefined();
+        }
+      });
+      await Promise.all(writers);
+
+      const note = await store.getNote(noteId);
+      expect(Object.keys(note!.meta ?? {}).sort()).toEqual(
+        ['w0-0', 'w0-1', 'w0-2', 'w0-3', 'w0-4', 'w1-0', 'w1-1', 'w1-2', 'w1-3', 'w1-4'],
+      );
+      expect(fs.readdirSync(path.join(tempDir, '.agentnotes', 'locks'))).toEqual(['.gitignore']);
+    });
+
+    it('fails with a clear error when another process holds the note lock', async () => {
+      const created = await store.createNote({ title: 'Busy', directory: '' });
+      const release = await acquireLock(tempDir, created.note!.id);
+      const impatient = new NoteStore({ notesDirectory: tempDir, lockTimeout: 30 });
+
+      const result = await impatient.updateNote({ noteId: created.note!.id, content: '# Busy\n\nlost' });
+      expect(result.success).toBe(false);
+      expect(result.error).toMatch(/^Timed out waiting for lock on .*busy\.md/);
+
+      release();
+      expect((await impatient.updateNote({ noteId: created.note!.id, content: '# Busy\n\nsaved' })).success).toBe(true);
+    });
+
+    it('takes the store lock to change saved searches', async () => {
+      await store.saveSearch({ name: 'kept', options: {} });
+      const release = await acquireLock(tempDir, 'store');
+      const impatient = new NoteStore({ notesDirectory: tempDir, lockTimeout: 30 });
+
+      expect((await impatient.saveSearch({ name: 'lost', options: {} })).error).toMatch(/^Timed out waiting for lock on store/);
+      expect((await impatient.deleteSavedSearch('kept')).error).toMatch(/^Timed out waiting for lock on store/);
+
+      release();
+      expect((await impatient.deleteSavedSearch('kept')).success).toBe(true);
+      expect(await store.listSavedSearches()).toEqual([]);
+    });
+
+    it('gives concurrent retitles to the same title their own files', async () => {
+      const first = await store.createNote({ title: 'First', directory: '' });
+      const second = await store.createNote({ title: 'Second', directory: '' });
+      const other = new NoteStore({ notesDirectory: tempDir });
+
+      const results = await Promise.all([
+        store.updateNote({ noteId: first.note!.id, content: '# Merged\n\nfrom first' }),
+        other.updateNote({ noteId: second.note!.id, content: '# Merged\n\nfrom second' }),
+      ]);
+      const ids = results.map((result) => result.note!.id);
+      expect(new Set(ids).size).toBe(2);
+      expect(ids.map((id) => fs.readFileSync(path.join(tempDir, id), 'utf-8')).sort()).toEqual([
+        '# Merged\n\nfrom first',
+        '# Merged\n\nfrom second',
+      ]);
+
+      const release = await acquireLock(tempDir, 'store');
+      const impatient = new NoteStore({ notesDirectory: tempDir, lockTimeout: 30 });
+      const blocked = await impatient.updateNote({ noteId: ids[0], content: '# Renamed\n\nlost' });
+      expect(blocked.error).toMatch(/^Timed out waiting for lock on store/);
+      release();
+    });
+
+    it('shares note locks between notebook-scoped and root stores', async () => {
+      const work = store.withNotebook('work');
+      const created = await work.createNote({ title: 'Scoped', directory: '' });
+      const release = await acquireLock(tempDir, `work/${created.note!.id}`);
+
+      const result = await new NoteStore({ notesDirectory: tempDir, notebook: 'work', lockTimeout: 30 }).updateNoteMetadata({
+        noteId: created.note!.id,
+        tags: ['x'],
+      });
+      expect(result.error).toMatch(/Timed out/);
+      release();
+    });
+  });
+
+  describe('notebooks', () => {
+    it('scopes listing to the notebook directory', async () => {
+      await store.createNote({ title: 'Root Note', directory: '' });
+      const work = store.withNotebook('work');
+      await work.createNote({ title: 'Work Note', directory: '' });
+
+      const result = await work.listNotes();
+      expect(result.notes.map((n) => n.title)).toEqual(['Work Note']);
+      expect(work.getNotebook()).toBe('work');
+      expect(work.getNotesDirectory()).toBe(path.join(tempDir, 'work'));
+    });
+
+    it('resolves note ids relative to the notebook', async () => {
+      const personal = store.withNotebook('personal');
+      const created = await personal.createNote({ title: 'Diary', directory: '' });
+      expect(created.note!.id).toBe(created.note!.filename);
+      expect(await personal.getNote(created.note!.id)).not.toBeNull();
+      expect(await store.withNotebook('work').getNote(created.note!.id)).toBeNull();
+    });
+
+    it('lists notebooks with note counts', async () => {
+      await store.withNotebook('work').createNote({ title: 'One', directory: '' });
+      await store.withNotebook('work').createNote({ title: 'Two', directory: 'sub' });
+      await store.withNotebook('personal').createNote({ title: 'Three', directory: '' });
+
+      const notebooks = await store.listNotebooks();
+      expect(notebooks).toEqual([
+        { name: 'personal', noteCount: 1 },
+        { name: 'work', noteCount: 2 },
+      ]);
+    });
+
+    it('moves a note between notebooks, keeping its ID and comments', async () => {
+      const work = store.withNotebook('work');
+      const created = await work.createNote({ title: 'Runbook', directory: 'ops' });
+      await work.addComment({
+        noteId: created.note!.id,
+        content: 'Check this',
+        author: 'sam',
+        anchor: { from: 2, to: 9, rev: 0 },
+      });
+      const before = (await work.getNote(created.note!.id))!;
+
+      const result = await work.moveNoteToNotebook({ noteId: created.note!.id, notebook: 'archive' });
+      expect(result.success).toBe(true);
+      expect(result.note!.id).toBe(created.note!.id);
+
+      expect(await work.getNote(created.note!.id)).toBeNull();
+      const moved = await store.withNotebook('archive').getNote(created.note!.id);
+      expect(moved?.filename).toBe(before.filename);
+      expect(moved?.created).toBe(before.created);
+      expect(moved?.updated).toBe(before.updated);
+      expect(moved?.comments.map((c) => c.content)).toEqual(['Check this']);
+      expect(fs.existsSync(path.join(tempDir, 'work'))).toBe(true);
+    });
+
+    it('takes the notebook from the root-relative path in the root store', async () => {
+      const created = await store.withNotebook('work').createNote({ title: 'Plan', directory: '' });
+      const result = await store.moveNoteToNotebook({ noteId: `work/${created.note!.id}`, notebook: 'personal' });
+      expect(result.note!.id).toBe(created.note!.id);
+
+      const again = await store.moveNoteToNotebook({ noteId: `personal/${created.note!.id}`, notebook: 'personal' });
+      expect(again.error).toBe('Note is already in notebook personal');
+    });
+
+    it('refuses to overwrite a note with the same filename in the target notebook', async () => {
+      const work = store.withNotebook('work');
+      const created = await work.createNote({ title: 'Plan', directory: '' });
+      fs.mkdirSync(path.join(tempDir, 'archive'));
+      fs.writeFileSync(path.join(tempDir, 'archive', created.note!.filename.toUpperCase()), '# Other\n');
+
+      const result = await work.moveNoteToNotebook({ noteId: created.note!.id, notebook: 'archive' });
+      expect(result.error).toBe(`A note named ${created.note!.filename} already exists in notebook archive`);
+      expect(await work.getNote(created.note!.id)).not.toBeNull();
+    });
+
+    it('rejects nested or traversal notebook names', () => {
+      expect(() => store.withNotebook('a/b')).toThrow('Invalid notebook name');
+      expect(() => store.withNotebook('..')).toThrow('Invalid notebook name');
     });
   });
 });
@@ -0,0 +1,34 @@
+import { describe, it, expect } from 'vitest';
+import { extractTasks, setTaskLineDone } from '../../src/notes/tasks.js';
+
+describe('extractTasks', () => {
+  it('finds open and done tasks with their line numbers', () => {
+    const content = '# List\n\n- [ ] buy milk\n- [x] call bob\n* [X] file taxes\n1. [ ] numbered\nnot - [ ] a task';
+    expect(extractTasks(content)).toEqual([
+      { line: 3, text: 'buy milk', done: false },
+      { line: 4, text: 'call bob', done: true },
+      { line: 5, text: 'file taxes', done: true },
+      { line: 6, text: 'numbered', done: false },
+    ]);
+  });
+
+  it('includes nested tasks and skips fenced code', () => {
+    const content = '- [ ] parent\n  - [x] child\n```\n- [ ] example\n```\n- [ ] after';
+    expect(extractTasks(content).map((task) => task.line)).toEqual([1, 2, 6]);
+  });
+
+  it('ignores links that look like checkboxes', () => {
+    expect(extractTasks('- [x](https://example.com)\n- []')).toEqual([]);
+  });
+});
+
+describe('setTaskLineDone', () => {
+  it('flips only the checkbox', () => {
+    expect(setTaskLineDone('  - [ ] write [x] docs', true)).toBe('  - [x] write [x] docs');
+    expect(setTaskLineDone('- [X] done', false)).toBe('- [ ] done');
+  });
+
+  it('leaves other lines alone', () => {
+    expect(setTaskLineDone('plain text', true)).toBe('plain text');
+  });
+});
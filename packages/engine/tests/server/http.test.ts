@@ -0,0 +1,197 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import http from 'node:http';
+import path from 'node:path';
+import os from 'node:os';
+import type { AddressInfo } from 'node:net';
+import { NoteStore } from '../../src/notes/store.js';
+import { createNotesApiHandler } from '../../src/server/http.js';
+
+let tmpDir: string;
+let store: NoteStore;
+let server: http.Server;
+let baseUrl: string;
+
+beforeEach(async () => {
+  tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-test-'));
+  store = new NoteStore({ notesDirectory: tmpDir });
+  server = http.createServer(createNotesApiHandler(store));
+  await new Promise<void>((resolve) => server.listen(0, '127.0.0.1', resolve));
+  baseUrl = `http://127.0.0.1:${(server.address() as AddressInfo).port}`;
+});
+
+afterEach(async () => {
+  await new Promise<void>((resolve) => server.close(() => resolve()));
+  fs.rmSync(tmpDir, { recursive: true, force: true });
+});
+
+function request(pathname: string, init: RequestInit = {}): Promise<Response> {
+  const method = init.method ?? 'GET';
+  return fetch(`${baseUrl}${pathname}`, {
+    ...init,
+    headers: method === 'GET' ? undefined : { 'Content-Type': 'application/json' },
+  });
+}
+
+/** A request with exactly these headers; fetch will not send a forged Host. */
+function rawRequest(
+  pathname: string,
+  options: { method?: string; headers?: http.OutgoingHttpHeaders; body?: string } = {},
+): Promise<{ status: number; body: string }> {
+  return new Promise((resolve, reject) => {
+    const req = http.request(
+      `${baseUrl}${pathname}`,
+      { method: options.method ?? 'GET', headers: options.headers },
+      (res) => {
+        const chunks: Buffer[] = [];
+        res.on('data', (chunk: Buffer) => chunks.push(chunk));
+        res.on('end', () => resolve({ status: res.statusCode ?? 0, body: Buffer.concat(chunks).toString('utf-8') }));
+      },
+    );
+    req.on('error', reject);
+    req.end(options.body);
+  });
+}
+
+describe('notes API', () => {
+  it('creates a note with content and tags', async () => {
+    const response = await request('/notes', {
+      method: 'POST',
+      body: JSON.stringify({ title: 'Hello', directory: 'work', content: '# Hello\n\nBody', tags: ['a'] }),
+    });
+
+    expect(response.status).toBe(201);
+    const note = await response.json();
+    expect(note.id).toMatch(/^work\/.*hello\.md$/);
+    expect(note.content).toBe('# Hello\n\nBody');
+    expect(note.tags).toEqual(['a']);
+  });
+
+  it('adds the heading to content without one and creates nothing for a rejected field', async () => {
+    const response = await request('/notes', {
+      method: 'POST',
+      body: JSON.stringify({ title: 'Plain', content: 'Body only', due: '2026-11-01', meta: { priority: 2 } }),
+    });
+    expect(response.status).toBe(201);
+    const note = await response.json();
+    expect(note.content).toBe('# Plain\n\nBody only');
+    expect(note.due).toBe('2026-11-01');
+    expect(note.meta).toEqual({ priority: 2 });
+
+    const rejected = await request('/notes', {
+      method: 'POST',
+      body: JSON.stringify({ title: 'Late', content: 'x', due: 'someday' }),
+    });
+    expect(rejected.status).toBe(400);
+    expect((await store.listNotes()).notes.map((entry) => entry.title)).toEqual(['Plain']);
+  });
+
+  it('gets a note by an id containing slashes', async () => {
+    const { note } = await store.createNote({ title: 'Nested', directory: 'a/b' });
+    expect(note!.id.startsWith('a/b/')).toBe(true);
+
+    const response = await request(`/notes/${note!.id}`);
+    expect(response.status).toBe(200);
+    expect((await response.json()).title).toBe('Nested');
+  });
+
+  it('lists notes filtered by tag', async () => {
+    const { note } = await store.createNote({ title: 'Tagged', directory: '' });
+    await store.updateNoteMetadata({ noteId: note!.id, tags: ['x'] });
+    await store.createNote({ title: 'Plain', directory: '' });
+
+    const response = await request('/notes?tags=x');
+    const notes = await response.json();
+    expect(notes.map((entry: { title: string }) => entry.title)).toEqual(['Tagged']);
+  });
+
+  it('updates content and metadata', async () => {
+    const { note } = await store.createNote({ title: 'Edit me', directory: '' });
+
+    const response = await request(`/notes/${note!.id}`, {
+      method: 'PUT',
+      body: JSON.stringify({ content: '# Edit me\n\nNew body', meta: { status: 'done' } }),
+    });
+
+    expect(response.status).toBe(200);
+    const updated = await store.getNote(note!.id);
+    expect(updated?.content).toBe('# Edit me\n\nNew body');
+    expect(updated?.meta).toEqual({ status: 'done' });
+  });
+
+  it('deletes a note', async () => {
+    const { note } = await store.createNote({ title: 'Gone', directory: '' });
+
+    const response = await request(`/notes/${note!.id}`, { method: 'DELETE' });
+    expect(response.status).toBe(204);
+    expect(await store.getNote(note!.id)).toBeNull();
+  });
+
+  it('searches notes', async () => {
+    const { note } = await store.createNote({ title: 'Alpha', directory: '' });
+    await store.updateNote({ noteId: note!.id, content: '# Alpha\n\nneedle here' });
+    await store.createNote({ title: 'Beta', directory: '' });
+
+    const response = await request('/search?q=needle');
+    const notes = await response.json();
+    expect(notes.map((entry: { title: string }) => entry.title)).toEqual(['Alpha']);
+  });
+
+  it('returns JSON errors', async () => {
+    const missing = await request('/notes/nope.md');
+    expect(missing.status).toBe(404);
+    expect(await missing.json()).toEqual({ error: 'Note not found' });
+
+    const invalid = await request('/notes', { method: 'POST', body: '{not json' });
+    expect(invalid.status).toBe(400);
+
+    const untitled = await request('/notes', { method: 'POST', body: JSON.stringify({ content: 'x' }) });
+    expect(untitled.status).toBe(400);
+    expect(await untitled.json()).toEqual({ error: 'title is required' });
+
+    const method = await request('/search', { method: 'POST', body: '{}' });
+    expect(method.status).toBe(405);
+  });
+
+  it('refuses writes that are not sent as JSON', async () => {
+    const { note } = await store.createNote({ title: 'Kept', directory: '' });
+    const simple = await rawRequest('/notes', {
+      method: 'POST',
+      headers: { 'Content-Type': 'text/plain' },
+      body: JSON.stringify({ title: 'Forged' }),
+    });
+    expect(simple.status).toBe(415);
+    expect(JSON.parse(simple.body)).toEqual({ error: 'Content-Type must be application/json' });
+
+    const untyped = await rawRequest(`/notes/${note!.id}`, { method: 'DELETE' });
+    expect(untyped.status).toBe(415);
+    expect(await store.getNote(note!.id)).not.toBeNull();
+    expect((await store.listNotes()).notes.map((entry) => entry.title)).toEqual(['Kept']);
+
+    const charset = await rawRequest('/notes', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json; charset=utf-8' },
+      body: JSON.stringify({ title: 'Typed' }),
+    });
+    expect(charset.status).toBe(201);
+  });
+
+  it('refuses requests addressed to a host other than localhost on its port', async () => {
+    const port = (server.address() as AddressInfo).port;
+    for (const host of ['evil.example', `evil.example:${port}`, 'localhost:1', `127.0.0.2:${port}`]) {
+      const response = await rawRequest('/notes', { headers: { Host: host } });
+      expect(response.status).toBe(403);
+      expect(JSON.parse(response.body)).toEqual({ error: `Host not allowed: ${host}` });
+    }
+
+    for (const host of [`localhost:${port}`, `127.0.0.1:${port}`, `[::1]:${port}`, `LOCALHOST:${port}`]) {
+      expect((await rawRequest('/notes', { headers: { Host: host } })).status).toBe(200);
+    }
+  });
+
+  it('answers a malformed escape in a note ID with 400', async () => {
+    const response = await rawRequest('/notes/%E0%A4%A');
+    expect(response.status).toBe(400);
+    expect(JSON.parse(response.body)).toEqual({ error: 'Malformed note ID in path' });
+  });
+});
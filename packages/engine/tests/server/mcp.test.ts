@@ -0,0 +1,148 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import { NoteStore } from '../../src/notes/store.js';
+import { MCP_PROTOCOL_VERSION, MCP_TOOLS, callMcpTool, handleMcpMessage } from '../../src/server/mcp.js';
+
+let tmpDir: string;
+let store: NoteStore;
+
+beforeEach(() => {
+  tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-test-'));
+  store = new NoteStore({ notesDirectory: tmpDir });
+});
+
+afterEach(() => {
+  fs.rmSync(tmpDir, { recursive: true, force: true });
+});
+
+function parseText(result: { content: Array<{ text: string }> }): unknown {
+  return JSON.parse(result.content[0].text);
+}
+
+describe('callMcpTool', () => {
+  it('adds and gets a note', async () => {
+    const added = await callMcpTool(store, 'add_note', {
+      title: 'Plan',
+      content: '# Plan\n\nShip it',
+      tags: ['work'],
+    });
+    expect(added.isError).toBeUndefined();
+    const { id } = parseText(added) as { id: string };
+
+    const fetched = parseText(await callMcpTool(store, 'get_note', { note: 'Plan' })) as {
+      id: string;
+      content: string;
+      tags: string[];
+    };
+    expect(fetched.id).toBe(id);
+    expect(fetched.content).toBe('# Plan\n\nShip it');
+    expect(fetched.tags).toEqual(['work']);
+  });
+
+  it('adds the title heading to content without one', async () => {
+    const added = parseText(await callMcpTool(store, 'add_note', { title: 'Plan', content: 'Ship it', tags: ['a'] })) as {
+      id: string;
+    };
+    const note = await store.getNote(added.id);
+    expect(note!.content).toBe('# Plan\n\nShip it');
+    expect(note!.tags).toEqual(['a']);
+  });
+
+  it('lists and searches notes with tags and limit', async () => {
+    await callMcpTool(store, 'add_note', { title: 'One', content: '# One\n\nneedle', tags: ['a'] });
+    await callMcpTool(store, 'add_note', { title: 'Two', content: '# Two\n\nneedle', tags: ['b'] });
+    await callMcpTool(store, 'add_note', { title: 'Three', tags: ['a'] });
+
+    const listed = parseText(await callMcpTool(store, 'list_notes', { tags: ['a'], sort: 'title' })) as Array<{
+      title: string;
+    }>;
+    expect(listed.map((note) => note.title)).toEqual(['One', 'Three']);
+
+    const found = parseText(await callMcpTool(store, 'search_notes', { query: 'needle', limit: 1 })) as unknown[];
+    expect(found).toHaveLength(1);
+  });
+
+  it('lists oldest first unless reversed', async () => {
+    await callMcpTool(store, 'add_note', { title: 'Alpha' });
+    await callMcpTool(store, 'add_note', { title: 'Beta' });
+
+    const titles = async (args: Record<string, unknown>) =>
+      (parseText(await callMcpTool(store, 'list_notes', args)) as Array<{ title: string }>).map((note) => note.title);
+    expect(await titles({})).toEqual(['Alpha', 'Beta']);
+    expect(await titles({ reverse: true })).toEqual(['Beta', 'Alpha']);
+    expect((await callMcpTool(store, 'list_notes', { reverse: 'yes' })).isError).toBe(true);
+  });
+
+  it('adds a comment anchored to a line', async () => {
+    await callMcpTool(store, 'add_note', { title: 'Doc', content: '# Doc\n\nFirst line\nSecond line' });
+
+    const result = await callMcpTool(store, 'add_comment', {
+      note: 'Doc',
+      content: 'Check this',
+      author: 'agent',
+      line: 4,
+    });
+    expect(result.isError).toBeUndefined();
+
+    const { comment } = parseText(result) as { comment: { author: string; anchor: { quote: string } } };
+    expect(comment.author).toBe('agent');
+    expect(comment.anchor.quote).toBe('Second line');
+  });
+
+  it('returns input problems as tool errors', async () => {
+    await callMcpTool(store, 'add_note', { title: 'Doc' });
+
+    expect((await callMcpTool(store, 'get_note', { note: 'missing' })).isError).toBe(true);
+    expect((await callMcpTool(store, 'search_notes', {})).content[0].text).toBe('query is required');
+    expect((await callMcpTool(store, 'list_notes', { limit: -1 })).isError).toBe(true);
+    expect(
+      (await callMcpTool(store, 'add_comment', { note: 'Doc', content: 'x', line: 1, exact: 'Doc' })).content[0]
+        .text,
+    ).toBe('Specify exactly one of exact, line, or from and to');
+    expect(
+      (await callMcpTool(store, 'add_comment', { note: 'Doc', content: 'x', from: 0, to: 3, quote: '# D' })).isError,
+    ).toBeUndefined();
+    expect(
+      (await callMcpTool(store, 'add_comment', { note: 'Doc', content: 'x', from: 0, to: 3, quote: 'Doc' })).content[0]
+        .text,
+    ).toBe('Quote mismatch: 0:3 covers "# D", expected "Doc"');
+    expect((await callMcpTool(store, 'nope')).content[0].text).toBe('Unknown tool: nope');
+  });
+});
+
+describe('handleMcpMessage', () => {
+  it('answers initialize and tools/list', async () => {
+    const init = await handleMcpMessage(store, { jsonrpc: '2.0', id: 1, method: 'initialize', params: {} });
+    expect(init?.result).toEqual({
+      protocolVersion: MCP_PROTOCOL_VERSION,
+      capabilities: { tools: {} },
+      serverInfo: { name: 'agentnotes', version: '1.0.0' },
+    });
+
+    const list = await handleMcpMessage(store, { jsonrpc: '2.0', id: 2, method: 'tools/list' });
+    expect((list?.result as { tools: unknown[] }).tools).toEqual(MCP_TOOLS);
+  });
+
+  it('dispatches tools/call', async () => {
+    const response = await handleMcpMessage(store, {
+      jsonrpc: '2.0',
+      id: 'a',
+      method: 'tools/call',
+      params: { name: 'add_note', arguments: { title: 'Via RPC' } },
+    });
+    expect(response?.id).toBe('a');
+    expect((await store.listNotes()).notes.map((note) => note.title)).toEqual(['Via RPC']);
+  });
+
+  it('ignores notifications and rejects unknown methods', async () => {
+    expect(await handleMcpMessage(store, { jsonrpc: '2.0', method: 'notifications/initialized' })).toBeNull();
+
+    const unknown = await handleMcpMessage(store, { jsonrpc: '2.0', id: 3, method: 'resources/list' });
+    expect(unknown?.error?.code).toBe(-32601);
+
+    const invalid = await handleMcpMessage(store, { id: 4 });
+    expect(invalid?.error?.code).toBe(-32600);
+  });
+});
@@ -0,0 +1,45 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import { writeFileAtomic } from '../../src/storage/atomic.js';
+
+let tempDir: string;
+
+beforeEach(() => {
+  tempDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-atomic-'));
+});
+
+afterEach(() => {
+  fs.rmSync(tempDir, { recursive: true, force: true });
+});
+
+describe('writeFileAtomic', () => {
+  it('writes new files with mode 0644', () => {
+    const filePath = path.join(tempDir, 'note.md');
+    writeFileAtomic(filePath, '# Note\n');
+
+    expect(fs.readFileSync(filePath, 'utf-8')).toBe('# Note\n');
+    expect(fs.statSync(filePath).mode & 0o777).toBe(0o644);
+    expect(fs.readdirSync(tempDir)).toEqual(['note.md']);
+  });
+
+  it('replaces existing content and keeps the file mode', () => {
+    const filePath = path.join(tempDir, 'note.md');
+    fs.writeFileSync(filePath, 'old content that is longer');
+    fs.chmodSync(filePath, 0o600);
+
+    writeFileAtomic(filePath, 'new');
+
+    expect(fs.readFileSync(filePath, 'utf-8')).toBe('new');
+    expect(fs.statSync(filePath).mode & 0o777).toBe(0o600);
+  });
+
+  it('removes the temp file when the rename fails', () => {
+    const target = path.join(tempDir, 'taken');
+    fs.mkdirSync(path.join(target, 'child'), { recursive: true });
+
+    expect(() => writeFileAtomic(target, 'x')).toThrow();
+    expect(fs.readdirSync(tempDir)).toEqual(['taken']);
+  });
+});
@@ -0,0 +1,100 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import zlib from 'node:zlib';
+import { createBackup, readBackupManifest, restoreBackup } from '../../src/storage/backup.js';
+
+let tempDir: string;
+let source: string;
+let target: string;
+
+beforeEach(() => {
+  tempDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-backup-'));
+  source = path.join(tempDir, 'source');
+  target = path.join(tempDir, 'target');
+  fs.mkdirSync(path.join(source, 'projects'), { recursive: true });
+  fs.mkdirSync(path.join(source, 'empty'));
+  fs.mkdirSync(path.join(source, '.agentnotes', 'templates'), { recursive: true });
+  fs.mkdirSync(path.join(source, '.agentnotes', 'locks'), { recursive: true });
+  fs.mkdirSync(path.join(source, '.git'));
+  fs.writeFileSync(path.join(source, 'one.md'), '# One\n');
+  fs.writeFileSync(path.join(source, 'one.json'), '{"tags":[]}\n');
+  fs.writeFileSync(path.join(source, 'projects', 'two.md'), '# Two\n');
+  fs.writeFileSync(path.join(source, '.agentnotes', 'config.yml'), 'limit: 5\n');
+  fs.writeFileSync(path.join(source, '.agentnotes', 'templates', 'daily.md'), '# {{date}}\n');
+  fs.writeFileSync(path.join(source, '.agentnotes', 'locks', 'store.lock'), '1');
+  fs.writeFileSync(path.join(source, '.git', 'HEAD'), 'ref: refs/heads/main\n');
+});
+
+afterEach(() => {
+  fs.rmSync(tempDir, { recursive: true, force: true });
+});
+
+describe('createBackup', () => {
+  it('records the note count and time in the manifest', () => {
+    const archive = path.join(tempDir, 'notes.tar.gz');
+    const manifest = createBackup(source, archive, new Date('2024-05-01T12:00:00.000Z'));
+
+    expect(manifest).toEqual({ format: 1, created: '2024-05-01T12:00:00.000Z', noteCount: 2 });
+    expect(readBackupManifest(archive)).toEqual(manifest);
+  });
+
+  it('leaves the archive out when it is written inside the notes directory', () => {
+    const archive = path.join(source, 'notes.tar.gz');
+    createBackup(source, archive);
+    createBackup(source, archive);
+    restoreBackup(archive, target);
+
+    expect(fs.existsSync(path.join(target, 'notes.tar.gz'))).toBe(false);
+  });
+});
+
+describe('restoreBackup', () => {
+  it('restores notes, folders and .agentnotes, without locks or .git', () => {
+    const archive = path.join(tempDir, 'notes.tar.gz');
+    createBackup(source, archive);
+    restoreBackup(archive, target);
+
+    expect(fs.readFileSync(path.join(target, 'one.md'), 'utf-8')).toBe('# One\n');
+    expect(fs.readFileSync(path.join(target, 'one.json'), 'utf-8')).toBe('{"tags":[]}\n');
+    expect(fs.readFileSync(path.join(target, 'projects', 'two.md'), 'utf-8')).toBe('# Two\n');
+    expect(fs.readFileSync(path.join(target, '.agentnotes', 'templates', 'daily.md'), 'utf-8')).toBe('# {{date}}\n');
+    expect(fs.existsSync(path.join(target, '.agentnotes', 'config.yml'))).toBe(true);
+    expect(fs.statSync(path.join(target, 'empty')).isDirectory()).toBe(true);
+    expect(fs.existsSync(path.join(target, '.agentnotes', 'locks'))).toBe(false);
+    expect(fs.existsSync(path.join(target, '.git'))).toBe(false);
+    expect(fs.existsSync(path.join(target, 'agentnotes-backup.json'))).toBe(false);
+  });
+
+  it('keeps paths longer than a ustar header holds', () => {
+    const deep = path.join(source, 'a'.repeat(120), 'b'.repeat(150));
+    fs.mkdirSync(deep, { recursive: true });
+    fs.writeFileSync(path.join(deep, `${'c'.repeat(110)}.md`), '# Deep\n');
+    const archive = path.join(tempDir, 'notes.tar.gz');
+    createBackup(source, archive);
+    restoreBackup(archive, target);
+
+    const restored = path.join(target, 'a'.repeat(120), 'b'.repeat(150), `${'c'.repeat(110)}.md`);
+    expect(fs.readFileSync(restored, 'utf-8')).toBe('# Deep\n');
+  });
+
+  it('refuses a directory with files unless forced', () => {
+    const archive = path.join(tempDir, 'notes.tar.gz');
+    createBackup(source, archive);
+    fs.mkdirSync(path.join(target, '.agentnotes', 'locks'), { recursive: true });
+    fs.writeFileSync(path.join(target, 'one.md'), '# Changed\n');
+    fs.writeFileSync(path.join(target, 'other.md'), '# Other\n');
+
+    expect(() => restoreBackup(archive, target)).toThrow('Notes directory is not empty');
+    restoreBackup(archive, target, { force: true });
+    expect(fs.readFileSync(path.join(target, 'one.md'), 'utf-8')).toBe('# One\n');
+    expect(fs.existsSync(path.join(target, 'other.md'))).toBe(true);
+  });
+
+  it('rejects an archive without a manifest', () => {
+    const archive = path.join(tempDir, 'other.tar.gz');
+    fs.writeFileSync(archive, zlib.gzipSync(Buffer.alloc(1024)));
+    expect(() => restoreBackup(archive, target)).toThrow('Not an agentnotes backup: no manifest');
+  });
+});
@@ -0,0 +1,63 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import { DEFAULT_CONFIG, getNotesConfigPath, loadConfig, parseConfig } from '../../src/storage/config.js';
+
+let tmpDir: string;
+let userConfigPath: string;
+
+beforeEach(() => {
+  tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-test-'));
+  userConfigPath = path.join(tmpDir, 'home', 'config.yml');
+});
+
+afterEach(() => {
+  fs.rmSync(tmpDir, { recursive: true, force: true });
+});
+
+function writeFile(filePath: string, content: string): void {
+  fs.mkdirSync(path.dirname(filePath), { recursive: true });
+  fs.writeFileSync(filePath, content, 'utf-8');
+}
+
+describe('parseConfig', () => {
+  it('reads every setting', () => {
+    const text = 'limit: 50\nsort: updated\nauthor: sam\neditor: nano\ncolor: false\nstrictTags: true\n';
+    expect(parseConfig(text, 'c.yml')).toEqual({
+      limit: 50,
+      sort: 'updated',
+      author: 'sam',
+      editor: 'nano',
+      color: false,
+      strictTags: true,
+    });
+  });
+
+  it('treats an empty file as no settings', () => {
+    expect(parseConfig('', 'c.yml')).toEqual({});
+  });
+
+  it('rejects unknown keys and bad values with the file name', () => {
+    expect(() => parseConfig('limt: 5', 'c.yml')).toThrow('Invalid config in c.yml: unknown setting "limt"');
+    expect(() => parseConfig('limit: -1', 'c.yml')).toThrow('limit must be a non-negative integer');
+    expect(() => parseConfig('sort: size', 'c.yml')).toThrow('sort must be one of');
+    expect(() => parseConfig('color: maybe', 'c.yml')).toThrow('color must be true or false');
+    expect(() => parseConfig('strictTags: 1', 'c.yml')).toThrow('strictTags must be true or false');
+  });
+});
+
+describe('loadConfig', () => {
+  it('returns defaults when no files exist', () => {
+    expect(loadConfig(tmpDir, userConfigPath)).toEqual({ config: DEFAULT_CONFIG, sources: [] });
+  });
+
+  it('layers the notes directory config over the user config', () => {
+    writeFile(userConfigPath, 'limit: 50\nauthor: sam\n');
+    writeFile(getNotesConfigPath(tmpDir), 'limit: 5\nsort: title\n');
+
+    const { config, sources } = loadConfig(tmpDir, userConfigPath);
+    expect(config).toEqual({ ...DEFAULT_CONFIG, limit: 5, sort: 'title', author: 'sam' });
+    expect(sources).toEqual([userConfigPath, getNotesConfigPath(tmpDir)]);
+  });
+});
@@ -8,7 +8,10 @@ import {
   getAllMarkdownFiles,
   getAllDirectories,
   generateUniqueFilePath,
+  getTitledFilePath,
   formatRelativePath,
+  findNotesRoot,
+  resolveNotesRoot,
 } from '../../src/storage/filesystem.js';
 
 let tempDir: string;
@@ -32,6 +35,11 @@ describe('normalizeDirectoryInput', () => {
     expect(normalizeDirectoryInput('foo/../bar')).toBeNull();
   });
 
+  it('returns null for hidden segments', () => {
+    expect(normalizeDirectoryInput('.agentnotes/trash')).toBeNull();
+    expect(normalizeDirectoryInput('foo/.git')).toBeNull();
+  });
+
   it('normalizes path separators', () => {
     expect(normalizeDirectoryInput('foo\\bar/baz')).toBe('foo/bar/baz');
   });
@@ -73,6 +81,15 @@ describe('getAllMarkdownFiles', () => {
   it('returns empty for nonexistent directory', () => {
     expect(getAllMarkdownFiles('/does/not/exist')).toEqual([]);
   });
+
+  it('skips hidden directories', () => {
+    const hiddenDir = path.join(tempDir, '.agentnotes', 'trash');
+    fs.mkdirSync(hiddenDir, { recursive: true });
+    fs.writeFileSync(path.join(hiddenDir, 'gone.md'), '# Gone');
+    fs.writeFileSync(path.join(tempDir, 'kept.md'), '# Kept');
+
+    expect(getAllMarkdownFiles(tempDir).map((f) => f.relativePath)).toEqual(['kept.md']);
+  });
 });
 
 describe('getAllDirectories', () => {
@@ -84,6 +101,13 @@ describe('getAllDirectories', () => {
     const dirs = getAllDirectories(tempDir);
     expect(dirs.sort()).toEqual(['a', 'a/b', 'c']);
   });
+
+  it('skips hidden directories', () => {
+    fs.mkdirSync(path.join(tempDir, '.agentnotes', 'trash'), { recursive: true });
+    fs.mkdirSync(path.join(tempDir, 'a'));
+
+    expect(getAllDirectories(tempDir)).toEqual(['a']);
+  });
 });
 
 describe('generateUniqueFilePath', () => {
@@ -99,6 +123,94 @@ describe('generateUniqueFilePath', () => {
   });
 });
 
+describe('getTitledFilePath', () => {
+  it('keeps the date prefix and swaps the slug', () => {
+    const result = getTitledFilePath('/notes/2024-01-15-old.md', 'New Title', '2025-01-01T00:00:00Z');
+    expect(result).toBe(path.join('/notes', '2024-01-15-new-title.md'));
+  });
+
+  it('uses the creation date when the filename has no date prefix', () => {
+    const result = getTitledFilePath('/notes/legacy.md', 'Legacy', '2023-03-04T00:00:00Z');
+    expect(result).toBe(path.join('/notes', '2023-03-04-legacy.md'));
+  });
+
+  it('leaves paths with a collision suffix alone', () => {
+    const current = '/notes/2024-01-15-same-2.md';
+    expect(getTitledFilePath(current, 'Same', '2024-01-15T00:00:00Z')).toBe(current);
+    expect(getTitledFilePath(current, 'Same', '2024-01-15T00:00:00Z', 'same')).toBe(current);
+  });
+
+  it('renames when the number ending the old title only looks like a collision suffix', () => {
+    const current = '/notes/2024-01-15-meeting-2024.md';
+    const result = getTitledFilePath(current, 'Meeting', '2024-01-15T00:00:00Z', 'Meeting 2024');
+    expect(result).toBe(path.join('/notes', '2024-01-15-meeting.md'));
+  });
+});
+
+describe('findNotesRoot', () => {
+  it('finds the nearest ancestor with an .agentnotes folder', () => {
+    fs.mkdirSync(path.join(tempDir, '.agentnotes'));
+    fs.mkdirSync(path.join(tempDir, 'project', '.agentnotes'), { recursive: true });
+    fs.mkdirSync(path.join(tempDir, 'project', 'docs', 'api'), { recursive: true });
+
+    expect(findNotesRoot(path.join(tempDir, 'project', 'docs', 'api'))).toBe(path.join(tempDir, 'project'));
+    expect(findNotesRoot(path.join(tempDir, 'project'))).toBe(path.join(tempDir, 'project'));
+  });
+
+  it('returns null when no ancestor has one', () => {
+    fs.mkdirSync(path.join(tempDir, 'plain'));
+    const found = findNotesRoot(path.join(tempDir, 'plain'));
+    // A stray folder above the temp directory would be found instead, never one inside it.
+    expect(found === null || !found.startsWith(tempDir)).toBe(true);
+  });
+});
+
+describe('resolveNotesRoot', () => {
+  const cwd = () => path.join(tempDir, 'project', 'docs');
+
+  beforeEach(() => {
+    fs.mkdirSync(path.join(tempDir, 'project', '.agentnotes'), { recursive: true });
+    fs.mkdirSync(cwd(), { recursive: true });
+  });
+
+  it('prefers the explicit directory over the environment and discovery', () => {
+    const dir = path.join(tempDir, 'flag');
+    expect(resolveNotesRoot({ dir, envDir: path.join(tempDir, 'env'), cwd: cwd() })).toBe(dir);
+  });
+
+  it('uses the environment directory over discovery', () => {
+    const envDir = path.join(tempDir, 'env');
+    expect(resolveNotesRoot({ envDir, cwd: cwd() })).toBe(envDir);
+  });
+
+  it('ignores empty values, as from an unset flag or a blank variable', () => {
+    expect(resolveNotesRoot({ dir: '', envDir: '', cwd: cwd() })).toBe(path.join(tempDir, 'project'));
+  });
+
+  it('discovers the nearest .agentnotes above cwd, or uses cwd when discovery is off', () => {
+    expect(resolveNotesRoot({ cwd: cwd() })).toBe(path.join(tempDir, 'project'));
+    expect(resolveNotesRoot({ cwd: cwd(), discover: false })).toBe(cwd());
+  });
+
+  it('falls back to cwd when no ancestor has an .agentnotes folder', () => {
+    const plain = path.join(tempDir, 'plain');
+    fs.mkdirSync(plain);
+    const found = findNotesRoot(plain);
+    // A stray folder above the temp directory would be found instead.
+    expect(resolveNotesRoot({ cwd: plain })).toBe(found ?? plain);
+  });
+
+  it('resolves a relative directory against cwd and creates it', () => {
+    const root = resolveNotesRoot({ dir: 'nested/notes', envDir: 'ignored', cwd: cwd() });
+    expect(root).toBe(path.join(cwd(), 'nested', 'notes'));
+    expect(fs.statSync(root).isDirectory()).toBe(true);
+    expect(fs.existsSync(path.join(cwd(), 'ignored'))).toBe(false);
+
+    const fromEnv = resolveNotesRoot({ envDir: path.join(tempDir, 'missing', 'env'), cwd: cwd() });
+    expect(fs.statSync(fromEnv).isDirectory()).toBe(true);
+  });
+});
+
 describe('formatRelativePath', () => {
   it('converts backslashes to forward slashes', () => {
     expect(formatRelativePath('foo\\bar\\baz')).toBe('foo/bar/baz');
@@ -0,0 +1,81 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import { STALE_LOCK_MS, acquireLock, acquireLocks, getLockPath, getLocksDirectory } from '../../src/storage/lock.js';
+
+let tempDir: string;
+
+beforeEach(() => {
+  tempDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-lock-'));
+});
+
+afterEach(() => {
+  fs.rmSync(tempDir, { recursive: true, force: true });
+});
+
+describe('acquireLock', () => {
+  it('creates the lock file and removes it on release', async () => {
+    const release = await acquireLock(tempDir, 'work/a.md');
+    const lockPath = getLockPath(tempDir, 'work/a.md');
+
+    expect(path.dirname(lockPath)).toBe(getLocksDirectory(tempDir));
+    expect(fs.readFileSync(lockPath, 'utf-8')).toContain(String(process.pid));
+    expect(fs.readFileSync(path.join(getLocksDirectory(tempDir), '.gitignore'), 'utf-8')).toBe('*\n');
+
+    release();
+    release();
+    expect(fs.existsSync(lockPath)).toBe(false);
+  });
+
+  it('waits for a held lock to be released', async () => {
+    const release = await acquireLock(tempDir, 'a.md');
+    setTimeout(release, 50);
+
+    const second = await acquireLock(tempDir, 'a.md', 1000);
+    second();
+  });
+
+  it('times out with the lock path in the error', async () => {
+    await acquireLock(tempDir, 'a.md');
+    await expect(acquireLock(tempDir, 'a.md', 30)).rejects.toThrow(/Timed out waiting for lock on a\.md .*a\.md\.lock/);
+  });
+
+  it('takes over a stale lock whose holder has exited', async () => {
+    await acquireLock(tempDir, 'a.md');
+    const lockPath = getLockPath(tempDir, 'a.md');
+    fs.writeFileSync(lockPath, `999999999\n${new Date().toISOString()}\n${os.hostname()}\n`);
+    const past = new Date(Date.now() - STALE_LOCK_MS - 1000);
+    fs.utimesSync(lockPath, past, past);
+
+    const release = await acquireLock(tempDir, 'a.md', 30);
+    expect(fs.readFileSync(lockPath, 'utf-8')).toContain(String(process.pid));
+    release();
+    expect(fs.readdirSync(getLocksDirectory(tempDir))).toEqual(['.gitignore']);
+  });
+
+  it('leaves an old lock alone while its holder is still running', async () => {
+    await acquireLock(tempDir, 'a.md');
+    const past = new Date(Date.now() - STALE_LOCK_MS - 1000);
+    fs.utimesSync(getLockPath(tempDir, 'a.md'), past, past);
+
+    await expect(acquireLock(tempDir, 'a.md', 30)).rejects.toThrow(/Timed out waiting for lock on a\.md/);
+  });
+
+  it('does not remove a lock that another holder has taken over', async () => {
+    const release = await acquireLock(tempDir, 'a.md');
+    const lockPath = getLockPath(tempDir, 'a.md');
+    fs.writeFileSync(lockPath, `999999999\n${new Date().toISOString()}\nother-host\n`);
+
+    release();
+    expect(fs.readFileSync(lockPath, 'utf-8')).toContain('other-host');
+  });
+});
+
+describe('acquireLocks', () => {
+  it('releases locks already taken when a later one times out', async () => {
+    await acquireLock(tempDir, 'b.md');
+    await expect(acquireLocks(tempDir, ['a.md', 'b.md'], 30)).rejects.toThrow(/b\.md/);
+    expect(fs.existsSync(getLockPath(tempDir, 'a.md'))).toBe(false);
+  });
+});
@@ -0,0 +1,63 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import { parseMarkdownContent, stripFrontmatter } from '../../src/storage/markdown.js';
+
+let tempDir: string;
+
+beforeEach(() => {
+  tempDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-md-test-'));
+});
+
+afterEach(() => {
+  fs.rmSync(tempDir, { recursive: true, force: true });
+});
+
+function parse(content: string, allowFrontmatter?: boolean) {
+  const filePath = path.join(tempDir, 'note.md');
+  fs.writeFileSync(filePath, content, 'utf-8');
+  return parseMarkdownContent(filePath, allowFrontmatter);
+}
+
+describe('parseMarkdownContent', () => {
+  it('keeps bodies that open with a horizontal rule', () => {
+    for (const content of ['---\n\nAfter a rule', '***\n\nAfter a star rule', '---', '---\n\ntext\n\n---\n\nmore']) {
+      expect(parse(content)).toEqual({ content, legacyData: {}, hasLegacyFrontmatter: false });
+    }
+  });
+
+  it('treats YAML that fails to parse as body text', () => {
+    const content = '---\nnot: valid: yaml: [\n---\nafter';
+    expect(parse(content).content).toBe(content);
+  });
+
+  it('treats YAML without note fields as body text', () => {
+    const content = '---\n- a\n- b\n---\nlist';
+    expect(parse(content).content).toBe(content);
+  });
+
+  it('splits legacy frontmatter at the first closing delimiter', () => {
+    const parsed = parse('---\ntitle: Old\n---\n# Old\n\n---\n\nbody');
+    expect(parsed.hasLegacyFrontmatter).toBe(true);
+    expect(parsed.legacyData.title).toBe('Old');
+    expect(parsed.content).toBe('# Old\n\n---\n\nbody');
+  });
+
+  it('reads frontmatter-shaped text as body when frontmatter is not allowed', () => {
+    const content = '---\ntitle: Old\n---\n# Old';
+    expect(parse(content, false)).toEqual({ content, legacyData: {}, hasLegacyFrontmatter: false });
+  });
+});
+
+describe('stripFrontmatter', () => {
+  it('drops a leading YAML block and the blank lines after it', () => {
+    expect(stripFrontmatter('---\nstatus: draft\n---\n\n# Title\n\nBody\n')).toBe('# Title\n\nBody');
+  });
+
+  it('keeps content without a YAML block', () => {
+    for (const content of ['# Title\n\nBody', '---\n\nAfter a rule', '---\n\ntext\n\n---\n\nmore', '---\n: [\n---\nbody']) {
+      expect(stripFrontmatter(content)).toBe(content);
+    }
+  });
+});
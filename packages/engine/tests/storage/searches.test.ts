@@ -0,0 +1,98 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import {
+  getSavedSearchesPath,
+  normalizeSearchOptions,
+  parseSortFields,
+  readSavedSearches,
+  resolveSearchDates,
+  writeSavedSearches,
+} from '../../src/storage/searches.js';
+
+let tempDir: string;
+
+beforeEach(() => {
+  tempDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-searches-'));
+});
+
+afterEach(() => {
+  fs.rmSync(tempDir, { recursive: true, force: true });
+});
+
+describe('readSavedSearches', () => {
+  it('returns an empty set when the file is missing', () => {
+    expect(readSavedSearches(tempDir)).toEqual([]);
+  });
+
+  it('round-trips searches through YAML', () => {
+    const searches = [
+      { name: 'daily', options: { query: 'todo: "x"', tags: ['work'], sortBy: 'title' as const, limit: 5 } },
+      { name: 'recent', options: { createdAfter: '7d', offset: 10 } },
+    ];
+    writeSavedSearches(tempDir, searches);
+
+    expect(fs.existsSync(getSavedSearchesPath(tempDir))).toBe(true);
+    expect(readSavedSearches(tempDir)).toEqual(searches);
+  });
+});
+
+describe('normalizeSearchOptions', () => {
+  it('drops unknown and malformed fields', () => {
+    expect(
+      normalizeSearchOptions({
+        query: 'x',
+        tags: ['a', 3],
+        limit: -1,
+        offset: 1.5,
+        sortBy: 'size',
+        regex: 'yes',
+        extra: true,
+      }),
+    ).toEqual({ query: 'x', tags: ['a'] });
+  });
+
+  it('keeps a list of valid sort fields', () => {
+    expect(normalizeSearchOptions({ sortBy: ['due', 'title'] })).toEqual({ sortBy: ['due', 'title'] });
+    expect(normalizeSearchOptions({ sortBy: ['due', 'size'] })).toEqual({});
+  });
+
+  it('keeps integer priority bounds', () => {
+    expect(normalizeSearchOptions({ minPriority: 3, maxPriority: 2.5 })).toEqual({ minPriority: 3 });
+  });
+
+  it('keeps the case and word matching flags', () => {
+    expect(normalizeSearchOptions({ caseSensitive: true, wholeWord: 'yes' })).toEqual({ caseSensitive: true });
+  });
+
+  it('returns empty options for non-records', () => {
+    expect(normalizeSearchOptions('nope')).toEqual({});
+  });
+});
+
+describe('resolveSearchDates', () => {
+  it('resolves relative and absolute bounds', () => {
+    const now = new Date('2024-03-10T12:00:00.000Z');
+    expect(
+      resolveSearchDates({ createdAfter: '1d', createdBefore: '2024-03-10', query: 'q' }, now),
+    ).toEqual({
+      createdAfter: '2024-03-09T12:00:00.000Z',
+      createdBefore: '2024-03-10T23:59:59.999Z',
+      query: 'q',
+    });
+  });
+});
+
+describe('parseSortFields', () => {
+  it('parses a comma-separated list', () => {
+    expect(parseSortFields('due, title')).toEqual(['due', 'title']);
+  });
+
+  it('rejects unknown and empty specs', () => {
+    expect(() => parseSortFields('priority')).toThrow(
+      'Unknown sort field: priority (expected created, updated, title, due, relevance)',
+    );
+    expect(() => parseSortFields(' , ')).toThrow('Sort must name at least one of');
+  });
+});
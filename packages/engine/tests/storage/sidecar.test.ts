@@ -0,0 +1,26 @@
+import { describe, it, expect } from 'vitest';
+import { NOTE_SCHEMA_VERSION, getSidecarSchema, migrateSidecarData } from '../../src/storage/sidecar.js';
+
+describe('getSidecarSchema', () => {
+  it('counts a sidecar without a schema field as version 1', () => {
+    expect(getSidecarSchema({ tags: [] })).toBe(1);
+    expect(getSidecarSchema({ schema: 'two' })).toBe(1);
+    expect(getSidecarSchema({ schema: 0 })).toBe(1);
+  });
+
+  it('reads the declared version', () => {
+    expect(getSidecarSchema({ schema: 2 })).toBe(2);
+  });
+});
+
+describe('migrateSidecarData', () => {
+  it('upgrades a version 1 sidecar to the current schema', () => {
+    const data = { tags: ['a'], created: '2024-01-01T00:00:00.000Z' };
+    expect(migrateSidecarData(data)).toEqual({ ...data, schema: NOTE_SCHEMA_VERSION });
+  });
+
+  it('leaves a sidecar from a newer version as it is', () => {
+    const data = { schema: NOTE_SCHEMA_VERSION + 1, tags: [], future: true };
+    expect(migrateSidecarData(data)).toEqual(data);
+  });
+});
@@ -0,0 +1,65 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import {
+  getTemplatesDirectory,
+  listTemplates,
+  readTemplate,
+  renderTemplate,
+} from '../../src/storage/templates.js';
+
+let tempDir: string;
+
+beforeEach(() => {
+  tempDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-templates-'));
+});
+
+afterEach(() => {
+  fs.rmSync(tempDir, { recursive: true, force: true });
+});
+
+function writeTemplate(name: string, content: string): void {
+  const templatesDir = getTemplatesDirectory(tempDir);
+  fs.mkdirSync(templatesDir, { recursive: true });
+  fs.writeFileSync(path.join(templatesDir, name), content, 'utf-8');
+}
+
+describe('listTemplates', () => {
+  it('returns nothing when there is no templates directory', () => {
+    expect(listTemplates(tempDir)).toEqual([]);
+  });
+
+  it('lists markdown templates by name', () => {
+    writeTemplate('meeting.md', '# {{title}}');
+    writeTemplate('bug-report.md', '# {{title}}');
+    writeTemplate('notes.txt', 'ignored');
+    expect(listTemplates(tempDir)).toEqual(['bug-report', 'meeting']);
+  });
+});
+
+describe('readTemplate', () => {
+  it('reads a template by name', () => {
+    writeTemplate('meeting.md', '# {{title}}\n\n## Attendees\n');
+    expect(readTemplate(tempDir, 'meeting')).toBe('# {{title}}\n\n## Attendees\n');
+  });
+
+  it('returns null for missing or invalid names', () => {
+    expect(readTemplate(tempDir, 'missing')).toBeNull();
+    expect(readTemplate(tempDir, '../secrets')).toBeNull();
+  });
+});
+
+describe('renderTemplate', () => {
+  const values = { title: 'Standup', date: '2024-03-10', id: '2024-03-10-standup.md' };
+
+  it('fills known placeholders', () => {
+    expect(renderTemplate('# {{title}}\n{{ date }} ({{id}})', values)).toBe(
+      '# Standup\n2024-03-10 (2024-03-10-standup.md)',
+    );
+  });
+
+  it('leaves unknown placeholders alone', () => {
+    expect(renderTemplate('{{author}} on {{date}}', values)).toBe('{{author}} on 2024-03-10');
+  });
+});
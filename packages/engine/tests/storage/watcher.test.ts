@@ -0,0 +1,58 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'node:fs';
+import path from 'node:path';
+import os from 'node:os';
+import { NotesWatcher } from '../../src/storage/watcher.js';
+
+let tempDir: string;
+let watcher: NotesWatcher | null = null;
+
+function waitFor(check: () => boolean, timeoutMs = 2000): Promise<boolean> {
+  return new Promise((resolve) => {
+    const started = Date.now();
+    const timer = setInterval(() => {
+      if (check() || Date.now() - started > timeoutMs) {
+        clearInterval(timer);
+        resolve(check());
+      }
+    }, 10);
+  });
+}
+
+beforeEach(() => {
+  tempDir = fs.mkdtempSync(path.join(os.tmpdir(), 'agentnotes-watch-'));
+  fs.writeFileSync(path.join(tempDir, 'a.md'), '# A');
+});
+
+afterEach(() => {
+  watcher?.close();
+  watcher = null;
+  fs.rmSync(tempDir, { recursive: true, force: true });
+});
+
+describe('NotesWatcher polling', () => {
+  it('reports a burst of note changes once', async () => {
+    let changes = 0;
+    watcher = new NotesWatcher(tempDir, () => changes++, { pollIntervalMs: 20, debounceMs: 60 });
+    watcher.start();
+
+    fs.writeFileSync(path.join(tempDir, 'b.md'), '# B');
+    await new Promise((resolve) => setTimeout(resolve, 30));
+    fs.writeFileSync(path.join(tempDir, 'a.md'), '# A, edited');
+
+    expect(await waitFor(() => changes > 0)).toBe(true);
+    await new Promise((resolve) => setTimeout(resolve, 150));
+    expect(changes).toBe(1);
+  });
+
+  it('ignores files that are not notes', async () => {
+    let changes = 0;
+    watcher = new NotesWatcher(tempDir, () => changes++, { pollIntervalMs: 20, debounceMs: 10 });
+    watcher.start();
+
+    fs.mkdirSync(path.join(tempDir, '.agentnotes'));
+    fs.writeFileSync(path.join(tempDir, '.agentnotes', 'config.yml'), 'limit: 5\n');
+
+    expect(await waitFor(() => changes > 0, 200)).toBe(false);
+  });
+});
@@ -0,0 +1,27 @@
+import { describe, it, expect } from 'vitest';
+import { normalizeAlias, normalizeAliases } from '../../src/utils/aliases.js';
+
+describe('normalizeAlias', () => {
+  it('lowercases and trims aliases', () => {
+    expect(normalizeAlias('  K8s-Runbook ')).toBe('k8s-runbook');
+    expect(normalizeAlias('v1.2_notes')).toBe('v1.2_notes');
+  });
+
+  it('rejects spaces, leading punctuation, and note-like names', () => {
+    expect(normalizeAlias('two words')).toBeNull();
+    expect(normalizeAlias('-dash')).toBeNull();
+    expect(normalizeAlias('')).toBeNull();
+    expect(normalizeAlias('note.md')).toBeNull();
+  });
+});
+
+describe('normalizeAliases', () => {
+  it('keeps valid, distinct aliases in order', () => {
+    expect(normalizeAliases(['b', 'A', 'a', 'bad alias', 3])).toEqual(['b', 'a']);
+  });
+
+  it('returns an empty list for non-arrays', () => {
+    expect(normalizeAliases('a')).toEqual([]);
+    expect(normalizeAliases(undefined)).toEqual([]);
+  });
+});
@@ -0,0 +1,72 @@
+import { describe, it, expect } from 'vitest';
+import { formatRelativeTime, parseDateExpression, parseDueDate } from '../../src/utils/dates.js';
+
+const now = new Date('2024-03-10T12:00:00.000Z');
+
+describe('parseDateExpression', () => {
+  it('parses relative hours, days, and weeks', () => {
+    expect(parseDateExpression('24h', 'start', now)).toBe('2024-03-09T12:00:00.000Z');
+    expect(parseDateExpression('7d', 'start', now)).toBe('2024-03-03T12:00:00.000Z');
+    expect(parseDateExpression('2w', 'start', now)).toBe('2024-02-25T12:00:00.000Z');
+  });
+
+  it('reads dates as UTC midnight', () => {
+    expect(parseDateExpression('2024-01-02')).toBe('2024-01-02T00:00:00.000Z');
+  });
+
+  it('extends dates to the end of the day for end bounds', () => {
+    expect(parseDateExpression('2024-01-02', 'end')).toBe('2024-01-02T23:59:59.999Z');
+  });
+
+  it('keeps full timestamps as given', () => {
+    expect(parseDateExpression('2024-01-02T05:06:07Z', 'end')).toBe('2024-01-02T05:06:07.000Z');
+  });
+
+  it('rejects unparseable expressions', () => {
+    expect(() => parseDateExpression('yesterday')).toThrow('Invalid date');
+    expect(() => parseDateExpression('7')).toThrow('Invalid date');
+    expect(() => parseDateExpression('3m')).toThrow('Invalid date');
+    expect(() => parseDateExpression('2024-13-45')).toThrow('Invalid date');
+    expect(() => parseDateExpression('')).toThrow('Invalid date');
+  });
+});
+
+describe('parseDueDate', () => {
+  it('returns the calendar date', () => {
+    expect(parseDueDate('2024-06-01')).toBe('2024-06-01');
+    expect(parseDueDate(' 2024-06-01T12:00:00Z ')).toBe('2024-06-01');
+  });
+
+  it('rejects anything that is not a date', () => {
+    expect(() => parseDueDate('tomorrow')).toThrow('Invalid due date: tomorrow (use YYYY-MM-DD)');
+    expect(() => parseDueDate('2024-13-45')).toThrow('Invalid due date');
+  });
+});
+
+describe('formatRelativeTime', () => {
+  const minutes = (n: number) => new Date(now.getTime() - n * 60 * 1000);
+  const days = (n: number) => minutes(n * 24 * 60);
+
+  it('describes the last few minutes and hours', () => {
+    expect(formatRelativeTime(minutes(0), now)).toBe('just now');
+    expect(formatRelativeTime(minutes(1), now)).toBe('1 minute ago');
+    expect(formatRelativeTime(minutes(45), now)).toBe('45 minutes ago');
+    expect(formatRelativeTime(minutes(180), now)).toBe('3 hours ago');
+  });
+
+  it('describes days, weeks, and months', () => {
+    expect(formatRelativeTime(days(1), now)).toBe('yesterday');
+    expect(formatRelativeTime(days(4), now)).toBe('4 days ago');
+    expect(formatRelativeTime(days(14), now)).toBe('2 weeks ago');
+    expect(formatRelativeTime(days(95), now)).toBe('3 months ago');
+  });
+
+  it('falls back to the date after a year', () => {
+    expect(formatRelativeTime('2023-01-05T08:00:00.000Z', now)).toBe('2023-01-05');
+  });
+
+  it('accepts ISO strings and treats future times as now', () => {
+    expect(formatRelativeTime('2024-03-10T09:00:00.000Z', now)).toBe('3 hours ago');
+    expect(formatRelativeTime('2024-03-11T00:00:00.000Z', now)).toBe('just now');
+  });
+});
@@ -0,0 +1,68 @@
+import { describe, it, expect } from 'vitest';
+import { diffLines, getDiffHunks } from '../../src/utils/diff.js';
+
+describe('diffLines', () => {
+  it('marks unchanged, added and removed lines', () => {
+    expect(diffLines('a\nb\nc', 'a\nx\nc')).toEqual([
+      { type: 'equal', text: 'a' },
+      { type: 'remove', text: 'b' },
+      { type: 'add', text: 'x' },
+      { type: 'equal', text: 'c' },
+    ]);
+  });
+
+  it('finds the common lines between scattered edits', () => {
+    const types = diffLines('one\ntwo\nthree\nfour', 'zero\none\nthree\nfour!').map((line) => line.type);
+    expect(types).toEqual(['add', 'equal', 'remove', 'equal', 'remove', 'add']);
+  });
+
+  it('ignores a trailing newline and CRLF line endings', () => {
+    expect(diffLines('a\r\nb\n', 'a\nb').every((line) => line.type === 'equal')).toBe(true);
+  });
+
+  it('handles empty texts', () => {
+    expect(diffLines('', '')).toEqual([]);
+    expect(diffLines('', 'new')).toEqual([{ type: 'add', text: 'new' }]);
+    expect(getDiffHunks('', 'new')[0]).toMatchObject({ oldStart: 0, oldLines: 0, newStart: 1, newLines: 1 });
+  });
+});
+
+describe('getDiffHunks', () => {
+  const lines = Array.from({ length: 20 }, (_, i) => `line ${i + 1}`);
+
+  it('returns no hunks for identical texts', () => {
+    expect(getDiffHunks(lines.join('\n'), lines.join('\n'))).toEqual([]);
+  });
+
+  it('wraps a change in three lines of context', () => {
+    const changed = [...lines];
+    changed[9] = 'changed';
+    const [hunk, ...rest] = getDiffHunks(lines.join('\n'), changed.join('\n'));
+
+    expect(rest).toEqual([]);
+    expect(hunk).toMatchObject({ oldStart: 7, oldLines: 7, newStart: 7, newLines: 7 });
+    expect(hunk.lines[0].text).toBe('line 7');
+    expect(hunk.lines.at(-1)!.text).toBe('line 13');
+  });
+
+  it('splits distant changes and merges close ones', () => {
+    const changed = [...lines];
+    changed[1] = 'x';
+    changed[5] = 'y';
+    changed[18] = 'z';
+    const hunks = getDiffHunks(lines.join('\n'), changed.join('\n'));
+
+    expect(hunks).toHaveLength(2);
+    expect(hunks[0]).toMatchObject({ oldStart: 1, oldLines: 9 });
+    expect(hunks[1]).toMatchObject({ oldStart: 16, oldLines: 5, newStart: 16, newLines: 5 });
+  });
+
+  it('tracks line numbers across insertions', () => {
+    const changed = ['new 1', 'new 2', ...lines];
+    changed[15] = 'changed';
+    const hunks = getDiffHunks(lines.join('\n'), changed.join('\n'), 1);
+
+    expect(hunks[0]).toMatchObject({ oldStart: 1, oldLines: 1, newStart: 1, newLines: 3 });
+    expect(hunks[1]).toMatchObject({ oldStart: 13, oldLines: 3, newStart: 15, newLines: 3 });
+  });
+});
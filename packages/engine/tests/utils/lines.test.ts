@@ -0,0 +1,53 @@
+import { describe, it, expect } from 'vitest';
+import {
+  deleteLineInContent,
+  insertLineInContent,
+  replaceLineInContent,
+} from '../../src/utils/lines.js';
+
+const content = 'one\ntwo\nthree';
+
+describe('insertLineInContent', () => {
+  it('inserts before the given line', () => {
+    expect(insertLineInContent(content, 2, 'new')).toBe('one\nnew\ntwo\nthree');
+  });
+
+  it('clamps out-of-range lines to the start or end', () => {
+    expect(insertLineInContent(content, 0, 'new')).toBe('new\none\ntwo\nthree');
+    expect(insertLineInContent(content, 99, 'new')).toBe('one\ntwo\nthree\nnew');
+  });
+});
+
+describe('replaceLineInContent', () => {
+  it('replaces the given line', () => {
+    expect(replaceLineInContent(content, 3, 'THREE')).toBe('one\ntwo\nTHREE');
+  });
+
+  it('rejects out-of-range lines', () => {
+    expect(() => replaceLineInContent(content, 4, 'x')).toThrow('Line 4 out of range (1-3)');
+  });
+});
+
+describe('deleteLineInContent', () => {
+  it('removes the given line', () => {
+    expect(deleteLineInContent(content, 1)).toBe('two\nthree');
+  });
+
+  it('rejects out-of-range lines', () => {
+    expect(() => deleteLineInContent(content, 0)).toThrow('Line 0 out of range (1-3)');
+  });
+});
+
+describe('CRLF content', () => {
+  const crlf = 'one\r\ntwo\r\nthree';
+
+  it('edits lines without leaving carriage returns behind', () => {
+    expect(insertLineInContent(crlf, 2, 'new')).toBe('one\nnew\ntwo\nthree');
+    expect(replaceLineInContent(crlf, 2, 'TWO')).toBe('one\nTWO\nthree');
+    expect(deleteLineInContent(crlf, 3)).toBe('one\ntwo');
+  });
+
+  it('normalizes line endings in the new text', () => {
+    expect(insertLineInContent(content, 1, 'a\r\nb')).toBe('a\nb\none\ntwo\nthree');
+  });
+});
@@ -0,0 +1,48 @@
+import { describe, it, expect, beforeEach, afterEach, vi } from 'vitest';
+import { getLogLevel, logDebug, logError, logWarning, setLogLevel } from '../../src/utils/log.js';
+
+const originalError = console.error;
+const originalWarn = console.warn;
+let errors: ReturnType<typeof vi.fn>;
+let warnings: ReturnType<typeof vi.fn>;
+
+beforeEach(() => {
+  errors = vi.fn();
+  warnings = vi.fn();
+  console.error = errors;
+  console.warn = warnings;
+});
+
+afterEach(() => {
+  console.error = originalError;
+  console.warn = originalWarn;
+  setLogLevel('normal');
+});
+
+describe('log levels', () => {
+  it('shows errors and warnings but not debug output by default', () => {
+    expect(getLogLevel()).toBe('normal');
+    logError('Error reading note:', 'details');
+    logWarning('Git auto-commit skipped');
+    logDebug('wrote a.md');
+
+    expect(errors.mock.calls).toEqual([['Error reading note:', 'details']]);
+    expect(warnings.mock.calls).toEqual([['Git auto-commit skipped']]);
+  });
+
+  it('hides everything when quiet', () => {
+    setLogLevel('quiet');
+    logError('Error reading note:');
+    logWarning('Git auto-commit skipped');
+    logDebug('wrote a.md');
+
+    expect(errors.mock.calls).toEqual([]);
+    expect(warnings.mock.calls).toEqual([]);
+  });
+
+  it('adds debug output when verbose', () => {
+    setLogLevel('verbose');
+    logDebug('wrote a.md');
+    expect(errors.mock.calls).toEqual([['debug: wrote a.md']]);
+  });
+});
@@ -0,0 +1,62 @@
+import { describe, it, expect } from 'vitest';
+import {
+  applyMetaChanges,
+  isValidMetaKey,
+  normalizeMeta,
+  parseMetaValue,
+} from '../../src/utils/meta.js';
+
+describe('parseMetaValue', () => {
+  it('reads booleans and numbers', () => {
+    expect(parseMetaValue('true')).toBe(true);
+    expect(parseMetaValue('false')).toBe(false);
+    expect(parseMetaValue('42')).toBe(42);
+    expect(parseMetaValue('-1.5')).toBe(-1.5);
+  });
+
+  it('keeps everything else as strings', () => {
+    expect(parseMetaValue('open')).toBe('open');
+    expect(parseMetaValue('007')).toBe('007');
+    expect(parseMetaValue('1e3')).toBe('1e3');
+    expect(parseMetaValue('')).toBe('');
+    expect(parseMetaValue('https://example.com')).toBe('https://example.com');
+  });
+});
+
+describe('isValidMetaKey', () => {
+  it('accepts identifier-like keys', () => {
+    expect(isValidMetaKey('status')).toBe(true);
+    expect(isValidMetaKey('due_by')).toBe(true);
+    expect(isValidMetaKey('story-points')).toBe(true);
+  });
+
+  it('rejects empty or punctuated keys', () => {
+    expect(isValidMetaKey('')).toBe(false);
+    expect(isValidMetaKey('a=b')).toBe(false);
+    expect(isValidMetaKey('1st')).toBe(false);
+  });
+});
+
+describe('normalizeMeta', () => {
+  it('drops invalid keys and null values', () => {
+    expect(normalizeMeta({ status: 'open', 'bad key': 1, gone: null })).toEqual({ status: 'open' });
+  });
+
+  it('returns undefined when nothing is left', () => {
+    expect(normalizeMeta({})).toBeUndefined();
+    expect(normalizeMeta(['a'])).toBeUndefined();
+    expect(normalizeMeta('status')).toBeUndefined();
+  });
+});
+
+describe('applyMetaChanges', () => {
+  it('sets and removes fields', () => {
+    expect(applyMetaChanges({ status: 'open', url: 'x' }, { status: 'done', url: null })).toEqual({
+      status: 'done',
+    });
+  });
+
+  it('returns undefined once every field is removed', () => {
+    expect(applyMetaChanges({ status: 'open' }, { status: null })).toBeUndefined();
+  });
+});
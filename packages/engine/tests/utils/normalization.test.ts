@@ -29,6 +29,10 @@ describe('normalizeContent', () => {
     expect(normalizeContent('hello\r\nworld')).toBe('hello\nworld');
   });
 
+  it('converts bare CR to LF', () => {
+    expect(normalizeContent('hello\rworld\r\n')).toBe('hello\nworld');
+  });
+
   it('strips trailing newlines', () => {
     expect(normalizeContent('hello\n\n\n')).toBe('hello');
   });
@@ -1,5 +1,5 @@
 import { describe, it, expect } from 'vitest';
-import { slugify } from '../../src/utils/slugify.js';
+import { slugify, slugifyTitle } from '../../src/utils/slugify.js';
 
 describe('slugify', () => {
   it('converts to lowercase', () => {
@@ -41,4 +41,39 @@ describe('slugify', () => {
   it('preserves digits', () => {
     expect(slugify('Chapter 1')).toBe('chapter-1');
   });
+
+  it('transliterates accented Latin characters', () => {
+    expect(slugify('Café résumé')).toBe('cafe-resume');
+    expect(slugify('Straße Ærø Łódź')).toBe('strasse-aero-lodz');
+  });
+
+  it('treats any whitespace as a separator', () => {
+    expect(slugify('tab\tseparated\u00a0words')).toBe('tab-separated-words');
+  });
+
+  it('drops characters with no ASCII form', () => {
+    expect(slugify('東京 notes')).toBe('notes');
+    expect(slugify('🚀🔥')).toBe('');
+  });
+});
+
+describe('slugifyTitle', () => {
+  it('uses the slug when there is one', () => {
+    expect(slugifyTitle('Café résumé')).toBe('cafe-resume');
+  });
+
+  it('falls back to a stable hash for CJK and emoji-only titles', () => {
+    const cjk = slugifyTitle('東京');
+    expect(cjk).toMatch(/^note-[0-9a-f]{8}$/);
+    expect(slugifyTitle('東京')).toBe(cjk);
+
+    const emoji = slugifyTitle('🚀🔥');
+    expect(emoji).toMatch(/^note-[0-9a-f]{8}$/);
+    expect(emoji).not.toBe(cjk);
+  });
+
+  it('falls back to "note" for whitespace-only titles', () => {
+    expect(slugifyTitle('   ')).toBe('note');
+    expect(slugifyTitle('')).toBe('note');
+  });
 });
@@ -0,0 +1,27 @@
+import { describe, it, expect } from 'vitest';
+import { addTagsToList, parseTagList, removeTagsFromList, renameTagInList } from '../../src/utils/tags.js';
+
+describe('parseTagList', () => {
+  it('splits on commas and drops blanks', () => {
+    expect(parseTagList(' a, b ,,c ')).toEqual(['a', 'b', 'c']);
+    expect(parseTagList('')).toEqual([]);
+  });
+});
+
+describe('addTagsToList', () => {
+  it('appends only tags not already present, ignoring case', () => {
+    expect(addTagsToList(['Work'], ['work', 'home', 'HOME'])).toEqual(['Work', 'home']);
+  });
+});
+
+describe('removeTagsFromList', () => {
+  it('removes tags ignoring case', () => {
+    expect(removeTagsFromList(['Work', 'home'], ['WORK'])).toEqual(['home']);
+  });
+});
+
+describe('renameTagInList', () => {
+  it('replaces matching tags in place, ignoring case', () => {
+    expect(renameTagInList(['a', 'Old', 'b'], 'old', 'new')).toEqual(['a', 'new', 'b']);
+  });
+});
@@ -0,0 +1,39 @@
+import { describe, it, expect } from 'vitest';
+import matter from 'gray-matter';
+import { toYaml } from '../../src/utils/yaml.js';
+
+function parseYaml(yaml: string): unknown {
+  return matter(`---\n${yaml}---\n`).data;
+}
+
+describe('toYaml', () => {
+  it('emits scalars and quotes strings', () => {
+    expect(toYaml({ title: 'Hello: world', count: 3, done: false })).toBe(
+      'title: "Hello: world"\ncount: 3\ndone: false\n',
+    );
+  });
+
+  it('emits empty collections inline', () => {
+    expect(toYaml({ tags: [], meta: {} })).toBe('tags: []\nmeta: {}\n');
+  });
+
+  it('emits sequences of records', () => {
+    const yaml = toYaml({ comments: [{ id: 'a', anchor: { from: 1, to: 2 } }] });
+    expect(yaml).toBe('comments:\n  - id: "a"\n    anchor:\n      from: 1\n      to: 2\n');
+  });
+
+  it('uses literal blocks for multi-line strings', () => {
+    expect(toYaml({ content: '# Title\n\nBody' })).toBe('content: |-\n  # Title\n\n  Body\n');
+  });
+
+  it('round-trips through a YAML parser', () => {
+    const value = {
+      id: 'notes/a.md',
+      tags: ['x', 'y z'],
+      comments: [{ id: 'c1', content: 'line "quoted"', anchor: { from: 0, to: 4 } }],
+      content: '---\n  indented start',
+      multi: 'first\n  second\n\nthird',
+    };
+    expect(parseYaml(toYaml(value))).toEqual(value);
+  });
+});